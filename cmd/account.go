@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/devlongs/evm-tracer/internal/analyzer"
+	"github.com/devlongs/evm-tracer/internal/formatter"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+var (
+	accountLast       int
+	accountScanBlocks uint64
+)
+
+var analyzeAccountCmd = &cobra.Command{
+	Use:   "analyze-account [address]",
+	Short: "Trace an account's recent transactions and aggregate their gas findings",
+	Long: `Finds the most recent transactions sent by or to an address, traces each
+one and reports which optimization types occur most often across them.
+
+Transactions are listed with the ots_searchTransactionsBefore index (Erigon and
+other Otterscan-compatible nodes). Other nodes fall back to scanning recent
+blocks, which is slow and only finds transactions sent to the address directly.
+
+Example:
+  evm-tracer analyze-account 0xCONTRACT --last 20
+  evm-tracer analyze-account 0xCONTRACT --scan-blocks 5000 --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAnalyzeAccount,
+}
+
+func runAnalyzeAccount(cmd *cobra.Command, args []string) error {
+	if !common.IsHexAddress(args[0]) {
+		return fmt.Errorf("invalid address: %s", args[0])
+	}
+	addr := common.HexToAddress(args[0])
+
+	if accountLast <= 0 {
+		return fmt.Errorf("--last must be positive, got %d", accountLast)
+	}
+
+	format, err := resolveFormat()
+	if err != nil {
+		return err
+	}
+	if format == "markdown" || format == "flamegraph" || format == "parity" {
+		return fmt.Errorf("analyze-account does not support the %s format", format)
+	}
+
+	criteria := filterCriteria()
+	if err := criteria.Validate(); err != nil {
+		return err
+	}
+
+	headers, err := rpcHeaders()
+	if err != nil {
+		return err
+	}
+
+	an, err := analyzer.NewTransactionAnalyzerWithOptions(rpcURL, analyzer.Options{
+		AllowEmptyState: allowEmptyState,
+		CacheSize:       cacheSize,
+		MaxSteps:        maxSteps,
+		Headers:         headers,
+	})
+	if errors.Is(err, analyzer.ErrNotArchiveNode) {
+		return fmt.Errorf("%w\nUse an archive node, or pass --allow-empty-state to trace against empty state on devnets", err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create analyzer: %w", err)
+	}
+	defer an.Close()
+
+	if err := configureTracer(an.GetTracer()); err != nil {
+		return err
+	}
+
+	client, err := analyzer.Dial(rpcURL, headers)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+
+	lister := &analyzer.FallbackLister{
+		Primary:  analyzer.NewIndexLister(client.Client()),
+		Fallback: analyzer.NewBlockScanLister(client, accountScanBlocks),
+		OnFallback: func(err error) {
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n   Scanning the last %d blocks instead; this is slow and misses calls made by other contracts\n",
+				err, accountScanBlocks)
+		},
+	}
+
+	// Ctrl-C stops the analysis like a timeout, keeping the transactions traced so far
+	interrupt, stop := interruptContext()
+	defer stop()
+	ctx, cancel := context.WithTimeout(interrupt, timeout)
+	defer cancel()
+
+	hashes, err := lister.RecentTransactions(ctx, addr, accountLast)
+	if err != nil {
+		return withTimeoutHint(fmt.Errorf("failed to list transactions: %w", err))
+	}
+	if len(hashes) == 0 {
+		fmt.Fprintf(os.Stderr, "No transactions found for %s\n", addr.Hex())
+	}
+
+	if verbose {
+		fmt.Fprintf(os.Stderr, "⚙️  Tracing %d transactions...\n", len(hashes))
+	}
+
+	stopProgress := startProgress(an.GetTracer())
+	report, err := an.AnalyzeAccount(ctx, addr, hashes, criteria)
+	stopProgress()
+	if report == nil {
+		return withTimeoutHint(err)
+	}
+
+	// A stopped analysis still writes the partial report, then exits nonzero
+	if writeErr := writeOutput(func(w io.Writer) error {
+		return writeAccountReport(w, report, format)
+	}); writeErr != nil {
+		return writeErr
+	}
+	if err != nil {
+		return withTimeoutHint(err)
+	}
+	return nil
+}
+
+// writeAccountReport writes an account report to w in the given output format
+func writeAccountReport(w io.Writer, report *analyzer.AccountReport, format string) error {
+	if format == "json" {
+		marshal := func(v interface{}) ([]byte, error) { return json.MarshalIndent(v, "", "  ") }
+		if compactJSON {
+			marshal = json.Marshal
+		}
+		data, err := marshal(report)
+		if err != nil {
+			return fmt.Errorf("failed to generate report: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	theme, err := consoleTheme()
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(w, formatter.FormatAccountReport(report, theme))
+	return nil
+}
+
+func init() {
+	analyzeAccountCmd.Flags().IntVar(&accountLast, "last", 10, "Number of most recent transactions to analyze")
+	analyzeAccountCmd.Flags().Uint64Var(&accountScanBlocks, "scan-blocks", 1000, "Blocks to scan when the node has no transaction index")
+	analyzeAccountCmd.Flags().BoolVar(&allowEmptyState, "allow-empty-state", false, "Trace against empty state when historical state is unavailable (devnets)")
+	rootCmd.AddCommand(analyzeAccountCmd)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/devlongs/evm-tracer/internal/analyzer"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestWriteAccountReport(t *testing.T) {
+	report := &analyzer.AccountReport{
+		Address:  common.HexToAddress("0x3000"),
+		Findings: []analyzer.FindingSummary{{Type: "redundant_sload", Severity: "high", Transactions: 2, Occurrences: 3}},
+		Transactions: []analyzer.AccountTransaction{
+			{Hash: common.HexToHash("0x01"), GasUsed: 50000, Optimizations: 2},
+			{Hash: common.HexToHash("0x02"), GasUsed: 40000, Optimizations: 1},
+		},
+	}
+
+	var out bytes.Buffer
+	if err := writeAccountReport(&out, report, "json"); err != nil {
+		t.Fatalf("writeAccountReport() error: %v", err)
+	}
+
+	var decoded analyzer.AccountReport
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+
+	if len(decoded.Findings) != 1 || decoded.Findings[0].Transactions != 2 {
+		t.Errorf("Expected the aggregated finding in the JSON report, got %+v", decoded.Findings)
+	}
+
+	out.Reset()
+	if err := writeAccountReport(&out, report, "console"); err != nil {
+		t.Fatalf("writeAccountReport() error: %v", err)
+	}
+
+	if !bytes.Contains(out.Bytes(), []byte("redundant_sload")) {
+		t.Errorf("Expected the console report to list the finding, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigName is the config file discovered in the working directory
+// and in the user config directory when --config is not given
+const defaultConfigName = "evm-tracer.yaml"
+
+// configFileKey is the flag naming the config file, which cannot be set from the file itself
+const configFileKey = "config"
+
+// loadConfig applies the environment, then the config file, to the flags of cmd
+// that were not set on the command line
+func loadConfig(cmd *cobra.Command, _ []string) error {
+	if err := applyEnvironment(cmd.Flags()); err != nil {
+		return err
+	}
+
+	path := configPath
+	if path == "" {
+		path = discoverConfig()
+	}
+	if path != "" {
+		if err := applyConfigFile(cmd.Root(), cmd.Flags(), path); err != nil {
+			return err
+		}
+	}
+	return loadIgnoreFile()
+}
+
+// discoverConfig returns the first default config file that exists, or an empty string
+func discoverConfig() string {
+	candidates := []string{defaultConfigName}
+	if dir, err := os.UserConfigDir(); err == nil {
+		candidates = append(candidates, filepath.Join(dir, "evm-tracer", defaultConfigName))
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// applyConfigFile sets each flag named by a key of the YAML file at path, unless
+// the flag was already given on the command line. Keys naming a flag of another
+// command in the tree of root are skipped, so one file can hold the flags of
+// every command.
+func applyConfigFile(root *cobra.Command, flags *pflag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	for key, value := range values {
+		if key == configFileKey {
+			return fmt.Errorf("config file %s: %q cannot be set from a config file", path, key)
+		}
+		flag := flags.Lookup(key)
+		if flag == nil {
+			if commandFlag(root, key) {
+				continue
+			}
+			return fmt.Errorf("config file %s: unknown key %q", path, key)
+		}
+		if flag.Changed {
+			continue
+		}
+		// Array flags such as --rpc-header take each list item whole, commas included
+		if list, ok := value.([]interface{}); ok && flag.Value.Type() == "stringArray" {
+			for _, item := range list {
+				if err := flag.Value.Set(fmt.Sprint(item)); err != nil {
+					return fmt.Errorf("config file %s: invalid value for %q: %w", path, key, err)
+				}
+			}
+			continue
+		}
+		if err := flag.Value.Set(configValue(value)); err != nil {
+			return fmt.Errorf("config file %s: invalid value for %q: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+// commandFlag reports whether cmd or any of its subcommands defines the named flag
+func commandFlag(cmd *cobra.Command, name string) bool {
+	if cmd.Flags().Lookup(name) != nil || cmd.PersistentFlags().Lookup(name) != nil {
+		return true
+	}
+	for _, sub := range cmd.Commands() {
+		if commandFlag(sub, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// configValue formats a YAML value as flag text; lists become comma-separated values
+func configValue(value interface{}) string {
+	list, ok := value.([]interface{})
+	if !ok {
+		return fmt.Sprint(value)
+	}
+
+	items := make([]string, len(list))
+	for i, item := range list {
+		items[i] = fmt.Sprint(item)
+	}
+	return strings.Join(items, ",")
+}
+
+// tracerConfig returns the tracer thresholds selected by the flags and config file
+func tracerConfig() (tracer.Config, error) {
+	if l2CompressionRatio <= 0 || l2CompressionRatio > 1 {
+		return tracer.Config{}, errors.New("--l2-compression-ratio must be in (0, 1]")
+	}
+	return tracer.Config{
+		MinForwardedGas:    minForwardedGas,
+		L2CompressionRatio: l2CompressionRatio,
+	}, nil
+}
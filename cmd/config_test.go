@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+)
+
+// resetConfigFlags restores the flags a config file test may set
+func resetConfigFlags(t *testing.T) {
+	t.Cleanup(func() {
+		defaults := tracer.DefaultConfig()
+		minForwardedGas = defaults.MinForwardedGas
+		l2CompressionRatio = defaults.L2CompressionRatio
+		rpcURL = "http://localhost:8545"
+		abiFiles = nil
+		rpcHeaderList = nil
+		rootCmd.PersistentFlags().Lookup("l2-compression-ratio").Changed = false
+	})
+}
+
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "evm-tracer.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestConfigFileThresholds(t *testing.T) {
+	resetConfigFlags(t)
+	path := writeConfig(t, `
+rpc: http://node:8545
+min-forwarded-gas: 5000
+l2-compression-ratio: 0.25
+abi: [token.json, router.json]
+`)
+
+	if err := applyConfigFile(rootCmd, rootCmd.PersistentFlags(), path); err != nil {
+		t.Fatalf("applyConfigFile() error: %v", err)
+	}
+
+	config, err := tracerConfig()
+	if err != nil {
+		t.Fatalf("tracerConfig() error: %v", err)
+	}
+	if config.MinForwardedGas != 5000 {
+		t.Errorf("Expected min forwarded gas 5000, got %d", config.MinForwardedGas)
+	}
+	if config.L2CompressionRatio != 0.25 {
+		t.Errorf("Expected L2 compression ratio 0.25, got %v", config.L2CompressionRatio)
+	}
+	if rpcURL != "http://node:8545" {
+		t.Errorf("Expected rpc from config file, got %s", rpcURL)
+	}
+	if len(abiFiles) != 2 || abiFiles[1] != "router.json" {
+		t.Errorf("Expected 2 ABI files from config file, got %v", abiFiles)
+	}
+}
+
+func TestConfigFileFlagsOverride(t *testing.T) {
+	resetConfigFlags(t)
+	path := writeConfig(t, "l2-compression-ratio: 0.25\n")
+
+	if err := rootCmd.PersistentFlags().Set("l2-compression-ratio", "0.5"); err != nil {
+		t.Fatalf("failed to set flag: %v", err)
+	}
+	if err := applyConfigFile(rootCmd, rootCmd.PersistentFlags(), path); err != nil {
+		t.Fatalf("applyConfigFile() error: %v", err)
+	}
+
+	if l2CompressionRatio != 0.5 {
+		t.Errorf("Expected the command line value 0.5 to win, got %v", l2CompressionRatio)
+	}
+}
+
+func TestConfigFileErrors(t *testing.T) {
+	resetConfigFlags(t)
+
+	tests := map[string]string{
+		"unknown key":   "no-such-flag: 1\n",
+		"invalid value": "min-forwarded-gas: lots\n",
+		"config key":    "config: other.yaml\n",
+		"not yaml":      "rpc: [\n",
+	}
+	for name, contents := range tests {
+		if err := applyConfigFile(rootCmd, rootCmd.PersistentFlags(), writeConfig(t, contents)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestConfigFileOtherCommandFlags(t *testing.T) {
+	resetConfigFlags(t)
+	defer func() {
+		rootCmd.SetArgs(nil)
+		rootCmd.SetOut(nil)
+		configPath = ""
+	}()
+
+	// Flags of trace and serve do not break gas-ref, which has neither
+	path := writeConfig(t, "gas-limit: 50000\naddr: :9000\nrpc: http://node:8545\n")
+	rootCmd.SetOut(&bytes.Buffer{})
+	rootCmd.SetArgs([]string{"--config", path, "gas-ref", "SLOAD"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("gas-ref with a shared config file failed: %v", err)
+	}
+	if gasLimit != 0 {
+		t.Errorf("Expected gas-limit left unset for gas-ref, got %d", gasLimit)
+	}
+	if rpcURL != "http://node:8545" {
+		t.Errorf("Expected rpc from config file, got %s", rpcURL)
+	}
+}
+
+func TestConfigFileRPCHeaders(t *testing.T) {
+	resetConfigFlags(t)
+	path := writeConfig(t, `
+rpc-header: ["X-Api-Key: secret", "Accept: application/json, text/plain"]
+`)
+
+	if err := applyConfigFile(rootCmd, rootCmd.PersistentFlags(), path); err != nil {
+		t.Fatalf("applyConfigFile() error: %v", err)
+	}
+	if len(rpcHeaderList) != 2 || rpcHeaderList[1] != "Accept: application/json, text/plain" {
+		t.Errorf("Expected 2 RPC headers kept whole, got %q", rpcHeaderList)
+	}
+}
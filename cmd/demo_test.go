@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDemoReport(t *testing.T) {
+	tr, err := traceDemo()
+	if err != nil {
+		t.Fatalf("traceDemo() error: %v", err)
+	}
+
+	if tr.TotalGasUsed == 0 {
+		t.Error("Expected the demo call to use gas")
+	}
+
+	report, err := tr.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+
+	for _, optType := range []string{"redundant_sload", "redundant_hash", "storage_in_loop"} {
+		if !strings.Contains(report, `"Type": "`+optType+`"`) {
+			t.Errorf("Expected report to contain a %s optimization", optType)
+		}
+	}
+}
+
+func TestDemoFailOn(t *testing.T) {
+	defer func() {
+		rootCmd.SetArgs(nil)
+		rootCmd.SetErr(nil)
+		rootCmd.SilenceUsage = false
+		failOn = "none"
+		outputPath = ""
+	}()
+	rootCmd.SetErr(io.Discard)
+
+	report := filepath.Join(t.TempDir(), "report.txt")
+
+	// The demo contract always produces high-severity findings
+	rootCmd.SetArgs([]string{"demo", "--output", report, "--fail-on", "high"})
+	err := rootCmd.Execute()
+	if err == nil {
+		t.Fatal("Expected --fail-on high to fail on the demo's high findings")
+	}
+
+	if !strings.Contains(err.Error(), "at or above high severity") {
+		t.Errorf("Expected a severity threshold error, got %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"demo", "--output", report, "--fail-on", "none"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("Expected --fail-on none to succeed, got %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"demo", "--output", report, "--fail-on", "critical"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected an unknown --fail-on severity to be rejected")
+	}
+}
+
+func TestDemoAssertions(t *testing.T) {
+	defer func() {
+		rootCmd.SetArgs(nil)
+		rootCmd.SetErr(nil)
+		rootCmd.SilenceUsage = false
+		assertMaxGas = 0
+		assertAbove = ""
+		outputPath = ""
+	}()
+	rootCmd.SetErr(io.Discard)
+
+	report := filepath.Join(t.TempDir(), "report.txt")
+
+	// The demo call uses far more than 1,000 gas
+	rootCmd.SetArgs([]string{"demo", "--output", report, "--assert-max-gas", "1000"})
+	err := rootCmd.Execute()
+	if err == nil {
+		t.Fatal("Expected --assert-max-gas to fail when the gas used exceeds the budget")
+	}
+	if !strings.Contains(err.Error(), "exceeds the --assert-max-gas budget of 1000") {
+		t.Errorf("Expected a gas budget error, got %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"demo", "--output", report, "--assert-max-gas", "10000000"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("Expected the demo to stay under a 10M gas budget, got %v", err)
+	}
+
+	// The demo has high findings, which are above medium but not above high
+	rootCmd.SetArgs([]string{"demo", "--output", report, "--assert-max-gas", "0", "--assert-no-findings-above", "medium"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "above medium severity") {
+		t.Errorf("Expected high findings to fail --assert-no-findings-above medium, got %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"demo", "--output", report, "--assert-no-findings-above", "high"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("Expected --assert-no-findings-above high to succeed, got %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"demo", "--output", report, "--assert-no-findings-above", "critical"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected an unknown --assert-no-findings-above severity to be rejected")
+	}
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/devlongs/evm-tracer/internal/analyzer"
+	"github.com/devlongs/evm-tracer/internal/ens"
+)
+
+// ensProbeTimeout bounds the chain ID lookup deciding whether names can be resolved
+const ensProbeTimeout = 5 * time.Second
+
+// resolveNames attaches ENS names to the traced addresses when --ens is set and
+// the analyzer is connected to mainnet. Lookup failures leave addresses as hex,
+// and the lookups stop once ctx is done, such as on --timeout or Ctrl-C.
+func resolveNames(ctx context.Context, an *analyzer.TransactionAnalyzer) {
+	if !useENS {
+		return
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, ensProbeTimeout)
+	defer cancel()
+
+	chainID, err := an.ChainID(probeCtx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Skipping ENS names: %v\n", err)
+		return
+	}
+	if !chainID.IsInt64() || chainID.Int64() != ens.MainnetChainID {
+		fmt.Fprintf(os.Stderr, "⚠️  Skipping ENS names: chain %s is not mainnet\n", chainID)
+		return
+	}
+	an.GetTracer().ResolveNames(ctx, ens.NewResolver(an))
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// envFlags lists the environment variables read for flags not given on the
+// command line, in order of preference. They take precedence over the config file.
+var envFlags = []struct {
+	flag string
+	vars []string
+}{
+	// URLs often embed an API key, which the command line leaks into shell
+	// history and process listings
+	{flag: "rpc", vars: []string{"EVM_TRACER_RPC", "ETH_RPC_URL"}},
+}
+
+// applyEnvironment sets each flag of envFlags not given on the command line
+// from the first of its environment variables that is set
+func applyEnvironment(flags *pflag.FlagSet) error {
+	for _, entry := range envFlags {
+		flag := flags.Lookup(entry.flag)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		for _, name := range entry.vars {
+			value, ok := os.LookupEnv(name)
+			if !ok || value == "" {
+				continue
+			}
+			if err := flags.Set(entry.flag, value); err != nil {
+				return fmt.Errorf("invalid %s: %w", name, err)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// maskedKeyLength is the length from which a URL path segment is taken for an
+// API key, as in Infura's /v3/<key> and Alchemy's /v2/<key>
+const maskedKeyLength = 16
+
+// maskRPCURL returns the RPC URL with its credentials hidden for printing: the
+// user info password, query values and path segments long enough to be API keys
+func maskRPCURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		// IPC paths hold no credentials; unparsable URLs are hidden whole
+		if err == nil && u.Scheme == "" {
+			return raw
+		}
+		return "***"
+	}
+
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "***")
+	}
+
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		if len(segment) >= maskedKeyLength {
+			segments[i] = segment[:4] + "***"
+		}
+	}
+	u.Path = strings.Join(segments, "/")
+	u.RawPath = ""
+
+	query := u.Query()
+	for key := range query {
+		query.Set(key, "***")
+	}
+	u.RawQuery = query.Encode()
+
+	// Keep the asterisks readable rather than percent-encoded
+	return strings.NewReplacer("%2A", "*", "%2a", "*").Replace(u.String())
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devlongs/evm-tracer/internal/formatter"
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/spf13/cobra"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain [optimization-type]",
+	Short: "Describe an optimization type in detail",
+	Long: `Prints what an optimization type means, why it matters, where its gas goes
+and a code pattern that triggers it, with its fix. Without an argument, lists
+the known optimization types. Pass --explain to trace or simulate to append
+the explanations of the types found to the console report.
+
+Example:
+  evm-tracer explain redundant_sload
+  evm-tracer explain`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), strings.Join(tracer.ExplainedTypes(), "\n"))
+			return nil
+		}
+
+		explanation, err := tracer.Explain(args[0])
+		if err != nil {
+			return fmt.Errorf("%w (run 'evm-tracer explain' to list the known types)", err)
+		}
+		theme, err := consoleTheme()
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), formatter.FormatExplanation(explanation, theme))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExplainCommand(t *testing.T) {
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"explain", "redundant_sload"})
+	defer rootCmd.SetArgs(nil)
+	defer rootCmd.SetOut(nil)
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("explain command failed: %v", err)
+	}
+
+	output := out.String()
+	for _, part := range []string{"redundant_sload", "Why it matters: ", "Gas cost: ", "Example: "} {
+		if !strings.Contains(output, part) {
+			t.Errorf("Expected %q in the explanation, got:\n%s", part, output)
+		}
+	}
+}
+
+func TestExplainUnknownType(t *testing.T) {
+	rootCmd.SetOut(&bytes.Buffer{})
+	rootCmd.SetErr(&bytes.Buffer{})
+	rootCmd.SetArgs([]string{"explain", "no_such_type"})
+	defer rootCmd.SetArgs(nil)
+	defer rootCmd.SetOut(nil)
+	defer rootCmd.SetErr(nil)
+
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "unknown optimization type: no_such_type") {
+		t.Errorf("Expected an unknown type error, got %v", err)
+	}
+}
+
+func TestExplainFindingsInline(t *testing.T) {
+	explainTypes = true
+	defer func() { explainTypes = false }()
+
+	tr, err := traceDemo()
+	if err != nil {
+		t.Fatalf("traceDemo() error: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := writeResults(&out, tr, "console"); err != nil {
+		t.Fatalf("writeResults() error: %v", err)
+	}
+	if !strings.Contains(out.String(), "EXPLANATIONS") || !strings.Contains(out.String(), "Why it matters: ") {
+		t.Errorf("Expected explanations after the findings, got:\n%s", out.String())
+	}
+}
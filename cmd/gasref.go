@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/devlongs/evm-tracer/internal/formatter"
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/spf13/cobra"
+)
+
+// evmFork selects the fork of gas-ref and trace-local
+var evmFork string
+
+var gasRefCmd = &cobra.Command{
+	Use:   "gas-ref [opcode]",
+	Short: "Print the gas cost of an opcode, or of every opcode, on a fork",
+	Long: `Prints what opcodes cost on a fork, as charged by the EVM's own gas schedule:
+each opcode is executed with zero operands in an in-memory EVM. The repeat
+column is the cost of executing it again with the same operands when that is
+cheaper, such as a warm SLOAD after EIP-2929. Without an argument, lists every
+opcode defined on the fork.
+
+Example:
+  evm-tracer gas-ref SLOAD
+  evm-tracer gas-ref --fork istanbul
+  evm-tracer gas-ref --fork istanbul --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var costs []tracer.OpcodeCost
+		if len(args) == 0 {
+			all, err := tracer.GasReference(evmFork)
+			if err != nil {
+				return err
+			}
+			costs = all
+		} else {
+			cost, err := tracer.OpcodeGas(evmFork, args[0])
+			if err != nil {
+				return err
+			}
+			costs = []tracer.OpcodeCost{cost}
+		}
+
+		if outputJSON {
+			marshal := func(v interface{}) ([]byte, error) { return json.MarshalIndent(v, "", "  ") }
+			if compactJSON {
+				marshal = json.Marshal
+			}
+			data, err := marshal(map[string]interface{}{"fork": evmFork, "opcodes": costs})
+			if err != nil {
+				return fmt.Errorf("failed to generate report: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			return nil
+		}
+
+		theme, err := consoleTheme()
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), formatter.FormatGasReference(evmFork, costs, theme))
+		return nil
+	},
+}
+
+func init() {
+	gasRefCmd.Flags().StringVar(&evmFork, "fork", tracer.DefaultGasModel().Fork, "Fork whose gas schedule is printed ("+strings.Join(tracer.ForkNames(), ", ")+")")
+	rootCmd.AddCommand(gasRefCmd)
+}
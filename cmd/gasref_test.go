@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGasRefCommand(t *testing.T) {
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"gas-ref", "SLOAD"})
+	defer rootCmd.SetArgs(nil)
+	defer rootCmd.SetOut(nil)
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("gas-ref command failed: %v", err)
+	}
+
+	output := out.String()
+	if !strings.Contains(output, "(cancun)") || !strings.Contains(output, "SLOAD") || !strings.Contains(output, "2100") {
+		t.Errorf("Expected SLOAD at 2100 gas on cancun, got:\n%s", output)
+	}
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"gopkg.in/yaml.v3"
+)
+
+// suppressions holds the findings listed in the --ignore-file
+var suppressions []tracer.Suppression
+
+// ignoreEntry is an accepted finding listed in the --ignore-file
+type ignoreEntry struct {
+	Type     string `yaml:"type"`
+	Location string `yaml:"location"`
+	Reason   string `yaml:"reason"` // Why the finding is accepted; for readers of the file only
+}
+
+// loadIgnoreFile reads the --ignore-file, when given, into suppressions
+func loadIgnoreFile() error {
+	suppressions = nil
+	if ignoreFilePath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(ignoreFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read ignore file: %w", err)
+	}
+
+	var entries []ignoreEntry
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&entries); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse ignore file %s: %w", ignoreFilePath, err)
+	}
+
+	for _, entry := range entries {
+		suppressions = append(suppressions, tracer.Suppression{Type: entry.Type, Location: entry.Location})
+	}
+	if err := (tracer.FilterCriteria{Suppress: suppressions}).Validate(); err != nil {
+		return fmt.Errorf("ignore file %s: %w", ignoreFilePath, err)
+	}
+	return nil
+}
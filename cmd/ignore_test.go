@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDemoIgnoreFileSuppressesFailOn(t *testing.T) {
+	defer func() {
+		rootCmd.SetArgs(nil)
+		rootCmd.SetErr(nil)
+		rootCmd.SilenceUsage = false
+		failOn = "none"
+		outputPath = ""
+		ignoreFilePath = ""
+		suppressions = nil
+		rootCmd.PersistentFlags().Lookup("ignore-file").Changed = false
+	}()
+	rootCmd.SetErr(io.Discard)
+
+	dir := t.TempDir()
+	report := filepath.Join(dir, "report.txt")
+	ignore := filepath.Join(dir, "ignore.yaml")
+	content := `- type: redundant_sload
+  reason: cached by the caller
+- type: storage_in_loop
+  location: "0x1*"
+`
+	if err := os.WriteFile(ignore, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The demo's high findings are all accepted, so --fail-on high passes
+	rootCmd.SetArgs([]string{"demo", "--output", report, "--fail-on", "high", "--ignore-file", ignore})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Expected suppressed high findings not to fail, got %v", err)
+	}
+
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "redundant_sload") {
+		t.Error("Expected suppressed redundant_sload findings to be left out of the report")
+	}
+
+	// The medium findings are not suppressed
+	rootCmd.SetArgs([]string{"demo", "--output", report, "--fail-on", "medium", "--ignore-file", ignore})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected unsuppressed medium findings to fail --fail-on medium")
+	}
+}
+
+func TestLoadIgnoreFileRejectsUnknownKeys(t *testing.T) {
+	defer func() {
+		ignoreFilePath = ""
+		suppressions = nil
+	}()
+
+	ignoreFilePath = filepath.Join(t.TempDir(), "ignore.yaml")
+	if err := os.WriteFile(ignoreFilePath, []byte("- kind: redundant_sload\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadIgnoreFile(); err == nil {
+		t.Error("Expected an unknown key in the ignore file to be rejected")
+	}
+
+	if err := os.WriteFile(ignoreFilePath, []byte("- reason: nothing to match\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadIgnoreFile(); err == nil {
+		t.Error("Expected an entry without type or location to be rejected")
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+)
+
+// progressInterval is how often the progress indicator is redrawn
+const progressInterval = 100 * time.Millisecond
+
+// spinnerFrames are the animation frames of the progress indicator
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// progressEnabled reports whether the progress indicator should be shown.
+// It is suppressed when stderr is not a terminal and in JSON mode.
+func progressEnabled(interactive bool) bool {
+	if !interactive {
+		return false
+	}
+	format, err := resolveFormat()
+	return err == nil && format != "json"
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// startProgress shows a spinner on stderr while a trace runs, using the
+// tracer's step count as a heartbeat. The returned function stops the
+// spinner and clears its line.
+func startProgress(tr *tracer.GasOptimizationTracer) func() {
+	if !progressEnabled(isTerminal(os.Stderr)) {
+		return func() {}
+	}
+	return runProgress(os.Stderr, tr, progressInterval)
+}
+
+// runProgress redraws the progress indicator on w every interval until the
+// returned function is called
+func runProgress(w io.Writer, tr *tracer.GasOptimizationTracer, interval time.Duration) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for frame := 0; ; frame++ {
+			// Execution has not started until the first step is traced
+			status := "Fetching chain data..."
+			if steps := tr.StepCount(); steps > 0 {
+				status = fmt.Sprintf("Executing... %d steps", steps)
+			}
+			fmt.Fprintf(w, "\r\033[K%s %s", spinnerFrames[frame%len(spinnerFrames)], status)
+
+			select {
+			case <-done:
+				fmt.Fprint(w, "\r\033[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
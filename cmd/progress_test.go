@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+)
+
+func TestProgressSuppressedForJSON(t *testing.T) {
+	defer func() {
+		outputJSON = false
+		outputFormat = "console"
+	}()
+
+	if !progressEnabled(true) {
+		t.Fatal("Expected progress on an interactive console run")
+	}
+
+	if progressEnabled(false) {
+		t.Error("Expected progress to be suppressed when not a terminal")
+	}
+
+	outputJSON = true
+	if progressEnabled(true) {
+		t.Error("Expected progress to be suppressed with --json")
+	}
+
+	outputJSON = false
+	outputFormat = "json"
+	if progressEnabled(true) {
+		t.Error("Expected progress to be suppressed with --format json")
+	}
+}
+
+func TestRunProgressClearsLine(t *testing.T) {
+	var buf bytes.Buffer
+	stop := runProgress(&buf, tracer.NewGasOptimizationTracer(), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	stop()
+	stop()
+
+	output := buf.String()
+	if !strings.Contains(output, "Fetching chain data") {
+		t.Errorf("Expected the fetch phase before any steps, got %q", output)
+	}
+
+	if !strings.HasSuffix(output, "\r\033[K") {
+		t.Errorf("Expected the spinner line to be cleared, got %q", output)
+	}
+}
@@ -3,14 +3,60 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/devlongs/evm-tracer/internal/analyzer"
+	"github.com/devlongs/evm-tracer/internal/formatter"
+	"github.com/devlongs/evm-tracer/internal/signatures"
+	"github.com/devlongs/evm-tracer/internal/tracer"
 	"github.com/spf13/cobra"
 )
 
 var (
-	rpcURL     string
-	outputJSON bool
-	verbose    bool
+	rpcURL       string
+	outputJSON   bool
+	compactJSON  bool
+	outputFormat string
+	outputPath   string
+	summaryPath  string
+	themeName    string
+	numberFormat string
+	gasUnit      string
+	sectionList  string
+	verbose      bool
+	disasm       bool
+	internalTxs  bool
+	minimal      bool
+	explainTypes bool
+	sampleRate   uint64
+	focusOps     []string
+	ignoreOps    []string
+	useENS       bool
+	baselinePath string
+	baselineFile string
+	timeout      time.Duration
+	cacheSize    int
+	configPath   string
+
+	rpcHeaderList []string
+	rpcAuth       string
+	maxSteps      uint64
+
+	minForwardedGas    uint64
+	l2CompressionRatio float64
+
+	minSeverity    string
+	failOn         string
+	assertMaxGas   uint64
+	assertAbove    string
+	onlyTypes      []string
+	excludeTypes   []string
+	ignoreFilePath string
+
+	signatureMode string
+	abiFiles      []string
+	offline       bool
 )
 
 var rootCmd = &cobra.Command{
@@ -26,19 +72,58 @@ It provides detailed insights into:
 - Expensive operations
 - Gas consumption by opcode
 - Specific optimization recommendations`,
-	Version: "1.0.0",
+	Version:           versionInfo(),
+	PersistentPreRunE: loadConfig,
 }
 
 // Execute runs the root command
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVar(&rpcURL, "rpc", "http://localhost:8545", "Ethereum RPC URL")
-	rootCmd.PersistentFlags().BoolVar(&outputJSON, "json", false, "Output as JSON")
+	defaults := tracer.DefaultConfig()
+
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "YAML file of flag values, overridden by flags given on the command line (default: ./evm-tracer.yaml, then the user config directory)")
+	rootCmd.PersistentFlags().StringVar(&rpcURL, "rpc", "http://localhost:8545", "Ethereum RPC URL (when not given: $EVM_TRACER_RPC, then $ETH_RPC_URL)")
+	rootCmd.PersistentFlags().StringArrayVar(&rpcHeaderList, "rpc-header", nil, "Header sent with every RPC request, as \"Key: Value\" (repeatable)")
+	rootCmd.PersistentFlags().StringVar(&rpcAuth, "rpc-auth", "", "Credentials sent to the RPC node as HTTP basic auth, as user:pass")
+	rootCmd.PersistentFlags().BoolVar(&outputJSON, "json", false, "Output as JSON (shorthand for --format json)")
+	rootCmd.PersistentFlags().BoolVar(&compactJSON, "compact", false, "Write JSON reports on a single line instead of indented")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "format", "console", "Output format: console, json, markdown, flamegraph (folded stacks), parity (OpenEthereum call traces)")
+	rootCmd.PersistentFlags().StringVarP(&outputPath, "output", "o", "", "Write the report to this file instead of stdout")
+	rootCmd.PersistentFlags().StringVar(&summaryPath, "summary-file", "", "Also write a compact JSON summary (gas, findings by severity, top finding) to this file")
+	rootCmd.PersistentFlags().StringVar(&themeName, "theme", "", "Console color theme: dark, light, mono, high-contrast (default: detected from the terminal)")
+	rootCmd.PersistentFlags().StringVar(&numberFormat, "number-format", string(formatter.NumberShort), "Gas amounts in console and Markdown output: short (1.23M), full (1234567), grouped (1,234,567)")
+	rootCmd.PersistentFlags().StringVar(&gasUnit, "gas-unit", "", "Unit of every gas amount in console and Markdown output: gas, kgas (1234.57K), mgas (1.23M) (default: K or M by size with --number-format short); JSON always carries raw gas")
 	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Verbose output")
+	rootCmd.PersistentFlags().BoolVar(&explainTypes, "explain", false, "Append a detailed explanation of each optimization type found to the console report")
+	rootCmd.PersistentFlags().StringVar(&sectionList, "sections", "", "Report sections in console and JSON output, comma-separated: "+strings.Join(formatter.SectionNames(), ", ")+" or "+formatter.AllSections+" (default: the usual report)")
+	rootCmd.PersistentFlags().StringVar(&baselineFile, "baseline", "", "Saved JSON report to compare the trace's gas and findings against")
+	rootCmd.PersistentFlags().StringVar(&baselinePath, "baseline-schedule", "", "JSON file of alternate per-opcode gas costs to project the trace's gas against")
+	rootCmd.PersistentFlags().BoolVar(&minimal, "minimal", false, "Profile gas per opcode only, skipping all detection for the lowest tracing overhead")
+	rootCmd.PersistentFlags().Uint64Var(&maxSteps, "max-steps", 0, "Abort execution after this many steps, reporting the trace as truncated; guards against runaway loops (0: no limit)")
+	rootCmd.PersistentFlags().Uint64Var(&sampleRate, "sample-rate", 1, "Record only every Nth step for an approximate gas-by-opcode and hot-PC profile of very long traces (implies --minimal)")
+	rootCmd.PersistentFlags().BoolVar(&useENS, "ens", false, "Show ENS names for the traced addresses (mainnet only)")
+	rootCmd.PersistentFlags().StringSliceVar(&focusOps, "focus-ops", nil, "Only analyze these opcodes in detail, e.g. SLOAD,SSTORE,CALL (gas is still counted for all)")
+	rootCmd.PersistentFlags().StringSliceVar(&ignoreOps, "ignore-ops", nil, "Skip detailed analysis of these opcodes (gas is still counted)")
+	rootCmd.PersistentFlags().BoolVar(&internalTxs, "internal-txs", false, "List internal transactions (value-bearing subcalls and contract creations)")
+	rootCmd.PersistentFlags().BoolVar(&disasm, "disasm", false, "Include disassembled bytecode around each optimization's location")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 60*time.Second, "Maximum time for fetching chain data and executing the trace")
+	rootCmd.PersistentFlags().IntVar(&cacheSize, "cache-size", analyzer.DefaultCacheSize, "Blocks, headers and code lookups cached per RPC connection (0 disables caching)")
+	rootCmd.PersistentFlags().Uint64Var(&minForwardedGas, "min-forwarded-gas", defaults.MinForwardedGas, "Gas forwarded by a fixed-gas call at or below which the callee is flagged as likely underfunded")
+	rootCmd.PersistentFlags().Float64Var(&l2CompressionRatio, "l2-compression-ratio", defaults.L2CompressionRatio, "Fraction of calldata gas assumed to remain after rollup compression")
+	rootCmd.PersistentFlags().StringVar(&minSeverity, "min-severity", "", "Only report optimizations at or above this severity: low, medium, high")
+	rootCmd.PersistentFlags().StringVar(&failOn, "fail-on", "none", "Exit with a nonzero status when findings at or above this severity exist: none, low, medium, high")
+	rootCmd.PersistentFlags().Uint64Var(&assertMaxGas, "assert-max-gas", 0, "Exit with a nonzero status when the traced gas used exceeds this budget (0 disables)")
+	rootCmd.PersistentFlags().StringVar(&assertAbove, "assert-no-findings-above", "", "Exit with a nonzero status when findings above this severity exist: low, medium")
+	rootCmd.PersistentFlags().StringSliceVar(&onlyTypes, "only-type", nil, "Only report these optimization types (comma-separated)")
+	rootCmd.PersistentFlags().StringVar(&signatureMode, "signatures", signatures.ModeLocal, "Function signature resolution: local (--abi files only) or remote (fall back to the 4byte directory)")
+	rootCmd.PersistentFlags().StringSliceVar(&abiFiles, "abi", nil, "ABI or compiler artifact JSON files used to name called functions and encode trace-local calls (repeatable)")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Disable all network lookups other than the RPC node")
+	rootCmd.PersistentFlags().StringSliceVar(&excludeTypes, "exclude-type", nil, "Do not report these optimization types (comma-separated)")
+	rootCmd.PersistentFlags().StringVar(&ignoreFilePath, "ignore-file", "", "YAML list of accepted findings (type and location, * wildcards) left out of reports, counts and --fail-on")
 }
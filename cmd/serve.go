@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/devlongs/evm-tracer/internal/analyzer"
+	"github.com/devlongs/evm-tracer/internal/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr        string
+	serveConcurrency int
+	allowRPCOverride bool
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve trace reports over HTTP",
+	Long: `Runs evm-tracer as a long-lived service. POST /trace with a JSON body
+{"txHash": "0x..."} returns the JSON report for the transaction.
+
+Connections to the RPC node are pooled across requests. Each request is
+bounded by --timeout, and requests beyond --max-concurrent are rejected with
+503. Failures are returned as {"error": "...", "code": "..."}.
+
+Example:
+  evm-tracer serve --addr :8080 --rpc https://mainnet.infura.io/v3/YOUR-KEY
+  curl -X POST localhost:8080/trace -d '{"txHash": "0x1234..."}'`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	headers, err := rpcHeaders()
+	if err != nil {
+		return err
+	}
+
+	backend := server.NewAnalyzerBackend(rpcURL, analyzer.Options{AllowEmptyState: allowEmptyState, CacheSize: cacheSize, MaxSteps: maxSteps, Headers: headers})
+	defer backend.Close()
+
+	srv := &http.Server{
+		Addr: serveAddr,
+		Handler: server.New(backend, server.Options{
+			Timeout:          timeout,
+			MaxConcurrent:    serveConcurrency,
+			AllowRPCOverride: allowRPCOverride,
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	fmt.Fprintf(os.Stderr, "🌐 Serving trace reports on %s (RPC: %s)\n", serveAddr, maskRPCURL(rpcURL))
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", ":8080", "Address to listen on")
+	serveCmd.Flags().IntVar(&serveConcurrency, "max-concurrent", 4, "Maximum number of traces running at once")
+	serveCmd.Flags().BoolVar(&allowRPCOverride, "allow-rpc-override", false, "Let requests choose the RPC node with an \"rpc\" field")
+	serveCmd.Flags().BoolVar(&allowEmptyState, "allow-empty-state", false, "Trace against empty state when historical state is unavailable (devnets)")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// interruptedExitCode is the conventional exit status of a command stopped by SIGINT
+const interruptedExitCode = 130
+
+// interruptContext returns a context cancelled on the first SIGINT or SIGTERM,
+// so a trace stops cleanly and reports what it collected. Once cancelled, the
+// signals are released and a second interrupt terminates the process at once.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+	return ctx, stop
+}
+
+// exitCode returns the process exit status for an error returned by a command
+func exitCode(err error) int {
+	if errors.Is(err, context.Canceled) {
+		return interruptedExitCode
+	}
+	return 1
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/devlongs/evm-tracer/internal/analyzer"
+)
+
+func TestExitCode(t *testing.T) {
+	interrupted := fmt.Errorf("%w: %w", analyzer.ErrExecutionInterrupted, context.Canceled)
+	if code := exitCode(fmt.Errorf("trace: %w", interrupted)); code != interruptedExitCode {
+		t.Errorf("Expected exit code %d for an interrupted trace, got %d", interruptedExitCode, code)
+	}
+	if code := exitCode(errors.New("rpc error")); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if code := exitCode(context.DeadlineExceeded); code != 1 {
+		t.Errorf("Expected exit code 1 for a timeout, got %d", code)
+	}
+}
+
+func TestInterruptContext(t *testing.T) {
+	ctx, stop := interruptContext()
+	defer stop()
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Skipf("Cannot find the test process: %v", err)
+	}
+	if err := p.Signal(os.Interrupt); err != nil {
+		t.Skipf("Cannot signal the test process: %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", ctx.Err())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the interrupt to cancel the context")
+	}
+}
@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/devlongs/evm-tracer/internal/analyzer"
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	simFrom  string
+	simTo    string
+	simData  string
+	simValue string
+	simCode  string
+
+	// Recipient state overrides
+	simBalance string
+	simNonce   uint64
+	simStorage []string
+
+	// Block context overrides
+	blockNumber   uint64
+	blockTime     uint64
+	blockBaseFee  string
+	blockRandao   string
+	blockCoinbase string
+)
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Simulate a call against the latest block and analyze its gas usage",
+	Long: `Simulates a call that has not been sent (or is still pending) on top of the
+latest block and analyzes it with the gas optimization tracer.
+
+Accounts and storage are loaded from the latest state as the call touches
+them. The recipient's code can be replaced with --code to test undeployed
+changes, its balance, nonce and storage slots with --balance, --nonce and
+--storage, and the block environment (number, timestamp, base fee,
+prevrandao, coinbase) with the --block-* flags to test time- or
+fee-dependent logic.
+
+Example:
+  evm-tracer simulate --from 0xSENDER --to 0xCONTRACT --data 0xa9059cbb...
+  evm-tracer simulate --to 0xCONTRACT --data 0x... --code 0x6080...
+  evm-tracer simulate --to 0xCONTRACT --data 0x... --storage 0x0=0x1 --balance 1000000000000000000
+  evm-tracer simulate --to 0xCONTRACT --data 0x... --block-timestamp 1735689600`,
+	Args: cobra.NoArgs,
+	RunE: runSimulate,
+}
+
+func runSimulate(cmd *cobra.Command, args []string) error {
+	if !common.IsHexAddress(simTo) {
+		return fmt.Errorf("invalid --to address: %s", simTo)
+	}
+	if simFrom != "" && !common.IsHexAddress(simFrom) {
+		return fmt.Errorf("invalid --from address: %s", simFrom)
+	}
+
+	from := common.HexToAddress(simFrom)
+	to := common.HexToAddress(simTo)
+
+	var data []byte
+	if simData != "" {
+		decoded, err := hexutil.Decode(simData)
+		if err != nil {
+			return fmt.Errorf("invalid --data: %w", err)
+		}
+		data = decoded
+	}
+
+	value, ok := new(big.Int).SetString(simValue, 10)
+	if !ok {
+		return fmt.Errorf("invalid --value: %s", simValue)
+	}
+
+	overrides, err := parseStateOverride(cmd.Flags(), to)
+	if err != nil {
+		return err
+	}
+
+	block, err := parseBlockOverride(cmd.Flags())
+	if err != nil {
+		return err
+	}
+
+	if verbose {
+		fmt.Fprintf(os.Stderr, "🧪 Simulating call: %s -> %s\n", from.Hex(), to.Hex())
+		fmt.Fprintf(os.Stderr, "📡 Connecting to: %s\n\n", maskRPCURL(rpcURL))
+	}
+
+	headers, err := rpcHeaders()
+	if err != nil {
+		return err
+	}
+
+	// Create analyzer
+	an, err := analyzer.NewTransactionAnalyzerWithOptions(rpcURL, analyzer.Options{LatestStateOnly: true, GasLimit: gasLimit, CacheSize: cacheSize, Block: block, MaxSteps: maxSteps, Headers: headers})
+	if err != nil {
+		return fmt.Errorf("failed to create analyzer: %w", err)
+	}
+	defer an.Close()
+
+	if err := configureTracer(an.GetTracer()); err != nil {
+		return err
+	}
+
+	closeSteps, err := attachStepWriter(an.GetTracer())
+	if err != nil {
+		return err
+	}
+
+	// Ctrl-C stops the trace like a timeout, keeping the results collected so far
+	interrupt, stop := interruptContext()
+	defer stop()
+	ctx, cancel := context.WithTimeout(interrupt, timeout)
+	defer cancel()
+
+	stopProgress := startProgress(an.GetTracer())
+	err = an.AnalyzeCall(ctx, from, to, data, value, overrides)
+	stopProgress()
+	if err != nil && !analyzer.IsPartial(err) {
+		closeSteps()
+		return withTimeoutHint(fmt.Errorf("simulation failed: %w", err))
+	}
+	partialErr := err
+
+	if err := closeSteps(); err != nil {
+		return err
+	}
+
+	if err := writeAccessList(an.GetTracer()); err != nil {
+		return err
+	}
+	if err := writeTimeline(an.GetTracer()); err != nil {
+		return err
+	}
+
+	resolveNames(ctx, an)
+	if err := finishResults(cmd, an.GetTracer()); err != nil {
+		return err
+	}
+	return partialFailure(cmd, "simulation", partialErr)
+}
+
+// parseStateOverride returns the recipient state set with --code, --balance,
+// --nonce and --storage
+func parseStateOverride(flags *pflag.FlagSet, to common.Address) (analyzer.StateOverride, error) {
+	var account analyzer.OverrideAccount
+	set := false
+
+	if simCode != "" {
+		code, err := hexutil.Decode(simCode)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --code: %w", err)
+		}
+		account.Code = code
+		set = true
+	}
+	if flags.Changed("balance") {
+		balance, ok := new(big.Int).SetString(simBalance, 10)
+		if !ok || balance.Sign() < 0 {
+			return nil, fmt.Errorf("invalid --balance: %s", simBalance)
+		}
+		account.Balance = balance
+		set = true
+	}
+	if flags.Changed("nonce") {
+		account.Nonce = &simNonce
+		set = true
+	}
+	for _, entry := range simStorage {
+		slot, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --storage %q: expected slot=value", entry)
+		}
+		key, err := parseWord(slot)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --storage slot %q: %w", slot, err)
+		}
+		word, err := parseWord(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --storage value %q: %w", value, err)
+		}
+		if account.State == nil {
+			account.State = make(map[common.Hash]common.Hash)
+		}
+		account.State[key] = word
+		set = true
+	}
+
+	if !set {
+		return nil, nil
+	}
+	return analyzer.StateOverride{to: account}, nil
+}
+
+// parseWord parses a 32-byte word given in hex with a 0x prefix, or in decimal
+func parseWord(s string) (common.Hash, error) {
+	word, ok := new(big.Int).SetString(strings.TrimSpace(s), 0)
+	if !ok || word.Sign() < 0 || word.BitLen() > 256 {
+		return common.Hash{}, errors.New("not a 32-byte word")
+	}
+	return common.BigToHash(word), nil
+}
+
+// parseBlockOverride returns the block context fields set with the --block-* flags,
+// or nil when none are set
+func parseBlockOverride(flags *pflag.FlagSet) (*analyzer.BlockOverride, error) {
+	var override analyzer.BlockOverride
+	set := false
+
+	if flags.Changed("block-number") {
+		override.Number = new(big.Int).SetUint64(blockNumber)
+		set = true
+	}
+	if flags.Changed("block-timestamp") {
+		override.Time = &blockTime
+		set = true
+	}
+	if flags.Changed("block-basefee") {
+		baseFee, ok := new(big.Int).SetString(blockBaseFee, 10)
+		if !ok || baseFee.Sign() < 0 {
+			return nil, fmt.Errorf("invalid --block-basefee: %s", blockBaseFee)
+		}
+		override.BaseFee = baseFee
+		set = true
+	}
+	if flags.Changed("block-prevrandao") {
+		randao, err := hexutil.Decode(blockRandao)
+		if err != nil || len(randao) > common.HashLength {
+			return nil, fmt.Errorf("invalid --block-prevrandao: %s", blockRandao)
+		}
+		hash := common.BytesToHash(randao)
+		override.PrevRandao = &hash
+		set = true
+	}
+	if flags.Changed("block-coinbase") {
+		if !common.IsHexAddress(blockCoinbase) {
+			return nil, fmt.Errorf("invalid --block-coinbase address: %s", blockCoinbase)
+		}
+		coinbase := common.HexToAddress(blockCoinbase)
+		override.Coinbase = &coinbase
+		set = true
+	}
+
+	if !set {
+		return nil, nil
+	}
+	return &override, nil
+}
+
+func init() {
+	simulateCmd.Flags().StringVar(&simFrom, "from", "", "Sender address (default: zero address)")
+	simulateCmd.Flags().StringVar(&simTo, "to", "", "Recipient contract address")
+	simulateCmd.Flags().StringVar(&simData, "data", "", "Hex-encoded calldata")
+	simulateCmd.Flags().StringVar(&simValue, "value", "0", "Value to send in wei")
+	simulateCmd.Flags().StringVar(&simCode, "code", "", "Hex-encoded code to override at the recipient address")
+	simulateCmd.Flags().StringVar(&simBalance, "balance", "", "Balance in wei to override at the recipient address")
+	simulateCmd.Flags().Uint64Var(&simNonce, "nonce", 0, "Nonce to override at the recipient address")
+	simulateCmd.Flags().StringArrayVar(&simStorage, "storage", nil, "Storage slot of the recipient to override as slot=value, in hex or decimal (repeatable)")
+	simulateCmd.Flags().Uint64Var(&blockNumber, "block-number", 0, "Block number seen by NUMBER, also selecting the fork rules (default: the latest block's)")
+	simulateCmd.Flags().Uint64Var(&blockTime, "block-timestamp", 0, "Block timestamp in seconds seen by TIMESTAMP, also selecting the fork rules (default: the latest block's)")
+	simulateCmd.Flags().StringVar(&blockBaseFee, "block-basefee", "", "Base fee in wei seen by BASEFEE (default: zero, as for eth_call)")
+	simulateCmd.Flags().StringVar(&blockRandao, "block-prevrandao", "", "Hex-encoded PREVRANDAO value (default: the latest block's)")
+	simulateCmd.Flags().StringVar(&blockCoinbase, "block-coinbase", "", "Coinbase address seen by COINBASE (default: the latest block's)")
+	simulateCmd.Flags().Uint64Var(&gasLimit, "gas-limit", 0, "Gas limit of the call (default: the latest block's gas limit)")
+	simulateCmd.Flags().StringVar(&stepsOut, "steps-out", "", "Stream raw trace steps as JSON lines to this file")
+	simulateCmd.Flags().StringVar(&accessListOut, "access-list-out", "", "Write the suggested EIP-2930 access list as JSON to this file")
+	simulateCmd.Flags().StringVar(&timelineOut, "timeline", "", "Write cumulative gas against step index as CSV to this file (also adds a timeline to JSON reports)")
+	simulateCmd.Flags().IntVar(&timelinePoints, "timeline-points", tracer.DefaultTimelinePoints, "Maximum number of points kept in the --timeline, downsampling longer traces")
+	simulateCmd.MarkFlagRequired("to")
+
+	rootCmd.AddCommand(simulateCmd)
+}
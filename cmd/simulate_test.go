@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devlongs/evm-tracer/internal/analyzer"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestSimulateShortTimeout(t *testing.T) {
+	rootCmd.SetArgs([]string{
+		"simulate",
+		"--rpc", "http://127.0.0.1:1",
+		"--to", "0x0000000000000000000000000000000000002000",
+		"--timeout", "1ns",
+	})
+	rootCmd.SetErr(io.Discard)
+	rootCmd.SilenceUsage = true
+	defer func() {
+		rootCmd.SetArgs(nil)
+		rootCmd.SetErr(nil)
+		rootCmd.SilenceUsage = false
+		timeout = 60 * time.Second
+	}()
+
+	err := rootCmd.Execute()
+	if err == nil {
+		t.Fatal("Expected simulate to fail with a 1ns timeout")
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+
+	if !errors.Is(err, analyzer.ErrRPCTimeout) {
+		t.Errorf("Expected ErrRPCTimeout, got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "--timeout") {
+		t.Errorf("Expected a --timeout hint, got %v", err)
+	}
+}
+
+func TestParseBlockOverride(t *testing.T) {
+	flags := simulateCmd.Flags()
+	defer func() {
+		for _, name := range []string{"block-number", "block-timestamp", "block-basefee", "block-prevrandao", "block-coinbase"} {
+			flag := flags.Lookup(name)
+			flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+		}
+	}()
+
+	override, err := parseBlockOverride(flags)
+	if err != nil || override != nil {
+		t.Fatalf("Expected no override without flags, got %+v (%v)", override, err)
+	}
+
+	for name, value := range map[string]string{
+		"block-timestamp":  "1735689600",
+		"block-basefee":    "1000000000",
+		"block-prevrandao": "0xabcd",
+		"block-coinbase":   "0x0000000000000000000000000000000000000c0b",
+	} {
+		if err := flags.Set(name, value); err != nil {
+			t.Fatalf("Failed to set --%s: %v", name, err)
+		}
+	}
+
+	override, err = parseBlockOverride(flags)
+	if err != nil {
+		t.Fatalf("parseBlockOverride() error: %v", err)
+	}
+	if override.Number != nil {
+		t.Errorf("Expected the block number to be kept, got %v", override.Number)
+	}
+	if override.Time == nil || *override.Time != 1735689600 {
+		t.Errorf("Expected timestamp 1735689600, got %v", override.Time)
+	}
+	if override.BaseFee == nil || override.BaseFee.Uint64() != 1000000000 {
+		t.Errorf("Expected base fee 1000000000, got %v", override.BaseFee)
+	}
+	if override.PrevRandao == nil || *override.PrevRandao != common.HexToHash("0xabcd") {
+		t.Errorf("Expected prevrandao 0xabcd, got %v", override.PrevRandao)
+	}
+	if override.Coinbase == nil || *override.Coinbase != common.HexToAddress("0xc0b") {
+		t.Errorf("Expected coinbase 0xc0b, got %v", override.Coinbase)
+	}
+
+	flags.Set("block-basefee", "-1")
+	if _, err := parseBlockOverride(flags); err == nil {
+		t.Error("Expected an error for a negative base fee")
+	}
+}
+
+func TestParseStateOverride(t *testing.T) {
+	flags := simulateCmd.Flags()
+	defer func() {
+		for _, name := range []string{"code", "balance", "nonce"} {
+			flag := flags.Lookup(name)
+			flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+		}
+		simStorage = nil
+		flags.Lookup("storage").Changed = false
+	}()
+
+	to := common.HexToAddress("0x2000")
+	override, err := parseStateOverride(flags, to)
+	if err != nil || override != nil {
+		t.Fatalf("Expected no override without flags, got %+v (%v)", override, err)
+	}
+
+	for _, arg := range [][2]string{
+		{"code", "0x6000"},
+		{"balance", "1000000000000000000"},
+		{"nonce", "7"},
+		{"storage", "0x0=0x2a"},
+		{"storage", "1=255"},
+	} {
+		if err := flags.Set(arg[0], arg[1]); err != nil {
+			t.Fatalf("Failed to set --%s: %v", arg[0], err)
+		}
+	}
+
+	override, err = parseStateOverride(flags, to)
+	if err != nil {
+		t.Fatalf("parseStateOverride() error: %v", err)
+	}
+	account, ok := override[to]
+	if !ok || len(override) != 1 {
+		t.Fatalf("Expected an override of the recipient only, got %+v", override)
+	}
+	if len(account.Code) != 2 {
+		t.Errorf("Expected 2 bytes of code, got %x", account.Code)
+	}
+	if account.Balance == nil || account.Balance.String() != "1000000000000000000" {
+		t.Errorf("Expected balance 1000000000000000000, got %v", account.Balance)
+	}
+	if account.Nonce == nil || *account.Nonce != 7 {
+		t.Errorf("Expected nonce 7, got %v", account.Nonce)
+	}
+	if len(account.State) != 2 {
+		t.Fatalf("Expected 2 storage slots, got %v", account.State)
+	}
+	if account.State[common.Hash{}] != common.HexToHash("0x2a") {
+		t.Errorf("Expected slot 0 set to 0x2a, got %s", account.State[common.Hash{}].Hex())
+	}
+	if account.State[common.HexToHash("0x1")] != common.HexToHash("0xff") {
+		t.Errorf("Expected slot 1 set to 0xff, got %s", account.State[common.HexToHash("0x1")].Hex())
+	}
+
+	simStorage = nil
+	flags.Set("balance", "-1")
+	if _, err := parseStateOverride(flags, to); err == nil {
+		t.Error("Expected an error for a negative balance")
+	}
+	flags.Set("balance", "1")
+
+	for _, entry := range []string{"0x0", "slot=1", "0x0=0x1" + strings.Repeat("0", 64)} {
+		simStorage = []string{entry}
+		if _, err := parseStateOverride(flags, to); err == nil {
+			t.Errorf("Expected an error for --storage %s", entry)
+		}
+	}
+}
@@ -1,16 +1,41 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"time"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/devlongs/evm-tracer/internal/analyzer"
 	"github.com/devlongs/evm-tracer/internal/formatter"
+	"github.com/devlongs/evm-tracer/internal/signatures"
+	"github.com/devlongs/evm-tracer/internal/tracer"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
+var (
+	// tuiMode and openTUI are set by builds with the tui tag (see tui.go)
+	tuiMode bool
+	openTUI func(tr *tracer.GasOptimizationTracer) error
+
+	stepsOut        string
+	accessListOut   string
+	timelineOut     string
+	timelinePoints  int
+	allowEmptyState bool
+	tracePending    bool
+	gasLimit        uint64
+	traceCacheDir   string
+)
+
 var traceCmd = &cobra.Command{
 	Use:   "trace [transaction-hash]",
 	Short: "Trace a transaction and analyze gas optimization opportunities",
@@ -22,7 +47,12 @@ The transaction must be on the connected network (default: local node).
 Example:
   evm-tracer trace 0x1234...
   evm-tracer trace 0x1234... --rpc https://mainnet.infura.io/v3/YOUR-KEY
-  evm-tracer trace 0x1234... --json > report.json`,
+  evm-tracer trace 0x1234... --json > report.json
+  evm-tracer trace 0x1234... --format markdown --output reports/comment.md
+  evm-tracer trace 0x1234... --steps-out steps.jsonl
+  evm-tracer trace 0x1234... --access-list-out access-list.json
+  evm-tracer trace 0x1234... --pending
+  evm-tracer trace 0x1234... --cache-dir ~/.cache/evm-tracer`,
 	Args: cobra.ExactArgs(1),
 	RunE: runTrace,
 }
@@ -38,68 +68,659 @@ func runTrace(cmd *cobra.Command, args []string) error {
 	txHash := common.HexToHash(txHashStr)
 
 	if verbose {
-		fmt.Printf("🔍 Analyzing transaction: %s\n", txHash.Hex())
-		fmt.Printf("📡 Connecting to: %s\n\n", rpcURL)
+		fmt.Fprintf(os.Stderr, "🔍 Analyzing transaction: %s\n", txHash.Hex())
+		fmt.Fprintf(os.Stderr, "📡 Connecting to: %s\n\n", maskRPCURL(rpcURL))
+	}
+
+	headers, err := rpcHeaders()
+	if err != nil {
+		return err
 	}
 
 	// Create analyzer
-	an, err := analyzer.NewTransactionAnalyzer(rpcURL)
+	// Pending transactions run against the current state, so no archive is needed
+	an, err := analyzer.NewTransactionAnalyzerWithOptions(rpcURL, analyzer.Options{
+		AllowEmptyState: allowEmptyState,
+		LatestStateOnly: tracePending,
+		GasLimit:        gasLimit,
+		CacheSize:       cacheSize,
+		MaxSteps:        maxSteps,
+		ResultCache:     resultCache(),
+		Headers:         headers,
+	})
+	if errors.Is(err, analyzer.ErrNotArchiveNode) {
+		return fmt.Errorf("%w\nUse an archive node, or pass --allow-empty-state to trace against empty state on devnets", err)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create analyzer: %w", err)
 	}
 	defer an.Close()
 
+	if err := configureTracer(an.GetTracer()); err != nil {
+		return err
+	}
+
+	closeSteps, err := attachStepWriter(an.GetTracer())
+	if err != nil {
+		return err
+	}
+
 	// Analyze transaction
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	// Ctrl-C stops the trace like a timeout, keeping the results collected so far
+	interrupt, stop := interruptContext()
+	defer stop()
+	ctx, cancel := context.WithTimeout(interrupt, timeout)
 	defer cancel()
 
 	if verbose {
-		fmt.Println("⚙️  Tracing transaction...")
+		fmt.Fprintln(os.Stderr, "⚙️  Tracing transaction...")
+	}
+
+	stopProgress := startProgress(an.GetTracer())
+	if tracePending {
+		err = an.AnalyzePendingTransaction(ctx, txHash)
+	} else {
+		err = an.AnalyzeTransaction(ctx, txHash)
+	}
+	stopProgress()
+	if err != nil && !analyzer.IsPartial(err) {
+		closeSteps()
+		return withTimeoutHint(fmt.Errorf("analysis failed: %w", err))
+	}
+	partialErr := err
+	if an.FromCache() {
+		fmt.Fprintf(os.Stderr, "💾 Loaded the trace from the cache in %s\n", traceCacheDir)
+	}
+
+	if err := closeSteps(); err != nil {
+		return err
+	}
+
+	if err := writeAccessList(an.GetTracer()); err != nil {
+		return err
+	}
+	if err := writeTimeline(an.GetTracer()); err != nil {
+		return err
+	}
+
+	resolveNames(ctx, an)
+	if err := finishResults(cmd, an.GetTracer()); err != nil {
+		return err
+	}
+	return partialFailure(cmd, "analysis", partialErr)
+}
+
+// resultCache returns the --cache-dir trace cache, or nil when traces are not
+// cached. Streamed steps and access lists need the trace to run, so they disable it.
+func resultCache() *analyzer.ResultCache {
+	if traceCacheDir == "" || stepsOut != "" || accessListOut != "" {
+		return nil
+	}
+	return &analyzer.ResultCache{
+		Dir:      traceCacheDir,
+		Version:  toolVersion + "/" + gethVersion(),
+		Settings: traceSettings(),
+		OnStoreError: func(err error) {
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		},
+	}
+}
+
+// traceSettings describes the flags that change what a trace records, keying
+// cached traces apart
+func traceSettings() string {
+	sections, _ := formatter.ParseSections(sectionList)
+	timeline := 0
+	if timelineOut != "" || sections.Selects("timeline") {
+		timeline = timelinePoints
+	}
+	return fmt.Sprintf("minimal=%t sample-rate=%d focus=%s ignore=%s disasm=%t internal=%t timeline=%d baseline=%s min-forwarded-gas=%d l2-ratio=%g max-steps=%d gas-limit=%d empty-state=%t",
+		minimal, sampleRate, strings.Join(focusOps, ","), strings.Join(ignoreOps, ","), disasm,
+		internalTxs || sections.Selects("internal"), timeline, baselinePath,
+		minForwardedGas, l2CompressionRatio, maxSteps, gasLimit, allowEmptyState)
+}
+
+// simulatedStateNote explains how results traced against the named state may
+// differ from the mined transaction
+func simulatedStateNote(state string) string {
+	if state == tracer.StateEmpty {
+		return "Traced against empty state - accounts and storage read as zero, so gas and findings may differ from the mined transaction"
+	}
+	return fmt.Sprintf("Simulated against %s state - results may change once the transaction is mined", state)
+}
+
+// partialFailure returns the error that cut a trace short after its partial
+// results were printed, or nil for a complete trace
+func partialFailure(cmd *cobra.Command, stage string, err error) error {
+	if err == nil {
+		return nil
+	}
+	// The partial results are already printed, so usage would only bury them
+	cmd.SilenceUsage = true
+	return withTimeoutHint(fmt.Errorf("%s incomplete, results are partial: %w", stage, err))
+}
+
+// disasmContext is the number of instructions shown either side of a location with --disasm
+const disasmContext = 3
+
+// configureTracer applies the tracer options selected on the command line
+func configureTracer(tr *tracer.GasOptimizationTracer) error {
+	config, err := tracerConfig()
+	if err != nil {
+		return err
+	}
+	tr.SetConfig(config)
+	if sampleRate == 0 {
+		return fmt.Errorf("--sample-rate must be positive")
+	}
+	tr.SetMinimal(minimal || sampleRate > 1)
+	tr.SetSampleRate(sampleRate)
+
+	sections, err := formatter.ParseSections(sectionList)
+	if err != nil {
+		return fmt.Errorf("invalid --sections: %w", err)
+	}
+	tr.SetInternalTxs(internalTxs || sections.Selects("internal"))
+	tr.OmitReportKeys(sections.OmittedReportKeys())
+
+	focus, err := tracer.ParseOpcodes(focusOps)
+	if err != nil {
+		return fmt.Errorf("invalid --focus-ops: %w", err)
+	}
+	ignore, err := tracer.ParseOpcodes(ignoreOps)
+	if err != nil {
+		return fmt.Errorf("invalid --ignore-ops: %w", err)
+	}
+	tr.SetOpcodeFocus(focus, ignore)
+
+	if disasm {
+		tr.SetDisassembly(disasmContext)
+	}
+
+	if timelineOut != "" || sections.Selects("timeline") {
+		if timelinePoints <= 0 {
+			return fmt.Errorf("--timeline-points must be positive")
+		}
+		tr.SetTimeline(timelinePoints)
+	}
+
+	if baselinePath != "" {
+		schedule, err := tracer.LoadGasSchedule(baselinePath)
+		if err != nil {
+			return err
+		}
+		tr.SetBaselineSchedule(schedule)
+	}
+	return nil
+}
+
+// diffBaseline compares the trace against the --baseline report
+func diffBaseline(tr *tracer.GasOptimizationTracer) (tracer.ReportDiff, error) {
+	baseline, err := tracer.LoadReport(baselineFile)
+	if err != nil {
+		return tracer.ReportDiff{}, fmt.Errorf("failed to load baseline: %w", err)
+	}
+	current, err := tr.GetTypedReport()
+	if err != nil {
+		return tracer.ReportDiff{}, fmt.Errorf("failed to generate report: %w", err)
+	}
+	return tracer.DiffReports(baseline, current), nil
+}
+
+// finishResults prints the results and a one-line summary, then applies the
+// --fail-on and --assert-* gates
+func finishResults(cmd *cobra.Command, tr *tracer.GasOptimizationTracer) error {
+	threshold := 0
+	if failOn != "none" {
+		threshold = tracer.SeverityRank(failOn)
+		if threshold == 0 {
+			return fmt.Errorf("unknown --fail-on severity: %s", failOn)
+		}
+	}
+	ceiling := 0
+	if assertAbove != "" {
+		ceiling = tracer.SeverityRank(assertAbove)
+		if ceiling == 0 {
+			return fmt.Errorf("unknown --assert-no-findings-above severity: %s", assertAbove)
+		}
 	}
 
-	err = an.AnalyzeTransaction(ctx, txHash)
+	resolver, err := signatures.New(signatureMode, abiFiles, offline, signatures.DefaultDirectoryURL)
 	if err != nil {
-		return fmt.Errorf("analysis failed: %w", err)
+		return err
+	}
+	tr.ResolveSignatures(resolver)
+
+	if tuiMode {
+		if err := applyFilter(tr); err != nil {
+			return err
+		}
+		if err := openTUI(tr); err != nil {
+			return fmt.Errorf("interactive viewer failed: %w", err)
+		}
+	} else if err := printResults(tr); err != nil {
+		return err
 	}
 
-	// Get results
-	tracer := an.GetTracer()
+	optimizations := tr.GetOptimizations()
+	counts := make(map[string]int)
+	failing, above := 0, 0
+	for _, opt := range optimizations {
+		counts[opt.Severity]++
+		rank := tracer.SeverityRank(opt.Severity)
+		if threshold > 0 && rank >= threshold {
+			failing++
+		}
+		if ceiling > 0 && rank > ceiling {
+			above++
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "RESULT: %d findings (%d high, %d medium, %d low)\n",
+		len(optimizations), counts["high"], counts["medium"], counts["low"])
+
+	if err := writeSummary(tr, optimizations, counts); err != nil {
+		return err
+	}
 
-	// Output results
+	var failures []error
+	if failing > 0 {
+		failures = append(failures, fmt.Errorf("%d findings at or above %s severity", failing, failOn))
+	}
+	if above > 0 {
+		failures = append(failures, fmt.Errorf("assertion failed: %d findings above %s severity", above, assertAbove))
+	}
+	if assertMaxGas > 0 && tr.TotalGasUsed > assertMaxGas {
+		failures = append(failures, fmt.Errorf("assertion failed: gas used %d exceeds the --assert-max-gas budget of %d by %d",
+			tr.TotalGasUsed, assertMaxGas, tr.TotalGasUsed-assertMaxGas))
+	}
+	if len(failures) > 0 {
+		// The findings are the result, not a usage mistake
+		cmd.SilenceUsage = true
+		return errors.Join(failures...)
+	}
+	return nil
+}
+
+// consoleTheme returns the --theme colors with the --number-format and --gas-unit applied
+func consoleTheme() (formatter.Theme, error) {
+	theme, err := formatter.ThemeByName(themeName)
+	if err != nil {
+		return formatter.Theme{}, err
+	}
+	if theme.Numbers, err = formatter.ParseNumberFormat(numberFormat); err != nil {
+		return formatter.Theme{}, err
+	}
+	theme.Unit, err = formatter.ParseGasUnit(gasUnit)
+	return theme, err
+}
+
+// withTimeoutHint suggests raising --timeout when err was caused by the deadline
+func withTimeoutHint(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w\nIncrease --timeout (currently %s) for long-running traces", err, timeout)
+	}
+	return err
+}
+
+// writeAccessList writes the suggested EIP-2930 access list to the --access-list-out file when set
+func writeAccessList(tr *tracer.GasOptimizationTracer) error {
+	if accessListOut == "" {
+		return nil
+	}
+
+	list, err := tr.AccessListJSON()
+	if err != nil {
+		return fmt.Errorf("failed to generate access list: %w", err)
+	}
+
+	if err := os.WriteFile(accessListOut, []byte(list+"\n"), 0o644); err != nil {
+		return fmt.Errorf("failed to write access list: %w", err)
+	}
+	return nil
+}
+
+// writeTimeline writes the gas timeline as CSV to the --timeline file, if set
+func writeTimeline(tr *tracer.GasOptimizationTracer) error {
+	if timelineOut == "" {
+		return nil
+	}
+
+	csv := formatter.FormatTimelineCSV(tr.GetTimeline())
+	if err := os.WriteFile(timelineOut, []byte(csv), 0o644); err != nil {
+		return fmt.Errorf("failed to write timeline: %w", err)
+	}
+	return nil
+}
+
+// traceSummary is the machine-readable summary written with --summary-file
+type traceSummary struct {
+	TotalGasUsed    uint64          `json:"total_gas_used"`
+	Findings        int             `json:"findings"`
+	BySeverity      map[string]int  `json:"by_severity"`
+	TotalGasSavings uint64          `json:"total_gas_savings"`
+	TopFinding      *summaryFinding `json:"top_finding,omitempty"`
+}
+
+// summaryFinding is the most severe finding in a trace summary
+type summaryFinding struct {
+	Type       string `json:"type"`
+	Severity   string `json:"severity"`
+	Location   string `json:"location"`
+	GasSavings uint64 `json:"gas_savings"`
+}
+
+// writeSummary writes the --summary-file summary of the reported optimizations,
+// which are sorted most severe first, with their counts by severity
+func writeSummary(tr *tracer.GasOptimizationTracer, optimizations []tracer.Optimization, counts map[string]int) error {
+	if summaryPath == "" {
+		return nil
+	}
+
+	savings, _ := tracer.SavingsTotals(optimizations)
+	savings, _ = tracer.CapSavings(savings, tr.TotalGasUsed)
+	summary := traceSummary{
+		TotalGasUsed:    tr.TotalGasUsed,
+		Findings:        len(optimizations),
+		BySeverity:      map[string]int{"high": counts["high"], "medium": counts["medium"], "low": counts["low"]},
+		TotalGasSavings: savings,
+	}
+	if len(optimizations) > 0 {
+		top := optimizations[0]
+		summary.TopFinding = &summaryFinding{Type: top.Type, Severity: top.Severity, Location: top.Location.String(), GasSavings: top.GasSavings}
+	}
+
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to generate summary: %w", err)
+	}
+	if err := os.WriteFile(summaryPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	return nil
+}
+
+// attachStepWriter streams raw trace steps to the --steps-out file when set.
+// The returned function flushes the output and closes the file.
+func attachStepWriter(tr *tracer.GasOptimizationTracer) (func() error, error) {
+	if stepsOut == "" {
+		return func() error { return nil }, nil
+	}
+
+	file, err := os.Create(stepsOut)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create steps output: %w", err)
+	}
+
+	w := bufio.NewWriter(file)
+	tr.SetStepWriter(w)
+
+	return func() error {
+		tr.SetStepWriter(nil)
+		flushErr := w.Flush()
+		closeErr := file.Close()
+		if err := tr.StepWriterError(); err != nil {
+			return fmt.Errorf("failed to write steps output: %w", err)
+		}
+		if flushErr != nil {
+			return fmt.Errorf("failed to write steps output: %w", flushErr)
+		}
+		return closeErr
+	}, nil
+}
+
+// resolveFormat returns the selected output format, honoring the --json shorthand
+func resolveFormat() (string, error) {
 	if outputJSON {
-		report, err := tracer.GetReport()
+		return "json", nil
+	}
+
+	switch outputFormat {
+	case "console", "json", "markdown", "flamegraph", "parity":
+		return outputFormat, nil
+	default:
+		return "", fmt.Errorf("unknown output format: %s", outputFormat)
+	}
+}
+
+// printResults writes the trace results in the selected output format to
+// stdout, or to the --output file when set
+func printResults(tr *tracer.GasOptimizationTracer) error {
+	format, err := resolveFormat()
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(func(w io.Writer) error {
+		return writeResults(w, tr, format)
+	})
+}
+
+// writeOutput calls write with stdout, or with the --output file when set
+func writeOutput(write func(w io.Writer) error) error {
+	if outputPath == "" {
+		return write(os.Stdout)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	// Terminal colors do not belong in files
+	color.NoColor = true
+
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+// rpcHeaders returns the headers sent to the node, selected by --rpc-header and --rpc-auth
+func rpcHeaders() (http.Header, error) {
+	return analyzer.RPCHeaders(rpcHeaderList, rpcAuth)
+}
+
+// filterCriteria returns the optimization filter selected by the severity and type
+// flags and the --ignore-file
+func filterCriteria() tracer.FilterCriteria {
+	return tracer.FilterCriteria{
+		MinSeverity:  minSeverity,
+		OnlyTypes:    onlyTypes,
+		ExcludeTypes: excludeTypes,
+		Suppress:     suppressions,
+	}
+}
+
+// applyFilter drops the optimizations excluded by the severity and type flags
+func applyFilter(tr *tracer.GasOptimizationTracer) error {
+	criteria := filterCriteria()
+	if err := criteria.Validate(); err != nil {
+		return err
+	}
+	tr.ApplyFilter(criteria)
+	return nil
+}
+
+// writeResults writes the trace results to w in the given output format
+func writeResults(w io.Writer, tr *tracer.GasOptimizationTracer, format string) error {
+	if err := applyFilter(tr); err != nil {
+		return err
+	}
+
+	switch format {
+	case "json":
+		getReport := tr.GetReport
+		if compactJSON {
+			getReport = tr.GetCompactReport
+		}
+		report, err := getReport()
 		if err != nil {
 			return fmt.Errorf("failed to generate report: %w", err)
 		}
-		fmt.Println(formatter.FormatJSON(report))
-	} else {
-		// Get optimizations
-		optimizations := tracer.GetOptimizations()
+		fmt.Fprintln(w, formatter.FormatJSON(report))
+		return nil
+
+	case "markdown":
+		if tr.Partial != "" {
+			fmt.Fprintf(w, "> ⚠️ **Partial results:** %s. Findings and gas cover only the steps traced before execution stopped.\n\n", tr.Partial)
+		}
+		numbers, err := formatter.ParseNumberFormat(numberFormat)
+		if err != nil {
+			return err
+		}
+		unit, err := formatter.ParseGasUnit(gasUnit)
+		if err != nil {
+			return err
+		}
+		if profile, ok := tr.GetSampledProfile(); ok {
+			fmt.Fprintf(w, "> 📉 **Sampled profile:** 1 in %d steps recorded. Gas per opcode is approximate; total gas is exact.\n\n", profile.Rate)
+		}
+		fmt.Fprint(w, formatter.FormatMarkdown(tr.GetOptimizations(), tr.GasPerOpcode, tr.TotalGasUsed, numbers, unit))
+		if tr.SimulatedState != "" {
+			fmt.Fprintf(w, "\n> ⏳ %s.\n", simulatedStateNote(tr.SimulatedState))
+		}
+		return nil
+
+	case "flamegraph":
+		fmt.Fprint(w, formatter.FormatFlamegraph(tr.GetCallTree()))
+		return nil
+
+	case "parity":
+		traces, err := formatter.FormatParity(tr.GetCallTree())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, traces)
+		return nil
+	}
+
+	// Get optimizations
+	optimizations := tr.GetOptimizations()
+
+	// Flag results cut short before execution completed before anything else
+	if tr.Partial != "" {
+		fmt.Fprintf(w, "⚠️  PARTIAL RESULTS: %s\n   Findings and gas cover only the steps traced before execution stopped\n\n", tr.Partial)
+	}
+
+	// Sampled gas per opcode is extrapolated, unlike the total
+	if profile, ok := tr.GetSampledProfile(); ok {
+		fmt.Fprintf(w, "📉 SAMPLED PROFILE: 1 in %d steps recorded\n   Gas per opcode and per location are approximate; total gas is exact\n\n", profile.Rate)
+	}
 
-		// Format and display
-		output := formatter.FormatOptimizations(optimizations, tracer.TotalGasUsed)
-		fmt.Print(output)
+	// Format and display
+	theme, err := consoleTheme()
+	if err != nil {
+		return err
+	}
+
+	sections, err := formatter.ParseSections(sectionList)
+	if err != nil {
+		return fmt.Errorf("invalid --sections: %w", err)
+	}
+	sectionCtx := formatter.SectionContext{Theme: theme, Verbose: verbose, Minimal: minimal || sampleRate > 1}
+	fmt.Fprint(w, formatter.FormatSections(tr, sections, true, sectionCtx))
+	if explainTypes && sections.Includes("opt") {
+		fmt.Fprint(w, formatter.FormatExplanations(optimizations, theme))
+	}
+
+	// Name the transaction's sender and target when their names are resolved
+	if root := tr.GetCallTree(); root != nil && (root.FromName != "" || root.ToName != "") {
+		fmt.Fprintf(w, "🏷️  %s → %s\n\n", formatter.FormatAddress(root.From, root.FromName), formatter.FormatAddress(root.To, root.ToName))
+	}
+
+	// Label simulations, whose results may differ from the mined transaction
+	if tr.SimulatedState != "" {
+		fmt.Fprintf(w, "⏳ %s\n\n", simulatedStateNote(tr.SimulatedState))
+	}
 
-		// Show gas breakdown if verbose
-		if verbose {
-			breakdown := formatter.FormatGasBreakdown(tracer.GasPerOpcode, tracer.TotalGasUsed)
-			fmt.Print(breakdown)
+	// Findings only cover the opcodes analyzed in detail
+	if len(focusOps) > 0 || len(ignoreOps) > 0 {
+		fmt.Fprint(w, "🔎 Findings limited by opcode")
+		if len(focusOps) > 0 {
+			fmt.Fprintf(w, ", focused on %s", strings.Join(focusOps, ","))
+		}
+		if len(ignoreOps) > 0 {
+			fmt.Fprintf(w, ", ignoring %s", strings.Join(ignoreOps, ","))
 		}
+		fmt.Fprint(w, "\n\n")
+	}
 
-		// Summary recommendations
-		if len(optimizations) > 0 {
-			fmt.Println("💡 RECOMMENDATIONS:")
-			fmt.Println("   1. Review high-priority optimizations first")
-			fmt.Println("   2. Consider caching frequently accessed storage values")
-			fmt.Println("   3. Batch external calls when possible")
-			fmt.Println("   4. Use memory instead of storage for temporary data")
-			fmt.Println()
+	// Point at opcodes that stopped a frame, such as running out of gas
+	faults := tr.GetFaults()
+	for _, fault := range faults {
+		label := "Fault"
+		if fault.OutOfGas {
+			label = "Out of gas"
 		}
+		fmt.Fprintf(w, "💥 %s at %s (%s, depth %d, contract %s): %s\n",
+			label, fault.Location(), fault.Op, fault.Depth, fault.Contract.Hex(), fault.Error)
+	}
+	if len(faults) > 0 {
+		fmt.Fprintln(w)
+	}
+
+	// Label contract deployments
+	if tr.IsCreation {
+		fmt.Fprintf(w, "📦 Contract deployment: %s (init code: %d bytes, %d gas)\n\n",
+			tr.CreatedAddress.Hex(), tr.InitCodeSize, tr.InitCodeGas)
+	}
+
+	// Blob gas is priced separately from execution gas
+	if tr.Blobs != nil {
+		fmt.Fprintf(w, "🫧 Blob gas: %d blobs, %d blob gas", tr.Blobs.Blobs, tr.Blobs.BlobGas)
+		if tr.Blobs.Cost != nil {
+			fmt.Fprintf(w, " (%s wei at %s wei per blob gas)", tr.Blobs.Cost, tr.Blobs.BlobBaseFee)
+		}
+		fmt.Fprint(w, ", not included in execution gas\n\n")
+	}
+
+	// Internal transactions, gas breakdown, call tree and the other sections
+	fmt.Fprint(w, formatter.FormatSections(tr, sections, false, sectionCtx))
+
+	// Show the what-if projection when a baseline schedule is set
+	if whatIf, ok := tr.BaselineWhatIf(); ok {
+		fmt.Fprint(w, formatter.FormatWhatIf(whatIf, theme))
+	}
+
+	// Show the changes since a saved report when a baseline report is set
+	if baselineFile != "" {
+		diff, err := diffBaseline(tr)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(w, formatter.FormatReportDiff(diff, theme))
+	}
+
+	// Summary recommendations
+	if len(optimizations) > 0 && sections.Includes("opt") {
+		fmt.Fprintln(w, "💡 RECOMMENDATIONS:")
+		fmt.Fprintln(w, "   1. Review high-priority optimizations first")
+		fmt.Fprintln(w, "   2. Consider caching frequently accessed storage values")
+		fmt.Fprintln(w, "   3. Batch external calls when possible")
+		fmt.Fprintln(w, "   4. Use memory instead of storage for temporary data")
+		fmt.Fprintln(w)
 	}
 
 	return nil
 }
 
 func init() {
+	traceCmd.Flags().StringVar(&stepsOut, "steps-out", "", "Stream raw trace steps as JSON lines to this file")
+	traceCmd.Flags().StringVar(&accessListOut, "access-list-out", "", "Write the suggested EIP-2930 access list as JSON to this file")
+	traceCmd.Flags().StringVar(&timelineOut, "timeline", "", "Write cumulative gas against step index as CSV to this file (also adds a timeline to JSON reports)")
+	traceCmd.Flags().IntVar(&timelinePoints, "timeline-points", tracer.DefaultTimelinePoints, "Maximum number of points kept in the --timeline, downsampling longer traces")
+	traceCmd.Flags().BoolVar(&allowEmptyState, "allow-empty-state", false, "Trace against empty state when historical state is unavailable (devnets)")
+	traceCmd.Flags().Uint64Var(&gasLimit, "gas-limit", 0, "Trace as if the transaction had this gas limit, e.g. to find where it runs out of gas (0 keeps the original)")
+	traceCmd.Flags().BoolVar(&tracePending, "pending", false, "Trace a mempool transaction by simulating it on top of the pending block")
+	traceCmd.Flags().StringVar(&traceCacheDir, "cache-dir", "", "Cache the reports of complete traces in this directory by transaction, chain and tool version, and load them instead of tracing again (not with --steps-out or --access-list-out)")
 	rootCmd.AddCommand(traceCmd)
 }
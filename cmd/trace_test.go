@@ -0,0 +1,340 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+)
+
+func TestOutputFile(t *testing.T) {
+	tr, err := traceDemo()
+	if err != nil {
+		t.Fatalf("traceDemo() error: %v", err)
+	}
+
+	dir := t.TempDir()
+	defer func() {
+		outputPath = ""
+		outputFormat = "console"
+	}()
+
+	for _, format := range []string{"console", "json", "markdown", "flamegraph"} {
+		outputFormat = format
+		outputPath = filepath.Join(dir, "nested", format, "report.out")
+
+		if err := printResults(tr); err != nil {
+			t.Fatalf("%s: printResults() error: %v", format, err)
+		}
+
+		written, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatalf("%s: failed to read output file: %v", format, err)
+		}
+
+		var expected bytes.Buffer
+		if err := writeResults(&expected, tr, format); err != nil {
+			t.Fatalf("%s: writeResults() error: %v", format, err)
+		}
+
+		if len(written) == 0 {
+			t.Errorf("%s: expected a non-empty report", format)
+		}
+
+		if !bytes.Equal(written, expected.Bytes()) {
+			t.Errorf("%s: file contents differ from formatted output:\n%s\nwant:\n%s", format, written, expected.String())
+		}
+	}
+}
+
+func TestFlamegraphSumsToTotalGas(t *testing.T) {
+	tr, err := traceDemo()
+	if err != nil {
+		t.Fatalf("traceDemo() error: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := writeResults(&out, tr, "flamegraph"); err != nil {
+		t.Fatalf("writeResults() error: %v", err)
+	}
+
+	total := uint64(0)
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			t.Fatalf("Expected 'stack gas', got '%s'", line)
+		}
+		gas, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			t.Fatalf("Expected a gas count, got '%s'", fields[1])
+		}
+		total += gas
+	}
+
+	if total != tr.TotalGasUsed {
+		t.Errorf("Expected the folded stacks to sum to %d, got %d", tr.TotalGasUsed, total)
+	}
+}
+
+func TestPendingResultsLabeled(t *testing.T) {
+	tr, err := traceDemo()
+	if err != nil {
+		t.Fatalf("traceDemo() error: %v", err)
+	}
+	tr.SetSimulatedState(tracer.StatePending)
+
+	for _, format := range []string{"console", "markdown", "json"} {
+		var out bytes.Buffer
+		if err := writeResults(&out, tr, format); err != nil {
+			t.Fatalf("%s: writeResults() error: %v", format, err)
+		}
+		if !strings.Contains(out.String(), "pending") {
+			t.Errorf("%s: expected the results to be labeled as simulated against pending state", format)
+		}
+	}
+
+	tr.SetSimulatedState(tracer.StateEmpty)
+	for _, format := range []string{"console", "markdown"} {
+		var out bytes.Buffer
+		if err := writeResults(&out, tr, format); err != nil {
+			t.Fatalf("%s: writeResults() error: %v", format, err)
+		}
+		if !strings.Contains(out.String(), "Traced against empty state") {
+			t.Errorf("%s: expected the results to be labeled as traced against empty state", format)
+		}
+	}
+}
+
+func TestPartialResultsBanner(t *testing.T) {
+	tr, err := traceDemo()
+	if err != nil {
+		t.Fatalf("traceDemo() error: %v", err)
+	}
+	tr.SetPartial("execution timed out")
+
+	for _, format := range []string{"console", "markdown", "json"} {
+		var out bytes.Buffer
+		if err := writeResults(&out, tr, format); err != nil {
+			t.Fatalf("%s: writeResults() error: %v", format, err)
+		}
+		if !strings.Contains(out.String(), "execution timed out") {
+			t.Errorf("%s: expected the partial results reason", format)
+		}
+		if format != "json" && !strings.Contains(strings.ToLower(out.String()), "partial results") {
+			t.Errorf("%s: expected a partial results banner", format)
+		}
+	}
+}
+
+func TestSectionsGasOnly(t *testing.T) {
+	sectionList = "gas"
+	defer func() { sectionList = "" }()
+
+	tr, err := traceDemo()
+	if err != nil {
+		t.Fatalf("traceDemo() error: %v", err)
+	}
+	if len(tr.GetOptimizations()) == 0 {
+		t.Fatal("Expected the demo to have findings")
+	}
+
+	var out bytes.Buffer
+	if err := writeResults(&out, tr, "console"); err != nil {
+		t.Fatalf("writeResults() error: %v", err)
+	}
+	console := out.String()
+	if !strings.Contains(console, "GAS USAGE BREAKDOWN") || !strings.Contains(console, "OPCODE FREQUENCY HISTOGRAM") {
+		t.Errorf("Expected the gas breakdown without --verbose, got:\n%s", console)
+	}
+	for _, section := range []string{"GAS OPTIMIZATION REPORT", "RECOMMENDATIONS", "CALL TREE"} {
+		if strings.Contains(console, section) {
+			t.Errorf("Expected no %s section, got:\n%s", section, console)
+		}
+	}
+
+	out.Reset()
+	if err := writeResults(&out, tr, "json"); err != nil {
+		t.Fatalf("writeResults() error: %v", err)
+	}
+	var report map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("failed to parse JSON report: %v", err)
+	}
+	if _, ok := report["gas_by_opcode"]; !ok {
+		t.Error("Expected gas_by_opcode in the JSON report")
+	}
+	for _, key := range []string{"optimizations", "total_gas_savings", "calls", "call_tree"} {
+		if _, ok := report[key]; ok {
+			t.Errorf("Expected %s to be left out of the JSON report", key)
+		}
+	}
+}
+
+func TestSectionsUnknown(t *testing.T) {
+	sectionList = "gas,stack"
+	defer func() { sectionList = "" }()
+
+	if _, err := traceDemo(); err == nil || !strings.Contains(err.Error(), "unknown section: stack") {
+		t.Errorf("Expected an unknown section error, got %v", err)
+	}
+}
+
+func TestSampledResultsLabeled(t *testing.T) {
+	sampleRate = 4
+	defer func() { sampleRate = 1 }()
+
+	tr, err := traceDemo()
+	if err != nil {
+		t.Fatalf("traceDemo() error: %v", err)
+	}
+
+	for _, format := range []string{"console", "markdown", "json"} {
+		var out bytes.Buffer
+		if err := writeResults(&out, tr, format); err != nil {
+			t.Fatalf("%s: writeResults() error: %v", format, err)
+		}
+		if !strings.Contains(strings.ToLower(out.String()), "sampled") {
+			t.Errorf("%s: expected the results to be labeled as sampled", format)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := writeResults(&out, tr, "console"); err != nil {
+		t.Fatalf("writeResults() error: %v", err)
+	}
+	if !strings.Contains(out.String(), "HOT CODE LOCATIONS") || !strings.Contains(out.String(), "GAS USAGE BREAKDOWN") {
+		t.Errorf("Expected the hot locations and gas breakdown, got:\n%s", out.String())
+	}
+}
+
+func TestGasUnitKeepsJSONRaw(t *testing.T) {
+	tr, err := traceDemo()
+	if err != nil {
+		t.Fatalf("traceDemo() error: %v", err)
+	}
+	defer func() { gasUnit = "" }()
+
+	totals := map[string]string{
+		"gas":  fmt.Sprintf("Total Gas Used: %d", tr.TotalGasUsed),
+		"kgas": fmt.Sprintf("Total Gas Used: %.2fK", float64(tr.TotalGasUsed)/1000),
+		"mgas": fmt.Sprintf("Total Gas Used: %.2fM", float64(tr.TotalGasUsed)/1000000),
+	}
+	for unit, total := range totals {
+		gasUnit = unit
+
+		var out bytes.Buffer
+		if err := writeResults(&out, tr, "console"); err != nil {
+			t.Fatalf("%s: writeResults() error: %v", unit, err)
+		}
+		if !strings.Contains(out.String(), total) {
+			t.Errorf("%s: expected %q in the console report", unit, total)
+		}
+
+		out.Reset()
+		if err := writeResults(&out, tr, "json"); err != nil {
+			t.Fatalf("%s: writeResults() error: %v", unit, err)
+		}
+		var report map[string]interface{}
+		if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+			t.Fatalf("%s: failed to decode report: %v", unit, err)
+		}
+		if report["total_gas_used"] != float64(tr.TotalGasUsed) {
+			t.Errorf("%s: expected raw total gas %d in JSON, got %v", unit, tr.TotalGasUsed, report["total_gas_used"])
+		}
+	}
+
+	gasUnit = "gwei"
+	if _, err := consoleTheme(); err == nil {
+		t.Error("Expected an unknown gas unit to be rejected")
+	}
+}
+
+func TestBaselineReportDiff(t *testing.T) {
+	tr, err := traceDemo()
+	if err != nil {
+		t.Fatalf("traceDemo() error: %v", err)
+	}
+	report, err := tr.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+
+	baselineFile = filepath.Join(t.TempDir(), "baseline.json")
+	defer func() { baselineFile = "" }()
+	if err := os.WriteFile(baselineFile, []byte(report), 0o644); err != nil {
+		t.Fatalf("failed to write baseline: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := writeResults(&out, tr, "console"); err != nil {
+		t.Fatalf("writeResults() error: %v", err)
+	}
+	want := fmt.Sprintf("New findings: 0, resolved: 0, unchanged: %d", len(tr.GetOptimizations()))
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("Expected %q against the demo's own report, got:\n%s", want, out.String())
+	}
+
+	baselineFile = filepath.Join(t.TempDir(), "missing.json")
+	if err := writeResults(&out, tr, "console"); err == nil {
+		t.Error("Expected an error for a missing baseline report")
+	}
+}
+
+func TestSummaryFile(t *testing.T) {
+	tr, err := traceDemo()
+	if err != nil {
+		t.Fatalf("traceDemo() error: %v", err)
+	}
+
+	dir := t.TempDir()
+	outputPath = filepath.Join(dir, "report.txt")
+	summaryPath = filepath.Join(dir, "summary.json")
+	defer func() {
+		outputPath = ""
+		summaryPath = ""
+	}()
+
+	if err := finishResults(traceCmd, tr); err != nil {
+		t.Fatalf("finishResults() error: %v", err)
+	}
+
+	data, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatalf("failed to read summary: %v", err)
+	}
+
+	var summary traceSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatalf("failed to parse summary: %v", err)
+	}
+
+	optimizations := tr.GetOptimizations()
+	counts := make(map[string]int)
+	for _, opt := range optimizations {
+		counts[opt.Severity]++
+	}
+
+	if summary.TotalGasUsed != tr.TotalGasUsed || summary.Findings != len(optimizations) {
+		t.Errorf("Expected %d gas and %d findings, got %d and %d", tr.TotalGasUsed, len(optimizations), summary.TotalGasUsed, summary.Findings)
+	}
+
+	for _, severity := range []string{"high", "medium", "low"} {
+		if summary.BySeverity[severity] != counts[severity] {
+			t.Errorf("Expected %d %s findings, got %d", counts[severity], severity, summary.BySeverity[severity])
+		}
+	}
+
+	if summary.TopFinding == nil || summary.TopFinding.Type != optimizations[0].Type {
+		t.Errorf("Expected the top finding to be %s, got %+v", optimizations[0].Type, summary.TopFinding)
+	}
+
+	if strings.Contains(strings.TrimSpace(string(data)), "\n") {
+		t.Error("Expected a single-line summary")
+	}
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/devlongs/evm-tracer/internal/signatures"
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/spf13/cobra"
+)
+
+// defaultLocalGasLimit is the gas limit of trace-local calls without --gas-limit
+const defaultLocalGasLimit = 30_000_000
+
+var (
+	localBin   string
+	localCall  string
+	localValue string
+)
+
+var traceLocalCmd = &cobra.Command{
+	Use:   "trace-local",
+	Short: "Trace a call to compiled bytecode in an in-memory EVM, without deploying it",
+	Long: `Deploys compiled contract bytecode into an in-memory EVM with fresh state,
+calls it with the gas optimization tracer attached and prints the report, like
+demo does with its sample contract. No RPC node is needed.
+
+--bin is a file holding the hex creation bytecode, as written by solc --bin;
+the constructor runs untraced and without arguments. --call is the function
+call, encoded with the --abi files, or hex calldata. The EVM runs the rules of
+--fork, by default the latest.
+
+Example:
+  evm-tracer trace-local --bin Token.bin --abi Token.abi --call 'transfer(0x00000000000000000000000000000000000000b1,100)'
+  evm-tracer trace-local --bin Token.bin --call 0xa9059cbb... --fork shanghai --json`,
+	Args: cobra.NoArgs,
+	RunE: runTraceLocal,
+}
+
+func runTraceLocal(cmd *cobra.Command, args []string) error {
+	tr, err := traceLocal()
+	if err != nil {
+		return err
+	}
+	return finishResults(cmd, tr)
+}
+
+// traceLocal deploys the --bin bytecode and traces the --call to it
+func traceLocal() (*tracer.GasOptimizationTracer, error) {
+	data, err := os.ReadFile(localBin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bytecode: %w", err)
+	}
+	initCode, err := hexutil.Decode("0x" + strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid bytecode in %s: %w", localBin, err)
+	}
+
+	input, err := localCalldata()
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := new(big.Int).SetString(localValue, 10)
+	if !ok || value.Sign() < 0 {
+		return nil, fmt.Errorf("invalid --value: %s", localValue)
+	}
+
+	config, err := tracer.ForkChainConfig(evmFork)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &runtime.Config{ChainConfig: config, GasLimit: defaultLocalGasLimit}
+	if gasLimit > 0 {
+		cfg.GasLimit = gasLimit
+	}
+	if config.TerminalTotalDifficulty != nil {
+		cfg.Random = &common.Hash{}
+	}
+
+	// Deploy without tracing so the report covers only the call
+	_, address, _, err := runtime.Create(initCode, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deploy contract: %w", err)
+	}
+	if len(cfg.State.GetCode(address)) == 0 {
+		return nil, fmt.Errorf("deploying %s left no code: expected creation bytecode, as written by solc --bin", localBin)
+	}
+
+	tr := tracer.NewGasOptimizationTracer()
+	if err := configureTracer(tr); err != nil {
+		return nil, err
+	}
+	cfg.EVMConfig.Tracer = tr
+	if value.Sign() > 0 {
+		cfg.State.AddBalance(cfg.Origin, value)
+		cfg.Value = value
+	}
+
+	// Reverted calls are still reported, like failed transactions are
+	if _, _, err := runtime.Call(address, input, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Call failed: %v\n", err)
+	}
+	return tr, nil
+}
+
+// localCalldata returns the --call calldata, encoding a function call with the --abi files
+func localCalldata() ([]byte, error) {
+	if strings.HasPrefix(localCall, "0x") {
+		input, err := hexutil.Decode(localCall)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --call calldata: %w", err)
+		}
+		return input, nil
+	}
+
+	if len(abiFiles) == 0 {
+		return nil, fmt.Errorf("--call %s needs --abi to encode it, or pass hex calldata", localCall)
+	}
+	abis := make([]abi.ABI, 0, len(abiFiles))
+	for _, path := range abiFiles {
+		parsed, err := signatures.ReadABI(path)
+		if err != nil {
+			return nil, err
+		}
+		abis = append(abis, parsed)
+	}
+	return signatures.EncodeCall(abis, localCall)
+}
+
+func init() {
+	traceLocalCmd.Flags().StringVar(&localBin, "bin", "", "File holding the contract's hex creation bytecode")
+	traceLocalCmd.Flags().StringVar(&localCall, "call", "", "Function call such as 'transfer(0x...,100)', or hex calldata")
+	traceLocalCmd.Flags().StringVar(&localValue, "value", "0", "Value to send in wei")
+	traceLocalCmd.Flags().StringVar(&evmFork, "fork", tracer.DefaultGasModel().Fork, "Fork whose rules the EVM runs ("+strings.Join(tracer.ForkNames(), ", ")+")")
+	traceLocalCmd.Flags().Uint64Var(&gasLimit, "gas-limit", 0, fmt.Sprintf("Gas limit of the call (default %d)", defaultLocalGasLimit))
+	traceLocalCmd.MarkFlagRequired("bin")
+	traceLocalCmd.MarkFlagRequired("call")
+	rootCmd.AddCommand(traceLocalCmd)
+}
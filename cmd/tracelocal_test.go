@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+const setterABI = `[{"type":"function","name":"set","inputs":[{"name":"value","type":"uint256"}],"outputs":[]}]`
+
+// writeLocalContract writes creation bytecode returning runtime code that
+// stores its first argument in slot 0 twice, along with the contract's ABI
+func writeLocalContract(t *testing.T) (bin, abiPath string) {
+	t.Helper()
+
+	runtimeCode := []byte{
+		byte(vm.PUSH1), 0x04, byte(vm.CALLDATALOAD), byte(vm.PUSH1), 0x00, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x04, byte(vm.CALLDATALOAD), byte(vm.PUSH1), 0x00, byte(vm.SSTORE),
+		byte(vm.STOP),
+	}
+	initCode := append([]byte{
+		byte(vm.PUSH1), byte(len(runtimeCode)), byte(vm.DUP1),
+		byte(vm.PUSH1), 0x0c, byte(vm.PUSH1), 0x00, byte(vm.CODECOPY),
+		byte(vm.PUSH1), 0x00, byte(vm.RETURN), byte(vm.INVALID),
+	}, runtimeCode...)
+
+	dir := t.TempDir()
+	bin = filepath.Join(dir, "Setter.bin")
+	abiPath = filepath.Join(dir, "Setter.abi")
+	if err := os.WriteFile(bin, []byte(common.Bytes2Hex(initCode)+"\n"), 0o644); err != nil {
+		t.Fatalf("failed to write bytecode: %v", err)
+	}
+	if err := os.WriteFile(abiPath, []byte(setterABI), 0o644); err != nil {
+		t.Fatalf("failed to write ABI: %v", err)
+	}
+	return bin, abiPath
+}
+
+func TestTraceLocal(t *testing.T) {
+	bin, abiPath := writeLocalContract(t)
+	localBin, localCall, abiFiles = bin, "set(42)", []string{abiPath}
+	defer func() { localBin, localCall, abiFiles = "", "", nil }()
+
+	tr, err := traceLocal()
+	if err != nil {
+		t.Fatalf("traceLocal() error: %v", err)
+	}
+
+	root := tr.GetCallTree()
+	if root == nil || hexutil.Encode(root.Input) != "0x60fe47b1"+common.Bytes2Hex(common.LeftPadBytes([]byte{42}, 32)) {
+		t.Fatalf("Expected the call to be encoded from the ABI, got %+v", root)
+	}
+
+	report, err := tr.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+	for _, optType := range []string{"write_only_storage", "use_push0"} {
+		if !strings.Contains(report, `"Type": "`+optType+`"`) {
+			t.Errorf("Expected report to contain a %s optimization, got:\n%s", optType, report)
+		}
+	}
+}
+
+func TestTraceLocalErrors(t *testing.T) {
+	bin, _ := writeLocalContract(t)
+	localBin, localCall = bin, "set(42)"
+	defer func() { localBin, localCall = "", "" }()
+
+	if _, err := traceLocal(); err == nil || !strings.Contains(err.Error(), "needs --abi") {
+		t.Errorf("Expected an error for a call without --abi, got %v", err)
+	}
+
+	localCall = "0x60fe47b1"
+	localBin = filepath.Join(t.TempDir(), "missing.bin")
+	if _, err := traceLocal(); err == nil || !strings.Contains(err.Error(), "failed to read bytecode") {
+		t.Errorf("Expected an error for a missing bytecode file, got %v", err)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build tui
+
+package cmd
+
+import (
+	"github.com/devlongs/evm-tracer/internal/tui"
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&tuiMode, "tui", false, "Browse the results in an interactive terminal viewer")
+	openTUI = tui.Run
+}
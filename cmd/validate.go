@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [report.json]",
+	Short: "Validate a saved JSON report against the report schema",
+	Long: `Checks that a JSON report saved with --json has every required field and
+was written with the supported schema version. Exits with a nonzero status when
+the report is corrupted or outdated, so pipelines can reject bad artifacts.
+
+Example:
+  evm-tracer trace 0x1234... --json --output report.json
+  evm-tracer validate report.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runValidate,
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read report: %w", err)
+	}
+
+	report, err := tracer.ParseReport(data)
+	if err != nil {
+		fmt.Fprintf(cmd.OutOrStdout(), "❌ FAIL %s\n", path)
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "✅ PASS %s (schema v%d, %d optimizations, %d gas)\n",
+		path, report.SchemaVersion, len(report.Optimizations), report.TotalGasUsed)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateCommand(t *testing.T) {
+	tr, err := traceDemo()
+	if err != nil {
+		t.Fatalf("traceDemo() error: %v", err)
+	}
+	report, err := tr.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.json")
+	if err := os.WriteFile(good, []byte(report), 0o644); err != nil {
+		t.Fatalf("failed to write report: %v", err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(report), &fields); err != nil {
+		t.Fatalf("failed to parse report: %v", err)
+	}
+	delete(fields, "optimizations")
+	broken, _ := json.Marshal(fields)
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, broken, 0o644); err != nil {
+		t.Fatalf("failed to write report: %v", err)
+	}
+
+	var out bytes.Buffer
+	validateCmd.SetOut(&out)
+	defer validateCmd.SetOut(nil)
+
+	if err := runValidate(validateCmd, []string{good}); err != nil {
+		t.Errorf("Expected the saved report to validate, got %v", err)
+	}
+	if !strings.Contains(out.String(), "PASS") {
+		t.Errorf("Expected a PASS line, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	err = runValidate(validateCmd, []string{bad})
+	if err == nil || !strings.Contains(err.Error(), "optimizations") {
+		t.Errorf("Expected the missing optimizations field to be reported, got %v", err)
+	}
+	if !strings.Contains(out.String(), "FAIL") {
+		t.Errorf("Expected a FAIL line, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// toolVersion is the evm-tracer release version
+const toolVersion = "1.0.0"
+
+// gethModulePath is the module whose version determines gas rules and tracer interfaces
+const gethModulePath = "github.com/ethereum/go-ethereum"
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print version information",
+	Long: `Prints the evm-tracer version along with the go-ethereum library version
+and Go toolchain the binary was built with. Gas rules and tracer interfaces
+change across go-ethereum releases, so include this in bug reports.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Fprint(cmd.OutOrStdout(), versionInfo())
+	},
+}
+
+// gethVersion returns the go-ethereum module version from the build info
+func gethVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path == gethModulePath {
+			if dep.Replace != nil {
+				return dep.Replace.Version + " (replaced)"
+			}
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
+
+// versionInfo returns the multi-line version report
+func versionInfo() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("evm-tracer: %s\n", toolVersion))
+	sb.WriteString(fmt.Sprintf("go-ethereum: %s\n", gethVersion()))
+	sb.WriteString(fmt.Sprintf("go: %s\n", runtime.Version()))
+	return sb.String()
+}
+
+func init() {
+	rootCmd.SetVersionTemplate("{{with .Version}}{{.}}{{end}}")
+	rootCmd.AddCommand(versionCmd)
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestVersionCommand(t *testing.T) {
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"version"})
+	defer rootCmd.SetArgs(nil)
+	defer rootCmd.SetOut(nil)
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("version command failed: %v", err)
+	}
+
+	output := out.String()
+
+	if !strings.Contains(output, "evm-tracer: "+toolVersion) {
+		t.Errorf("Expected tool version in output, got:\n%s", output)
+	}
+
+	if !strings.Contains(output, "go-ethereum: ") {
+		t.Errorf("Expected a go-ethereum line in output, got:\n%s", output)
+	}
+
+	if !strings.Contains(output, "go: go") {
+		t.Errorf("Expected a Go version line in output, got:\n%s", output)
+	}
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/devlongs/evm-tracer/internal/analyzer"
+	"github.com/devlongs/evm-tracer/internal/formatter"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+var watchBackfill uint64
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [address]",
+	Short: "Trace new transactions to an address live as blocks are mined",
+	Long: `Subscribes to new blocks and traces every transaction sent directly to the
+address, printing its findings as soon as it is traced. Stop with Ctrl-C.
+
+Subscriptions need a websocket (ws://, wss://) or IPC endpoint. When the
+connection drops, watch resubscribes and traces the blocks missed meanwhile,
+up to --backfill blocks. --timeout bounds the tracing of each transaction.
+
+Example:
+  evm-tracer watch 0xCONTRACT --rpc ws://localhost:8546
+  evm-tracer watch 0xCONTRACT --rpc ws://localhost:8546 --json --min-severity high`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWatch,
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	if !common.IsHexAddress(args[0]) {
+		return fmt.Errorf("invalid address: %s", args[0])
+	}
+	addr := common.HexToAddress(args[0])
+
+	format, err := resolveFormat()
+	if err != nil {
+		return err
+	}
+	if format != "console" && format != "json" {
+		return fmt.Errorf("watch does not support the %s format", format)
+	}
+
+	criteria := filterCriteria()
+	if err := criteria.Validate(); err != nil {
+		return err
+	}
+
+	theme, err := consoleTheme()
+	if err != nil {
+		return err
+	}
+
+	headers, err := rpcHeaders()
+	if err != nil {
+		return err
+	}
+
+	// New blocks replay against recent state, which full nodes keep
+	an, err := analyzer.NewTransactionAnalyzerWithOptions(rpcURL, analyzer.Options{
+		AllowEmptyState: allowEmptyState,
+		LatestStateOnly: true,
+		CacheSize:       cacheSize,
+		MaxSteps:        maxSteps,
+		Headers:         headers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create analyzer: %w", err)
+	}
+	defer an.Close()
+
+	if err := configureTracer(an.GetTracer()); err != nil {
+		return err
+	}
+
+	client, err := analyzer.Dial(rpcURL, headers)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+
+	// Ctrl-C is the normal way to stop watching
+	ctx, stop := interruptContext()
+	defer stop()
+
+	opts := analyzer.WatchOptions{
+		Criteria:     criteria,
+		Backfill:     watchBackfill,
+		TraceTimeout: timeout,
+		OnRetry: func(err error) {
+			fmt.Fprintf(os.Stderr, "⚠️  %v; retrying\n", err)
+		},
+		OnSkip: func(from, to uint64) {
+			fmt.Fprintf(os.Stderr, "⚠️  Skipped blocks %d-%d, beyond --backfill %d\n", from, to, watchBackfill)
+		},
+	}
+
+	fmt.Fprintf(os.Stderr, "👀 Watching %s for new transactions (Ctrl-C to stop)\n", addr.Hex())
+	err = writeOutput(func(w io.Writer) error {
+		return an.Watch(ctx, client, addr, opts, func(tx analyzer.WatchedTransaction) {
+			writeWatchedTransaction(w, tx, format, theme)
+		})
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// writeWatchedTransaction writes a traced transaction to w, as one JSON line in JSON format
+func writeWatchedTransaction(w io.Writer, tx analyzer.WatchedTransaction, format string, theme formatter.Theme) {
+	if format == "json" {
+		data, err := json.Marshal(tx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  failed to encode %s: %v\n", tx.Hash.Hex(), err)
+			return
+		}
+		fmt.Fprintln(w, string(data))
+		return
+	}
+	fmt.Fprint(w, formatter.FormatWatchedTransaction(tx, theme))
+}
+
+func init() {
+	watchCmd.Flags().Uint64Var(&watchBackfill, "backfill", analyzer.DefaultWatchBackfill, "Missed blocks traced after a reconnection; older missed blocks are skipped")
+	watchCmd.Flags().BoolVar(&allowEmptyState, "allow-empty-state", false, "Trace against empty state when the parent block's state is unavailable (devnets)")
+	rootCmd.AddCommand(watchCmd)
+}
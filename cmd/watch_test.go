@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/devlongs/evm-tracer/internal/analyzer"
+	"github.com/devlongs/evm-tracer/internal/formatter"
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestWriteWatchedTransactionJSONLines(t *testing.T) {
+	txs := []analyzer.WatchedTransaction{
+		{Block: 2, Hash: common.HexToHash("0x01"), GasUsed: 30000, Optimizations: []tracer.Optimization{{Type: "redundant_sload", Severity: "high"}}},
+		{Block: 3, Hash: common.HexToHash("0x02"), Optimizations: []tracer.Optimization{}, Error: "not found"},
+	}
+
+	var buf bytes.Buffer
+	for _, tx := range txs {
+		writeWatchedTransaction(&buf, tx, "json", formatter.MonoTheme())
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(txs) {
+		t.Fatalf("Expected one JSON line per transaction, got %d lines:\n%s", len(lines), buf.String())
+	}
+	for i, line := range lines {
+		var decoded analyzer.WatchedTransaction
+		if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+			t.Fatalf("Expected line %d to be JSON, got %v", i, err)
+		}
+		if decoded.Hash != txs[i].Hash || decoded.Block != txs[i].Block || decoded.Error != txs[i].Error {
+			t.Errorf("Expected %+v, got %+v", txs[i], decoded)
+		}
+	}
+}
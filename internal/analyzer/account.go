@@ -0,0 +1,300 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrListingUnsupported is returned when the endpoint cannot list an account's transactions
+var ErrListingUnsupported = errors.New("the endpoint cannot list account transactions")
+
+// methodNotFound is the JSON-RPC error code for an unknown method
+const methodNotFound = -32601
+
+// TransactionLister finds an account's most recent transactions
+type TransactionLister interface {
+	// RecentTransactions returns up to n hashes of transactions sent by or to addr, most recent first
+	RecentTransactions(ctx context.Context, addr common.Address, n int) ([]common.Hash, error)
+}
+
+// RPCCaller is the raw JSON-RPC call used by IndexLister, implemented by rpc.Client
+type RPCCaller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// IndexLister lists transactions through the Otterscan ots_searchTransactionsBefore
+// index served by Erigon and other Otterscan-compatible nodes
+type IndexLister struct {
+	rpc RPCCaller
+}
+
+// NewIndexLister creates a lister querying the node's transaction index
+func NewIndexLister(caller RPCCaller) *IndexLister {
+	return &IndexLister{rpc: caller}
+}
+
+// RecentTransactions implements TransactionLister
+func (l *IndexLister) RecentTransactions(ctx context.Context, addr common.Address, n int) ([]common.Hash, error) {
+	var page struct {
+		Txs []struct {
+			Hash common.Hash `json:"hash"`
+		} `json:"txs"`
+	}
+
+	// Block 0 searches backwards from the latest block
+	if err := l.rpc.CallContext(ctx, &page, "ots_searchTransactionsBefore", addr, 0, n); err != nil {
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == methodNotFound {
+			return nil, fmt.Errorf("%w: %v", ErrListingUnsupported, err)
+		}
+		return nil, fmt.Errorf("failed to search transactions: %w", rpcTimeout(err))
+	}
+
+	hashes := make([]common.Hash, 0, len(page.Txs))
+	for _, tx := range page.Txs {
+		if len(hashes) == n {
+			break
+		}
+		hashes = append(hashes, tx.Hash)
+	}
+	return hashes, nil
+}
+
+// BlockSource is the subset of the Ethereum RPC API used to scan blocks
+type BlockSource interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+}
+
+// BlockScanLister finds transactions by walking back from the latest block. It
+// works against any node but fetches every block, and only sees transactions
+// whose sender or direct recipient is the account.
+type BlockScanLister struct {
+	source    BlockSource
+	maxBlocks uint64
+}
+
+// NewBlockScanLister creates a lister scanning at most maxBlocks recent blocks
+func NewBlockScanLister(source BlockSource, maxBlocks uint64) *BlockScanLister {
+	return &BlockScanLister{source: source, maxBlocks: maxBlocks}
+}
+
+// RecentTransactions implements TransactionLister
+func (l *BlockScanLister) RecentTransactions(ctx context.Context, addr common.Address, n int) ([]common.Hash, error) {
+	header, err := l.source.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest header: %w", rpcTimeout(err))
+	}
+
+	var hashes []common.Hash
+	number := new(big.Int).Set(header.Number)
+	for scanned := uint64(0); scanned < l.maxBlocks && number.Sign() >= 0 && len(hashes) < n; scanned++ {
+		block, err := l.source.BlockByNumber(ctx, number)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get block %s: %w", number, rpcTimeout(err))
+		}
+
+		txs := block.Transactions()
+		for i := len(txs) - 1; i >= 0 && len(hashes) < n; i-- {
+			if involves(txs[i], addr) {
+				hashes = append(hashes, txs[i].Hash())
+			}
+		}
+		number.Sub(number, common.Big1)
+	}
+	return hashes, nil
+}
+
+// involves reports whether addr sent or directly receives tx
+func involves(tx *types.Transaction, addr common.Address) bool {
+	if to := tx.To(); to != nil && *to == addr {
+		return true
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	return err == nil && from == addr
+}
+
+// FallbackLister lists transactions with Primary, switching to Fallback when the
+// endpoint does not support Primary
+type FallbackLister struct {
+	Primary  TransactionLister
+	Fallback TransactionLister
+
+	// OnFallback, when set, is called with the primary's error before falling back
+	OnFallback func(err error)
+}
+
+// RecentTransactions implements TransactionLister
+func (l *FallbackLister) RecentTransactions(ctx context.Context, addr common.Address, n int) ([]common.Hash, error) {
+	hashes, err := l.Primary.RecentTransactions(ctx, addr, n)
+	if !errors.Is(err, ErrListingUnsupported) {
+		return hashes, err
+	}
+
+	if l.OnFallback != nil {
+		l.OnFallback(err)
+	}
+	return l.Fallback.RecentTransactions(ctx, addr, n)
+}
+
+// AccountReport aggregates the findings of an account's traced transactions
+type AccountReport struct {
+	Address      common.Address       `json:"address"`
+	TotalGasUsed uint64               `json:"total_gas_used"`
+	TotalSavings uint64               `json:"total_gas_savings"`
+	Findings     []FindingSummary     `json:"findings"`
+	Transactions []AccountTransaction `json:"transactions"`
+	FailedTraces int                  `json:"failed_traces"`
+
+	// Partial explains why the analysis stopped before tracing every
+	// transaction, e.g. an interrupt; empty when it completed
+	Partial string `json:"partial,omitempty"`
+
+	// Spread of the gas used and the number of findings across the traced
+	// transactions; nil when none was traced
+	GasDistribution          *Distribution `json:"gas_distribution,omitempty"`
+	OptimizationDistribution *Distribution `json:"optimization_distribution,omitempty"`
+
+	// Storage slots accessed by several of the transactions, most contended first
+	Contention []SlotContention `json:"storage_contention,omitempty"`
+}
+
+// AccountTransaction is the outcome of tracing one of the account's transactions
+type AccountTransaction struct {
+	Hash          common.Hash `json:"hash"`
+	GasUsed       uint64      `json:"gas_used"`
+	Optimizations int         `json:"optimizations"`
+	GasSavings    uint64      `json:"gas_savings"`         // Excludes heuristic savings
+	Truncated     bool        `json:"truncated,omitempty"` // Execution was cut short by MaxSteps
+	Error         string      `json:"error,omitempty"`
+}
+
+// FindingSummary counts one optimization type across the account's transactions
+type FindingSummary struct {
+	Type         string `json:"type"`
+	Severity     string `json:"severity"`     // Highest severity the type was reported at
+	Transactions int    `json:"transactions"` // Transactions the type was found in
+	Occurrences  int    `json:"occurrences"`  // Findings of the type across all transactions
+	GasSavings   uint64 `json:"gas_savings"`
+}
+
+// AnalyzeAccount traces each transaction in turn and aggregates the findings
+// matching criteria. Storage slots accessed by several transactions are reported
+// as a storage_contention finding. A transaction that fails to trace is recorded
+// in the report and skipped; the analysis stops early only when ctx is done, and
+// then returns the report of the transactions traced so far with the error.
+func (a *TransactionAnalyzer) AnalyzeAccount(ctx context.Context, addr common.Address, hashes []common.Hash, criteria tracer.FilterCriteria) (*AccountReport, error) {
+	report := &AccountReport{
+		Address:      addr,
+		Findings:     []FindingSummary{},
+		Transactions: make([]AccountTransaction, 0, len(hashes)),
+	}
+	findings := make(map[string]*FindingSummary)
+	contention := newContentionTracker()
+	var gasUsed, optimizationCounts []uint64
+
+	for _, hash := range hashes {
+		a.tracer.Reset()
+
+		entry := AccountTransaction{Hash: hash}
+		// Transactions that MaxSteps cut short still contribute what was traced
+		err := a.AnalyzeTransaction(ctx, hash)
+		entry.Truncated = errors.Is(err, ErrStepLimitExceeded)
+		if err != nil && !entry.Truncated {
+			if ctx.Err() != nil {
+				err = fmt.Errorf("analysis of %s failed: %w", hash.Hex(), err)
+				report.Partial = fmt.Sprintf("stopped after %d of %d transactions: %v", len(report.Transactions), len(hashes), err)
+				finishAccountReport(report, findings, contention, gasUsed, optimizationCounts, criteria)
+				return report, err
+			}
+			entry.Error = err.Error()
+			report.FailedTraces++
+			report.Transactions = append(report.Transactions, entry)
+			continue
+		}
+
+		optimizations := tracer.FilterOptimizations(a.tracer.GetOptimizations(), criteria)
+		entry.GasUsed = a.tracer.GetStats().TotalGasUsed
+		entry.Optimizations = len(optimizations)
+
+		seen := make(map[string]bool)
+		for _, opt := range optimizations {
+			summary, ok := findings[opt.Type]
+			if !ok {
+				summary = &FindingSummary{Type: opt.Type, Severity: opt.Severity}
+				findings[opt.Type] = summary
+			}
+			if tracer.SeverityRank(opt.Severity) > tracer.SeverityRank(summary.Severity) {
+				summary.Severity = opt.Severity
+			}
+			if !seen[opt.Type] {
+				seen[opt.Type] = true
+				summary.Transactions++
+			}
+			summary.Occurrences++
+			summary.GasSavings += opt.GasSavings
+		}
+		entry.GasSavings, _ = tracer.SavingsTotals(optimizations)
+		entry.GasSavings, _ = tracer.CapSavings(entry.GasSavings, entry.GasUsed)
+		contention.add(len(report.Transactions), a.tracer.GetSlotAccessOrders())
+
+		report.TotalGasUsed += entry.GasUsed
+		report.TotalSavings += entry.GasSavings
+		report.Transactions = append(report.Transactions, entry)
+		gasUsed = append(gasUsed, entry.GasUsed)
+		optimizationCounts = append(optimizationCounts, uint64(entry.Optimizations))
+	}
+	finishAccountReport(report, findings, contention, gasUsed, optimizationCounts, criteria)
+	return report, nil
+}
+
+// finishAccountReport adds the aggregates over the traced transactions to report
+func finishAccountReport(report *AccountReport, findings map[string]*FindingSummary, contention *contentionTracker, gasUsed, optimizationCounts []uint64, criteria tracer.FilterCriteria) {
+	report.GasDistribution = newDistribution(gasUsed)
+	report.OptimizationDistribution = newDistribution(optimizationCounts)
+
+	for _, summary := range findings {
+		report.Findings = append(report.Findings, *summary)
+	}
+
+	// Slots shared between transactions point at batching or ordering opportunities
+	// across them, which no single trace can show
+	slots, involved := contention.contended()
+	finding := tracer.Optimization{Type: "storage_contention", Severity: "low"}
+	if len(slots) > 0 && len(tracer.FilterOptimizations([]tracer.Optimization{finding}, criteria)) > 0 {
+		report.Contention = slots
+		report.Findings = append(report.Findings, FindingSummary{
+			Type:         finding.Type,
+			Severity:     finding.Severity,
+			Transactions: involved,
+			Occurrences:  len(slots),
+		})
+	}
+	sortFindings(report.Findings)
+}
+
+// sortFindings orders findings by the number of transactions they occur in, then by
+// occurrences and gas savings, most frequent first
+func sortFindings(findings []FindingSummary) {
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Transactions != b.Transactions {
+			return a.Transactions > b.Transactions
+		}
+		if a.Occurrences != b.Occurrences {
+			return a.Occurrences > b.Occurrences
+		}
+		if a.GasSavings != b.GasSavings {
+			return a.GasSavings > b.GasSavings
+		}
+		return a.Type < b.Type
+	})
+}
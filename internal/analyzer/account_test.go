@@ -0,0 +1,393 @@
+package analyzer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// mockLister returns a fixed transaction list
+type mockLister struct {
+	hashes []common.Hash
+	err    error
+	calls  int
+}
+
+func (m *mockLister) RecentTransactions(ctx context.Context, addr common.Address, n int) ([]common.Hash, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	if len(m.hashes) > n {
+		return m.hashes[:n], nil
+	}
+	return m.hashes, nil
+}
+
+// mockBlockSource serves a chain of blocks by number
+type mockBlockSource struct {
+	blocks []*types.Block
+}
+
+func (m *mockBlockSource) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return m.blocks[len(m.blocks)-1].Header(), nil
+}
+
+func (m *mockBlockSource) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if !number.IsUint64() || number.Uint64() >= uint64(len(m.blocks)) {
+		return nil, fmt.Errorf("block %s not found", number)
+	}
+	return m.blocks[number.Uint64()], nil
+}
+
+// rpcError is a JSON-RPC error response
+type rpcError struct {
+	code int
+}
+
+func (e rpcError) Error() string  { return "the method does not exist/is not available" }
+func (e rpcError) ErrorCode() int { return e.code }
+
+// mockCaller answers raw RPC calls with a fixed error
+type mockCaller struct {
+	err error
+}
+
+func (m mockCaller) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return m.err
+}
+
+// signedTx signs a legacy transaction from key to the recipient
+func signedTx(t *testing.T, key *ecdsa.PrivateKey, nonce uint64, to common.Address, gas uint64) *types.Transaction {
+	t.Helper()
+
+	tx, err := types.SignTx(types.NewTransaction(nonce, to, new(big.Int), gas, new(big.Int), nil), types.HomesteadSigner{}, key)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	return tx
+}
+
+// blockAt builds a block at number holding txs
+func blockAt(number int64, txs ...*types.Transaction) *types.Block {
+	header := &types.Header{
+		Number:     big.NewInt(number),
+		Difficulty: big.NewInt(1),
+		GasLimit:   30000000,
+	}
+	return types.NewBlockWithHeader(header).WithBody(txs, nil)
+}
+
+func TestAnalyzeAccountAggregatesFindings(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	contract := common.HexToAddress("0x3000")
+
+	client := newMockClient()
+
+	// Read slot 0 three times so every transaction has one redundant_sload finding
+	var code []byte
+	for i := 0; i < 3; i++ {
+		code = append(code, byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP))
+	}
+	client.code[contract] = append(code, byte(vm.STOP))
+
+	// Both replay against the parent state, where the sender's nonce is 0
+	first := signedTx(t, key, 0, contract, 100000)
+	second := signedTx(t, key, 0, contract, 200000)
+	client.addBlock(blockAt(1, first))
+	client.addBlock(blockAt(2, second))
+	missing := common.HexToHash("0xdead")
+
+	lister := &mockLister{hashes: []common.Hash{second.Hash(), missing, first.Hash()}}
+	hashes, err := lister.RecentTransactions(context.Background(), contract, 10)
+	if err != nil {
+		t.Fatalf("RecentTransactions() error: %v", err)
+	}
+
+	an := NewTransactionAnalyzerWithClient(client)
+	report, err := an.AnalyzeAccount(context.Background(), contract, hashes, tracer.FilterCriteria{})
+	if err != nil {
+		t.Fatalf("AnalyzeAccount() error: %v", err)
+	}
+
+	if len(report.Transactions) != 3 {
+		t.Fatalf("Expected 3 transactions, got %d", len(report.Transactions))
+	}
+
+	if report.FailedTraces != 1 || report.Transactions[1].Error == "" {
+		t.Errorf("Expected the missing transaction to be recorded as failed, got %+v", report.Transactions[1])
+	}
+
+	if len(report.Findings) == 0 {
+		t.Fatal("Expected aggregated findings")
+	}
+
+	var sload *FindingSummary
+	for i, finding := range report.Findings {
+		if i > 0 && finding.Transactions > report.Findings[i-1].Transactions {
+			t.Errorf("Expected findings ordered by frequency, got %+v", report.Findings)
+		}
+		if finding.Type == "redundant_sload" {
+			sload = &report.Findings[i]
+		}
+	}
+
+	if sload == nil || sload.Transactions != 2 || sload.Occurrences != 2 || sload.Severity != "high" {
+		t.Errorf("Expected a high severity redundant_sload in 2 transactions, got %+v", sload)
+	}
+
+	if report.TotalGasUsed != report.Transactions[0].GasUsed+report.Transactions[2].GasUsed || report.TotalGasUsed == 0 {
+		t.Errorf("Expected total gas to sum the traced transactions, got %d", report.TotalGasUsed)
+	}
+
+	// The failed trace is left out of the distributions
+	low, high := min(report.Transactions[0].GasUsed, report.Transactions[2].GasUsed), max(report.Transactions[0].GasUsed, report.Transactions[2].GasUsed)
+	if stats := report.GasDistribution; stats == nil || stats.Min != low || stats.Median != low || stats.Max != high {
+		t.Errorf("Expected a gas distribution from %d to %d, got %+v", low, high, stats)
+	}
+	if report.OptimizationDistribution == nil || report.OptimizationDistribution.Max == 0 {
+		t.Errorf("Expected a distribution of optimization counts, got %+v", report.OptimizationDistribution)
+	}
+
+	// Filtering drops the finding from the aggregate
+	filtered, err := an.AnalyzeAccount(context.Background(), contract, hashes, tracer.FilterCriteria{ExcludeTypes: []string{"redundant_sload"}})
+	if err != nil {
+		t.Fatalf("AnalyzeAccount() error: %v", err)
+	}
+	for _, finding := range filtered.Findings {
+		if finding.Type == "redundant_sload" {
+			t.Error("Expected excluded types to be left out of the aggregate")
+		}
+	}
+}
+
+func TestAnalyzeAccountKeepsTruncatedTraces(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	contract := common.HexToAddress("0x3000")
+
+	// Read slot 0 three times, then loop until the step limit stops execution
+	client := newMockClient()
+	var code []byte
+	for i := 0; i < 3; i++ {
+		code = append(code, byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP))
+	}
+	loop := byte(len(code))
+	client.code[contract] = append(code, byte(vm.JUMPDEST), byte(vm.PUSH1), loop, byte(vm.JUMP))
+
+	tx := signedTx(t, key, 0, contract, 1000000)
+	client.addBlock(blockAt(1, tx))
+
+	an := NewTransactionAnalyzerWithClient(client)
+	an.opts.MaxSteps = 1000
+	report, err := an.AnalyzeAccount(context.Background(), contract, []common.Hash{tx.Hash()}, tracer.FilterCriteria{})
+	if err != nil {
+		t.Fatalf("AnalyzeAccount() error: %v", err)
+	}
+
+	if report.FailedTraces != 0 || len(report.Transactions) != 1 {
+		t.Fatalf("Expected the truncated trace to be aggregated, got %d failed of %d", report.FailedTraces, len(report.Transactions))
+	}
+	entry := report.Transactions[0]
+	if !entry.Truncated || entry.Error != "" {
+		t.Errorf("Expected the transaction to be marked truncated, got %+v", entry)
+	}
+	if entry.GasUsed == 0 || report.TotalGasUsed != entry.GasUsed {
+		t.Errorf("Expected the traced gas to count, got %d of %d", entry.GasUsed, report.TotalGasUsed)
+	}
+	if entry.Optimizations == 0 {
+		t.Error("Expected the findings traced before the limit to be kept")
+	}
+}
+
+func TestAnalyzeAccountInterruptedKeepsTracedTransactions(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	contract := common.HexToAddress("0x3000")
+	looper := common.HexToAddress("0x4000")
+
+	client := newMockClient()
+	var code []byte
+	for i := 0; i < 3; i++ {
+		code = append(code, byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP))
+	}
+	client.code[contract] = append(code, byte(vm.STOP))
+	client.code[looper] = []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0x00, byte(vm.JUMP)}
+
+	// The second transaction loops until it is interrupted
+	first := signedTx(t, key, 0, contract, 100000)
+	second := signedTx(t, key, 0, looper, 1<<40)
+	client.addBlock(blockAt(1, first))
+	client.addBlock(types.NewBlockWithHeader(&types.Header{Number: big.NewInt(2), Difficulty: big.NewInt(1), GasLimit: 1 << 40}).WithBody([]*types.Transaction{second}, nil))
+
+	an := NewTransactionAnalyzerWithClient(client)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for an.GetTracer().StepCount() < 100 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+
+	report, err := an.AnalyzeAccount(ctx, contract, []common.Hash{first.Hash(), second.Hash()}, tracer.FilterCriteria{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the interrupt to be returned, got %v", err)
+	}
+	if report == nil {
+		t.Fatal("Expected the report of the transactions traced before the interrupt")
+	}
+	if len(report.Transactions) != 1 || report.Transactions[0].Hash != first.Hash() || report.TotalGasUsed == 0 {
+		t.Errorf("Expected the first transaction's results, got %+v", report.Transactions)
+	}
+	if len(report.Findings) == 0 || report.GasDistribution == nil {
+		t.Error("Expected the aggregates over the traced transaction")
+	}
+	if report.Partial == "" {
+		t.Error("Expected the report to be labeled partial")
+	}
+}
+
+func TestAnalyzeAccountStorageContention(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	contract := common.HexToAddress("0x3000")
+	client := newMockClient()
+
+	// Both transactions read slot 1; with more than 150000 gas the contract then
+	// reads slot 2, otherwise slot 0
+	client.code[contract] = []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP), // 0
+		byte(vm.PUSH3), 0x02, 0x49, 0xf0, // 4
+		byte(vm.GAS), byte(vm.GT), // 8
+		byte(vm.PUSH1), 0x13, byte(vm.JUMPI), // 10
+		byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP), byte(vm.STOP), // 13
+		byte(vm.JUMPDEST), byte(vm.PUSH1), 0x02, byte(vm.SLOAD), byte(vm.POP), byte(vm.STOP), // 19
+	}
+
+	first := signedTx(t, key, 0, contract, 100000)
+	second := signedTx(t, key, 0, contract, 200000)
+	client.addBlock(blockAt(1, first))
+	client.addBlock(blockAt(2, second))
+	hashes := []common.Hash{first.Hash(), second.Hash()}
+
+	an := NewTransactionAnalyzerWithClient(client)
+	report, err := an.AnalyzeAccount(context.Background(), contract, hashes, tracer.FilterCriteria{})
+	if err != nil {
+		t.Fatalf("AnalyzeAccount() error: %v", err)
+	}
+
+	// Only the overlapping slot is contended
+	if len(report.Contention) != 1 {
+		t.Fatalf("Expected 1 contended slot, got %+v", report.Contention)
+	}
+	slot := report.Contention[0]
+	if slot.Contract != contract || slot.Slot != common.HexToHash("0x01") || slot.Transactions != 2 || slot.Reads != 2 || slot.Writers != 0 {
+		t.Errorf("Expected slot 1 of %s read by 2 transactions, got %+v", contract.Hex(), slot)
+	}
+
+	var found *FindingSummary
+	for i, finding := range report.Findings {
+		if finding.Type == "storage_contention" {
+			found = &report.Findings[i]
+		}
+	}
+	if found == nil || found.Transactions != 2 || found.Occurrences != 1 || found.Severity != "low" {
+		t.Errorf("Expected a low severity storage_contention finding over 2 transactions, got %+v", found)
+	}
+
+	// Filtering drops the finding and the slots with it
+	filtered, err := an.AnalyzeAccount(context.Background(), contract, hashes, tracer.FilterCriteria{MinSeverity: "medium"})
+	if err != nil {
+		t.Fatalf("AnalyzeAccount() error: %v", err)
+	}
+	if len(filtered.Contention) != 0 {
+		t.Errorf("Expected no contention below the minimum severity, got %+v", filtered.Contention)
+	}
+
+	// A single transaction contends with nothing
+	single, err := an.AnalyzeAccount(context.Background(), contract, hashes[:1], tracer.FilterCriteria{})
+	if err != nil {
+		t.Fatalf("AnalyzeAccount() error: %v", err)
+	}
+	if len(single.Contention) != 0 {
+		t.Errorf("Expected no contention for one transaction, got %+v", single.Contention)
+	}
+}
+
+func TestBlockScanListerFindsAccountTransactions(t *testing.T) {
+	owner, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	ownerAddr := crypto.PubkeyToAddress(owner.PublicKey)
+	contract := common.HexToAddress("0x3000")
+	unrelated := common.HexToAddress("0x4000")
+
+	sent := signedTx(t, owner, 0, unrelated, 21000)
+	received := signedTx(t, other, 0, ownerAddr, 21000)
+	ignored := signedTx(t, other, 1, contract, 21000)
+	latest := signedTx(t, owner, 1, contract, 21000)
+
+	source := &mockBlockSource{blocks: []*types.Block{
+		blockAt(0),
+		blockAt(1, sent),
+		blockAt(2, received, ignored),
+		blockAt(3, latest),
+	}}
+
+	hashes, err := NewBlockScanLister(source, 100).RecentTransactions(context.Background(), ownerAddr, 10)
+	if err != nil {
+		t.Fatalf("RecentTransactions() error: %v", err)
+	}
+
+	expected := []common.Hash{latest.Hash(), received.Hash(), sent.Hash()}
+	if fmt.Sprint(hashes) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, hashes)
+	}
+
+	limited, _ := NewBlockScanLister(source, 100).RecentTransactions(context.Background(), ownerAddr, 1)
+	if len(limited) != 1 || limited[0] != latest.Hash() {
+		t.Errorf("Expected only the latest transaction, got %v", limited)
+	}
+
+	shallow, _ := NewBlockScanLister(source, 2).RecentTransactions(context.Background(), ownerAddr, 10)
+	if len(shallow) != 2 {
+		t.Errorf("Expected the scan to stop after 2 blocks, got %d transactions", len(shallow))
+	}
+}
+
+func TestFallbackListerOnUnsupportedIndex(t *testing.T) {
+	fallback := &mockLister{hashes: []common.Hash{common.HexToHash("0x01")}}
+
+	var warning error
+	lister := &FallbackLister{
+		Primary:    NewIndexLister(mockCaller{err: rpcError{code: methodNotFound}}),
+		Fallback:   fallback,
+		OnFallback: func(err error) { warning = err },
+	}
+
+	hashes, err := lister.RecentTransactions(context.Background(), common.Address{}, 5)
+	if err != nil {
+		t.Fatalf("RecentTransactions() error: %v", err)
+	}
+
+	if len(hashes) != 1 || fallback.calls != 1 {
+		t.Errorf("Expected the fallback's transactions, got %v", hashes)
+	}
+
+	if !errors.Is(warning, ErrListingUnsupported) {
+		t.Errorf("Expected a fallback warning wrapping ErrListingUnsupported, got %v", warning)
+	}
+
+	// Other failures are not a reason to fall back
+	lister.Primary = NewIndexLister(mockCaller{err: errors.New("connection refused")})
+	if _, err := lister.RecentTransactions(context.Background(), common.Address{}, 5); err == nil || fallback.calls != 1 {
+		t.Errorf("Expected the index error to be returned without falling back, got %v", err)
+	}
+}
@@ -2,10 +2,15 @@ package analyzer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"net/http"
+	"os"
+	"time"
 
 	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core"
@@ -15,111 +20,578 @@ import (
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// Client is the subset of the Ethereum RPC API used by the analyzer
+type Client interface {
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+	StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	ChainID(ctx context.Context) (*big.Int, error)
+	ClientVersion(ctx context.Context) (string, error)
+	Close()
+}
+
+// ErrNotArchiveNode is returned when the endpoint cannot serve historical state
+var ErrNotArchiveNode = errors.New("this endpoint is not an archive node; historical tracing unavailable")
+
+// ErrRPCTimeout is returned when the deadline expires while fetching chain data
+var ErrRPCTimeout = errors.New("RPC request timed out")
+
+// ErrExecutionTimeout is returned when the deadline expires while the EVM is executing
+var ErrExecutionTimeout = errors.New("execution timed out")
+
+// ErrExecutionInterrupted is returned when the context is cancelled while the EVM
+// is executing, such as on Ctrl-C
+var ErrExecutionInterrupted = errors.New("execution interrupted")
+
+// ErrStepLimitExceeded is returned when execution is aborted by the tracer's step limit
+var ErrStepLimitExceeded = errors.New("step limit exceeded")
+
+// ErrTransactionRejected is returned when the transaction fails the checks made
+// before execution, such as its nonce, intrinsic gas or fee cap, so that no step
+// was traced
+var ErrTransactionRejected = errors.New("transaction rejected")
+
+// IsPartial reports whether err stopped execution after the tracer was set up, so
+// the tracer holds partial results worth reporting rather than none at all
+func IsPartial(err error) bool {
+	return errors.Is(err, ErrExecutionTimeout) || errors.Is(err, ErrExecutionInterrupted) ||
+		errors.Is(err, ErrStepLimitExceeded)
+}
+
+// probeTimeout bounds the capability probe performed at construction
+const probeTimeout = 10 * time.Second
+
+// Options configures analyzer construction
+type Options struct {
+	// AllowEmptyState skips the archive probe and replays transactions against
+	// empty state when historical state is unavailable (useful on devnets)
+	AllowEmptyState bool
+
+	// LatestStateOnly skips the archive probe for callers that only execute
+	// against the latest state, such as call simulation
+	LatestStateOnly bool
+
+	// GasLimit replaces the gas limit of traced transactions and calls, and of
+	// the block gas pool, when nonzero
+	GasLimit uint64
+
+	// CacheSize is the number of blocks, headers and code lookups cached for
+	// the connection and shared by its clones; zero disables caching
+	CacheSize int
+
+	// Block replaces fields of the block context executions run in, when set
+	Block *BlockOverride
+
+	// MaxSteps aborts executions after this many traced steps when nonzero,
+	// labeling the results partial and truncated
+	MaxSteps uint64
+
+	// ResultCache, when set, stores the reports of completed traces of mined
+	// transactions and loads them instead of tracing them again
+	ResultCache *ResultCache
+
+	// Headers are sent with every request to the node, such as API keys or
+	// basic auth built by RPCHeaders
+	Headers http.Header
+}
+
 // TransactionAnalyzer handles the analysis of transactions
 type TransactionAnalyzer struct {
-	client *ethclient.Client
-	tracer *tracer.GasOptimizationTracer
+	client    Client
+	tracer    *tracer.GasOptimizationTracer
+	opts      Options
+	fromCache bool // Whether the last transaction analyzed was loaded from the result cache
+}
+
+// rpcClient adapts ethclient.Client to the Client interface
+type rpcClient struct {
+	*ethclient.Client
+}
+
+// ClientVersion returns the node's web3_clientVersion
+func (c rpcClient) ClientVersion(ctx context.Context) (string, error) {
+	var version string
+	err := c.Client.Client().CallContext(ctx, &version, "web3_clientVersion")
+	return version, err
+}
+
+// OverrideAccount specifies state injected into an account before simulation,
+// mirroring the eth_call state override set. State replaces individual storage
+// slots; the others keep the node's values.
+type OverrideAccount struct {
+	Nonce   *uint64
+	Code    []byte
+	Balance *big.Int
+	State   map[common.Hash]common.Hash
+}
+
+// StateOverride maps account addresses to the state injected before simulation
+type StateOverride map[common.Address]OverrideAccount
+
+// BlockOverride specifies block context fields replaced before execution,
+// mirroring the eth_call block override set. Nil fields keep the block's value.
+type BlockOverride struct {
+	Number     *big.Int
+	Time       *uint64
+	BaseFee    *big.Int
+	PrevRandao *common.Hash
+	Coinbase   *common.Address
 }
 
 // NewTransactionAnalyzer creates a new transaction analyzer
 func NewTransactionAnalyzer(rpcURL string) (*TransactionAnalyzer, error) {
-	client, err := ethclient.Dial(rpcURL)
+	return NewTransactionAnalyzerWithOptions(rpcURL, Options{})
+}
+
+// NewTransactionAnalyzerWithOptions creates a new transaction analyzer and probes the
+// endpoint's capabilities according to opts
+func NewTransactionAnalyzerWithOptions(rpcURL string, opts Options) (*TransactionAnalyzer, error) {
+	client, err := Dial(rpcURL, opts.Headers)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ethereum node: %w", err)
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	an, err := NewTransactionAnalyzerFromClient(ctx, rpcClient{client}, opts)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	return an, nil
+}
+
+// NewTransactionAnalyzerFromClient creates a new transaction analyzer using an existing
+// client, probing its capabilities according to opts
+func NewTransactionAnalyzerFromClient(ctx context.Context, client Client, opts Options) (*TransactionAnalyzer, error) {
+	if !opts.AllowEmptyState && !opts.LatestStateOnly {
+		if err := ProbeNode(ctx, client); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.CacheSize > 0 {
+		client = newCachingClient(client, opts.CacheSize)
+	}
+
+	an := NewTransactionAnalyzerWithClient(client)
+	an.opts = opts
+	return an, nil
+}
+
+// NewTransactionAnalyzerWithClient creates a new transaction analyzer using an existing client
+// without probing it
+func NewTransactionAnalyzerWithClient(client Client) *TransactionAnalyzer {
 	return &TransactionAnalyzer{
 		client: client,
 		tracer: tracer.NewGasOptimizationTracer(),
-	}, nil
+	}
+}
+
+// ProbeNode checks that the endpoint responds and can serve historical state
+// by sampling the state of an early block
+func ProbeNode(ctx context.Context, client Client) error {
+	if _, err := client.ClientVersion(ctx); err != nil {
+		return fmt.Errorf("endpoint did not respond to web3_clientVersion: %w", err)
+	}
+
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get latest header: %w", err)
+	}
+
+	// A chain at genesis has no older state to sample
+	if header.Number.Sign() == 0 {
+		return nil
+	}
+
+	if _, err := client.BalanceAt(ctx, common.Address{}, common.Big1); err != nil {
+		return fmt.Errorf("%w: %v", ErrNotArchiveNode, err)
+	}
+	return nil
 }
 
 // AnalyzeTransaction analyzes a transaction and returns optimization opportunities
 func (a *TransactionAnalyzer) AnalyzeTransaction(ctx context.Context, txHash common.Hash) error {
-	// Get transaction
-	tx, pending, err := a.client.TransactionByHash(ctx, txHash)
+	if a.opts.ResultCache != nil {
+		return a.analyzeCached(ctx, txHash)
+	}
+	return a.analyzeTransaction(ctx, txHash)
+}
+
+// analyzeCached loads the trace of txHash from the result cache, or traces it and
+// caches its report once the trace completes. A hit only requests the chain ID.
+func (a *TransactionAnalyzer) analyzeCached(ctx context.Context, txHash common.Hash) error {
+	cache := a.opts.ResultCache
+	chainID, err := a.client.ChainID(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get transaction: %w", err)
+		return fmt.Errorf("failed to get chain ID: %w", rpcTimeout(err))
+	}
+
+	// An entry that no longer parses is traced again and replaced
+	if report, ok := cache.Load(chainID, txHash); ok && a.tracer.RestoreReport(report) == nil {
+		a.fromCache = true
+		return nil
+	}
+
+	if err := a.analyzeTransaction(ctx, txHash); err != nil {
+		return err
 	}
-	if pending {
-		return fmt.Errorf("transaction is still pending")
+	report, err := a.tracer.SnapshotReport()
+	if err == nil {
+		err = cache.Store(chainID, txHash, report)
 	}
+	if err != nil && cache.OnStoreError != nil {
+		cache.OnStoreError(err)
+	}
+	return nil
+}
+
+// FromCache reports whether the last transaction analyzed was loaded from the result cache
+func (a *TransactionAnalyzer) FromCache() bool {
+	return a.fromCache
+}
+
+// analyzeTransaction fetches, replays and traces a mined transaction
+func (a *TransactionAnalyzer) analyzeTransaction(ctx context.Context, txHash common.Hash) error {
+	a.fromCache = false
 
-	// Get receipt
-	receipt, err := a.client.TransactionReceipt(ctx, txHash)
+	// Get transaction and receipt
+	tx, receipt, err := a.fetchTransaction(ctx, txHash)
 	if err != nil {
-		return fmt.Errorf("failed to get receipt: %w", err)
+		return err
 	}
 
 	// Get block
 	block, err := a.client.BlockByHash(ctx, receipt.BlockHash)
 	if err != nil {
-		return fmt.Errorf("failed to get block: %w", err)
+		return fmt.Errorf("failed to get block: %w", rpcTimeout(err))
 	}
 
-	// Find transaction index in block
-	txIndex := 0
-	for i, blockTx := range block.Transactions() {
-		if blockTx.Hash() == txHash {
-			txIndex = i
-			break
-		}
+	// Get message from transaction
+	msg, err := core.TransactionToMessage(tx, types.LatestSignerForChainID(tx.ChainId()), block.BaseFee())
+	if err != nil {
+		return fmt.Errorf("failed to convert tx to message: %w", err)
 	}
 
 	// Create state database for the block
-	statedb, err := a.createStateDB(ctx, block, txIndex)
+	statedb, err := a.createStateDB(ctx, block, msg)
 	if err != nil {
 		return fmt.Errorf("failed to create state: %w", err)
 	}
 
-	// Get message from transaction
-	msg, err := core.TransactionToMessage(tx, types.LatestSignerForChainID(tx.ChainId()), block.BaseFee())
+	return a.execute(ctx, block.Header(), statedb, msg, false)
+}
+
+// pendingBlock selects the pending block in RPC lookups
+var pendingBlock = big.NewInt(int64(rpc.PendingBlockNumber))
+
+// AnalyzePendingTransaction simulates a transaction from the mempool on top of the
+// pending block and traces it. Accounts and storage are fetched from the pending
+// state as execution touches them, and the tracer is labeled as having run
+// against pending state.
+func (a *TransactionAnalyzer) AnalyzePendingTransaction(ctx context.Context, txHash common.Hash) error {
+	tx, pending, err := a.client.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction: %w", rpcTimeout(err))
+	}
+	if !pending {
+		return fmt.Errorf("transaction is already mined; trace it without --pending")
+	}
+
+	header, err := a.client.HeaderByNumber(ctx, pendingBlock)
+	if err != nil {
+		return fmt.Errorf("failed to get pending header: %w", rpcTimeout(err))
+	}
+
+	msg, err := core.TransactionToMessage(tx, types.LatestSignerForChainID(tx.ChainId()), header.BaseFee)
 	if err != nil {
 		return fmt.Errorf("failed to convert tx to message: %w", err)
 	}
 
+	statedb, err := a.newRemoteStateDB(ctx, pendingBlock)
+	if err != nil {
+		return fmt.Errorf("failed to create state: %w", err)
+	}
+
+	a.tracer.SetSimulatedState(tracer.StatePending)
+	return a.execute(ctx, header, statedb, msg, false)
+}
+
+// AnalyzeCall simulates a call on top of the latest block and traces it.
+// Accounts and storage are fetched from the latest state as execution touches
+// them, after the overrides are applied on top.
+func (a *TransactionAnalyzer) AnalyzeCall(ctx context.Context, from, to common.Address, data []byte, value *big.Int, overrides StateOverride) error {
+	header, err := a.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get latest header: %w", rpcTimeout(err))
+	}
+
+	// Read the state of the header fetched, even once later blocks arrive
+	statedb, err := a.newRemoteStateDB(ctx, header.Number)
+	if err != nil {
+		return fmt.Errorf("failed to create state: %w", err)
+	}
+
+	if err := overrides.Apply(statedb); err != nil {
+		return fmt.Errorf("failed to apply state overrides: %w", err)
+	}
+
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	msg := &core.Message{
+		To:                &to,
+		From:              from,
+		Value:             value,
+		GasLimit:          header.GasLimit,
+		GasPrice:          new(big.Int),
+		GasFeeCap:         new(big.Int),
+		GasTipCap:         new(big.Int),
+		Data:              data,
+		SkipAccountChecks: true,
+	}
+
+	return a.execute(ctx, header, statedb, msg, true)
+}
+
+// seedAccount copies an account's balance, nonce and code from the state at
+// blockNumber, or the latest state when blockNumber is nil
+func (a *TransactionAnalyzer) seedAccount(ctx context.Context, statedb *state.StateDB, addr common.Address, blockNumber *big.Int) error {
+	balance, err := a.client.BalanceAt(ctx, addr, blockNumber)
+	if err != nil {
+		return err
+	}
+	nonce, err := a.client.NonceAt(ctx, addr, blockNumber)
+	if err != nil {
+		return err
+	}
+	code, err := a.client.CodeAt(ctx, addr, blockNumber)
+	if err != nil {
+		return err
+	}
+
+	statedb.SetBalance(addr, balance)
+	statedb.SetNonce(addr, nonce)
+	if len(code) > 0 {
+		statedb.SetCode(addr, code)
+	}
+	return nil
+}
+
+// Apply injects the overridden account state into the state database
+func (o StateOverride) Apply(statedb *state.StateDB) error {
+	for addr, account := range o {
+		if account.Nonce != nil {
+			statedb.SetNonce(addr, *account.Nonce)
+		}
+		if account.Code != nil {
+			statedb.SetCode(addr, account.Code)
+		}
+		if account.Balance != nil {
+			if account.Balance.Sign() < 0 {
+				return fmt.Errorf("negative balance override for %s", addr.Hex())
+			}
+			statedb.SetBalance(addr, account.Balance)
+		}
+		for key, value := range account.State {
+			statedb.SetState(addr, key, value)
+		}
+	}
+	return nil
+}
+
+// Apply replaces the overridden fields of the block context. A PREVRANDAO
+// override also sets the difficulty to zero, as after the merge.
+func (o *BlockOverride) Apply(blockContext *vm.BlockContext) {
+	if o == nil {
+		return
+	}
+	if o.Number != nil {
+		blockContext.BlockNumber = new(big.Int).Set(o.Number)
+	}
+	if o.Time != nil {
+		blockContext.Time = *o.Time
+	}
+	if o.BaseFee != nil {
+		blockContext.BaseFee = new(big.Int).Set(o.BaseFee)
+	}
+	if o.PrevRandao != nil {
+		random := *o.PrevRandao
+		blockContext.Random = &random
+		blockContext.Difficulty = new(big.Int)
+	}
+	if o.Coinbase != nil {
+		blockContext.Coinbase = *o.Coinbase
+	}
+}
+
+// execute runs a message in an EVM configured with the analyzer's tracer.
+// Execution is aborted when ctx is done.
+func (a *TransactionAnalyzer) execute(ctx context.Context, header *types.Header, statedb *state.StateDB, msg *core.Message, noBaseFee bool) error {
 	// Create EVM context
-	blockContext := core.NewEVMBlockContext(block.Header(), a, nil)
+	// Overridden block numbers and times also select the fork rules
+	blockContext := core.NewEVMBlockContext(header, a, &header.Coinbase)
+	a.opts.Block.Apply(&blockContext)
 	txContext := core.NewEVMTxContext(msg)
 
 	// Create EVM with our custom tracer
+	if a.opts.MaxSteps > 0 {
+		a.tracer.SetMaxSteps(a.opts.MaxSteps)
+	}
 	vmConfig := vm.Config{
 		Tracer:    a.tracer,
-		NoBaseFee: false,
+		NoBaseFee: noBaseFee,
 	}
 
-	evm := vm.NewEVM(blockContext, txContext, statedb, params.MainnetChainConfig, vmConfig)
+	evm := vm.NewEVM(blockContext, txContext, statedb, chainConfigFor(header), vmConfig)
+	// NewEVM zeroes the base fee of fee-less calls, so reapply an overridden one
+	a.opts.Block.Apply(&evm.Context)
+
+	// Stop the interpreter once the deadline expires or the context is cancelled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			evm.Cancel()
+		case <-done:
+		}
+	}()
 
 	// Execute the transaction
-	_, err = core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(block.GasLimit()))
+	// Replace the gas limit to observe execution under tighter or looser gas
+	gasPool := header.GasLimit
+	if a.opts.GasLimit > 0 {
+		msg.GasLimit = a.opts.GasLimit
+		gasPool = a.opts.GasLimit
+	}
+
+	// The tracer keeps the data collected before execution stopped, so label it as
+	// partial. Errors of ApplyMessage itself reject the transaction before its first
+	// step; failures inside the EVM, such as reverts, are part of a complete trace.
+	_, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(gasPool))
+	// Execution against state that failed to load says nothing about the transaction
+	stateErr := statedb.Error()
+	if a.tracer.Truncated() {
+		err = fmt.Errorf("%w: aborted after %d steps", ErrStepLimitExceeded, a.tracer.StepCount())
+	} else if evm.Cancelled() && errors.Is(ctx.Err(), context.Canceled) {
+		err = fmt.Errorf("%w: %w", ErrExecutionInterrupted, ctx.Err())
+	} else if evm.Cancelled() {
+		err = fmt.Errorf("%w: %w", ErrExecutionTimeout, ctx.Err())
+	} else if stateErr != nil {
+		return fmt.Errorf("failed to fetch state: %w", stateErr)
+	} else if err != nil {
+		return fmt.Errorf("%w: %w", ErrTransactionRejected, err)
+	}
 	if err != nil {
-		// Even if execution fails, we might have useful trace data
-		fmt.Printf("Transaction execution error (this is OK for analysis): %v\n", err)
+		a.tracer.SetPartial(err.Error())
 	}
+	return err
+}
 
-	return nil
+// chainConfigFor returns the mainnet chain config, with Cancun activated for
+// headers carrying blob gas fields in case the config does not schedule it yet.
+// Without Cancun rules blob gas is neither validated nor charged.
+func chainConfigFor(header *types.Header) *params.ChainConfig {
+	config := params.MainnetChainConfig
+	if header.ExcessBlobGas == nil || config.IsCancun(header.Number, header.Time) {
+		return config
+	}
+
+	activation := header.Time
+	cancun := *config
+	cancun.CancunTime = &activation
+	if cancun.ShanghaiTime == nil || *cancun.ShanghaiTime > activation {
+		cancun.ShanghaiTime = &activation
+	}
+	return &cancun
+}
+
+// rpcTimeout marks an RPC error caused by the context deadline as an ErrRPCTimeout
+func rpcTimeout(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrRPCTimeout, err)
+	}
+	return err
 }
 
 // createStateDB creates a state database for analysis
-// This is a simplified version - in production, you'd need proper state access
-func (a *TransactionAnalyzer) createStateDB(ctx context.Context, block *types.Block, txIndex int) (*state.StateDB, error) {
-	// Note: This requires an archive node for proper historical state access
-	// For simplicity, we create a new in-memory state
+// This is a simplified version - in production, you'd need proper state access.
+// With AllowEmptyState, accounts the node cannot serve are left empty and the
+// tracer is labeled as having run against empty state.
+func (a *TransactionAnalyzer) createStateDB(ctx context.Context, block *types.Block, msg *core.Message) (*state.StateDB, error) {
+	// Note: This requires an archive node for proper historical state access.
+	// The sender and recipient are loaded from the parent block into an in-memory
+	// state; contract storage and earlier transactions in the block are not replayed.
 	db := rawdb.NewMemoryDatabase()
-	statedb, err := state.New(block.Root(), state.NewDatabase(db), nil)
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(db), nil)
 	if err != nil {
 		return nil, err
 	}
+
+	parent := new(big.Int)
+	if block.Number().Sign() > 0 {
+		parent.Sub(block.Number(), common.Big1)
+	}
+
+	accounts := []common.Address{msg.From}
+	if msg.To != nil {
+		accounts = append(accounts, *msg.To)
+	}
+
+	for _, addr := range accounts {
+		if err := a.seedAccount(ctx, statedb, addr, parent); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return nil, rpcTimeout(err)
+			}
+			if a.opts.AllowEmptyState {
+				a.tracer.SetSimulatedState(tracer.StateEmpty)
+				continue
+			}
+			return nil, fmt.Errorf("%w: %v", ErrNotArchiveNode, err)
+		}
+	}
 	return statedb, nil
 }
 
+// Clone returns an analyzer sharing a's connection and options with a tracer of its
+// own, so that transactions can be traced concurrently. Only the original should be closed.
+func (a *TransactionAnalyzer) Clone() *TransactionAnalyzer {
+	return &TransactionAnalyzer{
+		client: a.client,
+		tracer: tracer.NewGasOptimizationTracer(),
+		opts:   a.opts,
+	}
+}
+
 // GetTracer returns the tracer instance
 func (a *TransactionAnalyzer) GetTracer() *tracer.GasOptimizationTracer {
 	return a.tracer
 }
 
+// ChainID returns the chain ID reported by the node
+func (a *TransactionAnalyzer) ChainID(ctx context.Context) (*big.Int, error) {
+	return a.client.ChainID(ctx)
+}
+
+// CallContract executes a read-only call on the node, such as a name lookup
+func (a *TransactionAnalyzer) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return a.client.CallContract(ctx, msg, blockNumber)
+}
+
 // Close closes the analyzer connection
 func (a *TransactionAnalyzer) Close() {
 	if a.client != nil {
@@ -136,10 +608,18 @@ func (a *TransactionAnalyzer) GetHeader(hash common.Hash, number uint64) *types.
 	return header
 }
 
-// GetHeaderByHash implements ChainContext interface
+// GetHeaderByHash implements ChainContext interface.
+// It returns nil when the node does not know the hash or the lookup fails.
 func (a *TransactionAnalyzer) GetHeaderByHash(hash common.Hash) *types.Header {
-	// This is used by the EVM during execution
-	return nil
+	header, err := a.client.HeaderByHash(context.Background(), hash)
+	if errors.Is(err, ethereum.NotFound) {
+		return nil
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to fetch header %s: %v\n", hash.Hex(), err)
+		return nil
+	}
+	return header
 }
 
 // Engine implements ChainContext interface
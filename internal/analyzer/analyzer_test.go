@@ -0,0 +1,820 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// mockClient serves canned chain data in place of an RPC connection
+type mockClient struct {
+	header     *types.Header
+	code       map[common.Address][]byte
+	balances   map[common.Address]*big.Int
+	storage    map[common.Address]map[common.Hash]common.Hash
+	storageErr error // Error returned by StorageAt, when set
+	notArchive bool
+	delay      time.Duration // Latency of HeaderByNumber, honoring the context deadline
+	closed     bool
+
+	// RPC call counts
+	txCalls     int
+	headerCalls int
+	blockCalls  int
+	codeCalls   int
+
+	storageBlocks []*big.Int // Block of each storage lookup
+
+	txs            map[common.Hash]*types.Transaction
+	pendingTxs     map[common.Hash]*types.Transaction
+	pendingHeader  *types.Header
+	pendingLookups int // State lookups against the pending block
+	receipts       map[common.Hash]*types.Receipt
+	blocks         map[common.Hash]*types.Block
+}
+
+func newMockClient() *mockClient {
+	return &mockClient{
+		header: &types.Header{
+			Number:     big.NewInt(1),
+			Difficulty: big.NewInt(1),
+			GasLimit:   30000000,
+		},
+		code:       make(map[common.Address][]byte),
+		balances:   make(map[common.Address]*big.Int),
+		storage:    make(map[common.Address]map[common.Hash]common.Hash),
+		txs:        make(map[common.Hash]*types.Transaction),
+		pendingTxs: make(map[common.Hash]*types.Transaction),
+		receipts:   make(map[common.Hash]*types.Receipt),
+		blocks:     make(map[common.Hash]*types.Block),
+	}
+}
+
+// addBlock makes the block and its transactions available from the mock
+func (m *mockClient) addBlock(block *types.Block) {
+	m.blocks[block.Hash()] = block
+	for _, tx := range block.Transactions() {
+		m.txs[tx.Hash()] = tx
+		m.receipts[tx.Hash()] = &types.Receipt{TxHash: tx.Hash(), BlockHash: block.Hash(), BlockNumber: block.Number()}
+	}
+}
+
+func (m *mockClient) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	m.txCalls++
+	if tx, ok := m.txs[hash]; ok {
+		return tx, false, nil
+	}
+	if tx, ok := m.pendingTxs[hash]; ok {
+		return tx, true, nil
+	}
+	return nil, false, errors.New("not found")
+}
+
+func (m *mockClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if receipt, ok := m.receipts[txHash]; ok {
+		return receipt, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (m *mockClient) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	m.blockCalls++
+	if block, ok := m.blocks[hash]; ok {
+		return block, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (m *mockClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	m.headerCalls++
+	if m.delay > 0 {
+		select {
+		case <-time.After(m.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if number != nil && number.Cmp(pendingBlock) == 0 && m.pendingHeader != nil {
+		return m.pendingHeader, nil
+	}
+	return m.header, nil
+}
+
+func (m *mockClient) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	m.headerCalls++
+	if block, ok := m.blocks[hash]; ok {
+		return block.Header(), nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (m *mockClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	if blockNumber != nil && blockNumber.Cmp(pendingBlock) == 0 {
+		m.pendingLookups++
+	} else if m.notArchive && blockNumber != nil {
+		return nil, errors.New("missing trie node")
+	}
+	if balance, ok := m.balances[account]; ok {
+		return new(big.Int).Set(balance), nil
+	}
+	return new(big.Int), nil
+}
+
+func (m *mockClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return 0, nil
+}
+
+func (m *mockClient) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	m.codeCalls++
+	return m.code[account], nil
+}
+
+func (m *mockClient) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	m.storageBlocks = append(m.storageBlocks, blockNumber)
+	if m.storageErr != nil {
+		return nil, m.storageErr
+	}
+	value := m.storage[account][key]
+	return value.Bytes(), nil
+}
+
+func (m *mockClient) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (m *mockClient) ChainID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (m *mockClient) ClientVersion(ctx context.Context) (string, error) {
+	return "Geth/v1.13.5-mock", nil
+}
+
+func (m *mockClient) Close() {
+	m.closed = true
+}
+
+func TestAnalyzeCallWithCodeOverride(t *testing.T) {
+	an := NewTransactionAnalyzerWithClient(newMockClient())
+	defer an.Close()
+
+	from := common.HexToAddress("0x1000")
+	to := common.HexToAddress("0x2000")
+
+	// SLOAD slot 0 and store the result in slot 1
+	code := []byte{
+		byte(vm.PUSH1), 0x00, byte(vm.SLOAD),
+		byte(vm.PUSH1), 0x01, byte(vm.SSTORE),
+		byte(vm.STOP),
+	}
+
+	overrides := StateOverride{
+		to: {
+			Code:  code,
+			State: map[common.Hash]common.Hash{{}: common.HexToHash("0x2a")},
+		},
+	}
+
+	if err := an.AnalyzeCall(context.Background(), from, to, nil, nil, overrides); err != nil {
+		t.Fatalf("AnalyzeCall() error: %v", err)
+	}
+
+	tr := an.GetTracer()
+
+	if tr.GasPerOpcode["SLOAD"] == 0 {
+		t.Error("Expected SLOAD gas to be traced")
+	}
+
+	if tr.StorageReads[common.Hash{}] != 1 {
+		t.Errorf("Expected 1 read of slot 0, got %d", tr.StorageReads[common.Hash{}])
+	}
+
+	if tr.StorageWrites[common.BigToHash(big.NewInt(1))] != 1 {
+		t.Error("Expected a write to slot 1")
+	}
+
+	if tr.TotalGasUsed == 0 {
+		t.Error("Expected total gas to be recorded")
+	}
+}
+
+// copySlotCode loads storage slot 0 and stores the value in slot 1
+var copySlotCode = []byte{
+	byte(vm.PUSH1), 0x00, byte(vm.SLOAD),
+	byte(vm.PUSH1), 0x01, byte(vm.SSTORE),
+	byte(vm.STOP),
+}
+
+func TestAnalyzeCallReadsNodeStorage(t *testing.T) {
+	client := newMockClient()
+	client.header.Number = big.NewInt(42)
+	to := common.HexToAddress("0x2000")
+	client.code[to] = copySlotCode
+	client.storage[to] = map[common.Hash]common.Hash{{}: common.HexToHash("0x2a")}
+
+	an := NewTransactionAnalyzerWithClient(client)
+	if err := an.AnalyzeCall(context.Background(), common.HexToAddress("0x1000"), to, nil, nil, nil); err != nil {
+		t.Fatalf("AnalyzeCall() error: %v", err)
+	}
+
+	// Storing the nonzero value fetched from the node sets a fresh slot
+	tr := an.GetTracer()
+	if tr.GasPerOpcode["SSTORE"] < params.SstoreSetGasEIP2200 {
+		t.Errorf("Expected SSTORE of the node's value to cost at least %d, got %d", params.SstoreSetGasEIP2200, tr.GasPerOpcode["SSTORE"])
+	}
+
+	if len(client.storageBlocks) == 0 {
+		t.Fatal("Expected storage to be fetched from the node")
+	}
+	for _, block := range client.storageBlocks {
+		if block == nil || block.Int64() != 42 {
+			t.Errorf("Expected storage fetched at block 42, got %v", block)
+		}
+	}
+
+	// Overridden slots replace the node's values
+	client.storageBlocks = nil
+	an = NewTransactionAnalyzerWithClient(client)
+	overrides := StateOverride{to: {State: map[common.Hash]common.Hash{{}: {}}}}
+	if err := an.AnalyzeCall(context.Background(), common.HexToAddress("0x1000"), to, nil, nil, overrides); err != nil {
+		t.Fatalf("AnalyzeCall() error: %v", err)
+	}
+	if sstore := an.GetTracer().GasPerOpcode["SSTORE"]; sstore >= params.SstoreSetGasEIP2200 {
+		t.Errorf("Expected SSTORE of the overridden zero to be a no-op, got %d gas", sstore)
+	}
+}
+
+func TestAnalyzeCallStateFetchError(t *testing.T) {
+	client := newMockClient()
+	to := common.HexToAddress("0x2000")
+	client.code[to] = copySlotCode
+	client.storageErr = errors.New("missing trie node")
+
+	an := NewTransactionAnalyzerWithClient(client)
+	err := an.AnalyzeCall(context.Background(), common.HexToAddress("0x1000"), to, nil, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to fetch state") {
+		t.Fatalf("Expected a state fetch error, got %v", err)
+	}
+
+	if IsPartial(err) {
+		t.Error("Did not expect results against unfetched state to be partial")
+	}
+}
+
+func TestStateOverrideApplyRejectsNegativeBalance(t *testing.T) {
+	an := NewTransactionAnalyzerWithClient(newMockClient())
+
+	overrides := StateOverride{
+		common.HexToAddress("0x1000"): {Balance: big.NewInt(-1)},
+	}
+
+	err := an.AnalyzeCall(context.Background(), common.Address{}, common.HexToAddress("0x2000"), nil, nil, overrides)
+	if err == nil {
+		t.Error("Expected error for negative balance override")
+	}
+}
+
+func TestProbeRejectsNonArchiveNode(t *testing.T) {
+	client := newMockClient()
+	client.notArchive = true
+
+	an, err := NewTransactionAnalyzerFromClient(context.Background(), client, Options{})
+	if err == nil {
+		t.Fatal("Expected construction to fail against a non-archive node")
+	}
+
+	if !errors.Is(err, ErrNotArchiveNode) {
+		t.Errorf("Expected ErrNotArchiveNode, got %v", err)
+	}
+
+	if an != nil {
+		t.Error("Expected no analyzer to be returned")
+	}
+}
+
+func TestProbeAllowEmptyState(t *testing.T) {
+	client := newMockClient()
+	client.notArchive = true
+
+	an, err := NewTransactionAnalyzerFromClient(context.Background(), client, Options{AllowEmptyState: true})
+	if err != nil {
+		t.Fatalf("Expected --allow-empty-state to skip the probe, got %v", err)
+	}
+
+	if an == nil {
+		t.Fatal("Expected analyzer to be created")
+	}
+}
+
+func TestAllowEmptyStateReplayLabeled(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	client := newMockClient()
+	client.notArchive = true
+	tx := signedTx(t, key, 0, common.HexToAddress("0x3000"), 100000)
+	client.addBlock(blockAt(7, tx))
+
+	an, err := NewTransactionAnalyzerFromClient(context.Background(), client, Options{AllowEmptyState: true})
+	if err != nil {
+		t.Fatalf("NewTransactionAnalyzerFromClient() error: %v", err)
+	}
+	if err := an.AnalyzeTransaction(context.Background(), tx.Hash()); err != nil {
+		t.Fatalf("AnalyzeTransaction() error: %v", err)
+	}
+
+	tr := an.GetTracer()
+	if tr.SimulatedState != tracer.StateEmpty {
+		t.Errorf("Expected the replay to be labeled %q, got %q", tracer.StateEmpty, tr.SimulatedState)
+	}
+	report, err := tr.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+	if !strings.Contains(report, `"simulated_against": "empty"`) {
+		t.Error("Expected the report to be labeled as traced against empty state")
+	}
+
+	// Replays with the historical state available are not labeled
+	client.notArchive = false
+	an.GetTracer().Reset()
+	if err := an.AnalyzeTransaction(context.Background(), tx.Hash()); err != nil {
+		t.Fatalf("AnalyzeTransaction() error: %v", err)
+	}
+	if state := an.GetTracer().SimulatedState; state != "" {
+		t.Errorf("Expected no label with historical state, got %q", state)
+	}
+}
+
+func TestProbeAcceptsArchiveNode(t *testing.T) {
+	if _, err := NewTransactionAnalyzerFromClient(context.Background(), newMockClient(), Options{}); err != nil {
+		t.Errorf("Expected probe to pass on an archive node, got %v", err)
+	}
+}
+
+func TestAnalyzeCallRPCTimeout(t *testing.T) {
+	client := newMockClient()
+	client.delay = time.Second
+	an := NewTransactionAnalyzerWithClient(client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	err := an.AnalyzeCall(ctx, common.Address{}, common.HexToAddress("0x2000"), nil, nil, nil)
+	if !errors.Is(err, ErrRPCTimeout) {
+		t.Fatalf("Expected ErrRPCTimeout, got %v", err)
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+
+	if errors.Is(err, ErrExecutionTimeout) {
+		t.Error("Did not expect an execution timeout")
+	}
+}
+
+func TestAnalyzeCallExecutionTimeout(t *testing.T) {
+	client := newMockClient()
+	client.header.GasLimit = 1 << 40
+	an := NewTransactionAnalyzerWithClient(client)
+
+	to := common.HexToAddress("0x2000")
+
+	// Loop until the gas runs out
+	loop := []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0x00, byte(vm.JUMP)}
+	overrides := StateOverride{to: {Code: loop}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := an.AnalyzeCall(ctx, common.Address{}, to, nil, nil, overrides)
+	if !errors.Is(err, ErrExecutionTimeout) {
+		t.Fatalf("Expected ErrExecutionTimeout, got %v", err)
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+
+	if errors.Is(err, ErrRPCTimeout) {
+		t.Error("Did not expect an RPC timeout")
+	}
+}
+
+func TestExecutionTimeoutKeepsPartialResults(t *testing.T) {
+	client := newMockClient()
+	client.header.GasLimit = 1 << 40
+	an := NewTransactionAnalyzerWithClient(client)
+
+	to := common.HexToAddress("0x2000")
+	loop := []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0x00, byte(vm.JUMP)}
+	overrides := StateOverride{to: {Code: loop}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := an.AnalyzeCall(ctx, common.Address{}, to, nil, nil, overrides)
+	if !IsPartial(err) {
+		t.Fatalf("Expected a partial result error, got %v", err)
+	}
+
+	tr := an.GetTracer()
+	if tr.Partial == "" {
+		t.Error("Expected the tracer to be labeled partial")
+	}
+	if tr.StepCount() == 0 || tr.TotalGasUsed == 0 {
+		t.Errorf("Expected traced steps and gas, got %d steps and %d gas", tr.StepCount(), tr.TotalGasUsed)
+	}
+
+	data, err := tr.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+	var report map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if report["partial"] != tr.Partial {
+		t.Errorf("Expected partial reason %q in the report, got %v", tr.Partial, report["partial"])
+	}
+	if len(report["gas_by_opcode"].(map[string]interface{})) == 0 {
+		t.Error("Expected gas by opcode in the partial report")
+	}
+}
+
+func TestMaxStepsAbortsExecution(t *testing.T) {
+	client := newMockClient()
+	client.header.GasLimit = 1 << 40
+	an := NewTransactionAnalyzerWithClient(client)
+	an.opts.MaxSteps = 1000
+
+	to := common.HexToAddress("0x2000")
+	loop := []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0x00, byte(vm.JUMP)}
+	overrides := StateOverride{to: {Code: loop}}
+
+	err := an.AnalyzeCall(context.Background(), common.Address{}, to, nil, nil, overrides)
+	if !errors.Is(err, ErrStepLimitExceeded) || !IsPartial(err) {
+		t.Fatalf("Expected a partial result error for the step limit, got %v", err)
+	}
+
+	tr := an.GetTracer()
+	if !tr.Truncated() || tr.Partial == "" {
+		t.Errorf("Expected a truncated, partial trace, got truncated %v and reason %q", tr.Truncated(), tr.Partial)
+	}
+	if tr.StepCount() != 1000 {
+		t.Errorf("Expected 1000 steps, got %d", tr.StepCount())
+	}
+}
+
+func TestExecutionInterruptedKeepsPartialResults(t *testing.T) {
+	client := newMockClient()
+	client.header.GasLimit = 1 << 40
+	an := NewTransactionAnalyzerWithClient(client)
+
+	to := common.HexToAddress("0x2000")
+	loop := []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0x00, byte(vm.JUMP)}
+	overrides := StateOverride{to: {Code: loop}}
+
+	// Cancel like an interrupt once the loop is running
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for an.GetTracer().StepCount() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+
+	err := an.AnalyzeCall(ctx, common.Address{}, to, nil, nil, overrides)
+	if !errors.Is(err, ErrExecutionInterrupted) || errors.Is(err, ErrExecutionTimeout) {
+		t.Fatalf("Expected an interrupted execution, got %v", err)
+	}
+	if !IsPartial(err) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a partial result error wrapping context.Canceled, got %v", err)
+	}
+
+	tr := an.GetTracer()
+	if tr.Partial == "" || tr.StepCount() == 0 {
+		t.Errorf("Expected partial results, got reason %q after %d steps", tr.Partial, tr.StepCount())
+	}
+
+	an.Close()
+	if !client.closed {
+		t.Error("Expected the connection to be closed")
+	}
+}
+
+func TestBlockOverride(t *testing.T) {
+	an := NewTransactionAnalyzerWithClient(newMockClient())
+	defer an.Close()
+
+	timestamp := uint64(1700000000)
+	randao := common.HexToHash("0xabcd")
+	an.opts.Block = &BlockOverride{
+		Number:     big.NewInt(18000000),
+		Time:       &timestamp,
+		BaseFee:    big.NewInt(7),
+		PrevRandao: &randao,
+	}
+
+	// Return TIMESTAMP, NUMBER, BASEFEE and PREVRANDAO as four words
+	var code []byte
+	for i, op := range []vm.OpCode{vm.TIMESTAMP, vm.NUMBER, vm.BASEFEE, vm.DIFFICULTY} {
+		code = append(code, byte(op), byte(vm.PUSH1), byte(i*32), byte(vm.MSTORE))
+	}
+	code = append(code, byte(vm.PUSH1), 0x80, byte(vm.PUSH1), 0x00, byte(vm.RETURN))
+
+	to := common.HexToAddress("0x2000")
+	if err := an.AnalyzeCall(context.Background(), common.Address{}, to, nil, nil, StateOverride{to: {Code: code}}); err != nil {
+		t.Fatalf("AnalyzeCall() error: %v", err)
+	}
+
+	output := an.GetTracer().CallTree.Output
+	if len(output) != 128 {
+		t.Fatalf("Expected 128 bytes of output, got %d", len(output))
+	}
+	if got := new(big.Int).SetBytes(output[:32]).Uint64(); got != timestamp {
+		t.Errorf("Expected TIMESTAMP %d, got %d", timestamp, got)
+	}
+	if got := new(big.Int).SetBytes(output[32:64]).Uint64(); got != 18000000 {
+		t.Errorf("Expected NUMBER 18000000, got %d", got)
+	}
+	if got := new(big.Int).SetBytes(output[64:96]).Uint64(); got != 7 {
+		t.Errorf("Expected BASEFEE 7, got %d", got)
+	}
+	if got := common.BytesToHash(output[96:]); got != randao {
+		t.Errorf("Expected PREVRANDAO %s, got %s", randao.Hex(), got.Hex())
+	}
+}
+
+func TestGasLimitOverride(t *testing.T) {
+	an, err := NewTransactionAnalyzerFromClient(context.Background(), newMockClient(), Options{LatestStateOnly: true, GasLimit: params.TxGas + 1000})
+	if err != nil {
+		t.Fatalf("NewTransactionAnalyzerFromClient() error: %v", err)
+	}
+
+	to := common.HexToAddress("0x2000")
+	loop := []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0x00, byte(vm.JUMP)}
+	if err := an.AnalyzeCall(context.Background(), common.Address{}, to, nil, nil, StateOverride{to: {Code: loop}}); err != nil {
+		t.Fatalf("AnalyzeCall() error: %v", err)
+	}
+
+	tr := an.GetTracer()
+	if tr.GasLimit != params.TxGas+1000 {
+		t.Errorf("Expected gas limit %d, got %d", params.TxGas+1000, tr.GasLimit)
+	}
+
+	faults := tr.GetFaults()
+	if len(faults) != 1 || !faults[0].OutOfGas {
+		t.Fatalf("Expected 1 out of gas fault, got %v", faults)
+	}
+	if faults[0].Depth != 1 || faults[0].Contract != to {
+		t.Errorf("Expected the fault at depth 1 in %s, got %+v", to.Hex(), faults[0])
+	}
+}
+
+func TestRejectedTransactionIsNotPartial(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	client := newMockClient()
+
+	// The sender's nonce is 0 on the node
+	tx := signedTx(t, key, 5, common.HexToAddress("0x3000"), 100000)
+	client.pendingTxs[tx.Hash()] = tx
+
+	an := NewTransactionAnalyzerWithClient(client)
+	err := an.AnalyzePendingTransaction(context.Background(), tx.Hash())
+	if !errors.Is(err, ErrTransactionRejected) {
+		t.Fatalf("Expected ErrTransactionRejected, got %v", err)
+	}
+	if !errors.Is(err, core.ErrNonceTooHigh) {
+		t.Errorf("Expected the nonce error to be kept, got %v", err)
+	}
+	if IsPartial(err) {
+		t.Error("Did not expect a rejected transaction to leave partial results")
+	}
+	if tr := an.GetTracer(); tr.Partial != "" || tr.StepCount() != 0 {
+		t.Errorf("Expected no partial label and no steps, got %q and %d steps", tr.Partial, tr.StepCount())
+	}
+}
+
+func TestRPCErrorsAreNotPartial(t *testing.T) {
+	if IsPartial(fmt.Errorf("failed to get block: %w", ErrRPCTimeout)) {
+		t.Error("Did not expect an RPC failure to leave partial results")
+	}
+	if IsPartial(fmt.Errorf("%w: nonce too high", ErrTransactionRejected)) {
+		t.Error("Did not expect a rejected transaction to leave partial results")
+	}
+}
+
+func TestCloneHasOwnTracer(t *testing.T) {
+	an := NewTransactionAnalyzerWithClient(newMockClient())
+	defer an.Close()
+
+	clone := an.Clone()
+	if clone.GetTracer() == an.GetTracer() {
+		t.Error("Expected the clone to have its own tracer")
+	}
+
+	if clone.client != an.client {
+		t.Error("Expected the clone to share the connection")
+	}
+}
+
+func TestAnalyzeBlobTransaction(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	contract := common.HexToAddress("0x3000")
+
+	client := newMockClient()
+	client.balances[sender] = big.NewInt(1e18)
+
+	// Read the versioned hash of blob 0 only
+	client.code[contract] = []byte{
+		byte(vm.PUSH1), 0x00, byte(vm.BLOBHASH), byte(vm.POP),
+		byte(vm.STOP),
+	}
+
+	blobHash := func(b byte) common.Hash {
+		hash := common.Hash{b}
+		hash[0] = params.BlobTxHashVersion
+		hash[31] = b
+		return hash
+	}
+
+	signer := types.NewCancunSigner(params.MainnetChainConfig.ChainID)
+	tx, err := types.SignNewTx(key, signer, &types.BlobTx{
+		ChainID:    uint256.MustFromBig(params.MainnetChainConfig.ChainID),
+		GasTipCap:  uint256.NewInt(1),
+		GasFeeCap:  uint256.NewInt(1000),
+		Gas:        100000,
+		To:         contract,
+		Value:      new(uint256.Int),
+		BlobFeeCap: uint256.NewInt(1000),
+		BlobHashes: []common.Hash{blobHash(1), blobHash(2)},
+	})
+	if err != nil {
+		t.Fatalf("failed to sign blob transaction: %v", err)
+	}
+
+	excessBlobGas := uint64(10 * params.BlobTxBlobGasPerBlob)
+	blobGasUsed := uint64(2 * params.BlobTxBlobGasPerBlob)
+	header := &types.Header{
+		Number:        big.NewInt(19500000),
+		Time:          1710400000,
+		Difficulty:    new(big.Int),
+		GasLimit:      30000000,
+		BaseFee:       big.NewInt(7),
+		ExcessBlobGas: &excessBlobGas,
+		BlobGasUsed:   &blobGasUsed,
+	}
+	client.addBlock(types.NewBlockWithHeader(header).WithBody([]*types.Transaction{tx}, nil))
+
+	an := NewTransactionAnalyzerWithClient(client)
+	if err := an.AnalyzeTransaction(context.Background(), tx.Hash()); err != nil {
+		t.Fatalf("AnalyzeTransaction() error: %v", err)
+	}
+
+	tr := an.GetTracer()
+	if tr.Blobs == nil {
+		t.Fatal("Expected the blob transaction to be analyzed")
+	}
+
+	if tr.Blobs.Blobs != 2 || tr.Blobs.BlobGas != blobGasUsed {
+		t.Errorf("Expected 2 blobs using %d blob gas, got %d using %d", blobGasUsed, tr.Blobs.Blobs, tr.Blobs.BlobGas)
+	}
+
+	fee := eip4844.CalcBlobFee(excessBlobGas)
+	if tr.Blobs.BlobBaseFee == nil || tr.Blobs.BlobBaseFee.Cmp(fee) != 0 {
+		t.Errorf("Expected blob base fee %s, got %v", fee, tr.Blobs.BlobBaseFee)
+	}
+
+	if tr.GasPerOpcode["BLOBHASH"] == 0 {
+		t.Error("Expected BLOBHASH to execute under Cancun rules")
+	}
+
+	report, err := tr.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+
+	var parsed struct {
+		TotalGasUsed uint64 `json:"total_gas_used"`
+		BlobGas      struct {
+			Blobs   int    `json:"blobs"`
+			BlobGas uint64 `json:"blob_gas"`
+			Cost    string `json:"blob_cost_wei"`
+		} `json:"blob_gas"`
+	}
+	if err := json.Unmarshal([]byte(report), &parsed); err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(blobGasUsed), fee)
+	if parsed.BlobGas.Blobs != 2 || parsed.BlobGas.BlobGas != blobGasUsed || parsed.BlobGas.Cost != cost.String() {
+		t.Errorf("Expected a blob gas section costing %s wei, got %+v", cost, parsed.BlobGas)
+	}
+
+	if parsed.TotalGasUsed >= blobGasUsed {
+		t.Errorf("Expected blob gas to be kept out of execution gas, got %d", parsed.TotalGasUsed)
+	}
+
+	var unread []int
+	for _, opt := range tr.GetOptimizations() {
+		if opt.Type == "unreferenced_blob" {
+			unread = opt.Details["unread_blobs"].([]int)
+		}
+	}
+	if len(unread) != 1 || unread[0] != 1 {
+		t.Errorf("Expected blob 1 to be flagged as unread, got %v", unread)
+	}
+}
+
+func TestGetHeaderByHash(t *testing.T) {
+	client := newMockClient()
+	block := blockAt(7)
+	client.addBlock(block)
+
+	an := NewTransactionAnalyzerWithClient(client)
+
+	header := an.GetHeaderByHash(block.Hash())
+	if header == nil || header.Number.Uint64() != 7 {
+		t.Errorf("Expected the header of block 7, got %v", header)
+	}
+
+	if header := an.GetHeaderByHash(common.HexToHash("0xdead")); header != nil {
+		t.Errorf("Expected nil for an unknown hash, got %v", header)
+	}
+}
+
+func TestAnalyzePendingTransaction(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	contract := common.HexToAddress("0x3000")
+
+	client := newMockClient()
+	client.code[contract] = copySlotCode
+	client.storage[contract] = map[common.Hash]common.Hash{{}: common.HexToHash("0x2a")}
+	client.pendingHeader = &types.Header{
+		Number:     big.NewInt(2),
+		Difficulty: big.NewInt(1),
+		GasLimit:   30000000,
+	}
+
+	tx := signedTx(t, key, 0, contract, 100000)
+	client.pendingTxs[tx.Hash()] = tx
+
+	an := NewTransactionAnalyzerWithClient(client)
+	if err := an.AnalyzePendingTransaction(context.Background(), tx.Hash()); err != nil {
+		t.Fatalf("AnalyzePendingTransaction() error: %v", err)
+	}
+
+	tr := an.GetTracer()
+	if tr.GasPerOpcode["SLOAD"] == 0 {
+		t.Error("Expected the pending transaction to be traced")
+	}
+
+	if tr.SimulatedState != tracer.StatePending {
+		t.Errorf("Expected the trace to be labeled %q, got %q", tracer.StatePending, tr.SimulatedState)
+	}
+
+	if client.pendingLookups == 0 {
+		t.Error("Expected state to be fetched from the pending block")
+	}
+
+	// Storage is read from the pending state too
+	if len(client.storageBlocks) == 0 || client.storageBlocks[0].Cmp(pendingBlock) != 0 {
+		t.Errorf("Expected storage fetched from the pending block, got %v", client.storageBlocks)
+	}
+	if tr.GasPerOpcode["SSTORE"] < params.SstoreSetGasEIP2200 {
+		t.Errorf("Expected SSTORE of the pending value to cost at least %d, got %d", params.SstoreSetGasEIP2200, tr.GasPerOpcode["SSTORE"])
+	}
+
+	report, err := tr.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+	if !strings.Contains(report, `"simulated_against": "pending"`) {
+		t.Error("Expected the report to be labeled as simulated against pending state")
+	}
+
+	// Mined transactions are rejected
+	mined := signedTx(t, key, 0, contract, 200000)
+	client.addBlock(blockAt(1, mined))
+	if err := an.AnalyzePendingTransaction(context.Background(), mined.Hash()); err == nil {
+		t.Error("Expected an error for a mined transaction")
+	}
+}
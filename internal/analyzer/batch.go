@@ -0,0 +1,112 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// BatchCaller is implemented by clients that can send several JSON-RPC requests
+// in a single round trip
+type BatchCaller interface {
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
+
+// errBatchUnsupported is returned by clients wrapping one that cannot batch requests
+var errBatchUnsupported = errors.New("batch requests not supported")
+
+// BatchCallContext sends the requests to the node in one batch
+func (c rpcClient) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	return c.Client.Client().BatchCallContext(ctx, b)
+}
+
+// BatchCallContext forwards the batch to the wrapped client. Batched results
+// are not cached.
+func (c *cachingClient) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	if batcher, ok := c.Client.(BatchCaller); ok {
+		return batcher.BatchCallContext(ctx, b)
+	}
+	return errBatchUnsupported
+}
+
+// rpcTransaction is a transaction as returned by eth_getTransactionByHash
+type rpcTransaction struct {
+	tx *types.Transaction
+	txExtraInfo
+}
+
+// txExtraInfo holds the inclusion fields returned alongside a transaction
+type txExtraInfo struct {
+	BlockNumber *string `json:"blockNumber"` // Null while the transaction is pending
+}
+
+// UnmarshalJSON decodes the transaction and its inclusion fields
+func (t *rpcTransaction) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &t.tx); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &t.txExtraInfo)
+}
+
+// fetchTransaction returns a mined transaction and its receipt. Both are
+// requested in a single batch when the client supports it, falling back to
+// one call each when the batch fails. The block is requested separately as
+// its hash is only known from the receipt.
+func (a *TransactionAnalyzer) fetchTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, *types.Receipt, error) {
+	if batcher, ok := a.client.(BatchCaller); ok {
+		var tx *rpcTransaction
+		var receipt *types.Receipt
+		batch := []rpc.BatchElem{
+			{Method: "eth_getTransactionByHash", Args: []interface{}{txHash}, Result: &tx},
+			{Method: "eth_getTransactionReceipt", Args: []interface{}{txHash}, Result: &receipt},
+		}
+		if err := batcher.BatchCallContext(ctx, batch); err == nil {
+			return batchedTransaction(tx, receipt, batch)
+		}
+		// Deadlines are not a sign of a missing batch capability
+		if ctx.Err() != nil {
+			return nil, nil, fmt.Errorf("failed to get transaction: %w", rpcTimeout(ctx.Err()))
+		}
+	}
+
+	tx, pending, err := a.client.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get transaction: %w", rpcTimeout(err))
+	}
+	if pending {
+		return nil, nil, fmt.Errorf("transaction is still pending")
+	}
+
+	receipt, err := a.client.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get receipt: %w", rpcTimeout(err))
+	}
+	return tx, receipt, nil
+}
+
+// batchedTransaction checks the results of a transaction and receipt batch
+func batchedTransaction(tx *rpcTransaction, receipt *types.Receipt, batch []rpc.BatchElem) (*types.Transaction, *types.Receipt, error) {
+	if err := batch[0].Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get transaction: %w", rpcTimeout(err))
+	}
+	if tx == nil || tx.tx == nil {
+		return nil, nil, fmt.Errorf("failed to get transaction: %w", ethereum.NotFound)
+	}
+	if tx.BlockNumber == nil {
+		return nil, nil, fmt.Errorf("transaction is still pending")
+	}
+
+	if err := batch[1].Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get receipt: %w", rpcTimeout(err))
+	}
+	if receipt == nil {
+		return nil, nil, fmt.Errorf("failed to get receipt: %w", ethereum.NotFound)
+	}
+	return tx.tx, receipt, nil
+}
@@ -0,0 +1,146 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// batchingClient serves JSON-RPC batches from the transactions of a mockClient
+type batchingClient struct {
+	*mockClient
+	unsupported bool // Reject batches like an endpoint without batch support
+
+	// Request counts
+	batches      int
+	batchSize    int
+	txCalls      int
+	receiptCalls int
+}
+
+func (c *batchingClient) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	c.batches++
+	if c.unsupported {
+		return errors.New("batch requests are not allowed")
+	}
+	c.batchSize += len(b)
+
+	for i := range b {
+		hash := b[i].Args[0].(common.Hash)
+		var result interface{}
+		switch b[i].Method {
+		case "eth_getTransactionByHash":
+			if tx, ok := c.txs[hash]; ok {
+				result = minedTxJSON(tx, c.receipts[hash])
+			}
+		case "eth_getTransactionReceipt":
+			if receipt, ok := c.receipts[hash]; ok {
+				withLogs := *receipt
+				withLogs.Logs = []*types.Log{}
+				result = &withLogs
+			}
+		default:
+			b[i].Error = fmt.Errorf("method %s not found", b[i].Method)
+			continue
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, b[i].Result); err != nil {
+			b[i].Error = err
+		}
+	}
+	return nil
+}
+
+// minedTxJSON adds the inclusion fields of receipt to the JSON form of tx
+func minedTxJSON(tx *types.Transaction, receipt *types.Receipt) map[string]interface{} {
+	data, _ := tx.MarshalJSON()
+	var fields map[string]interface{}
+	json.Unmarshal(data, &fields)
+	fields["blockHash"] = receipt.BlockHash
+	fields["blockNumber"] = (*hexutil.Big)(receipt.BlockNumber)
+	return fields
+}
+
+func (c *batchingClient) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	c.txCalls++
+	return c.mockClient.TransactionByHash(ctx, hash)
+}
+
+func (c *batchingClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	c.receiptCalls++
+	return c.mockClient.TransactionReceipt(ctx, txHash)
+}
+
+// newBatchingClient returns a client holding a mined call to a contract, and the call's transaction
+func newBatchingClient(t *testing.T) (*batchingClient, *types.Transaction) {
+	t.Helper()
+
+	key, _ := crypto.GenerateKey()
+	contract := common.HexToAddress("0x3000")
+	client := &batchingClient{mockClient: newMockClient()}
+	client.code[contract] = []byte{byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP), byte(vm.STOP)}
+
+	tx := signedTx(t, key, 0, contract, 100000)
+	client.addBlock(blockAt(3, tx))
+	return client, tx
+}
+
+func TestBatchedTransactionFetch(t *testing.T) {
+	for _, cacheSize := range []int{0, DefaultCacheSize} {
+		client, tx := newBatchingClient(t)
+		an, err := NewTransactionAnalyzerFromClient(context.Background(), client, Options{AllowEmptyState: true, CacheSize: cacheSize})
+		if err != nil {
+			t.Fatalf("NewTransactionAnalyzerFromClient() error: %v", err)
+		}
+
+		if err := an.AnalyzeTransaction(context.Background(), tx.Hash()); err != nil {
+			t.Fatalf("AnalyzeTransaction() error: %v", err)
+		}
+		if client.batches != 1 || client.batchSize != 2 {
+			t.Errorf("cache %d: Expected a single batch of 2 requests, got %d batches of %d requests", cacheSize, client.batches, client.batchSize)
+		}
+		if client.txCalls != 0 || client.receiptCalls != 0 {
+			t.Errorf("cache %d: Expected no sequential lookups, got %d transaction and %d receipt calls", cacheSize, client.txCalls, client.receiptCalls)
+		}
+		if an.GetTracer().TotalGasUsed == 0 {
+			t.Errorf("cache %d: Expected the transaction to be traced", cacheSize)
+		}
+	}
+}
+
+func TestBatchedTransactionFetchFallsBack(t *testing.T) {
+	client, tx := newBatchingClient(t)
+	client.unsupported = true
+	an := NewTransactionAnalyzerWithClient(client)
+
+	if err := an.AnalyzeTransaction(context.Background(), tx.Hash()); err != nil {
+		t.Fatalf("AnalyzeTransaction() error: %v", err)
+	}
+	if client.batches != 1 || client.txCalls != 1 || client.receiptCalls != 1 {
+		t.Errorf("Expected a rejected batch then one call each, got %d batches, %d transaction and %d receipt calls",
+			client.batches, client.txCalls, client.receiptCalls)
+	}
+}
+
+func TestBatchedTransactionNotFound(t *testing.T) {
+	client, _ := newBatchingClient(t)
+	an := NewTransactionAnalyzerWithClient(client)
+
+	err := an.AnalyzeTransaction(context.Background(), common.HexToHash("0xdead"))
+	if err == nil || client.txCalls != 0 {
+		t.Errorf("Expected a missing transaction to fail from the batch alone, got %v after %d sequential calls", err, client.txCalls)
+	}
+}
@@ -0,0 +1,102 @@
+package analyzer
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DefaultCacheSize is the default number of entries kept in each of the block, header and code caches
+const DefaultCacheSize = 256
+
+// codeKey identifies an account's code at a block
+type codeKey struct {
+	account common.Address
+	block   uint64
+}
+
+// cachingClient serves repeated block, header and code lookups from LRU caches.
+// Only lookups pinned to a block are cached; queries of the latest state always
+// reach the node. Failed lookups are not cached.
+type cachingClient struct {
+	Client
+	blocks  *lru.Cache[common.Hash, *types.Block]
+	headers *lru.Cache[uint64, *types.Header]
+	hashes  *lru.Cache[common.Hash, *types.Header]
+	code    *lru.Cache[codeKey, []byte]
+}
+
+// newCachingClient wraps client with caches holding up to size entries each
+func newCachingClient(client Client, size int) *cachingClient {
+	return &cachingClient{
+		Client:  client,
+		blocks:  lru.NewCache[common.Hash, *types.Block](size),
+		headers: lru.NewCache[uint64, *types.Header](size),
+		hashes:  lru.NewCache[common.Hash, *types.Header](size),
+		code:    lru.NewCache[codeKey, []byte](size),
+	}
+}
+
+// BlockByHash returns the block with the given hash, fetching it on a cache miss
+func (c *cachingClient) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	if block, ok := c.blocks.Get(hash); ok {
+		return block, nil
+	}
+	block, err := c.Client.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	c.blocks.Add(hash, block)
+	return block, nil
+}
+
+// HeaderByNumber returns the header at number, fetching it on a cache miss.
+// The latest header (nil number) is never cached.
+func (c *cachingClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number == nil || !number.IsUint64() {
+		return c.Client.HeaderByNumber(ctx, number)
+	}
+	if header, ok := c.headers.Get(number.Uint64()); ok {
+		return header, nil
+	}
+	header, err := c.Client.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	c.headers.Add(number.Uint64(), header)
+	return header, nil
+}
+
+// HeaderByHash returns the header with the given hash, fetching it on a cache miss
+func (c *cachingClient) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	if header, ok := c.hashes.Get(hash); ok {
+		return header, nil
+	}
+	header, err := c.Client.HeaderByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	c.hashes.Add(hash, header)
+	return header, nil
+}
+
+// CodeAt returns the account's code at blockNumber, fetching it on a cache miss.
+// Code at the latest block (nil number) is never cached.
+func (c *cachingClient) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	if blockNumber == nil || !blockNumber.IsUint64() {
+		return c.Client.CodeAt(ctx, account, blockNumber)
+	}
+	key := codeKey{account: account, block: blockNumber.Uint64()}
+	if code, ok := c.code.Get(key); ok {
+		return code, nil
+	}
+	code, err := c.Client.CodeAt(ctx, account, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	c.code.Add(key, code)
+	return code, nil
+}
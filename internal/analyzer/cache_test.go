@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestCachedHeaderLookup(t *testing.T) {
+	client := newMockClient()
+
+	an, err := NewTransactionAnalyzerFromClient(context.Background(), client, Options{AllowEmptyState: true, CacheSize: 8})
+	if err != nil {
+		t.Fatalf("NewTransactionAnalyzerFromClient() error: %v", err)
+	}
+
+	if an.GetHeader(common.Hash{}, 5) == nil {
+		t.Fatal("Expected a header")
+	}
+	if an.GetHeader(common.Hash{}, 5) == nil || client.headerCalls != 1 {
+		t.Errorf("Expected the second fetch to hit the cache, got %d RPC calls", client.headerCalls)
+	}
+
+	// Clones share the cache
+	if an.Clone().GetHeader(common.Hash{}, 5) == nil || client.headerCalls != 1 {
+		t.Errorf("Expected clones to share the cache, got %d RPC calls", client.headerCalls)
+	}
+
+	// Lookups by hash are cached separately
+	block := blockAt(6)
+	client.addBlock(block)
+	if an.GetHeaderByHash(block.Hash()) == nil || an.GetHeaderByHash(block.Hash()) == nil || client.headerCalls != 2 {
+		t.Errorf("Expected the second fetch by hash to hit the cache, got %d RPC calls", client.headerCalls)
+	}
+
+	// The latest header always reaches the node
+	if _, err := an.client.HeaderByNumber(context.Background(), nil); err != nil {
+		t.Fatalf("HeaderByNumber() error: %v", err)
+	}
+	if _, err := an.client.HeaderByNumber(context.Background(), nil); err != nil {
+		t.Fatalf("HeaderByNumber() error: %v", err)
+	}
+	if client.headerCalls != 4 {
+		t.Errorf("Expected latest header lookups to bypass the cache, got %d RPC calls", client.headerCalls)
+	}
+}
+
+func TestCachedTransactionReplay(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	contract := common.HexToAddress("0x3000")
+
+	client := newMockClient()
+	client.code[contract] = []byte{byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP), byte(vm.STOP)}
+
+	first := signedTx(t, key, 0, contract, 100000)
+	second := signedTx(t, key, 0, contract, 200000)
+	client.addBlock(blockAt(3, first, second))
+
+	an, err := NewTransactionAnalyzerFromClient(context.Background(), client, Options{AllowEmptyState: true, CacheSize: 8})
+	if err != nil {
+		t.Fatalf("NewTransactionAnalyzerFromClient() error: %v", err)
+	}
+
+	if err := an.AnalyzeTransaction(context.Background(), first.Hash()); err != nil {
+		t.Fatalf("AnalyzeTransaction() error: %v", err)
+	}
+	blocks, code := client.blockCalls, client.codeCalls
+
+	if err := an.Clone().AnalyzeTransaction(context.Background(), second.Hash()); err != nil {
+		t.Fatalf("AnalyzeTransaction() error: %v", err)
+	}
+
+	if client.blockCalls != blocks || client.codeCalls != code {
+		t.Errorf("Expected the block and code to be served from the cache, got %d block and %d code calls (was %d and %d)",
+			client.blockCalls, client.codeCalls, blocks, code)
+	}
+
+	// Without a cache every replay fetches again
+	uncached := NewTransactionAnalyzerWithClient(client)
+	if err := uncached.AnalyzeTransaction(context.Background(), second.Hash()); err != nil {
+		t.Fatalf("AnalyzeTransaction() error: %v", err)
+	}
+	if client.blockCalls != blocks+1 {
+		t.Errorf("Expected an uncached analyzer to refetch the block, got %d calls", client.blockCalls)
+	}
+}
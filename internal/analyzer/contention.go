@@ -0,0 +1,92 @@
+package analyzer
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// MinContendedTransactions is the number of transactions that must access a
+// storage slot for it to be reported as contended
+const MinContendedTransactions = 2
+
+// SlotContention is a storage slot accessed by several of the analyzed transactions
+type SlotContention struct {
+	Contract     common.Address `json:"contract"`
+	Slot         common.Hash    `json:"slot"`
+	Transactions int            `json:"transactions"` // Transactions that accessed the slot
+	Writers      int            `json:"writers"`      // Transactions that wrote the slot
+	Reads        int            `json:"reads"`
+	Writes       int            `json:"writes"`
+}
+
+// contentionKey identifies a storage slot across transactions
+type contentionKey struct {
+	contract common.Address
+	slot     common.Hash
+}
+
+// contentionTracker aggregates the storage slots accessed by each traced transaction
+type contentionTracker struct {
+	slots map[contentionKey]*SlotContention
+	txs   map[contentionKey][]int // Indexes of the transactions that accessed each slot
+}
+
+func newContentionTracker() *contentionTracker {
+	return &contentionTracker{
+		slots: make(map[contentionKey]*SlotContention),
+		txs:   make(map[contentionKey][]int),
+	}
+}
+
+// add records the slots accessed by the transaction at index tx
+func (c *contentionTracker) add(tx int, accesses []tracer.SlotAccessOrder) {
+	for _, access := range accesses {
+		key := contentionKey{contract: access.Contract, slot: access.Key}
+		slot, ok := c.slots[key]
+		if !ok {
+			slot = &SlotContention{Contract: access.Contract, Slot: access.Key}
+			c.slots[key] = slot
+		}
+		slot.Transactions++
+		if access.Writes > 0 {
+			slot.Writers++
+		}
+		slot.Reads += access.Reads
+		slot.Writes += access.Writes
+		c.txs[key] = append(c.txs[key], tx)
+	}
+}
+
+// contended returns the slots accessed by at least MinContendedTransactions
+// transactions, most contended first, and the number of transactions touching them
+func (c *contentionTracker) contended() ([]SlotContention, int) {
+	var slots []SlotContention
+	involved := make(map[int]bool)
+	for key, slot := range c.slots {
+		if slot.Transactions < MinContendedTransactions {
+			continue
+		}
+		slots = append(slots, *slot)
+		for _, tx := range c.txs[key] {
+			involved[tx] = true
+		}
+	}
+
+	sort.Slice(slots, func(i, j int) bool {
+		a, b := slots[i], slots[j]
+		if a.Transactions != b.Transactions {
+			return a.Transactions > b.Transactions
+		}
+		if a.Writers != b.Writers {
+			return a.Writers > b.Writers
+		}
+		if a.Contract != b.Contract {
+			return bytes.Compare(a.Contract[:], b.Contract[:]) < 0
+		}
+		return bytes.Compare(a.Slot[:], b.Slot[:]) < 0
+	})
+	return slots, len(involved)
+}
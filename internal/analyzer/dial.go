@@ -0,0 +1,44 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Dial connects to the node at rpcURL, sending headers with every request.
+// Over websocket the headers are sent with the handshake.
+func Dial(rpcURL string, headers http.Header) (*ethclient.Client, error) {
+	client, err := rpc.DialOptions(context.Background(), rpcURL, rpc.WithHeaders(headers))
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(client), nil
+}
+
+// RPCHeaders builds the headers sent to the node from "Key: Value" entries and
+// an optional "user:pass" credential sent as HTTP basic auth
+func RPCHeaders(entries []string, auth string) (http.Header, error) {
+	headers := make(http.Header)
+	for _, entry := range entries {
+		key, value, ok := strings.Cut(entry, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("invalid RPC header %q: expected \"Key: Value\"", entry)
+		}
+		headers.Add(key, strings.TrimSpace(value))
+	}
+
+	if auth != "" {
+		if !strings.Contains(auth, ":") {
+			return nil, fmt.Errorf("invalid RPC auth: expected user:pass")
+		}
+		headers.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(auth)))
+	}
+	return headers, nil
+}
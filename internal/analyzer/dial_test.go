@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDialSendsHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+	}))
+	defer server.Close()
+
+	headers, err := RPCHeaders([]string{"X-Api-Key: secret", "X-Team:  tracing "}, "alice:hunter2")
+	if err != nil {
+		t.Fatalf("RPCHeaders() error: %v", err)
+	}
+	client, err := Dial(server.URL, headers)
+	if err != nil {
+		t.Fatalf("Dial() error: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.ChainID(context.Background()); err != nil {
+		t.Fatalf("ChainID() error: %v", err)
+	}
+
+	if got := received.Get("X-Api-Key"); got != "secret" {
+		t.Errorf("Expected X-Api-Key header secret, got %q", got)
+	}
+	if got := received.Get("X-Team"); got != "tracing" {
+		t.Errorf("Expected X-Team header tracing, got %q", got)
+	}
+	want := "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:hunter2"))
+	if got := received.Get("Authorization"); got != want {
+		t.Errorf("Expected Authorization %q, got %q", want, got)
+	}
+}
+
+func TestRPCHeadersInvalid(t *testing.T) {
+	for _, entry := range []string{"X-Api-Key", ": value", "X Api: value"} {
+		if _, err := RPCHeaders([]string{entry}, ""); err == nil {
+			t.Errorf("Expected an error for header %q", entry)
+		}
+	}
+	if _, err := RPCHeaders(nil, "alice"); err == nil {
+		t.Errorf("Expected an error for credentials without a password")
+	}
+}
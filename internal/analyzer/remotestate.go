@@ -0,0 +1,173 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+)
+
+// errRemoteStateReadOnly is returned by the trie operations a simulation never needs
+var errRemoteStateReadOnly = errors.New("remote state is read-only")
+
+// remoteState is a state database that fetches accounts and storage slots from
+// the node as execution first touches them, at a fixed block. Writes stay in
+// the StateDB built on top of it and never reach the node.
+type remoteState struct {
+	state.Database // In-memory database backing the trie and disk accessors
+
+	ctx    context.Context
+	client Client
+	block  *big.Int               // Block the state is read at, nil for the latest
+	code   map[common.Hash][]byte // Code fetched with each account, by hash
+}
+
+// newRemoteStateDB returns a state database reading from the node at blockNumber,
+// or the latest state when blockNumber is nil. Fetch failures are recorded by the
+// StateDB and reported by its Error method.
+func (a *TransactionAnalyzer) newRemoteStateDB(ctx context.Context, blockNumber *big.Int) (*state.StateDB, error) {
+	db := &remoteState{
+		Database: state.NewDatabase(rawdb.NewMemoryDatabase()),
+		ctx:      ctx,
+		client:   a.client,
+		block:    blockNumber,
+		code:     make(map[common.Hash][]byte),
+	}
+	return state.New(types.EmptyRootHash, db, nil)
+}
+
+// OpenTrie returns the account trie, read from the node
+func (s *remoteState) OpenTrie(root common.Hash) (state.Trie, error) {
+	return &remoteTrie{state: s}, nil
+}
+
+// OpenStorageTrie returns the storage trie of an account, read from the node
+func (s *remoteState) OpenStorageTrie(stateRoot common.Hash, address common.Address, root common.Hash) (state.Trie, error) {
+	return &remoteTrie{state: s}, nil
+}
+
+// CopyTrie returns the trie itself, which holds no state of its own
+func (s *remoteState) CopyTrie(t state.Trie) state.Trie {
+	return t
+}
+
+// ContractCode returns the code fetched along with the account
+func (s *remoteState) ContractCode(addr common.Address, codeHash common.Hash) ([]byte, error) {
+	if code, ok := s.code[codeHash]; ok {
+		return code, nil
+	}
+	return nil, fmt.Errorf("code %s of %s was not fetched", codeHash.Hex(), addr.Hex())
+}
+
+// ContractCodeSize returns the size of the code fetched along with the account
+func (s *remoteState) ContractCodeSize(addr common.Address, codeHash common.Hash) (int, error) {
+	code, err := s.ContractCode(addr, codeHash)
+	return len(code), err
+}
+
+// remoteTrie serves account and storage reads of a remoteState
+type remoteTrie struct {
+	state *remoteState
+}
+
+// GetAccount fetches the balance, nonce and code of an account, returning nil
+// for an empty account
+func (t *remoteTrie) GetAccount(address common.Address) (*types.StateAccount, error) {
+	s := t.state
+	balance, err := s.client.BalanceAt(s.ctx, address, s.block)
+	if err != nil {
+		return nil, rpcTimeout(err)
+	}
+	nonce, err := s.client.NonceAt(s.ctx, address, s.block)
+	if err != nil {
+		return nil, rpcTimeout(err)
+	}
+	code, err := s.client.CodeAt(s.ctx, address, s.block)
+	if err != nil {
+		return nil, rpcTimeout(err)
+	}
+	if balance.Sign() == 0 && nonce == 0 && len(code) == 0 {
+		return nil, nil
+	}
+
+	codeHash := types.EmptyCodeHash
+	if len(code) > 0 {
+		codeHash = crypto.Keccak256Hash(code)
+		s.code[codeHash] = code
+	}
+	return &types.StateAccount{
+		Nonce:    nonce,
+		Balance:  balance,
+		Root:     types.EmptyRootHash,
+		CodeHash: codeHash.Bytes(),
+	}, nil
+}
+
+// GetStorage fetches a storage slot of an account
+func (t *remoteTrie) GetStorage(addr common.Address, key []byte) ([]byte, error) {
+	s := t.state
+	value, err := s.client.StorageAt(s.ctx, addr, common.BytesToHash(key), s.block)
+	if err != nil {
+		return nil, rpcTimeout(err)
+	}
+	return common.TrimLeftZeroes(value), nil
+}
+
+// GetKey returns nil, as the trie keeps no preimages
+func (t *remoteTrie) GetKey([]byte) []byte {
+	return nil
+}
+
+// UpdateStorage is a no-op; the StateDB keeps the simulation's writes
+func (t *remoteTrie) UpdateStorage(addr common.Address, key, value []byte) error {
+	return nil
+}
+
+// UpdateAccount is a no-op; the StateDB keeps the simulation's writes
+func (t *remoteTrie) UpdateAccount(address common.Address, account *types.StateAccount) error {
+	return nil
+}
+
+// UpdateContractCode is a no-op; the StateDB keeps the simulation's writes
+func (t *remoteTrie) UpdateContractCode(address common.Address, codeHash common.Hash, code []byte) error {
+	return nil
+}
+
+// DeleteStorage is a no-op; the StateDB keeps the simulation's writes
+func (t *remoteTrie) DeleteStorage(addr common.Address, key []byte) error {
+	return nil
+}
+
+// DeleteAccount is a no-op; the StateDB keeps the simulation's writes
+func (t *remoteTrie) DeleteAccount(address common.Address) error {
+	return nil
+}
+
+// Hash returns the empty root, as the trie holds no nodes
+func (t *remoteTrie) Hash() common.Hash {
+	return types.EmptyRootHash
+}
+
+// Commit is not supported by remote state
+func (t *remoteTrie) Commit(collectLeaf bool) (common.Hash, *trienode.NodeSet, error) {
+	return common.Hash{}, nil, errRemoteStateReadOnly
+}
+
+// NodeIterator is not supported by remote state
+func (t *remoteTrie) NodeIterator(startKey []byte) (trie.NodeIterator, error) {
+	return nil, errRemoteStateReadOnly
+}
+
+// Prove is not supported by remote state
+func (t *remoteTrie) Prove(key []byte, proofDb ethdb.KeyValueWriter) error {
+	return errRemoteStateReadOnly
+}
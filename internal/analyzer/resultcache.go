@@ -0,0 +1,93 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ResultCache stores the reports of completed transaction traces on disk, so that
+// tracing a mined transaction again loads its report instead of replaying it
+type ResultCache struct {
+	// Dir holds one file per cached trace
+	Dir string
+
+	// Version is the tool version writing the entries. Entries written by
+	// another version are ignored and replaced, since its findings may differ.
+	Version string
+
+	// Settings describes the tracer settings the reports depend on, and is part
+	// of the key so that differently configured traces are cached separately
+	Settings string
+
+	// OnStoreError, when set, is called when a trace cannot be cached. The
+	// trace itself still succeeds.
+	OnStoreError func(err error)
+}
+
+// resultEntry is the file format of a cached trace
+type resultEntry struct {
+	Version string          `json:"version"`
+	ChainID string          `json:"chain_id"`
+	TxHash  common.Hash     `json:"tx_hash"`
+	Report  json.RawMessage `json:"report"`
+}
+
+// path returns the file caching the trace of hash on the chain
+func (c *ResultCache) path(chainID *big.Int, hash common.Hash) string {
+	key := sha256.Sum256([]byte(chainID.String() + "/" + hash.Hex() + "/" + c.Settings))
+	return filepath.Join(c.Dir, hex.EncodeToString(key[:])+".json")
+}
+
+// Load returns the cached report of the trace of hash on the chain. Missing,
+// unreadable and stale entries are misses.
+func (c *ResultCache) Load(chainID *big.Int, hash common.Hash) ([]byte, bool) {
+	data, err := os.ReadFile(c.path(chainID, hash))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry resultEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	if entry.Version != c.Version || entry.ChainID != chainID.String() || entry.TxHash != hash {
+		return nil, false
+	}
+	return entry.Report, true
+}
+
+// Store caches the report of the trace of hash on the chain, replacing any
+// entry. The file is written atomically so that concurrent runs never read a
+// partial entry.
+func (c *ResultCache) Store(chainID *big.Int, hash common.Hash, report []byte) error {
+	data, err := json.Marshal(resultEntry{Version: c.Version, ChainID: chainID.String(), TxHash: hash, Report: report})
+	if err != nil {
+		return fmt.Errorf("failed to encode cached trace: %w", err)
+	}
+
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(c.Dir, "trace-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write cached trace: %w", err)
+	}
+	_, err = tmp.Write(data)
+	err = errors.Join(err, tmp.Close())
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(chainID, hash))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cached trace: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestResultCacheSkipsRPC(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	contract := common.HexToAddress("0x3000")
+
+	client := newMockClient()
+	var code []byte
+	for i := 0; i < 3; i++ {
+		code = append(code, byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP))
+	}
+	client.code[contract] = append(code, byte(vm.STOP))
+	tx := signedTx(t, key, 0, contract, 100000)
+	client.addBlock(blockAt(2, tx))
+
+	cache := &ResultCache{Dir: t.TempDir(), Version: "v1.0.0"}
+	an := NewTransactionAnalyzerWithClient(client)
+	an.opts.ResultCache = cache
+	if err := an.AnalyzeTransaction(context.Background(), tx.Hash()); err != nil {
+		t.Fatalf("AnalyzeTransaction() error: %v", err)
+	}
+	if an.FromCache() {
+		t.Error("Expected the first trace not to come from the cache")
+	}
+	traced, err := an.GetTracer().GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+
+	// The second run has a node with nothing to serve, so every lookup would fail
+	empty := newMockClient()
+	cached := NewTransactionAnalyzerWithClient(empty)
+	cached.opts.ResultCache = cache
+	if err := cached.AnalyzeTransaction(context.Background(), tx.Hash()); err != nil {
+		t.Fatalf("AnalyzeTransaction() from cache error: %v", err)
+	}
+	if !cached.FromCache() {
+		t.Error("Expected the second trace to come from the cache")
+	}
+	if calls := empty.txCalls + empty.blockCalls + empty.headerCalls + empty.codeCalls; calls != 0 {
+		t.Errorf("Expected no transaction, block or state requests on a cache hit, got %d", calls)
+	}
+	if report, _ := cached.GetTracer().GetReport(); report != traced {
+		t.Errorf("Expected the cached report to match the traced one\ntraced:\n%s\ncached:\n%s", traced, report)
+	}
+
+	// Entries written by another version are traced again
+	upgraded := NewTransactionAnalyzerWithClient(client)
+	upgraded.opts.ResultCache = &ResultCache{Dir: cache.Dir, Version: "v1.1.0"}
+	client.txCalls = 0
+	if err := upgraded.AnalyzeTransaction(context.Background(), tx.Hash()); err != nil {
+		t.Fatalf("AnalyzeTransaction() after upgrade error: %v", err)
+	}
+	if upgraded.FromCache() || client.txCalls == 0 {
+		t.Errorf("Expected a version mismatch to trace again, got %d transaction requests", client.txCalls)
+	}
+}
@@ -0,0 +1,41 @@
+package analyzer
+
+import "sort"
+
+// Distribution summarizes a set of per-transaction values, such as the gas used
+// by each traced transaction, to show outliers that totals hide
+type Distribution struct {
+	Min    uint64 `json:"min"`
+	Median uint64 `json:"median"`
+	P90    uint64 `json:"p90"`
+	P99    uint64 `json:"p99"`
+	Max    uint64 `json:"max"`
+}
+
+// newDistribution summarizes values using nearest-rank percentiles, so that each
+// percentile is one of the values. It returns nil for an empty set.
+func newDistribution(values []uint64) *Distribution {
+	if len(values) == 0 {
+		return nil
+	}
+
+	sorted := append([]uint64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return &Distribution{
+		Min:    sorted[0],
+		Median: percentile(sorted, 50),
+		P90:    percentile(sorted, 90),
+		P99:    percentile(sorted, 99),
+		Max:    sorted[len(sorted)-1],
+	}
+}
+
+// percentile returns the nearest-rank p-th percentile of the sorted values: the
+// smallest value at least p percent of the values are less than or equal to
+func percentile(sorted []uint64, p int) uint64 {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,36 @@
+package analyzer
+
+import "testing"
+
+func TestDistribution(t *testing.T) {
+	// 1000 to 100000 gas in steps of 1000, in reverse order
+	var values []uint64
+	for i := 100; i >= 1; i-- {
+		values = append(values, uint64(i)*1000)
+	}
+
+	stats := newDistribution(values)
+	expected := Distribution{Min: 1000, Median: 50000, P90: 90000, P99: 99000, Max: 100000}
+	if stats == nil || *stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+
+	if values[0] != 100000 {
+		t.Error("Expected newDistribution to leave the values unsorted")
+	}
+}
+
+func TestDistributionOutlier(t *testing.T) {
+	stats := newDistribution([]uint64{21000, 21000, 50000, 21000, 900000})
+	expected := Distribution{Min: 21000, Median: 21000, P90: 900000, P99: 900000, Max: 900000}
+	if stats == nil || *stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+
+	if single := newDistribution([]uint64{7}); single == nil || single.Min != 7 || single.Median != 7 || single.P99 != 7 {
+		t.Errorf("Expected every statistic of a single value to be that value, got %+v", single)
+	}
+	if newDistribution(nil) != nil {
+		t.Error("Expected no distribution for an empty set")
+	}
+}
@@ -0,0 +1,232 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrSubscriptionsUnsupported is returned when the endpoint cannot push new heads,
+// such as over HTTP
+var ErrSubscriptionsUnsupported = errors.New("the endpoint does not support subscriptions; use a websocket (ws://) or IPC endpoint")
+
+// DefaultWatchBackfill is the default number of missed blocks traced after a reconnection
+const DefaultWatchBackfill = 128
+
+// DefaultWatchRetryDelay is the default wait before resubscribing after the subscription drops
+const DefaultWatchRetryDelay = 5 * time.Second
+
+// HeadSubscriber pushes new chain heads, implemented by ethclient.Client over websocket
+type HeadSubscriber interface {
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
+// WatchSource is the chain access used to watch an address
+type WatchSource interface {
+	BlockSource
+	HeadSubscriber
+}
+
+// WatchOptions configures Watch
+type WatchOptions struct {
+	// Criteria filters the findings reported for each transaction
+	Criteria tracer.FilterCriteria
+
+	// Backfill bounds the missed blocks traced when heads arrive after a gap;
+	// older missed blocks are skipped. Zero uses DefaultWatchBackfill.
+	Backfill uint64
+
+	// RetryDelay is the wait between resubscription attempts; zero uses DefaultWatchRetryDelay
+	RetryDelay time.Duration
+
+	// TraceTimeout bounds the tracing of each transaction when nonzero
+	TraceTimeout time.Duration
+
+	// OnRetry, when set, is called with the error whenever the subscription drops
+	// or a block cannot be fetched, before retrying
+	OnRetry func(err error)
+
+	// OnSkip, when set, is called with the range of missed blocks skipped beyond Backfill
+	OnSkip func(from, to uint64)
+}
+
+// WatchedTransaction is a transaction to the watched address traced in a new block
+type WatchedTransaction struct {
+	Block         uint64                `json:"block"`
+	Hash          common.Hash           `json:"hash"`
+	GasUsed       uint64                `json:"gas_used"`
+	Optimizations []tracer.Optimization `json:"optimizations"`
+	Partial       string                `json:"partial,omitempty"`
+	Error         string                `json:"error,omitempty"`
+}
+
+// Watch traces every transaction sent directly to addr in blocks mined after the
+// call, passing each to emit as soon as it is traced. When the subscription drops
+// it resubscribes and backfills the blocks missed meanwhile. Blocks replaced by a
+// reorg are not traced again. Watch runs until ctx is done and returns its error.
+func (a *TransactionAnalyzer) Watch(ctx context.Context, source WatchSource, addr common.Address, opts WatchOptions, emit func(WatchedTransaction)) error {
+	if opts.Backfill == 0 {
+		opts.Backfill = DefaultWatchBackfill
+	}
+	if opts.RetryDelay == 0 {
+		opts.RetryDelay = DefaultWatchRetryDelay
+	}
+
+	head, err := source.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get latest header: %w", rpcTimeout(err))
+	}
+	w := &watcher{an: a, source: source, addr: addr, opts: opts, emit: emit, next: head.Number.Uint64() + 1}
+
+	for {
+		headers := make(chan *types.Header)
+		sub, err := w.subscribe(ctx, headers)
+		if err != nil {
+			return err
+		}
+		err = w.follow(ctx, sub, headers)
+		sub.Unsubscribe()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// watcher holds the state of a Watch call
+type watcher struct {
+	an     *TransactionAnalyzer
+	source WatchSource
+	addr   common.Address
+	opts   WatchOptions
+	emit   func(WatchedTransaction)
+	next   uint64 // First block not traced yet
+}
+
+// subscribe subscribes to new heads, retrying until it succeeds or ctx is done.
+// Endpoints without subscription support fail at once.
+func (w *watcher) subscribe(ctx context.Context, headers chan *types.Header) (ethereum.Subscription, error) {
+	for {
+		sub, err := w.source.SubscribeNewHead(ctx, headers)
+		if err == nil {
+			return sub, nil
+		}
+		if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+			return nil, fmt.Errorf("%w: %v", ErrSubscriptionsUnsupported, err)
+		}
+		if err := w.retry(ctx, fmt.Errorf("failed to subscribe to new heads: %w", err)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// follow traces the blocks announced by sub until it drops, returning nil so that
+// Watch resubscribes, or until ctx is done
+func (w *watcher) follow(ctx context.Context, sub ethereum.Subscription, headers <-chan *types.Header) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-sub.Err():
+			if err == nil {
+				err = errors.New("closed by the endpoint")
+			}
+			return w.retry(ctx, fmt.Errorf("head subscription dropped: %w", err))
+		case header := <-headers:
+			if err := w.traceUpTo(ctx, header.Number.Uint64()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// traceUpTo traces the blocks from the first untraced block up to number, skipping
+// those beyond the backfill bound. A block that cannot be fetched is retried with
+// the next head.
+func (w *watcher) traceUpTo(ctx context.Context, number uint64) error {
+	if number < w.next {
+		return nil
+	}
+	if missed := number - w.next + 1; missed > w.opts.Backfill {
+		skipTo := number - w.opts.Backfill + 1
+		if w.opts.OnSkip != nil {
+			w.opts.OnSkip(w.next, skipTo-1)
+		}
+		w.next = skipTo
+	}
+
+	for ; w.next <= number; w.next++ {
+		block, err := w.source.BlockByNumber(ctx, new(big.Int).SetUint64(w.next))
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if w.opts.OnRetry != nil {
+				w.opts.OnRetry(fmt.Errorf("failed to get block %d: %w", w.next, rpcTimeout(err)))
+			}
+			return nil
+		}
+
+		for _, tx := range block.Transactions() {
+			if to := tx.To(); to == nil || *to != w.addr {
+				continue
+			}
+			traced, err := w.trace(ctx, block.NumberU64(), tx.Hash())
+			if err != nil {
+				return err
+			}
+			w.emit(traced)
+		}
+	}
+	return nil
+}
+
+// trace traces one transaction, recording failures in the result. Only the
+// cancellation of ctx is returned as an error.
+func (w *watcher) trace(ctx context.Context, block uint64, hash common.Hash) (WatchedTransaction, error) {
+	traced := WatchedTransaction{Block: block, Hash: hash, Optimizations: []tracer.Optimization{}}
+
+	traceCtx := ctx
+	if w.opts.TraceTimeout > 0 {
+		var cancel context.CancelFunc
+		traceCtx, cancel = context.WithTimeout(ctx, w.opts.TraceTimeout)
+		defer cancel()
+	}
+
+	w.an.tracer.Reset()
+	err := w.an.AnalyzeTransaction(traceCtx, hash)
+	if ctx.Err() != nil {
+		return traced, ctx.Err()
+	}
+	if err != nil && !IsPartial(err) {
+		traced.Error = err.Error()
+		return traced, nil
+	}
+	if err != nil {
+		traced.Partial = err.Error()
+	}
+
+	traced.GasUsed = w.an.tracer.GetStats().TotalGasUsed
+	traced.Optimizations = tracer.FilterOptimizations(w.an.tracer.GetOptimizations(), w.opts.Criteria)
+	return traced, nil
+}
+
+// retry reports err and waits out the retry delay, returning early when ctx is done
+func (w *watcher) retry(ctx context.Context, err error) error {
+	if w.opts.OnRetry != nil {
+		w.opts.OnRetry(err)
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(w.opts.RetryDelay):
+		return nil
+	}
+}
@@ -0,0 +1,210 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// mockHeadSource serves blocks by number and pushes one batch of heads per subscription
+type mockHeadSource struct {
+	*mockClient
+	byNumber map[uint64]*types.Block
+	heads    [][]*types.Header // Heads pushed by each successive subscription; a nil batch drops at once
+	subs     int
+	err      error // Returned by SubscribeNewHead when set
+}
+
+func (m *mockHeadSource) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if block, ok := m.byNumber[number.Uint64()]; ok {
+		return block, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (m *mockHeadSource) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var heads []*types.Header
+	if m.subs < len(m.heads) {
+		heads = m.heads[m.subs]
+	}
+	m.subs++
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		if heads == nil {
+			return errors.New("connection reset")
+		}
+		for _, head := range heads {
+			select {
+			case ch <- head:
+			case <-quit:
+				return nil
+			}
+		}
+		<-quit
+		return nil
+	}), nil
+}
+
+// addBlock makes the block available by hash and by number
+func (m *mockHeadSource) addBlock(block *types.Block) {
+	m.mockClient.addBlock(block)
+	m.byNumber[block.NumberU64()] = block
+}
+
+// newWatchSource returns a source at head block 1 whose contract reads slot 0
+// three times, so each traced transaction has a redundant_sload finding
+func newWatchSource(contract common.Address) *mockHeadSource {
+	client := newMockClient()
+	var code []byte
+	for i := 0; i < 3; i++ {
+		code = append(code, byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP))
+	}
+	client.code[contract] = append(code, byte(vm.STOP))
+	return &mockHeadSource{mockClient: client, byNumber: make(map[uint64]*types.Block)}
+}
+
+func TestWatchTracesMatchingTransactions(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	contract := common.HexToAddress("0x3000")
+	source := newWatchSource(contract)
+
+	matching := signedTx(t, key, 0, contract, 100000)
+	other := signedTx(t, key, 1, common.HexToAddress("0x4000"), 21000)
+	block := blockAt(2, other, matching)
+	source.addBlock(block)
+	source.heads = [][]*types.Header{{block.Header()}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var traced []WatchedTransaction
+	an := NewTransactionAnalyzerWithClient(source)
+	err := an.Watch(ctx, source, contract, WatchOptions{}, func(tx WatchedTransaction) {
+		traced = append(traced, tx)
+		cancel()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected Watch to stop with context.Canceled, got %v", err)
+	}
+
+	if len(traced) != 1 {
+		t.Fatalf("Expected 1 traced transaction, got %d", len(traced))
+	}
+	tx := traced[0]
+	if tx.Hash != matching.Hash() || tx.Block != 2 || tx.Error != "" {
+		t.Errorf("Expected the matching transaction in block 2, got %+v", tx)
+	}
+	if tx.GasUsed == 0 || len(tx.Optimizations) == 0 || tx.Optimizations[0].Type != "redundant_sload" {
+		t.Errorf("Expected gas and a redundant_sload finding, got %+v", tx)
+	}
+}
+
+func TestWatchReconnectsAndBackfills(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	contract := common.HexToAddress("0x3000")
+	source := newWatchSource(contract)
+
+	// Blocks 2 and 3 are mined while the first subscription is down, and only
+	// block 4 is announced once resubscribed
+	var hashes []common.Hash
+	var latest *types.Block
+	for number := int64(2); number <= 4; number++ {
+		tx := signedTx(t, key, 0, contract, uint64(100000+number))
+		latest = blockAt(number, tx)
+		source.addBlock(latest)
+		hashes = append(hashes, tx.Hash())
+	}
+	source.heads = [][]*types.Header{nil, {latest.Header()}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	retries := 0
+	var traced []common.Hash
+	an := NewTransactionAnalyzerWithClient(source)
+	opts := WatchOptions{
+		RetryDelay: time.Millisecond,
+		Criteria:   tracer.FilterCriteria{OnlyTypes: []string{"redundant_sload"}},
+		OnRetry:    func(error) { retries++ },
+	}
+	err := an.Watch(ctx, source, contract, opts, func(tx WatchedTransaction) {
+		traced = append(traced, tx.Hash)
+		if len(traced) == len(hashes) {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected Watch to stop with context.Canceled, got %v", err)
+	}
+
+	if source.subs != 2 || retries != 1 {
+		t.Errorf("Expected one retry and 2 subscriptions, got %d retries and %d subscriptions", retries, source.subs)
+	}
+	for i, hash := range hashes {
+		if i >= len(traced) || traced[i] != hash {
+			t.Fatalf("Expected blocks 2-4 to be traced in order, got %v", traced)
+		}
+	}
+}
+
+func TestWatchBackfillBound(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	contract := common.HexToAddress("0x3000")
+	source := newWatchSource(contract)
+
+	var latest *types.Block
+	for number := int64(2); number <= 5; number++ {
+		latest = blockAt(number, signedTx(t, key, 0, contract, uint64(100000+number)))
+		source.addBlock(latest)
+	}
+	source.heads = [][]*types.Header{{latest.Header()}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var skipped [2]uint64
+	var blocks []uint64
+	an := NewTransactionAnalyzerWithClient(source)
+	opts := WatchOptions{
+		Backfill: 2,
+		OnSkip:   func(from, to uint64) { skipped = [2]uint64{from, to} },
+	}
+	an.Watch(ctx, source, contract, opts, func(tx WatchedTransaction) {
+		blocks = append(blocks, tx.Block)
+		if len(blocks) == 2 {
+			cancel()
+		}
+	})
+
+	if skipped != [2]uint64{2, 3} {
+		t.Errorf("Expected blocks 2-3 to be skipped, got %v", skipped)
+	}
+	if len(blocks) != 2 || blocks[0] != 4 || blocks[1] != 5 {
+		t.Errorf("Expected blocks 4 and 5 to be traced, got %v", blocks)
+	}
+}
+
+func TestWatchRequiresSubscriptions(t *testing.T) {
+	source := newWatchSource(common.HexToAddress("0x3000"))
+	source.err = rpc.ErrNotificationsUnsupported
+
+	an := NewTransactionAnalyzerWithClient(source)
+	err := an.Watch(context.Background(), source, common.HexToAddress("0x3000"), WatchOptions{}, func(WatchedTransaction) {})
+	if !errors.Is(err, ErrSubscriptionsUnsupported) {
+		t.Errorf("Expected ErrSubscriptionsUnsupported, got %v", err)
+	}
+}
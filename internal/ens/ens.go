@@ -0,0 +1,162 @@
+package ens
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MainnetChainID is the only chain on which names are resolved
+const MainnetChainID = 1
+
+// RegistryAddress is the ENS registry deployed on mainnet
+var RegistryAddress = common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+
+// Function selectors of the registry and resolver methods used for lookups
+var (
+	resolverSelector = []byte{0x01, 0x78, 0xb8, 0xbf} // resolver(bytes32)
+	nameSelector     = []byte{0x69, 0x1f, 0x34, 0x31} // name(bytes32)
+	addrSelector     = []byte{0x3b, 0x3b, 0x57, 0xde} // addr(bytes32)
+)
+
+// lookupTimeout bounds the calls made to resolve a single address
+const lookupTimeout = 5 * time.Second
+
+// Caller executes read-only contract calls against the latest state
+type Caller interface {
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// Resolver reverse-resolves addresses through the ENS registry. A name is only
+// returned when its forward record points back at the address. Results,
+// including addresses without a name, are cached.
+type Resolver struct {
+	caller Caller
+
+	mu    sync.Mutex
+	names map[common.Address]string
+}
+
+// NewResolver creates a resolver making its calls through caller
+func NewResolver(caller Caller) *Resolver {
+	return &Resolver{caller: caller, names: make(map[common.Address]string)}
+}
+
+// ReverseResolve returns the primary ENS name of addr. Failed lookups fall
+// back to no name, and lookups cut short by ctx are not cached.
+func (r *Resolver) ReverseResolve(ctx context.Context, addr common.Address) (string, bool) {
+	r.mu.Lock()
+	name, ok := r.names[addr]
+	r.mu.Unlock()
+	if ok {
+		return name, name != ""
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+
+	name, err := r.lookup(lookupCtx, addr)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", false
+		}
+		name = ""
+	}
+
+	r.mu.Lock()
+	r.names[addr] = name
+	r.mu.Unlock()
+	return name, name != ""
+}
+
+// lookup reads the reverse record of addr and verifies it against the forward record
+func (r *Resolver) lookup(ctx context.Context, addr common.Address) (string, error) {
+	node := Namehash(strings.ToLower(addr.Hex()[2:]) + ".addr.reverse")
+	resolver, err := r.resolver(ctx, node)
+	if err != nil || resolver == (common.Address{}) {
+		return "", err
+	}
+
+	out, err := r.call(ctx, resolver, nameSelector, node)
+	if err != nil {
+		return "", err
+	}
+	name, err := decodeString(out)
+	if err != nil || name == "" {
+		return "", err
+	}
+
+	// Anyone can claim any name in their reverse record, so only trust it when
+	// the name resolves back to the address
+	forward := Namehash(name)
+	resolver, err = r.resolver(ctx, forward)
+	if err != nil || resolver == (common.Address{}) {
+		return "", err
+	}
+	out, err = r.call(ctx, resolver, addrSelector, forward)
+	if err != nil {
+		return "", err
+	}
+	if len(out) < 32 || common.BytesToAddress(out[:32]) != addr {
+		return "", nil
+	}
+	return name, nil
+}
+
+// resolver returns the resolver the registry sets for node, zero when unset
+func (r *Resolver) resolver(ctx context.Context, node common.Hash) (common.Address, error) {
+	out, err := r.call(ctx, RegistryAddress, resolverSelector, node)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(out) < 32 {
+		return common.Address{}, nil
+	}
+	return common.BytesToAddress(out[:32]), nil
+}
+
+// call invokes a single-argument bytes32 method of contract
+func (r *Resolver) call(ctx context.Context, contract common.Address, selector []byte, node common.Hash) ([]byte, error) {
+	data := append(append([]byte{}, selector...), node.Bytes()...)
+	out, err := r.caller.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ENS call to %s failed: %w", contract.Hex(), err)
+	}
+	return out, nil
+}
+
+// decodeString decodes an ABI-encoded string return value
+func decodeString(out []byte) (string, error) {
+	stringType, err := abi.NewType("string", "", nil)
+	if err != nil {
+		return "", err
+	}
+	values, err := abi.Arguments{{Type: stringType}}.Unpack(out)
+	if err != nil {
+		return "", fmt.Errorf("invalid ENS name: %w", err)
+	}
+	name, _ := values[0].(string)
+	return name, nil
+}
+
+// Namehash returns the EIP-137 namehash of an ENS name
+func Namehash(name string) common.Hash {
+	var node common.Hash
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := crypto.Keccak256([]byte(labels[i]))
+		node = crypto.Keccak256Hash(node.Bytes(), label)
+	}
+	return node
+}
@@ -0,0 +1,139 @@
+package ens
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	vitalik      = common.HexToAddress("0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045")
+	resolverAddr = common.HexToAddress("0x4976fb03C32e5B8cfe2b6cCB31c09Ba78EBaBa41")
+)
+
+// mockCaller answers calls from fixed return data keyed by target and calldata
+type mockCaller struct {
+	responses map[string][]byte
+	err       error
+	calls     int
+}
+
+func (m *mockCaller) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	m.calls++
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.responses[callKey(*msg.To, msg.Data)], nil
+}
+
+func callKey(to common.Address, data []byte) string {
+	return to.Hex() + common.Bytes2Hex(data)
+}
+
+// set registers the return data of a bytes32 method call
+func (m *mockCaller) set(to common.Address, selector []byte, node common.Hash, out []byte) {
+	m.responses[callKey(to, append(append([]byte{}, selector...), node.Bytes()...))] = out
+}
+
+// newMockRegistry returns a caller where addr has name as its reverse record and
+// forwardAddr as the forward record of name
+func newMockRegistry(t *testing.T, addr common.Address, name string, forwardAddr common.Address) *mockCaller {
+	t.Helper()
+
+	stringType, _ := abi.NewType("string", "", nil)
+	encodedName, err := abi.Arguments{{Type: stringType}}.Pack(name)
+	if err != nil {
+		t.Fatalf("failed to encode name: %v", err)
+	}
+
+	m := &mockCaller{responses: make(map[string][]byte)}
+	reverse := Namehash(strings.ToLower(addr.Hex()[2:]) + ".addr.reverse")
+	forward := Namehash(name)
+	m.set(RegistryAddress, resolverSelector, reverse, common.LeftPadBytes(resolverAddr.Bytes(), 32))
+	m.set(resolverAddr, nameSelector, reverse, encodedName)
+	m.set(RegistryAddress, resolverSelector, forward, common.LeftPadBytes(resolverAddr.Bytes(), 32))
+	m.set(resolverAddr, addrSelector, forward, common.LeftPadBytes(forwardAddr.Bytes(), 32))
+	return m
+}
+
+func TestNamehash(t *testing.T) {
+	tests := map[string]string{
+		"":        "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"eth":     "0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae",
+		"foo.eth": "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f",
+	}
+	for name, want := range tests {
+		if got := Namehash(name).Hex(); got != want {
+			t.Errorf("Expected namehash %s for %q, got %s", want, name, got)
+		}
+	}
+}
+
+func TestReverseResolve(t *testing.T) {
+	caller := newMockRegistry(t, vitalik, "vitalik.eth", vitalik)
+	resolver := NewResolver(caller)
+
+	name, ok := resolver.ReverseResolve(context.Background(), vitalik)
+	if !ok || name != "vitalik.eth" {
+		t.Fatalf("Expected vitalik.eth, got %q (ok=%v)", name, ok)
+	}
+
+	// Repeated lookups are served from the cache
+	calls := caller.calls
+	resolver.ReverseResolve(context.Background(), vitalik)
+	if caller.calls != calls {
+		t.Errorf("Expected a cached lookup, got %d more calls", caller.calls-calls)
+	}
+
+	// Addresses without a reverse record have no name, and are cached too
+	other := common.HexToAddress("0x1234")
+	if name, ok := resolver.ReverseResolve(context.Background(), other); ok {
+		t.Errorf("Expected no name for an unregistered address, got %q", name)
+	}
+	calls = caller.calls
+	resolver.ReverseResolve(context.Background(), other)
+	if caller.calls != calls {
+		t.Errorf("Expected a cached miss, got %d more calls", caller.calls-calls)
+	}
+}
+
+func TestReverseResolveRequiresForwardMatch(t *testing.T) {
+	// The reverse record claims a name whose forward record points elsewhere
+	caller := newMockRegistry(t, vitalik, "vitalik.eth", common.HexToAddress("0xbad"))
+
+	if name, ok := NewResolver(caller).ReverseResolve(context.Background(), vitalik); ok {
+		t.Errorf("Expected an unverified name to be ignored, got %q", name)
+	}
+}
+
+func TestReverseResolveCancelled(t *testing.T) {
+	resolver := NewResolver(newMockRegistry(t, vitalik, "vitalik.eth", vitalik))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if name, ok := resolver.ReverseResolve(ctx, vitalik); ok {
+		t.Errorf("Expected no name once the context is done, got %q", name)
+	}
+
+	// A lookup cut short is not cached as a miss
+	if name, ok := resolver.ReverseResolve(context.Background(), vitalik); !ok || name != "vitalik.eth" {
+		t.Errorf("Expected vitalik.eth after a cancelled lookup, got %q (ok=%v)", name, ok)
+	}
+}
+
+func TestReverseResolveFallsBackOnError(t *testing.T) {
+	caller := &mockCaller{err: errors.New("connection refused")}
+
+	if name, ok := NewResolver(caller).ReverseResolve(context.Background(), vitalik); ok {
+		t.Errorf("Expected no name when lookups fail, got %q", name)
+	}
+}
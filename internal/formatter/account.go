@@ -0,0 +1,95 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devlongs/evm-tracer/internal/analyzer"
+)
+
+// FormatAccountReport formats the aggregate findings of an account's transactions
+func FormatAccountReport(report *analyzer.AccountReport, theme Theme) string {
+	var sb strings.Builder
+
+	sb.WriteString("\n")
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n"))
+	sb.WriteString(theme.Header.Sprint("           EVM TRACER - ACCOUNT GAS REPORT\n"))
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n\n"))
+
+	sb.WriteString(theme.Info.Sprintf("👤 Account: %s\n", report.Address.Hex()))
+	sb.WriteString(theme.Info.Sprintf("📊 Transactions Traced: %d", len(report.Transactions)-report.FailedTraces))
+	if report.FailedTraces > 0 {
+		sb.WriteString(theme.High.Sprintf(" (%d failed)", report.FailedTraces))
+	}
+	sb.WriteString("\n")
+	if report.Partial != "" {
+		sb.WriteString(theme.Medium.Sprintf("⚠️  Partial results: %s\n", report.Partial))
+	}
+	sb.WriteString(theme.Info.Sprintf("⛽ Total Gas Used: %s\n\n", formatGas(report.TotalGasUsed, theme.Numbers, theme.Unit)))
+
+	if gas, counts := report.GasDistribution, report.OptimizationDistribution; gas != nil && counts != nil {
+		sb.WriteString(theme.Header.Sprint("📈 PER-TRANSACTION DISTRIBUTION\n"))
+		sb.WriteString(fmt.Sprintf("%-8s %10s %10s %10s %10s %10s\n", "", "MIN", "MEDIAN", "P90", "P99", "MAX"))
+		sb.WriteString(strings.Repeat("─", 63) + "\n")
+		gasValue := func(gas uint64) string { return formatGas(gas, theme.Numbers, theme.Unit) }
+		sb.WriteString(fmt.Sprintf("%-8s %10s %10s %10s %10s %10s\n", "Gas",
+			gasValue(gas.Min), gasValue(gas.Median), gasValue(gas.P90), gasValue(gas.P99), gasValue(gas.Max)))
+		sb.WriteString(fmt.Sprintf("%-8s %10d %10d %10d %10d %10d\n", "Findings",
+			counts.Min, counts.Median, counts.P90, counts.P99, counts.Max))
+		sb.WriteString("\n")
+	}
+
+	if len(report.Findings) == 0 {
+		sb.WriteString(theme.Success.Sprint("✨ No optimization opportunities found in these transactions!\n\n"))
+	} else {
+		sb.WriteString(theme.Header.Sprint("🔁 MOST FREQUENT OPTIMIZATIONS\n"))
+		sb.WriteString(fmt.Sprintf("%-28s %-8s %5s %7s %12s\n", "TYPE", "SEVERITY", "TXS", "COUNT", "SAVINGS"))
+		sb.WriteString(strings.Repeat("─", 63) + "\n")
+		for _, finding := range report.Findings {
+			sb.WriteString(theme.severity(finding.Severity).Sprintf("%-28s %-8s %5d %7d %12s\n",
+				finding.Type,
+				strings.ToUpper(finding.Severity),
+				finding.Transactions,
+				finding.Occurrences,
+				formatGas(finding.GasSavings, theme.Numbers, theme.Unit)))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(report.Contention) > 0 {
+		sb.WriteString(theme.Header.Sprint("🔥 STORAGE CONTENTION\n"))
+		sb.WriteString(theme.Info.Sprint("Slots accessed by several transactions - consider batching or reordering them\n"))
+		sb.WriteString(strings.Repeat("─", 63) + "\n")
+		for _, slot := range report.Contention {
+			sb.WriteString(fmt.Sprintf("%s slot %s\n   %d txs (%d writing), %d reads, %d writes\n",
+				slot.Contract.Hex(), slot.Slot.Hex(), slot.Transactions, slot.Writers, slot.Reads, slot.Writes))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(theme.Header.Sprint("🧾 TRANSACTIONS\n"))
+	sb.WriteString(strings.Repeat("─", 63) + "\n")
+	for _, tx := range report.Transactions {
+		if tx.Error != "" {
+			sb.WriteString(theme.High.Sprintf("%s  failed: %s\n", tx.Hash.Hex(), tx.Error))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%s  %s gas, %d findings, %s savings",
+			tx.Hash.Hex(), formatGas(tx.GasUsed, theme.Numbers, theme.Unit), tx.Optimizations, formatGas(tx.GasSavings, theme.Numbers, theme.Unit)))
+		if tx.Truncated {
+			sb.WriteString(theme.Medium.Sprint(" (truncated by --max-steps)"))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+
+	if report.TotalSavings > 0 {
+		sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n"))
+		sb.WriteString(theme.Success.Sprintf("💰 Total Potential Savings: %s (~%.2f%%)\n",
+			formatGas(report.TotalSavings, theme.Numbers, theme.Unit),
+			float64(report.TotalSavings)/float64(report.TotalGasUsed)*100))
+		sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n\n"))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,42 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+)
+
+// FormatExplanation formats the catalog entry of an optimization type
+func FormatExplanation(explanation tracer.Explanation, theme Theme) string {
+	var sb strings.Builder
+
+	sb.WriteString(theme.Header.Sprint(explanation.Type) + "\n")
+	sb.WriteString("   " + explanation.Summary + "\n")
+	sb.WriteString(theme.Info.Sprint("   Why it matters: ") + explanation.Why + "\n")
+	sb.WriteString(theme.Info.Sprint("   Gas cost: ") + explanation.GasCost + "\n")
+	sb.WriteString(theme.Info.Sprint("   Example: ") + explanation.Example + "\n")
+	return sb.String()
+}
+
+// FormatExplanations formats the explanations of the optimization types found,
+// once per type in order of first appearance
+func FormatExplanations(optimizations []tracer.Optimization, theme Theme) string {
+	var sb strings.Builder
+	seen := make(map[string]bool)
+	for _, opt := range optimizations {
+		if seen[opt.Type] {
+			continue
+		}
+		seen[opt.Type] = true
+
+		explanation, err := tracer.Explain(opt.Type)
+		if err != nil {
+			continue
+		}
+		if sb.Len() == 0 {
+			sb.WriteString(theme.Header.Sprint("📚 EXPLANATIONS:\n\n"))
+		}
+		sb.WriteString(FormatExplanation(explanation, theme) + "\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,60 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+)
+
+// FormatFlamegraph formats the call tree's gas usage as folded stacks
+// ("frame1;frame2;frame3 gas" per line) for flamegraph.pl or inferno. Each line
+// carries the gas a frame used itself, so the lines sum to the root frame's gas.
+func FormatFlamegraph(root *tracer.CallNode) string {
+	if root == nil {
+		return ""
+	}
+
+	var order []string
+	gas := make(map[string]uint64)
+	foldCallNode(root, "", &order, gas)
+
+	var sb strings.Builder
+	for _, stack := range order {
+		sb.WriteString(fmt.Sprintf("%s %d\n", stack, gas[stack]))
+	}
+	return sb.String()
+}
+
+// foldCallNode adds the self gas of node and its descendants under prefix,
+// merging identical stacks in order of first appearance
+func foldCallNode(node *tracer.CallNode, prefix string, order *[]string, gas map[string]uint64) {
+	stack := flameLabel(node)
+	if prefix != "" {
+		stack = prefix + ";" + stack
+	}
+
+	if self := node.SelfGas(); self > 0 {
+		if _, ok := gas[stack]; !ok {
+			*order = append(*order, stack)
+		}
+		gas[stack] += self
+	}
+
+	for _, child := range node.Children {
+		foldCallNode(child, stack, order, gas)
+	}
+}
+
+// flameLabel names a frame by its contract and resolved function. Separators
+// used by the folded format are replaced.
+func flameLabel(node *tracer.CallNode) string {
+	label := node.To.Hex()
+	if node.Signature != "" {
+		label += ":" + node.Signature
+	}
+	if node.Type != "CALL" {
+		label = strings.ToLower(node.Type) + ":" + label
+	}
+	return strings.NewReplacer(";", ",", " ", "_").Replace(label)
+}
@@ -0,0 +1,61 @@
+package formatter
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestFormatFlamegraph(t *testing.T) {
+	token := common.HexToAddress("0x00000000000000000000000000000000000000b2")
+	root := &tracer.CallNode{
+		Type:    "CALL",
+		To:      common.HexToAddress("0x00000000000000000000000000000000000000b1"),
+		GasUsed: 52000,
+		Children: []*tracer.CallNode{
+			{
+				Type:      "CALL",
+				To:        token,
+				Signature: "transfer(address,uint256)",
+				GasUsed:   9000,
+				Children: []*tracer.CallNode{
+					{Type: "DELEGATECALL", To: common.HexToAddress("0x00000000000000000000000000000000000000b3"), GasUsed: 3000},
+				},
+			},
+			// A second call to the same function merges into the same stack
+			{Type: "CALL", To: token, Signature: "transfer(address,uint256)", GasUsed: 1000},
+			{Type: "STATICCALL", To: common.HexToAddress("0x00000000000000000000000000000000000000b4"), GasUsed: 700},
+		},
+	}
+
+	output := FormatFlamegraph(root)
+	assertGolden(t, "flamegraph", output)
+
+	total := uint64(0)
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		space := strings.LastIndex(line, " ")
+		if space <= 0 {
+			t.Fatalf("Expected 'stack gas', got '%s'", line)
+		}
+		stack, count := line[:space], line[space+1:]
+		if strings.Contains(stack, " ") {
+			t.Errorf("Expected no spaces in the stack, got '%s'", stack)
+		}
+		gas, err := strconv.ParseUint(count, 10, 64)
+		if err != nil || gas == 0 {
+			t.Errorf("Expected a positive gas count, got '%s'", count)
+		}
+		total += gas
+	}
+
+	if total != root.GasUsed {
+		t.Errorf("Expected the folded stacks to sum to %d, got %d", root.GasUsed, total)
+	}
+
+	if FormatFlamegraph(nil) != "" {
+		t.Error("Expected no output without a call tree")
+	}
+}
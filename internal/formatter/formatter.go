@@ -3,135 +3,194 @@ package formatter
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/devlongs/evm-tracer/internal/tracer"
-	"github.com/fatih/color"
+	"github.com/ethereum/go-ethereum/common"
 )
 
-var (
-	highSeverity   = color.New(color.FgRed, color.Bold)
-	mediumSeverity = color.New(color.FgYellow, color.Bold)
-	lowSeverity    = color.New(color.FgCyan)
-	successColor   = color.New(color.FgGreen, color.Bold)
-	headerColor    = color.New(color.FgMagenta, color.Bold)
-	infoColor      = color.New(color.FgWhite)
-)
+func init() {
+	RegisterSection(Section{
+		Name:       "opt",
+		ReportKeys: []string{"optimizations", "total_gas_savings", "heuristic_savings", "savings_capped"},
+		Lead:       true,
+		Console: func(tr *tracer.GasOptimizationTracer, ctx SectionContext) string {
+			return FormatOptimizations(tr.GetOptimizations(), tr.TotalGasUsed, ctx.Theme)
+		},
+	})
+	RegisterSection(Section{
+		Name:       "internal",
+		ReportKeys: []string{"internal_transactions"},
+		Console: func(tr *tracer.GasOptimizationTracer, ctx SectionContext) string {
+			if !tr.InternalTxsEnabled() {
+				return ""
+			}
+			return FormatInternalTxs(tr.GetInternalTxs(), ctx.Theme)
+		},
+	})
+	RegisterSection(Section{
+		Name:       "gas",
+		ReportKeys: []string{"gas_by_opcode", "gas_by_category", "opcode_counts"},
+		Console: func(tr *tracer.GasOptimizationTracer, ctx SectionContext) string {
+			// The breakdown is all a minimal trace collects, so it is shown without -v
+			if !ctx.Verbose && !ctx.Selected && !ctx.Minimal {
+				return ""
+			}
+			out := FormatGasBreakdown(tr.GasPerOpcode, tr.TotalGasUsed, ctx.Theme) +
+				FormatGasByCategory(tr.GasPerOpcode, tr.TotalGasUsed, ctx.Theme)
+			if ctx.Verbose || ctx.Selected {
+				out += FormatOpcodeHistogram(tr.OpcodeCounts, tr.GasPerOpcode, ctx.Theme)
+			}
+			return out
+		},
+	})
+	RegisterSection(Section{
+		Name:       "calls",
+		ReportKeys: []string{"calls", "call_tree", "precompiles"},
+		Console: func(tr *tracer.GasOptimizationTracer, ctx SectionContext) string {
+			if !ctx.Verbose && !ctx.Selected {
+				return ""
+			}
+			return FormatCallTree(tr.GetCallTree(), ctx.Theme)
+		},
+	})
+}
 
 // FormatOptimizations formats optimization results for console output
-func FormatOptimizations(optimizations []tracer.Optimization, totalGas uint64) string {
+func FormatOptimizations(optimizations []tracer.Optimization, totalGas uint64, theme Theme) string {
 	var sb strings.Builder
 
 	// Header
 	sb.WriteString("\n")
-	sb.WriteString(headerColor.Sprint("═══════════════════════════════════════════════════════════════\n"))
-	sb.WriteString(headerColor.Sprint("           EVM TRACER - GAS OPTIMIZATION REPORT\n"))
-	sb.WriteString(headerColor.Sprint("═══════════════════════════════════════════════════════════════\n\n"))
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n"))
+	sb.WriteString(theme.Header.Sprint("           EVM TRACER - GAS OPTIMIZATION REPORT\n"))
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n\n"))
 
 	// Summary
-	sb.WriteString(infoColor.Sprintf("📊 Total Gas Used: %s\n", formatGas(totalGas)))
-	sb.WriteString(infoColor.Sprintf("🔍 Optimizations Found: %d\n\n", len(optimizations)))
+	sb.WriteString(theme.Info.Sprintf("📊 Total Gas Used: %s\n", formatGas(totalGas, theme.Numbers, theme.Unit)))
+	sb.WriteString(theme.Info.Sprintf("🔍 Optimizations Found: %d\n\n", len(optimizations)))
 
 	if len(optimizations) == 0 {
-		sb.WriteString(successColor.Sprint("✨ No obvious optimization opportunities found!\n"))
-		sb.WriteString(successColor.Sprint("   Your transaction appears to be well-optimized.\n\n"))
+		sb.WriteString(theme.Success.Sprint("✨ No obvious optimization opportunities found!\n"))
+		sb.WriteString(theme.Success.Sprint("   Your transaction appears to be well-optimized.\n\n"))
 		return sb.String()
 	}
 
 	// Group by severity
-	high := []tracer.Optimization{}
-	medium := []tracer.Optimization{}
-	low := []tracer.Optimization{}
-
-	for _, opt := range optimizations {
-		switch opt.Severity {
-		case "high":
-			high = append(high, opt)
-		case "medium":
-			medium = append(medium, opt)
-		case "low":
-			low = append(low, opt)
-		}
-	}
+	high, medium, low := groupBySeverity(optimizations)
 
 	// Display by severity
 	if len(high) > 0 {
-		sb.WriteString(highSeverity.Sprint("🚨 HIGH PRIORITY OPTIMIZATIONS\n"))
+		sb.WriteString(theme.High.Sprint("🚨 HIGH PRIORITY OPTIMIZATIONS\n"))
 		sb.WriteString(strings.Repeat("─", 63) + "\n")
 		for i, opt := range high {
-			sb.WriteString(formatOptimization(opt, i+1, "high"))
+			sb.WriteString(formatOptimization(opt, i+1, "high", theme))
 		}
 		sb.WriteString("\n")
 	}
 
 	if len(medium) > 0 {
-		sb.WriteString(mediumSeverity.Sprint("⚠️  MEDIUM PRIORITY OPTIMIZATIONS\n"))
+		sb.WriteString(theme.Medium.Sprint("⚠️  MEDIUM PRIORITY OPTIMIZATIONS\n"))
 		sb.WriteString(strings.Repeat("─", 63) + "\n")
 		for i, opt := range medium {
-			sb.WriteString(formatOptimization(opt, i+1, "medium"))
+			sb.WriteString(formatOptimization(opt, i+1, "medium", theme))
 		}
 		sb.WriteString("\n")
 	}
 
 	if len(low) > 0 {
-		sb.WriteString(lowSeverity.Sprint("ℹ️  LOW PRIORITY OPTIMIZATIONS\n"))
+		sb.WriteString(theme.Low.Sprint("ℹ️  LOW PRIORITY OPTIMIZATIONS\n"))
 		sb.WriteString(strings.Repeat("─", 63) + "\n")
 		for i, opt := range low {
-			sb.WriteString(formatOptimization(opt, i+1, "low"))
+			sb.WriteString(formatOptimization(opt, i+1, "low", theme))
 		}
 		sb.WriteString("\n")
 	}
 
-	// Calculate total potential savings
-	totalSavings := uint64(0)
-	for _, opt := range optimizations {
-		totalSavings += opt.GasSavings
-	}
+	// Calculate total potential savings, keeping heuristic estimates out of the total
+	totalSavings, heuristicSavings := tracer.SavingsTotals(optimizations)
+	totalSavings, capped := tracer.CapSavings(totalSavings, totalGas)
 
-	if totalSavings > 0 {
-		sb.WriteString(headerColor.Sprint("═══════════════════════════════════════════════════════════════\n"))
-		sb.WriteString(successColor.Sprintf("💰 Total Potential Savings: %s (~%.2f%%)\n",
-			formatGas(totalSavings),
-			float64(totalSavings)/float64(totalGas)*100))
-		sb.WriteString(headerColor.Sprint("═══════════════════════════════════════════════════════════════\n\n"))
+	if totalSavings > 0 || heuristicSavings > 0 {
+		sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n"))
+		if capped {
+			sb.WriteString(theme.Medium.Sprint("⚠️  The estimated savings exceed the gas used; the total is capped at the gas used\n"))
+		}
+		if totalSavings > 0 {
+			sb.WriteString(theme.Success.Sprintf("💰 Total Potential Savings: %s (~%.2f%%)\n",
+				formatGas(totalSavings, theme.Numbers, theme.Unit),
+				float64(totalSavings)/float64(totalGas)*100))
+		}
+		if heuristicSavings > 0 {
+			sb.WriteString(theme.Info.Sprintf("🔮 Heuristic Savings (not in total): %s\n", formatGas(heuristicSavings, theme.Numbers, theme.Unit)))
+		}
+		sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n\n"))
 	}
 
 	return sb.String()
 }
 
-func formatOptimization(opt tracer.Optimization, index int, severity string) string {
-	var sb strings.Builder
-	var severityColor *color.Color
+// groupBySeverity splits optimizations into high, medium and low severity groups
+func groupBySeverity(optimizations []tracer.Optimization) (high, medium, low []tracer.Optimization) {
+	for _, opt := range optimizations {
+		switch opt.Severity {
+		case "high":
+			high = append(high, opt)
+		case "medium":
+			medium = append(medium, opt)
+		case "low":
+			low = append(low, opt)
+		}
+	}
+	return high, medium, low
+}
 
-	switch severity {
-	case "high":
-		severityColor = highSeverity
-	case "medium":
-		severityColor = mediumSeverity
-	case "low":
-		severityColor = lowSeverity
+// confidenceLabel returns the suffix describing how reliable an optimization's savings are
+func confidenceLabel(opt tracer.Optimization) string {
+	if opt.Confidence == "" {
+		return ""
 	}
+	return fmt.Sprintf(" (%s)", opt.Confidence)
+}
+
+// sortedDetailKeys returns the detail keys of an optimization in sorted order
+func sortedDetailKeys(details map[string]interface{}) []string {
+	keys := make([]string, 0, len(details))
+	for k := range details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatOptimization(opt tracer.Optimization, index int, severity string, theme Theme) string {
+	var sb strings.Builder
+	severityColor := theme.severity(severity)
 
 	sb.WriteString(severityColor.Sprintf("\n%d. %s\n", index, opt.Type))
 	sb.WriteString(fmt.Sprintf("   Description: %s\n", opt.Description))
 	sb.WriteString(fmt.Sprintf("   Location: %s\n", opt.Location))
 
 	if opt.GasSavings > 0 {
-		sb.WriteString(fmt.Sprintf("   💰 Potential Savings: %s\n", formatGas(opt.GasSavings)))
+		sb.WriteString(fmt.Sprintf("   💰 Potential Savings: %s%s\n", formatGas(opt.GasSavings, theme.Numbers, theme.Unit), confidenceLabel(opt)))
 	}
 
 	if len(opt.Details) > 0 {
 		sb.WriteString("   Details:\n")
 
 		// Sort keys for consistent output
-		keys := make([]string, 0, len(opt.Details))
-		for k := range opt.Details {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
-
-		for _, key := range keys {
+		for _, key := range sortedDetailKeys(opt.Details) {
 			value := opt.Details[key]
+
+			// Multi-line values such as disassembly go on their own indented lines
+			if s, ok := value.(string); ok && strings.Contains(s, "\n") {
+				sb.WriteString(fmt.Sprintf("     • %s:\n", key))
+				for _, line := range strings.Split(s, "\n") {
+					sb.WriteString("         " + line + "\n")
+				}
+				continue
+			}
 			sb.WriteString(fmt.Sprintf("     • %s: %v\n", key, value))
 		}
 	}
@@ -140,28 +199,16 @@ func formatOptimization(opt tracer.Optimization, index int, severity string) str
 }
 
 // FormatGasBreakdown formats gas usage by opcode
-func FormatGasBreakdown(gasPerOpcode map[string]uint64, totalGas uint64) string {
+func FormatGasBreakdown(gasPerOpcode map[string]uint64, totalGas uint64, theme Theme) string {
 	var sb strings.Builder
 
 	sb.WriteString("\n")
-	sb.WriteString(headerColor.Sprint("═══════════════════════════════════════════════════════════════\n"))
-	sb.WriteString(headerColor.Sprint("                    GAS USAGE BREAKDOWN\n"))
-	sb.WriteString(headerColor.Sprint("═══════════════════════════════════════════════════════════════\n\n"))
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n"))
+	sb.WriteString(theme.Header.Sprint("                    GAS USAGE BREAKDOWN\n"))
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n\n"))
 
 	// Sort opcodes by gas usage
-	type opcodeGas struct {
-		opcode string
-		gas    uint64
-	}
-
-	opcodes := make([]opcodeGas, 0, len(gasPerOpcode))
-	for op, gas := range gasPerOpcode {
-		opcodes = append(opcodes, opcodeGas{op, gas})
-	}
-
-	sort.Slice(opcodes, func(i, j int) bool {
-		return opcodes[i].gas > opcodes[j].gas
-	})
+	opcodes := sortOpcodesByGas(gasPerOpcode)
 
 	// Show top 10 gas consumers
 	limit := 10
@@ -176,16 +223,52 @@ func FormatGasBreakdown(gasPerOpcode map[string]uint64, totalGas uint64) string
 		op := opcodes[i]
 		percentage := float64(op.gas) / float64(totalGas) * 100
 
-		colorFunc := infoColor
+		colorFunc := theme.Info
 		if percentage > 20 {
-			colorFunc = highSeverity
+			colorFunc = theme.High
 		} else if percentage > 10 {
-			colorFunc = mediumSeverity
+			colorFunc = theme.Medium
 		}
 
 		sb.WriteString(colorFunc.Sprintf("%-20s %15s %9.2f%%\n",
 			op.opcode,
-			formatGas(op.gas),
+			formatGas(op.gas, theme.Numbers, theme.Unit),
+			percentage))
+	}
+
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// FormatGasByCategory formats gas rolled up into opcode categories, largest first
+func FormatGasByCategory(gasPerOpcode map[string]uint64, totalGas uint64, theme Theme) string {
+	var sb strings.Builder
+
+	sb.WriteString("\n")
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n"))
+	sb.WriteString(theme.Header.Sprint("                    GAS BY OPCODE CATEGORY\n"))
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n\n"))
+
+	sb.WriteString(fmt.Sprintf("%-20s %15s %10s\n", "CATEGORY", "GAS USED", "% OF TOTAL"))
+	sb.WriteString(strings.Repeat("─", 63) + "\n")
+
+	// Categories sort like opcodes, by descending gas
+	for _, category := range sortOpcodesByGas(tracer.GasByCategory(gasPerOpcode)) {
+		percentage := 0.0
+		if totalGas > 0 {
+			percentage = float64(category.gas) / float64(totalGas) * 100
+		}
+
+		colorFunc := theme.Info
+		if percentage > 50 {
+			colorFunc = theme.High
+		} else if percentage > 25 {
+			colorFunc = theme.Medium
+		}
+
+		sb.WriteString(colorFunc.Sprintf("%-20s %15s %9.2f%%\n",
+			category.opcode,
+			formatGas(category.gas, theme.Numbers, theme.Unit),
 			percentage))
 	}
 
@@ -193,13 +276,291 @@ func FormatGasBreakdown(gasPerOpcode map[string]uint64, totalGas uint64) string
 	return sb.String()
 }
 
-func formatGas(gas uint64) string {
-	if gas >= 1000000 {
-		return fmt.Sprintf("%.2fM", float64(gas)/1000000)
-	} else if gas >= 1000 {
-		return fmt.Sprintf("%.2fK", float64(gas)/1000)
+// FormatWhatIf formats the projected gas under a baseline schedule next to the current gas
+func FormatWhatIf(whatIf tracer.WhatIf, theme Theme) string {
+	var sb strings.Builder
+
+	sb.WriteString("\n")
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n"))
+	sb.WriteString(theme.Header.Sprint("              WHAT-IF: BASELINE GAS SCHEDULE\n"))
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n\n"))
+
+	sb.WriteString(fmt.Sprintf("%-12s %10s %15s %15s %8s\n", "OPCODE", "COUNT", "CURRENT", "BASELINE", "CHANGE"))
+	sb.WriteString(strings.Repeat("─", 63) + "\n")
+
+	for _, c := range whatIf.Opcodes {
+		colorFunc := theme.Info
+		if c.BaselineGas > c.CurrentGas {
+			colorFunc = theme.High
+		} else if c.BaselineGas < c.CurrentGas {
+			colorFunc = theme.Success
+		}
+
+		sb.WriteString(colorFunc.Sprintf("%-12s %10d %15s %15s %7.1f%%\n",
+			c.Opcode,
+			c.Count,
+			formatGas(c.CurrentGas, theme.Numbers, theme.Unit),
+			formatGas(c.BaselineGas, theme.Numbers, theme.Unit),
+			percentChange(c.CurrentGas, c.BaselineGas)))
+	}
+
+	sb.WriteString(strings.Repeat("─", 63) + "\n")
+	sb.WriteString(fmt.Sprintf("%-12s %10s %15s %15s %7.1f%%\n\n",
+		"TOTAL", "",
+		formatGas(whatIf.CurrentTotal, theme.Numbers, theme.Unit),
+		formatGas(whatIf.BaselineTotal, theme.Numbers, theme.Unit),
+		percentChange(whatIf.CurrentTotal, whatIf.BaselineTotal)))
+	return sb.String()
+}
+
+// FormatReportDiff formats the gas change and the new and resolved findings
+// against a baseline report
+func FormatReportDiff(diff tracer.ReportDiff, theme Theme) string {
+	var sb strings.Builder
+
+	sb.WriteString("\n")
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n"))
+	sb.WriteString(theme.Header.Sprint("                  DIFF AGAINST BASELINE REPORT\n"))
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n\n"))
+
+	colorFunc := theme.Info
+	if diff.GasDelta() > 0 {
+		colorFunc = theme.High
+	} else if diff.GasDelta() < 0 {
+		colorFunc = theme.Success
+	}
+	sb.WriteString(fmt.Sprintf("%-12s %15s %15s %8s\n", "", "BASELINE", "CURRENT", "CHANGE"))
+	sb.WriteString(strings.Repeat("─", 53) + "\n")
+	sb.WriteString(colorFunc.Sprintf("%-12s %15s %15s %7.1f%%\n\n",
+		"TOTAL GAS",
+		formatGas(diff.BaselineGas, theme.Numbers, theme.Unit),
+		formatGas(diff.CurrentGas, theme.Numbers, theme.Unit),
+		percentChange(diff.BaselineGas, diff.CurrentGas)))
+
+	sb.WriteString(fmt.Sprintf("New findings: %d, resolved: %d, unchanged: %d\n\n", len(diff.New), len(diff.Resolved), diff.Unchanged))
+	for _, opt := range diff.New {
+		sb.WriteString(theme.High.Sprintf("  + [%s] %s at %s\n", opt.Severity, opt.Type, opt.Location))
+	}
+	for _, opt := range diff.Resolved {
+		sb.WriteString(theme.Success.Sprintf("  - [%s] %s at %s\n", opt.Severity, opt.Type, opt.Location))
+	}
+	if len(diff.New) > 0 || len(diff.Resolved) > 0 {
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// FormatInternalTxs formats the internal transactions as a table, one per line
+func FormatInternalTxs(txs []tracer.InternalTx, theme Theme) string {
+	var sb strings.Builder
+
+	sb.WriteString("\n")
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n"))
+	sb.WriteString(theme.Header.Sprint("                  INTERNAL TRANSACTIONS\n"))
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n\n"))
+
+	if len(txs) == 0 {
+		sb.WriteString("No internal transactions\n\n")
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("%-4s %-12s %5s %-42s %-42s %24s  %s\n", "#", "TYPE", "DEPTH", "FROM", "TO", "VALUE (WEI)", "STATUS"))
+	sb.WriteString(strings.Repeat("─", 144) + "\n")
+
+	for i, tx := range txs {
+		status, colorFunc := "ok", theme.Info
+		if !tx.Success {
+			status, colorFunc = "reverted", theme.High
+		} else if tx.ContractAddress != (common.Address{}) {
+			status = "created"
+		}
+
+		sb.WriteString(colorFunc.Sprintf("%-4d %-12s %5d %-42s %-42s %24s  %s\n",
+			i+1, tx.Type, tx.Depth, FormatAddress(tx.From, tx.FromName), FormatAddress(tx.To, tx.ToName), tx.Value, status))
+	}
+
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// FormatCallTree formats the call tree as an indented view, one frame per line
+func FormatCallTree(root *tracer.CallNode, theme Theme) string {
+	var sb strings.Builder
+
+	sb.WriteString("\n")
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n"))
+	sb.WriteString(theme.Header.Sprint("                         CALL TREE\n"))
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n\n"))
+
+	if root == nil {
+		sb.WriteString("No calls recorded\n\n")
+		return sb.String()
+	}
+
+	writeCallNode(&sb, root, "", "", theme)
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// writeCallNode writes node after prefix and its descendants below it, indented by indent
+func writeCallNode(sb *strings.Builder, node *tracer.CallNode, prefix, indent string, theme Theme) {
+	line := fmt.Sprintf("%s %s [%s gas]", node.Type, FormatAddress(node.To, node.ToName), formatGas(node.GasUsed, theme.Numbers, theme.Unit))
+	if node.Signature != "" {
+		line += " " + node.Signature
+	} else if selector, ok := node.Selector(); ok {
+		line += fmt.Sprintf(" %#x", selector)
+	}
+	if node.Value != nil && node.Value.Sign() > 0 {
+		line += fmt.Sprintf(" value=%s", node.Value)
+	}
+
+	colorFunc := theme.Info
+	if node.Reverted() {
+		colorFunc = theme.High
+		line += " ✗ " + node.Error
+	}
+	sb.WriteString(prefix + colorFunc.Sprint(line) + "\n")
+
+	for i, child := range node.Children {
+		if i == len(node.Children)-1 {
+			writeCallNode(sb, child, indent+"└─ ", indent+"   ", theme)
+		} else {
+			writeCallNode(sb, child, indent+"├─ ", indent+"│  ", theme)
+		}
+	}
+}
+
+// percentChange returns the change from current to baseline as a percentage of current
+func percentChange(current, baseline uint64) float64 {
+	if current == 0 {
+		return 0
+	}
+	return (float64(baseline) - float64(current)) / float64(current) * 100
+}
+
+// FormatOpcodeHistogram formats opcode execution counts alongside their gas usage
+func FormatOpcodeHistogram(opcodeCounts map[string]uint64, gasPerOpcode map[string]uint64, theme Theme) string {
+	var sb strings.Builder
+
+	sb.WriteString("\n")
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n"))
+	sb.WriteString(theme.Header.Sprint("                  OPCODE FREQUENCY HISTOGRAM\n"))
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n\n"))
+
+	// Sort opcodes by execution count
+	type opcodeCount struct {
+		opcode string
+		count  uint64
+	}
+
+	opcodes := make([]opcodeCount, 0, len(opcodeCounts))
+	for op, count := range opcodeCounts {
+		opcodes = append(opcodes, opcodeCount{op, count})
+	}
+
+	sort.Slice(opcodes, func(i, j int) bool {
+		if opcodes[i].count != opcodes[j].count {
+			return opcodes[i].count > opcodes[j].count
+		}
+		return opcodes[i].opcode < opcodes[j].opcode
+	})
+
+	// Show top 10 most executed opcodes
+	limit := 10
+	if len(opcodes) < limit {
+		limit = len(opcodes)
+	}
+
+	sb.WriteString(fmt.Sprintf("%-12s %8s %12s  %s\n", "OPCODE", "COUNT", "GAS USED", "FREQUENCY"))
+	sb.WriteString(strings.Repeat("─", 63) + "\n")
+
+	const barWidth = 25
+	for i := 0; i < limit; i++ {
+		op := opcodes[i]
+		bar := int(op.count * barWidth / opcodes[0].count)
+		if bar == 0 {
+			bar = 1
+		}
+
+		sb.WriteString(theme.Info.Sprintf("%-12s %8d %12s  %s\n",
+			op.opcode,
+			op.count,
+			formatGas(gasPerOpcode[op.opcode], theme.Numbers, theme.Unit),
+			strings.Repeat("█", bar)))
+	}
+
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// opcodeGas pairs an opcode with the gas it consumed
+type opcodeGas struct {
+	opcode string
+	gas    uint64
+}
+
+// sortOpcodesByGas returns opcodes ordered by descending gas usage
+func sortOpcodesByGas(gasPerOpcode map[string]uint64) []opcodeGas {
+	opcodes := make([]opcodeGas, 0, len(gasPerOpcode))
+	for op, gas := range gasPerOpcode {
+		opcodes = append(opcodes, opcodeGas{op, gas})
+	}
+
+	sort.Slice(opcodes, func(i, j int) bool {
+		if opcodes[i].gas != opcodes[j].gas {
+			return opcodes[i].gas > opcodes[j].gas
+		}
+		return opcodes[i].opcode < opcodes[j].opcode
+	})
+	return opcodes
+}
+
+// FormatAddress formats an address as hex, or as its name followed by the
+// shortened hex when the name is known
+func FormatAddress(addr common.Address, name string) string {
+	if name == "" {
+		return addr.Hex()
+	}
+	hex := addr.Hex()
+	return fmt.Sprintf("%s (%s...%s)", name, hex[:6], hex[len(hex)-4:])
+}
+
+// formatGas formats a gas amount in the given number format and unit. Without a
+// unit, the short format picks K or M by size and the others print raw gas.
+func formatGas(gas uint64, format NumberFormat, unit GasUnit) string {
+	if unit == GasUnitAuto && format != NumberFull && format != NumberGrouped {
+		switch {
+		case gas >= 1000000:
+			unit = GasUnitMGas
+		case gas >= 1000:
+			unit = GasUnitKGas
+		default:
+			unit = GasUnitGas
+		}
+	}
+
+	switch unit {
+	case GasUnitKGas:
+		return scaledGas(gas, 1000, format) + "K"
+	case GasUnitMGas:
+		return scaledGas(gas, 1000000, format) + "M"
+	}
+	if format == NumberGrouped {
+		return groupDigits(gas)
+	}
+	return strconv.FormatUint(gas, 10)
+}
+
+// scaledGas formats gas divided by scale to two decimals, grouping the integer
+// part in the grouped format
+func scaledGas(gas, scale uint64, format NumberFormat) string {
+	scaled := fmt.Sprintf("%.2f", float64(gas)/float64(scale))
+	if format != NumberGrouped {
+		return scaled
 	}
-	return fmt.Sprintf("%d", gas)
+	whole, fraction, _ := strings.Cut(scaled, ".")
+	n, _ := strconv.ParseUint(whole, 10, 64)
+	return groupDigits(n) + "." + fraction
 }
 
 // FormatJSON formats the trace as JSON
@@ -0,0 +1,625 @@
+package formatter
+
+import (
+	"context"
+	"flag"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/devlongs/evm-tracer/internal/analyzer"
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/fatih/color"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestMain(m *testing.M) {
+	// Disable colors so golden output is deterministic
+	color.NoColor = true
+	os.Exit(m.Run())
+}
+
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+
+	if got != string(want) {
+		t.Errorf("output does not match %s\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+func TestFormatOptimizationsEmpty(t *testing.T) {
+	output := FormatOptimizations(nil, 21000, DarkTheme())
+	assertGolden(t, "optimizations_empty", output)
+}
+
+func TestFormatOptimizationsHighOnly(t *testing.T) {
+	optimizations := []tracer.Optimization{
+		{
+			Type:        "redundant_sload",
+			Severity:    "high",
+			Description: "Multiple SLOAD operations for the same storage slot",
+			Location:    tracer.PCLocation(0x2a),
+			GasSavings:  200,
+			Confidence:  "exact",
+			Details: map[string]interface{}{
+				"storage_key": "0x01",
+				"read_count":  3,
+			},
+		},
+	}
+
+	output := FormatOptimizations(optimizations, 50000, DarkTheme())
+	assertGolden(t, "optimizations_high_only", output)
+}
+
+func TestFormatOptimizationsHeuristicSavings(t *testing.T) {
+	optimizations := []tracer.Optimization{
+		{Type: "redundant_sload", Severity: "high", Location: tracer.PCLocation(0x2a), GasSavings: 200, Confidence: "exact"},
+		{Type: "memory_expansion", Severity: "medium", Location: tracer.PCLocation(0x40), GasSavings: 5000, Confidence: "heuristic"},
+	}
+
+	output := FormatOptimizations(optimizations, 50000, DarkTheme())
+	if !strings.Contains(output, "Total Potential Savings: 200 (~0.40%)") {
+		t.Errorf("Expected the total to exclude heuristic savings, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Heuristic Savings (not in total): 5.00K") {
+		t.Errorf("Expected heuristic savings to be shown separately, got:\n%s", output)
+	}
+
+	heuristicOnly := FormatOptimizations(optimizations[1:], 50000, DarkTheme())
+	if strings.Contains(heuristicOnly, "Total Potential Savings") {
+		t.Errorf("Expected no total for heuristic-only savings, got:\n%s", heuristicOnly)
+	}
+
+	markdown := FormatMarkdown(optimizations, nil, 50000, NumberShort, GasUnitAuto)
+	if !strings.Contains(markdown, "**200 (~0.40%)**") || !strings.Contains(markdown, "5.00K (heuristic)") {
+		t.Errorf("Expected the Markdown total to exclude heuristic savings, got:\n%s", markdown)
+	}
+}
+
+func TestFormatOptimizationsCappedSavings(t *testing.T) {
+	optimizations := []tracer.Optimization{
+		{Type: "wasted_gas_on_revert", Severity: "high", Location: tracer.PCLocation(0x2a), GasSavings: 30000, Confidence: "estimated"},
+		{Type: "redundant_sload", Severity: "high", Location: tracer.PCLocation(0x40), GasSavings: 40000, Confidence: "exact"},
+	}
+
+	output := FormatOptimizations(optimizations, 50000, DarkTheme())
+	if !strings.Contains(output, "Total Potential Savings: 50.00K (~100.00%)") || !strings.Contains(output, "capped at the gas used") {
+		t.Errorf("Expected the total capped at the gas used with a warning, got:\n%s", output)
+	}
+
+	markdown := FormatMarkdown(optimizations, nil, 50000, NumberShort, GasUnitAuto)
+	if !strings.Contains(markdown, "**50.00K (~100.00%)**") || !strings.Contains(markdown, "capped at the gas used") {
+		t.Errorf("Expected the Markdown total capped with a warning, got:\n%s", markdown)
+	}
+}
+
+func TestFormatOptimizationsMixed(t *testing.T) {
+	optimizations := []tracer.Optimization{
+		{
+			Type:        "gas_forwarding",
+			Severity:    "low",
+			Description: "Forwarding all available gas to external call",
+			Location:    tracer.PCLocation(0x10),
+		},
+		{
+			Type:        "redundant_sload",
+			Severity:    "high",
+			Description: "Multiple SLOAD operations for the same storage slot",
+			Location:    tracer.PCLocation(0x2a),
+			GasSavings:  300,
+		},
+		{
+			Type:        "multiple_calls",
+			Severity:    "medium",
+			Description: "Multiple external calls detected - consider batching",
+			Location:    tracer.MultipleLocation(),
+			GasSavings:  12600,
+			Details: map[string]interface{}{
+				"call_count": 6,
+			},
+		},
+		{
+			Type:        "redundant_sload",
+			Severity:    "high",
+			Description: "Multiple SLOAD operations for the same storage slot",
+			Location:    tracer.PCLocation(0x3b),
+			GasSavings:  1200,
+		},
+	}
+
+	output := FormatOptimizations(optimizations, 1500000, DarkTheme())
+	assertGolden(t, "optimizations_mixed", output)
+}
+
+func TestFormatGasBreakdown(t *testing.T) {
+	gasPerOpcode := map[string]uint64{
+		"SLOAD":  25000,
+		"SSTORE": 44000,
+		"CALL":   15000,
+		"ADD":    300,
+		"PUSH1":  900,
+	}
+
+	output := FormatGasBreakdown(gasPerOpcode, 100000, DarkTheme())
+	assertGolden(t, "gas_breakdown", output)
+}
+
+func TestFormatGasByCategory(t *testing.T) {
+	gasPerOpcode := map[string]uint64{
+		"SLOAD":  25000,
+		"SSTORE": 44000,
+		"CALL":   15000,
+		"ADD":    300,
+		"PUSH1":  900,
+	}
+
+	output := FormatGasByCategory(gasPerOpcode, 100000, DarkTheme())
+	assertGolden(t, "gas_by_category", output)
+}
+
+func TestFormatWhatIf(t *testing.T) {
+	whatIf := tracer.WhatIf{
+		Opcodes: []tracer.ScheduleComparison{
+			{Opcode: "SLOAD", Count: 10, CurrentGas: 8000, BaselineGas: 4000},
+			{Opcode: "SSTORE", Count: 2, CurrentGas: 10000, BaselineGas: 12000},
+		},
+		CurrentTotal:  50000,
+		BaselineTotal: 48000,
+	}
+
+	output := FormatWhatIf(whatIf, DarkTheme())
+	assertGolden(t, "what_if", output)
+}
+
+func TestFormatReportDiff(t *testing.T) {
+	diff := tracer.ReportDiff{
+		BaselineGas: 120000,
+		CurrentGas:  96000,
+		New: []tracer.Optimization{
+			{Type: "long_revert_string", Severity: "low", Location: tracer.PCLocation(0x41)},
+		},
+		Resolved: []tracer.Optimization{
+			{Type: "storage_in_loop", Severity: "high", Location: tracer.PCLocation(0x12)},
+			{Type: "redundant_sload", Severity: "medium", Location: tracer.PCLocation(0x12)},
+		},
+		Unchanged: 3,
+	}
+
+	assertGolden(t, "report_diff", FormatReportDiff(diff, DarkTheme()))
+}
+
+func TestFormatInternalTxs(t *testing.T) {
+	txs := []tracer.InternalTx{
+		{
+			Type:    "CALL",
+			From:    common.HexToAddress("0x00000000000000000000000000000000000000a1"),
+			To:      common.HexToAddress("0x00000000000000000000000000000000000000b1"),
+			Value:   big.NewInt(1e18),
+			Depth:   1,
+			Success: true,
+		},
+		{
+			Type:            "CREATE",
+			From:            common.HexToAddress("0x00000000000000000000000000000000000000a1"),
+			To:              common.HexToAddress("0x00000000000000000000000000000000000000c1"),
+			Value:           big.NewInt(0),
+			Depth:           1,
+			Success:         true,
+			ContractAddress: common.HexToAddress("0x00000000000000000000000000000000000000c1"),
+		},
+		{
+			Type:  "CALL",
+			From:  common.HexToAddress("0x00000000000000000000000000000000000000c1"),
+			To:    common.HexToAddress("0x00000000000000000000000000000000000000d1"),
+			Value: big.NewInt(7),
+			Depth: 2,
+		},
+	}
+
+	assertGolden(t, "internal_txs", FormatInternalTxs(txs, DarkTheme()))
+
+	if !strings.Contains(FormatInternalTxs(nil, DarkTheme()), "No internal transactions") {
+		t.Error("Expected a placeholder for no internal transactions")
+	}
+}
+
+func TestFormatCallTree(t *testing.T) {
+	root := &tracer.CallNode{
+		Type:    "CALL",
+		To:      common.HexToAddress("0x00000000000000000000000000000000000000b1"),
+		Input:   []byte{0xa9, 0x05, 0x9c, 0xbb, 0x00},
+		GasUsed: 52000,
+		Children: []*tracer.CallNode{
+			{
+				Type:    "CALL",
+				To:      common.HexToAddress("0x00000000000000000000000000000000000000b2"),
+				Value:   big.NewInt(1000),
+				GasUsed: 9000,
+				Children: []*tracer.CallNode{
+					{
+						Type:    "DELEGATECALL",
+						To:      common.HexToAddress("0x00000000000000000000000000000000000000b3"),
+						GasUsed: 300,
+						Error:   "execution reverted",
+					},
+				},
+			},
+			{
+				Type:    "STATICCALL",
+				To:      common.HexToAddress("0x00000000000000000000000000000000000000b4"),
+				GasUsed: 700,
+			},
+		},
+	}
+
+	output := FormatCallTree(root, DarkTheme())
+	assertGolden(t, "call_tree", output)
+
+	if !strings.Contains(FormatCallTree(nil, DarkTheme()), "No calls recorded") {
+		t.Error("Expected an empty call tree to say so")
+	}
+}
+
+// mockNames resolves addresses from a fixed map
+type mockNames map[common.Address]string
+
+func (m mockNames) ReverseResolve(ctx context.Context, addr common.Address) (string, bool) {
+	name, ok := m[addr]
+	return name, ok
+}
+
+func TestFormatResolvedNames(t *testing.T) {
+	vitalik := common.HexToAddress("0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045")
+	unnamed := common.HexToAddress("0x00000000000000000000000000000000000000b2")
+
+	tr := tracer.NewGasOptimizationTracer()
+	tr.CallTree = &tracer.CallNode{
+		Type:    "CALL",
+		To:      vitalik,
+		GasUsed: 21000,
+		Children: []*tracer.CallNode{
+			{Type: "CALL", From: vitalik, To: unnamed, Value: big.NewInt(1), GasUsed: 9000},
+		},
+	}
+
+	tr.ResolveNames(context.Background(), mockNames{vitalik: "vitalik.eth"})
+
+	want := "vitalik.eth (0xd8dA...6045)"
+	if output := FormatCallTree(tr.GetCallTree(), DarkTheme()); !strings.Contains(output, want) {
+		t.Errorf("Expected %q in the call tree, got:\n%s", want, output)
+	}
+	output := FormatInternalTxs(tr.GetInternalTxs(), DarkTheme())
+	if !strings.Contains(output, want) {
+		t.Errorf("Expected %q in the internal transactions, got:\n%s", want, output)
+	}
+	if !strings.Contains(output, unnamed.Hex()) {
+		t.Errorf("Expected unresolved address %s as hex, got:\n%s", unnamed.Hex(), output)
+	}
+}
+
+func TestFormatAccountReport(t *testing.T) {
+	report := &analyzer.AccountReport{
+		Address:      common.HexToAddress("0x00000000000000000000000000000000000000b1"),
+		TotalGasUsed: 250000,
+		TotalSavings: 4000,
+		FailedTraces: 1,
+		Findings: []analyzer.FindingSummary{
+			{Type: "redundant_sload", Severity: "high", Transactions: 2, Occurrences: 5, GasSavings: 3500},
+			{Type: "multiple_calls", Severity: "medium", Transactions: 1, Occurrences: 1, GasSavings: 500},
+			{Type: "storage_contention", Severity: "low", Transactions: 2, Occurrences: 1},
+		},
+		Contention: []analyzer.SlotContention{
+			{Contract: common.HexToAddress("0x3000"), Slot: common.HexToHash("0x01"), Transactions: 2, Writers: 1, Reads: 3, Writes: 1},
+		},
+		Transactions: []analyzer.AccountTransaction{
+			{Hash: common.HexToHash("0x01"), GasUsed: 150000, Optimizations: 4, GasSavings: 3000},
+			{Hash: common.HexToHash("0x02"), GasUsed: 100000, Optimizations: 2, GasSavings: 1000},
+			{Hash: common.HexToHash("0x03"), Error: "failed to get transaction: not found"},
+		},
+		GasDistribution:          &analyzer.Distribution{Min: 100000, Median: 100000, P90: 150000, P99: 150000, Max: 150000},
+		OptimizationDistribution: &analyzer.Distribution{Min: 2, Median: 2, P90: 4, P99: 4, Max: 4},
+	}
+
+	output := FormatAccountReport(report, DarkTheme())
+	assertGolden(t, "account_report", output)
+}
+
+func TestFormatWatchedTransaction(t *testing.T) {
+	tx := analyzer.WatchedTransaction{
+		Block:   19000000,
+		Hash:    common.HexToHash("0x01"),
+		GasUsed: 52000,
+		Optimizations: []tracer.Optimization{
+			{Type: "redundant_sload", Severity: "high", Description: "Storage slot read multiple times", Location: tracer.PCLocation(0x2a), GasSavings: 200, Confidence: "exact"},
+			{Type: "gas_forwarding", Severity: "low", Description: "Forwarding all available gas to external call", Location: tracer.PCLocation(0x40), Confidence: "heuristic"},
+		},
+	}
+	failed := analyzer.WatchedTransaction{Block: 19000001, Hash: common.HexToHash("0x02"), Error: "failed to get transaction: not found"}
+
+	output := FormatWatchedTransaction(tx, DarkTheme()) + FormatWatchedTransaction(failed, DarkTheme())
+	assertGolden(t, "watched_transactions", output)
+}
+
+func TestFormatGas(t *testing.T) {
+	tests := []struct {
+		gas      uint64
+		format   NumberFormat
+		expected string
+	}{
+		{0, NumberShort, "0"},
+		{999, NumberShort, "999"},
+		{1000, NumberShort, "1.00K"},
+		{125430, NumberShort, "125.43K"},
+		{2500000, NumberShort, "2.50M"},
+		{2500000, "", "2.50M"},
+		{1234567, NumberFull, "1234567"},
+		{1234567, NumberGrouped, "1,234,567"},
+		{123456, NumberGrouped, "123,456"},
+		{999, NumberGrouped, "999"},
+		{0, NumberGrouped, "0"},
+	}
+
+	for _, tt := range tests {
+		result := formatGas(tt.gas, tt.format, GasUnitAuto)
+		if result != tt.expected {
+			t.Errorf("formatGas(%d, %q) = %s, expected %s", tt.gas, tt.format, result, tt.expected)
+		}
+	}
+}
+
+func TestFormatOpcodeHistogram(t *testing.T) {
+	opcodeCounts := map[string]uint64{
+		"PUSH1":  120,
+		"ADD":    40,
+		"SLOAD":  4,
+		"SSTORE": 2,
+		"MSTORE": 40,
+	}
+	gasPerOpcode := map[string]uint64{
+		"PUSH1":  360,
+		"ADD":    120,
+		"SLOAD":  8400,
+		"SSTORE": 44000,
+		"MSTORE": 120,
+	}
+
+	output := FormatOpcodeHistogram(opcodeCounts, gasPerOpcode, DarkTheme())
+	assertGolden(t, "opcode_histogram", output)
+}
+
+func TestFormatMarkdown(t *testing.T) {
+	optimizations := []tracer.Optimization{
+		{
+			Type:        "redundant_sload",
+			Severity:    "high",
+			Description: "Multiple SLOAD operations for the same storage slot",
+			Location:    tracer.PCLocation(0x2a),
+			GasSavings:  300,
+			Details: map[string]interface{}{
+				"read_count": 4,
+			},
+		},
+		{
+			Type:        "gas_forwarding",
+			Severity:    "low",
+			Description: "Forwarding all available gas to external call",
+			Location:    tracer.PCLocation(0x10),
+		},
+	}
+	gasPerOpcode := map[string]uint64{
+		"SLOAD": 8400,
+		"CALL":  2600,
+	}
+
+	output := FormatMarkdown(optimizations, gasPerOpcode, 50000, NumberShort, GasUnitAuto)
+
+	for _, expected := range []string{
+		"# ⛽ EVM Tracer Gas Optimization Report",
+		"## Summary",
+		"## Optimizations",
+		"## Gas by Opcode",
+		"| --- | --- |",
+		"| --- | ---: | ---: |",
+		"<summary>🚨 High Priority (1)</summary>",
+		"<summary>ℹ️ Low Priority (1)</summary>",
+		"**💰 Total Potential Savings**",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Markdown output missing %q", expected)
+		}
+	}
+
+	if strings.Contains(output, "Medium Priority") {
+		t.Error("Did not expect an empty medium severity section")
+	}
+
+	assertGolden(t, "markdown", output)
+}
+
+func themeFixture() []tracer.Optimization {
+	return []tracer.Optimization{
+		{Type: "redundant_sload", Severity: "high", Description: "Multiple SLOAD operations", Location: tracer.PCLocation(0x10), GasSavings: 200},
+		{Type: "multiple_calls", Severity: "medium", Description: "Multiple external calls", Location: tracer.MultipleLocation()},
+	}
+}
+
+// withColors enables color output for the duration of a test
+func withColors(t *testing.T) {
+	t.Helper()
+
+	color.NoColor = false
+	t.Cleanup(func() { color.NoColor = true })
+}
+
+func TestMonoThemeHasNoColorCodes(t *testing.T) {
+	withColors(t)
+
+	theme, err := ThemeByName("mono")
+	if err != nil {
+		t.Fatalf("ThemeByName() error: %v", err)
+	}
+
+	output := FormatOptimizations(themeFixture(), 50000, theme)
+	output += FormatGasBreakdown(map[string]uint64{"SLOAD": 4200}, 50000, theme)
+
+	if strings.Contains(output, "\x1b[") {
+		t.Errorf("Expected no color codes in mono output, got:\n%q", output)
+	}
+}
+
+func TestLightThemeDiffersFromDark(t *testing.T) {
+	withColors(t)
+
+	dark := FormatOptimizations(themeFixture(), 50000, DarkTheme())
+	light := FormatOptimizations(themeFixture(), 50000, LightTheme())
+
+	if !strings.Contains(dark, "\x1b[") || !strings.Contains(light, "\x1b[") {
+		t.Fatal("Expected both themes to emit color codes")
+	}
+
+	if dark == light {
+		t.Error("Expected light theme output to differ from dark")
+	}
+
+	if DarkTheme().Info.Sprint("x") == LightTheme().Info.Sprint("x") {
+		t.Error("Expected light theme to use a different info color than dark")
+	}
+}
+
+func TestThemeByName(t *testing.T) {
+	for _, name := range ThemeNames {
+		if _, err := ThemeByName(name); err != nil {
+			t.Errorf("ThemeByName(%q) error: %v", name, err)
+		}
+	}
+
+	if _, err := ThemeByName("neon"); err == nil {
+		t.Error("Expected an unknown theme to be rejected")
+	}
+
+	t.Setenv("COLORFGBG", "0;15")
+	if detectTheme() != "light" {
+		t.Error("Expected a white background to select the light theme")
+	}
+
+	t.Setenv("COLORFGBG", "15;0")
+	if detectTheme() != "dark" {
+		t.Error("Expected a black background to select the dark theme")
+	}
+}
+
+func TestFormatNumberFormat(t *testing.T) {
+	theme := MonoTheme()
+	theme.Numbers = NumberGrouped
+	if output := FormatOptimizations(nil, 1234567, theme); !strings.Contains(output, "Total Gas Used: 1,234,567") {
+		t.Errorf("Expected grouped total gas, got:\n%s", output)
+	}
+	if output := FormatMarkdown(nil, nil, 1234567, NumberFull, GasUnitAuto); !strings.Contains(output, "| Total Gas Used | 1234567 |") {
+		t.Errorf("Expected full total gas in Markdown, got:\n%s", output)
+	}
+}
+
+func TestFormatGasUnit(t *testing.T) {
+	tests := []struct {
+		gas      uint64
+		format   NumberFormat
+		unit     GasUnit
+		expected string
+	}{
+		{1234567, NumberShort, GasUnitGas, "1234567"},
+		{1234567, NumberGrouped, GasUnitGas, "1,234,567"},
+		{21, NumberShort, GasUnitKGas, "0.02K"},
+		{1234567, NumberShort, GasUnitKGas, "1234.57K"},
+		{1234567, NumberGrouped, GasUnitKGas, "1,234.57K"},
+		{1234567, NumberFull, GasUnitMGas, "1.23M"},
+		{50000, NumberShort, GasUnitMGas, "0.05M"},
+	}
+
+	for _, tt := range tests {
+		result := formatGas(tt.gas, tt.format, tt.unit)
+		if result != tt.expected {
+			t.Errorf("formatGas(%d, %q, %q) = %s, expected %s", tt.gas, tt.format, tt.unit, result, tt.expected)
+		}
+	}
+
+	theme := MonoTheme()
+	theme.Unit = GasUnitGas
+	if output := FormatOptimizations(nil, 1234567, theme); !strings.Contains(output, "Total Gas Used: 1234567") {
+		t.Errorf("Expected raw total gas, got:\n%s", output)
+	}
+	if output := FormatMarkdown(nil, nil, 1234567, NumberShort, GasUnitKGas); !strings.Contains(output, "| Total Gas Used | 1234.57K |") {
+		t.Errorf("Expected total gas in kgas in Markdown, got:\n%s", output)
+	}
+
+	for _, name := range GasUnitNames {
+		if unit, err := ParseGasUnit(name); err != nil || string(unit) != name {
+			t.Errorf("ParseGasUnit(%q): expected %s, got %s (%v)", name, name, unit, err)
+		}
+	}
+	if _, err := ParseGasUnit("gwei"); err == nil {
+		t.Error("Expected an unknown gas unit to be rejected")
+	}
+}
+
+func TestParseNumberFormat(t *testing.T) {
+	for _, name := range NumberFormatNames {
+		if format, err := ParseNumberFormat(name); err != nil || string(format) != name {
+			t.Errorf("ParseNumberFormat(%q): expected %s, got %s (%v)", name, name, format, err)
+		}
+	}
+	if format, _ := ParseNumberFormat(""); format != NumberShort {
+		t.Errorf("Expected short by default, got %s", format)
+	}
+	if _, err := ParseNumberFormat("scientific"); err == nil {
+		t.Error("Expected an unknown number format to be rejected")
+	}
+}
+
+func TestParseSections(t *testing.T) {
+	set, err := ParseSections("")
+	if err != nil || !set.Includes("opt") || set.Selects("opt") || len(set.OmittedReportKeys()) != 0 {
+		t.Errorf("Expected the default report from an empty list, got %+v, %v", set, err)
+	}
+
+	set, err = ParseSections("gas, calls")
+	if err != nil {
+		t.Fatalf("ParseSections() error: %v", err)
+	}
+	if !set.Selects("gas") || !set.Selects("calls") || set.Includes("opt") || set.Includes("timeline") {
+		t.Errorf("Expected only gas and calls, got %+v", set)
+	}
+	if omitted := strings.Join(set.OmittedReportKeys(), ","); omitted != "heuristic_savings,internal_transactions,optimizations,sampled_profile,savings_capped,timeline,total_gas_savings" {
+		t.Errorf("Expected the other sections' report keys to be omitted, got %s", omitted)
+	}
+
+	set, err = ParseSections("all")
+	if err != nil {
+		t.Fatalf("ParseSections() error: %v", err)
+	}
+	for _, name := range SectionNames() {
+		if !set.Selects(name) {
+			t.Errorf("Expected all to select %s", name)
+		}
+	}
+
+	if _, err := ParseSections("opt,stack"); err == nil {
+		t.Error("Expected an error for an unknown section")
+	}
+}
@@ -0,0 +1,26 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+)
+
+// FormatGasReference formats the opcode gas costs of a fork
+func FormatGasReference(fork string, costs []tracer.OpcodeCost, theme Theme) string {
+	var sb strings.Builder
+
+	sb.WriteString(theme.Header.Sprintf("⛽ OPCODE GAS COSTS (%s)\n", fork))
+	sb.WriteString(theme.Info.Sprint("Measured with zero operands; memory expansion, copies and data cost more with larger operands\n"))
+	sb.WriteString(fmt.Sprintf("%-14s %8s %8s\n", "OPCODE", "GAS", "REPEAT"))
+	sb.WriteString(strings.Repeat("─", 32) + "\n")
+	for _, cost := range costs {
+		repeat := "-"
+		if cost.RepeatGas > 0 {
+			repeat = fmt.Sprintf("%d", cost.RepeatGas)
+		}
+		sb.WriteString(fmt.Sprintf("%-14s %8d %8s\n", cost.Op, cost.Gas, repeat))
+	}
+	return sb.String()
+}
@@ -0,0 +1,114 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+)
+
+// FormatMarkdown formats the trace results as GitHub-flavored Markdown, suitable for PR comments
+func FormatMarkdown(optimizations []tracer.Optimization, gasPerOpcode map[string]uint64, totalGas uint64, numbers NumberFormat, unit GasUnit) string {
+	var sb strings.Builder
+
+	high, medium, low := groupBySeverity(optimizations)
+	totalSavings, heuristicSavings := tracer.SavingsTotals(optimizations)
+	totalSavings, capped := tracer.CapSavings(totalSavings, totalGas)
+
+	// Summary
+	sb.WriteString("# ⛽ EVM Tracer Gas Optimization Report\n\n")
+	sb.WriteString("## Summary\n\n")
+	sb.WriteString("| Metric | Value |\n")
+	sb.WriteString("| --- | --- |\n")
+	sb.WriteString(fmt.Sprintf("| Total Gas Used | %s |\n", formatGas(totalGas, numbers, unit)))
+	sb.WriteString(fmt.Sprintf("| Optimizations Found | %d |\n", len(optimizations)))
+	sb.WriteString(fmt.Sprintf("| 🚨 High | %d |\n", len(high)))
+	sb.WriteString(fmt.Sprintf("| ⚠️ Medium | %d |\n", len(medium)))
+	sb.WriteString(fmt.Sprintf("| ℹ️ Low | %d |\n", len(low)))
+	if totalSavings > 0 && totalGas > 0 {
+		sb.WriteString(fmt.Sprintf("| **💰 Total Potential Savings** | **%s (~%.2f%%)** |\n",
+			formatGas(totalSavings, numbers, unit),
+			float64(totalSavings)/float64(totalGas)*100))
+	}
+	if capped {
+		sb.WriteString("| ⚠️ Savings | Estimates exceed the gas used; the total is capped at the gas used |\n")
+	}
+	if heuristicSavings > 0 {
+		sb.WriteString(fmt.Sprintf("| 🔮 Heuristic Savings (not in total) | %s |\n", formatGas(heuristicSavings, numbers, unit)))
+	}
+	sb.WriteString("\n")
+
+	// Optimizations by severity
+	sb.WriteString("## Optimizations\n\n")
+	if len(optimizations) == 0 {
+		sb.WriteString("✨ No obvious optimization opportunities found.\n\n")
+	}
+	sb.WriteString(formatMarkdownSeverity("🚨 High Priority", high, numbers, unit))
+	sb.WriteString(formatMarkdownSeverity("⚠️ Medium Priority", medium, numbers, unit))
+	sb.WriteString(formatMarkdownSeverity("ℹ️ Low Priority", low, numbers, unit))
+
+	// Gas by opcode
+	sb.WriteString("## Gas by Opcode\n\n")
+	sb.WriteString("| Opcode | Gas Used | % of Total |\n")
+	sb.WriteString("| --- | ---: | ---: |\n")
+
+	opcodes := sortOpcodesByGas(gasPerOpcode)
+	limit := 10
+	if len(opcodes) < limit {
+		limit = len(opcodes)
+	}
+
+	for i := 0; i < limit; i++ {
+		op := opcodes[i]
+		percentage := 0.0
+		if totalGas > 0 {
+			percentage = float64(op.gas) / float64(totalGas) * 100
+		}
+		sb.WriteString(fmt.Sprintf("| `%s` | %s | %.2f%% |\n", op.opcode, formatGas(op.gas, numbers, unit), percentage))
+	}
+
+	return sb.String()
+}
+
+// formatMarkdownSeverity renders one severity group as a collapsible table
+func formatMarkdownSeverity(title string, optimizations []tracer.Optimization, numbers NumberFormat, unit GasUnit) string {
+	if len(optimizations) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("<details>\n")
+	sb.WriteString(fmt.Sprintf("<summary>%s (%d)</summary>\n\n", title, len(optimizations)))
+	sb.WriteString("| # | Type | Description | Location | Savings | Details |\n")
+	sb.WriteString("| --- | --- | --- | --- | ---: | --- |\n")
+
+	for i, opt := range optimizations {
+		savings := "-"
+		if opt.GasSavings > 0 {
+			savings = formatGas(opt.GasSavings, numbers, unit) + confidenceLabel(opt)
+		}
+
+		details := make([]string, 0, len(opt.Details))
+		for _, key := range sortedDetailKeys(opt.Details) {
+			details = append(details, fmt.Sprintf("%s: %v", key, opt.Details[key]))
+		}
+
+		sb.WriteString(fmt.Sprintf("| %d | `%s` | %s | `%s` | %s | %s |\n",
+			i+1,
+			opt.Type,
+			escapeMarkdownCell(opt.Description),
+			opt.Location,
+			savings,
+			escapeMarkdownCell(strings.Join(details, "<br>"))))
+	}
+
+	sb.WriteString("\n</details>\n\n")
+	return sb.String()
+}
+
+// escapeMarkdownCell escapes characters that would break a Markdown table cell
+func escapeMarkdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
@@ -0,0 +1,67 @@
+package formatter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NumberFormat selects how gas amounts are printed
+type NumberFormat string
+
+const (
+	NumberShort   NumberFormat = "short"   // 1.23M, 4.56K
+	NumberFull    NumberFormat = "full"    // 1234567
+	NumberGrouped NumberFormat = "grouped" // 1,234,567
+)
+
+// NumberFormatNames lists the available number formats
+var NumberFormatNames = []string{string(NumberShort), string(NumberFull), string(NumberGrouped)}
+
+// ParseNumberFormat returns the named number format. An empty name selects short.
+func ParseNumberFormat(name string) (NumberFormat, error) {
+	switch NumberFormat(name) {
+	case "", NumberShort:
+		return NumberShort, nil
+	case NumberFull, NumberGrouped:
+		return NumberFormat(name), nil
+	default:
+		return "", fmt.Errorf("unknown number format: %s (available: %s)", name, strings.Join(NumberFormatNames, ", "))
+	}
+}
+
+// GasUnit selects the scale gas amounts are printed in
+type GasUnit string
+
+const (
+	GasUnitAuto GasUnit = ""     // K or M picked by size in the short number format
+	GasUnitGas  GasUnit = "gas"  // 1234567
+	GasUnitKGas GasUnit = "kgas" // 1234.57K
+	GasUnitMGas GasUnit = "mgas" // 1.23M
+)
+
+// GasUnitNames lists the available gas units
+var GasUnitNames = []string{string(GasUnitGas), string(GasUnitKGas), string(GasUnitMGas)}
+
+// ParseGasUnit returns the named gas unit. An empty name picks the unit by size.
+func ParseGasUnit(name string) (GasUnit, error) {
+	switch GasUnit(name) {
+	case GasUnitAuto, GasUnitGas, GasUnitKGas, GasUnitMGas:
+		return GasUnit(name), nil
+	default:
+		return "", fmt.Errorf("unknown gas unit: %s (available: %s)", name, strings.Join(GasUnitNames, ", "))
+	}
+}
+
+// groupDigits formats n with commas between groups of three digits
+func groupDigits(n uint64) string {
+	digits := strconv.FormatUint(n, 10)
+	var sb strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(digit)
+	}
+	return sb.String()
+}
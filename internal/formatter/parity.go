@@ -0,0 +1,120 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// ParityTrace is one frame of a call tree in the OpenEthereum trace_transaction format
+type ParityTrace struct {
+	Action       ParityAction  `json:"action"`
+	Error        string        `json:"error,omitempty"`
+	Result       *ParityResult `json:"result"`
+	Subtraces    int           `json:"subtraces"`
+	TraceAddress []int         `json:"traceAddress"`
+	Type         string        `json:"type"`
+}
+
+// ParityAction describes what a frame did; the fields present depend on the trace type
+type ParityAction struct {
+	CallType      string          `json:"callType,omitempty"`
+	From          *common.Address `json:"from,omitempty"`
+	To            *common.Address `json:"to,omitempty"`
+	Gas           *hexutil.Uint64 `json:"gas,omitempty"`
+	Input         *hexutil.Bytes  `json:"input,omitempty"`
+	Init          *hexutil.Bytes  `json:"init,omitempty"`
+	Value         *hexutil.Big    `json:"value,omitempty"`
+	Address       *common.Address `json:"address,omitempty"`
+	RefundAddress *common.Address `json:"refundAddress,omitempty"`
+	Balance       *hexutil.Big    `json:"balance,omitempty"`
+}
+
+// ParityResult is the outcome of a successful frame
+type ParityResult struct {
+	GasUsed hexutil.Uint64  `json:"gasUsed"`
+	Output  *hexutil.Bytes  `json:"output,omitempty"`
+	Address *common.Address `json:"address,omitempty"`
+	Code    *hexutil.Bytes  `json:"code,omitempty"`
+}
+
+// FormatParity formats the call tree as a trace_transaction result, one trace per
+// frame in depth-first order. Without a call tree the result is an empty list.
+func FormatParity(root *tracer.CallNode) (string, error) {
+	traces := []ParityTrace{}
+	if root != nil {
+		traces = appendParityTraces(traces, root, []int{})
+	}
+
+	data, err := json.MarshalIndent(traces, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode parity traces: %w", err)
+	}
+	return string(data), nil
+}
+
+// appendParityTraces appends the traces of node and its descendants, where
+// address is the path of child indices leading to node
+func appendParityTraces(traces []ParityTrace, node *tracer.CallNode, address []int) []ParityTrace {
+	trace := ParityTrace{
+		Subtraces:    len(node.Children),
+		TraceAddress: address,
+	}
+	from, to := node.From, node.To
+	value := (*hexutil.Big)(node.Value)
+	if value == nil {
+		value = (*hexutil.Big)(new(big.Int))
+	}
+
+	switch node.Type {
+	case "CREATE", "CREATE2":
+		trace.Type = "create"
+		gas, init := hexutil.Uint64(node.Gas), hexutil.Bytes(node.Input)
+		trace.Action = ParityAction{From: &from, Gas: &gas, Init: &init, Value: value}
+		if !node.Reverted() {
+			code := hexutil.Bytes(node.Output)
+			trace.Result = &ParityResult{GasUsed: hexutil.Uint64(node.GasUsed), Address: &to, Code: &code}
+		}
+
+	case "SELFDESTRUCT":
+		trace.Type = "suicide"
+		trace.Action = ParityAction{Address: &from, RefundAddress: &to, Balance: value}
+
+	default:
+		trace.Type = "call"
+		gas, input := hexutil.Uint64(node.Gas), hexutil.Bytes(node.Input)
+		trace.Action = ParityAction{CallType: strings.ToLower(node.Type), From: &from, To: &to, Gas: &gas, Input: &input, Value: value}
+		if !node.Reverted() {
+			output := hexutil.Bytes(node.Output)
+			trace.Result = &ParityResult{GasUsed: hexutil.Uint64(node.GasUsed), Output: &output}
+		}
+	}
+	if node.Reverted() {
+		trace.Error = parityError(node.Error)
+	}
+
+	traces = append(traces, trace)
+	for i, child := range node.Children {
+		childAddress := append(append([]int{}, address...), i)
+		traces = appendParityTraces(traces, child, childAddress)
+	}
+	return traces
+}
+
+// parityError names common EVM errors the way OpenEthereum does
+func parityError(message string) string {
+	switch message {
+	case vm.ErrExecutionReverted.Error():
+		return "Reverted"
+	case vm.ErrOutOfGas.Error():
+		return "Out of gas"
+	default:
+		return message
+	}
+}
@@ -0,0 +1,76 @@
+package formatter
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestFormatParity(t *testing.T) {
+	sender := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	router := common.HexToAddress("0x00000000000000000000000000000000000000b1")
+	impl := common.HexToAddress("0x00000000000000000000000000000000000000b2")
+	oracle := common.HexToAddress("0x00000000000000000000000000000000000000b3")
+	created := common.HexToAddress("0x00000000000000000000000000000000000000c1")
+
+	root := &tracer.CallNode{
+		Type: "CALL", From: sender, To: router, Value: big.NewInt(16),
+		Input: []byte{0xa9, 0x05, 0x9c, 0xbb}, Gas: 100000, GasUsed: 60000, Output: []byte{0x01},
+		Children: []*tracer.CallNode{
+			{
+				Type: "DELEGATECALL", From: router, To: impl, Gas: 90000, GasUsed: 20000,
+				Children: []*tracer.CallNode{
+					{Type: "STATICCALL", From: router, To: oracle, Gas: 5000, GasUsed: 5000, Error: "execution reverted"},
+				},
+			},
+			{Type: "CREATE", From: router, To: created, Value: big.NewInt(0), Input: []byte{0x60, 0x00}, Gas: 30000, GasUsed: 25000, Output: []byte{0x00}},
+		},
+	}
+
+	expected := `[
+	  {
+	    "action": {"callType": "call", "from": "0x00000000000000000000000000000000000000a1", "to": "0x00000000000000000000000000000000000000b1", "gas": "0x186a0", "input": "0xa9059cbb", "value": "0x10"},
+	    "result": {"gasUsed": "0xea60", "output": "0x01"},
+	    "subtraces": 2, "traceAddress": [], "type": "call"
+	  },
+	  {
+	    "action": {"callType": "delegatecall", "from": "0x00000000000000000000000000000000000000b1", "to": "0x00000000000000000000000000000000000000b2", "gas": "0x15f90", "input": "0x", "value": "0x0"},
+	    "result": {"gasUsed": "0x4e20", "output": "0x"},
+	    "subtraces": 1, "traceAddress": [0], "type": "call"
+	  },
+	  {
+	    "action": {"callType": "staticcall", "from": "0x00000000000000000000000000000000000000b1", "to": "0x00000000000000000000000000000000000000b3", "gas": "0x1388", "input": "0x", "value": "0x0"},
+	    "error": "Reverted", "result": null,
+	    "subtraces": 0, "traceAddress": [0, 0], "type": "call"
+	  },
+	  {
+	    "action": {"from": "0x00000000000000000000000000000000000000b1", "gas": "0x7530", "init": "0x6000", "value": "0x0"},
+	    "result": {"gasUsed": "0x61a8", "address": "0x00000000000000000000000000000000000000c1", "code": "0x00"},
+	    "subtraces": 0, "traceAddress": [1], "type": "create"
+	  }
+	]`
+
+	output, err := FormatParity(root)
+	if err != nil {
+		t.Fatalf("FormatParity() error: %v", err)
+	}
+
+	var got, want interface{}
+	if err := json.Unmarshal([]byte(output), &got); err != nil {
+		t.Fatalf("failed to decode parity traces: %v", err)
+	}
+	if err := json.Unmarshal([]byte(expected), &want); err != nil {
+		t.Fatalf("failed to decode expected traces: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected parity traces\n%s\ngot\n%s", expected, output)
+	}
+
+	if empty, _ := FormatParity(nil); empty != "[]" {
+		t.Errorf("Expected an empty list without a call tree, got %s", empty)
+	}
+}
@@ -0,0 +1,47 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+)
+
+func init() {
+	RegisterSection(Section{
+		Name:       "profile",
+		ReportKeys: []string{"sampled_profile"},
+		Console: func(tr *tracer.GasOptimizationTracer, ctx SectionContext) string {
+			profile, ok := tr.GetSampledProfile()
+			if !ok {
+				return ""
+			}
+			return FormatSampledProfile(profile, ctx.Theme)
+		},
+	})
+}
+
+// FormatSampledProfile formats the hottest code locations of a sampled trace
+func FormatSampledProfile(profile tracer.SampledProfile, theme Theme) string {
+	var sb strings.Builder
+
+	sb.WriteString("\n")
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n"))
+	sb.WriteString(theme.Header.Sprint("                 HOT CODE LOCATIONS (SAMPLED)\n"))
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n\n"))
+
+	sb.WriteString(fmt.Sprintf("%d samples, 1 in %d steps; gas is estimated\n\n", profile.Samples, profile.Rate))
+	if len(profile.HotPCs) == 0 {
+		sb.WriteString("No steps sampled\n\n")
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("%-42s %8s %-14s %8s %12s\n", "CONTRACT", "PC", "OPCODE", "SAMPLES", "EST. GAS"))
+	for _, hot := range profile.HotPCs {
+		sb.WriteString(fmt.Sprintf("%-42s %8s %-14s %8d %12s\n",
+			hot.Contract.Hex(), fmt.Sprintf("%#x", hot.PC), hot.Op, hot.Samples, formatGas(hot.Gas, theme.Numbers, theme.Unit)))
+	}
+
+	sb.WriteString("\n")
+	return sb.String()
+}
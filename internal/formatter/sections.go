@@ -0,0 +1,134 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+)
+
+// Section is a part of the trace report that --sections includes or excludes
+type Section struct {
+	Name       string   // Name listed in --sections
+	ReportKeys []string // Keys of the JSON report holding the section
+
+	// Lead sections are written before the transaction summary lines of the
+	// console report, the others after them
+	Lead bool
+
+	// Console formats the section for the console report, or returns "" when
+	// there is nothing to show. Nil for sections only found in JSON.
+	Console func(tr *tracer.GasOptimizationTracer, ctx SectionContext) string
+}
+
+// SectionContext is passed to a section's console formatter
+type SectionContext struct {
+	Theme    Theme
+	Verbose  bool // -v was given
+	Minimal  bool // Only gas per opcode was collected
+	Selected bool // The section was listed in --sections, by name or with all
+}
+
+// AllSections is the --sections keyword selecting every section
+const AllSections = "all"
+
+// sections holds the registered sections in registration order, which is the
+// order they are written in
+var sections []Section
+
+// RegisterSection adds a section to the report. Sections register themselves
+// from the init function of the file that formats them.
+func RegisterSection(section Section) {
+	for _, s := range sections {
+		if s.Name == section.Name {
+			panic("formatter: section registered twice: " + section.Name)
+		}
+	}
+	sections = append(sections, section)
+}
+
+// SectionNames lists the registered section names in report order
+func SectionNames() []string {
+	names := make([]string, 0, len(sections))
+	for _, s := range sections {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// SectionSet is a selection of report sections. The zero value is the default
+// report, where each section shows what it shows without --sections.
+type SectionSet struct {
+	selected map[string]bool
+}
+
+// ParseSections parses a comma-separated list of section names, where all
+// selects every section. An empty list selects the default report.
+func ParseSections(list string) (SectionSet, error) {
+	if strings.TrimSpace(list) == "" {
+		return SectionSet{}, nil
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == AllSections {
+			for _, s := range sections {
+				selected[s.Name] = true
+			}
+			continue
+		}
+		if _, ok := sectionByName(name); !ok {
+			return SectionSet{}, fmt.Errorf("unknown section: %s (available: %s, %s)", name, strings.Join(SectionNames(), ", "), AllSections)
+		}
+		selected[name] = true
+	}
+	return SectionSet{selected: selected}, nil
+}
+
+// sectionByName returns the registered section with the given name
+func sectionByName(name string) (Section, bool) {
+	for _, s := range sections {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Section{}, false
+}
+
+// Includes reports whether the named section is part of the report
+func (s SectionSet) Includes(name string) bool {
+	return s.selected == nil || s.selected[name]
+}
+
+// Selects reports whether the named section was listed explicitly
+func (s SectionSet) Selects(name string) bool {
+	return s.selected[name]
+}
+
+// OmittedReportKeys returns the JSON report keys of the excluded sections, sorted
+func (s SectionSet) OmittedReportKeys() []string {
+	var keys []string
+	for _, section := range sections {
+		if !s.Includes(section.Name) {
+			keys = append(keys, section.ReportKeys...)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// FormatSections formats the included lead sections, or the included other
+// sections, for the console report
+func FormatSections(tr *tracer.GasOptimizationTracer, set SectionSet, lead bool, ctx SectionContext) string {
+	var sb strings.Builder
+	for _, section := range sections {
+		if section.Lead != lead || section.Console == nil || !set.Includes(section.Name) {
+			continue
+		}
+		ctx.Selected = set.Selects(section.Name)
+		sb.WriteString(section.Console(tr, ctx))
+	}
+	return sb.String()
+}
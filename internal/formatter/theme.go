@@ -0,0 +1,126 @@
+package formatter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Theme is the set of colors and the number format and unit used for console output
+type Theme struct {
+	High    *color.Color
+	Medium  *color.Color
+	Low     *color.Color
+	Success *color.Color
+	Header  *color.Color
+	Info    *color.Color
+
+	Numbers NumberFormat // How gas amounts are printed, short when empty
+	Unit    GasUnit      // Scale gas amounts are printed in, picked by size when empty
+}
+
+// ThemeNames lists the available themes
+var ThemeNames = []string{"dark", "light", "mono", "high-contrast"}
+
+// DarkTheme returns the default palette for dark terminal backgrounds
+func DarkTheme() Theme {
+	return Theme{
+		High:    color.New(color.FgRed, color.Bold),
+		Medium:  color.New(color.FgYellow, color.Bold),
+		Low:     color.New(color.FgCyan),
+		Success: color.New(color.FgGreen, color.Bold),
+		Header:  color.New(color.FgMagenta, color.Bold),
+		Info:    color.New(color.FgWhite),
+	}
+}
+
+// LightTheme returns a palette readable on light terminal backgrounds
+func LightTheme() Theme {
+	return Theme{
+		High:    color.New(color.FgRed, color.Bold),
+		Medium:  color.New(color.FgMagenta, color.Bold),
+		Low:     color.New(color.FgBlue),
+		Success: color.New(color.FgGreen),
+		Header:  color.New(color.FgBlue, color.Bold),
+		Info:    color.New(color.FgBlack),
+	}
+}
+
+// MonoTheme returns a theme that emits no color codes
+func MonoTheme() Theme {
+	theme := Theme{
+		High:    color.New(),
+		Medium:  color.New(),
+		Low:     color.New(),
+		Success: color.New(),
+		Header:  color.New(),
+		Info:    color.New(),
+	}
+	for _, c := range theme.colors() {
+		c.DisableColor()
+	}
+	return theme
+}
+
+// HighContrastTheme returns a palette that distinguishes severities by background
+// as well as hue, for colorblind users
+func HighContrastTheme() Theme {
+	return Theme{
+		High:    color.New(color.BgRed, color.FgHiWhite, color.Bold),
+		Medium:  color.New(color.BgYellow, color.FgBlack, color.Bold),
+		Low:     color.New(color.BgCyan, color.FgBlack),
+		Success: color.New(color.FgHiGreen, color.Bold),
+		Header:  color.New(color.FgHiWhite, color.Bold, color.Underline),
+		Info:    color.New(color.FgHiWhite),
+	}
+}
+
+// ThemeByName returns the named theme. An empty name detects the terminal background.
+func ThemeByName(name string) (Theme, error) {
+	if name == "" {
+		name = detectTheme()
+	}
+
+	switch name {
+	case "dark":
+		return DarkTheme(), nil
+	case "light":
+		return LightTheme(), nil
+	case "mono":
+		return MonoTheme(), nil
+	case "high-contrast":
+		return HighContrastTheme(), nil
+	default:
+		return Theme{}, fmt.Errorf("unknown theme: %s (available: %s)", name, strings.Join(ThemeNames, ", "))
+	}
+}
+
+// detectTheme guesses the terminal background from COLORFGBG, set by many terminals
+// as "foreground;background", defaulting to dark
+func detectTheme() string {
+	fields := strings.Split(os.Getenv("COLORFGBG"), ";")
+	switch fields[len(fields)-1] {
+	case "7", "15":
+		return "light"
+	default:
+		return "dark"
+	}
+}
+
+// severity returns the color for a severity level
+func (t Theme) severity(severity string) *color.Color {
+	switch severity {
+	case "high":
+		return t.High
+	case "medium":
+		return t.Medium
+	default:
+		return t.Low
+	}
+}
+
+func (t Theme) colors() []*color.Color {
+	return []*color.Color{t.High, t.Medium, t.Low, t.Success, t.Header, t.Info}
+}
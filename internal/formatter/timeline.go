@@ -0,0 +1,68 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+)
+
+// timelineSummaryPoints is the number of timeline points listed in the console report
+const timelineSummaryPoints = 10
+
+func init() {
+	RegisterSection(Section{
+		Name:       "timeline",
+		ReportKeys: []string{"timeline"},
+		Console: func(tr *tracer.GasOptimizationTracer, ctx SectionContext) string {
+			if !ctx.Selected {
+				return ""
+			}
+			return FormatTimeline(tr.GetTimeline(), ctx.Theme)
+		},
+	})
+}
+
+// FormatTimelineCSV formats the gas timeline as CSV with a header row, one
+// "step,cumulative_gas,depth" line per point
+func FormatTimelineCSV(points []tracer.TimelinePoint) string {
+	var sb strings.Builder
+	sb.WriteString("step,cumulative_gas,depth\n")
+	for _, point := range points {
+		sb.WriteString(fmt.Sprintf("%d,%d,%d\n", point.Step, point.Gas, point.Depth))
+	}
+	return sb.String()
+}
+
+// FormatTimeline formats an evenly spaced sample of the gas timeline for the
+// console, ending with its last point
+func FormatTimeline(points []tracer.TimelinePoint, theme Theme) string {
+	var sb strings.Builder
+
+	sb.WriteString("\n")
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n"))
+	sb.WriteString(theme.Header.Sprint("                       GAS TIMELINE\n"))
+	sb.WriteString(theme.Header.Sprint("═══════════════════════════════════════════════════════════════\n\n"))
+
+	if len(points) == 0 {
+		sb.WriteString("No timeline recorded\n\n")
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("%10s %16s %6s\n", "STEP", "CUMULATIVE GAS", "DEPTH"))
+	stride := (len(points) + timelineSummaryPoints - 1) / timelineSummaryPoints
+	for i := 0; i < len(points); i += stride {
+		writeTimelinePoint(&sb, points[i], theme)
+	}
+	if (len(points)-1)%stride != 0 {
+		writeTimelinePoint(&sb, points[len(points)-1], theme)
+	}
+
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// writeTimelinePoint writes one row of the console timeline
+func writeTimelinePoint(sb *strings.Builder, point tracer.TimelinePoint, theme Theme) {
+	sb.WriteString(fmt.Sprintf("%10d %16s %6d\n", point.Step, formatGas(point.Gas, theme.Numbers, theme.Unit), point.Depth))
+}
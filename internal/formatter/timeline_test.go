@@ -0,0 +1,41 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+)
+
+func TestFormatTimelineCSV(t *testing.T) {
+	points := []tracer.TimelinePoint{
+		{Step: 0, Gas: 0, Depth: 1},
+		{Step: 4, Gas: 2109, Depth: 1},
+		{Step: 8, Gas: 2650, Depth: 2},
+	}
+
+	want := "step,cumulative_gas,depth\n0,0,1\n4,2109,1\n8,2650,2\n"
+	if got := FormatTimelineCSV(points); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestFormatTimelineSamplesPoints(t *testing.T) {
+	var points []tracer.TimelinePoint
+	for i := 0; i < 26; i++ {
+		points = append(points, tracer.TimelinePoint{Step: uint64(i * 4), Gas: uint64(i * 100), Depth: 1})
+	}
+
+	out := FormatTimeline(points, MonoTheme())
+	rows := 0
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasSuffix(line, " 1") {
+			rows++
+		}
+	}
+
+	// Every third point, plus the last one
+	if rows != 10 || !strings.Contains(out, "       100") {
+		t.Errorf("Expected 10 rows ending with step 100, got %d:\n%s", rows, out)
+	}
+}
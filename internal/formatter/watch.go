@@ -0,0 +1,34 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devlongs/evm-tracer/internal/analyzer"
+)
+
+// FormatWatchedTransaction formats a transaction traced by watch mode as a short entry
+func FormatWatchedTransaction(tx analyzer.WatchedTransaction, theme Theme) string {
+	var sb strings.Builder
+
+	sb.WriteString(theme.Header.Sprintf("📦 Block %d  %s", tx.Block, tx.Hash.Hex()))
+	if tx.Error != "" {
+		sb.WriteString(theme.High.Sprintf("  failed: %s\n", tx.Error))
+		return sb.String()
+	}
+	sb.WriteString(theme.Info.Sprintf("  %s gas, %d findings", formatGas(tx.GasUsed, theme.Numbers, theme.Unit), len(tx.Optimizations)))
+	if tx.Partial != "" {
+		sb.WriteString(theme.Medium.Sprint(" (partial)"))
+	}
+	sb.WriteString("\n")
+
+	for _, opt := range tx.Optimizations {
+		sb.WriteString(theme.severity(opt.Severity).Sprintf("   [%s] %s at %s", strings.ToUpper(opt.Severity), opt.Type, opt.Location))
+		sb.WriteString(fmt.Sprintf(": %s", opt.Description))
+		if opt.GasSavings > 0 {
+			sb.WriteString(fmt.Sprintf(" · 💰 %s%s", formatGas(opt.GasSavings, theme.Numbers, theme.Unit), confidenceLabel(opt)))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
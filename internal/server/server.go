@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/devlongs/evm-tracer/internal/analyzer"
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Backend traces transactions on behalf of the server
+type Backend interface {
+	// Trace replays the transaction against the node at rpcURL, or the
+	// backend's default node when rpcURL is empty
+	Trace(ctx context.Context, rpcURL string, txHash common.Hash) (*tracer.GasOptimizationTracer, error)
+}
+
+// Options configures the server
+type Options struct {
+	// Timeout bounds each trace request
+	Timeout time.Duration
+
+	// MaxConcurrent limits the number of traces running at once. Requests
+	// beyond the limit are rejected rather than queued.
+	MaxConcurrent int
+
+	// AllowRPCOverride lets requests name the RPC node to trace against
+	AllowRPCOverride bool
+}
+
+// TraceRequest is the body of a POST /trace request
+type TraceRequest struct {
+	TxHash string `json:"txHash"`
+	RPC    string `json:"rpc,omitempty"`
+}
+
+// ErrorResponse is the body returned when a request fails
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// Server serves trace reports over HTTP
+type Server struct {
+	backend Backend
+	opts    Options
+	slots   chan struct{}
+	mux     *http.ServeMux
+}
+
+// New creates a server that traces transactions with backend
+func New(backend Backend, opts Options) *Server {
+	if opts.MaxConcurrent <= 0 {
+		opts.MaxConcurrent = 1
+	}
+
+	s := &Server{
+		backend: backend,
+		opts:    opts,
+		slots:   make(chan struct{}, opts.MaxConcurrent),
+		mux:     http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/trace", s.handleTrace)
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleTrace traces the requested transaction and writes its JSON report
+func (s *Server) handleTrace(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "use POST")
+		return
+	}
+
+	var req TraceRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON body: "+err.Error())
+		return
+	}
+
+	hash, err := parseTxHash(req.TxHash)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	if req.RPC != "" && !s.opts.AllowRPCOverride {
+		writeError(w, http.StatusForbidden, "rpc_override_disabled", "this server does not accept an rpc override")
+		return
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	default:
+		writeError(w, http.StatusServiceUnavailable, "busy", "too many concurrent traces, retry later")
+		return
+	}
+
+	ctx := r.Context()
+	if s.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.Timeout)
+		defer cancel()
+	}
+
+	tr, err := s.backend.Trace(ctx, req.RPC, hash)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusGatewayTimeout, "timeout", err.Error())
+		return
+	case errors.Is(err, analyzer.ErrNotArchiveNode):
+		writeError(w, http.StatusBadGateway, "not_archive_node", err.Error())
+		return
+	case errors.Is(err, analyzer.ErrTransactionRejected):
+		writeError(w, http.StatusUnprocessableEntity, "transaction_rejected", err.Error())
+		return
+	case err != nil:
+		writeError(w, http.StatusBadGateway, "trace_failed", err.Error())
+		return
+	}
+
+	report, err := tr.GetReport()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "report_failed", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(report))
+}
+
+// parseTxHash validates a 0x-prefixed 32-byte transaction hash
+func parseTxHash(s string) (common.Hash, error) {
+	b, err := hexutil.Decode(s)
+	if err != nil || len(b) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("txHash must be a 0x-prefixed 32-byte hex string: %q", s)
+	}
+	return common.BytesToHash(b), nil
+}
+
+// writeError writes a structured JSON error response
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Code: code})
+}
+
+// AnalyzerBackend traces transactions with analyzers that keep one pooled
+// connection per RPC endpoint
+type AnalyzerBackend struct {
+	defaultRPC string
+	opts       analyzer.Options
+
+	mu        sync.Mutex
+	analyzers map[string]*analyzer.TransactionAnalyzer
+}
+
+// NewAnalyzerBackend creates a backend tracing against defaultRPC unless a
+// request names another endpoint
+func NewAnalyzerBackend(defaultRPC string, opts analyzer.Options) *AnalyzerBackend {
+	return &AnalyzerBackend{
+		defaultRPC: defaultRPC,
+		opts:       opts,
+		analyzers:  make(map[string]*analyzer.TransactionAnalyzer),
+	}
+}
+
+// Trace implements Backend
+func (b *AnalyzerBackend) Trace(ctx context.Context, rpcURL string, txHash common.Hash) (*tracer.GasOptimizationTracer, error) {
+	if rpcURL == "" {
+		rpcURL = b.defaultRPC
+	}
+
+	pooled, err := b.analyzer(rpcURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each request gets its own tracer on the shared connection
+	// Traces cut short by --max-steps are served as reports labeled partial
+	an := pooled.Clone()
+	if err := an.AnalyzeTransaction(ctx, txHash); err != nil && !reportable(err) {
+		return nil, err
+	}
+	return an.GetTracer(), nil
+}
+
+// reportable reports whether a trace that failed with err still has a report to serve
+func reportable(err error) bool {
+	return errors.Is(err, analyzer.ErrStepLimitExceeded)
+}
+
+// analyzer returns the pooled analyzer for rpcURL, connecting on first use
+func (b *AnalyzerBackend) analyzer(rpcURL string) (*analyzer.TransactionAnalyzer, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if an, ok := b.analyzers[rpcURL]; ok {
+		return an, nil
+	}
+
+	// Credentials are only sent to the default endpoint, never to one named by a request
+	opts := b.opts
+	if rpcURL != b.defaultRPC {
+		opts.Headers = nil
+	}
+
+	an, err := analyzer.NewTransactionAnalyzerWithOptions(rpcURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	b.analyzers[rpcURL] = an
+	return an, nil
+}
+
+// Close closes every pooled connection
+func (b *AnalyzerBackend) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for url, an := range b.analyzers {
+		an.Close()
+		delete(b.analyzers, url)
+	}
+}
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devlongs/evm-tracer/internal/analyzer"
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+)
+
+const testTxHash = "0x1111111111111111111111111111111111111111111111111111111111111111"
+
+// mockBackend traces fixed code in an in-memory EVM instead of replaying from a node
+type mockBackend struct {
+	block    chan struct{} // When set, Trace waits on it or the request context
+	rpc      string        // RPC URL of the last request
+	maxSteps uint64        // Step limit of the tracer, or zero
+	err      error         // Error returned by Trace, when set
+}
+
+func (m *mockBackend) Trace(ctx context.Context, rpcURL string, txHash common.Hash) (*tracer.GasOptimizationTracer, error) {
+	m.rpc = rpcURL
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.block != nil {
+		select {
+		case <-m.block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	tr := tracer.NewGasOptimizationTracer()
+	tr.SetMaxSteps(m.maxSteps)
+	code := []byte{
+		byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.STOP),
+	}
+	runtime.Execute(code, nil, &runtime.Config{GasLimit: 1000000, EVMConfig: vm.Config{Tracer: tr}})
+	if tr.Truncated() {
+		tr.SetPartial("step limit exceeded")
+	}
+	return tr, nil
+}
+
+func postTrace(t *testing.T, url, body string) (*http.Response, map[string]interface{}) {
+	t.Helper()
+
+	resp, err := http.Post(url+"/trace", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var decoded map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		t.Fatalf("Expected a JSON body, got error %v", err)
+	}
+	return resp, decoded
+}
+
+func TestTraceEndpoint(t *testing.T) {
+	srv := httptest.NewServer(New(&mockBackend{}, Options{Timeout: time.Second, MaxConcurrent: 2}))
+	defer srv.Close()
+
+	resp, report := postTrace(t, srv.URL, `{"txHash": "`+testTxHash+`"}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %v", resp.StatusCode, report)
+	}
+
+	if resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected JSON content type, got %s", resp.Header.Get("Content-Type"))
+	}
+
+	if report["total_gas_used"] == nil {
+		t.Error("Expected total_gas_used in the report")
+	}
+
+	optimizations, ok := report["optimizations"].([]interface{})
+	if !ok || len(optimizations) == 0 {
+		t.Errorf("Expected optimizations in the report, got %v", report["optimizations"])
+	}
+}
+
+func TestTraceEndpointTruncated(t *testing.T) {
+	srv := httptest.NewServer(New(&mockBackend{maxSteps: 4}, Options{Timeout: time.Second}))
+	defer srv.Close()
+
+	resp, report := postTrace(t, srv.URL, `{"txHash": "`+testTxHash+`"}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for a truncated trace, got %d: %v", resp.StatusCode, report)
+	}
+	if report["truncated"] != true {
+		t.Errorf("Expected the report to be marked truncated, got %v", report["truncated"])
+	}
+	if report["partial"] != "step limit exceeded" {
+		t.Errorf("Expected the report to be labeled partial, got %v", report["partial"])
+	}
+}
+
+func TestReportable(t *testing.T) {
+	if !reportable(fmt.Errorf("%w: aborted after 4 steps", analyzer.ErrStepLimitExceeded)) {
+		t.Error("Expected a step-limited trace to be reportable")
+	}
+	if reportable(fmt.Errorf("%w: nonce too high", analyzer.ErrTransactionRejected)) {
+		t.Error("Did not expect a rejected transaction to be reportable")
+	}
+	if reportable(fmt.Errorf("%w: deadline exceeded", analyzer.ErrExecutionTimeout)) {
+		t.Error("Did not expect a timed-out trace to be reportable")
+	}
+}
+
+func TestTraceEndpointErrors(t *testing.T) {
+	srv := httptest.NewServer(New(&mockBackend{}, Options{Timeout: time.Second}))
+	defer srv.Close()
+
+	tests := []struct {
+		body   string
+		status int
+		code   string
+	}{
+		{`not json`, http.StatusBadRequest, "invalid_request"},
+		{`{"txHash": "0x1234"}`, http.StatusBadRequest, "invalid_request"},
+		{`{"txHash": "` + testTxHash + `", "rpc": "http://other:8545"}`, http.StatusForbidden, "rpc_override_disabled"},
+	}
+
+	for _, tt := range tests {
+		resp, body := postTrace(t, srv.URL, tt.body)
+		if resp.StatusCode != tt.status {
+			t.Errorf("Expected status %d for %s, got %d", tt.status, tt.body, resp.StatusCode)
+		}
+		if body["code"] != tt.code || body["error"] == "" {
+			t.Errorf("Expected error code %s for %s, got %v", tt.code, tt.body, body)
+		}
+	}
+
+	resp, err := http.Get(srv.URL + "/trace")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for GET, got %d", resp.StatusCode)
+	}
+}
+
+func TestTraceEndpointRejected(t *testing.T) {
+	backend := &mockBackend{err: fmt.Errorf("%w: nonce too high", analyzer.ErrTransactionRejected)}
+	srv := httptest.NewServer(New(backend, Options{}))
+	defer srv.Close()
+
+	resp, body := postTrace(t, srv.URL, `{"txHash": "`+testTxHash+`"}`)
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got %d", resp.StatusCode)
+	}
+	if body["code"] != "transaction_rejected" {
+		t.Errorf("Expected error code transaction_rejected, got %v", body)
+	}
+}
+
+func TestTraceEndpointRPCOverride(t *testing.T) {
+	backend := &mockBackend{}
+	srv := httptest.NewServer(New(backend, Options{AllowRPCOverride: true}))
+	defer srv.Close()
+
+	resp, _ := postTrace(t, srv.URL, `{"txHash": "`+testTxHash+`", "rpc": "http://other:8545"}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	if backend.rpc != "http://other:8545" {
+		t.Errorf("Expected the override to reach the backend, got %q", backend.rpc)
+	}
+}
+
+func TestTraceEndpointLimits(t *testing.T) {
+	backend := &mockBackend{block: make(chan struct{})}
+	srv := httptest.NewServer(New(backend, Options{Timeout: 200 * time.Millisecond, MaxConcurrent: 1}))
+	defer srv.Close()
+
+	// The first request holds the only slot until it times out
+	done := make(chan *http.Response)
+	go func() {
+		resp, _ := postTrace(t, srv.URL, `{"txHash": "`+testTxHash+`"}`)
+		done <- resp
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	resp, body := postTrace(t, srv.URL, `{"txHash": "`+testTxHash+`"}`)
+	if resp.StatusCode != http.StatusServiceUnavailable || body["code"] != "busy" {
+		t.Errorf("Expected 503 busy while at the concurrency limit, got %d %v", resp.StatusCode, body)
+	}
+
+	if first := <-done; first.StatusCode != http.StatusGatewayTimeout {
+		t.Errorf("Expected 504 for the timed out request, got %d", first.StatusCode)
+	}
+}
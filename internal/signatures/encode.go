@@ -0,0 +1,177 @@
+package signatures
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ErrMethodNotFound is returned by EncodeCall when no ABI has the called method
+var ErrMethodNotFound = errors.New("method not found")
+
+// EncodeCall ABI-encodes a call written as name(arg1,arg2,...), such as
+// transfer(0x1234...,100), with the first of the ABIs declaring a method of
+// that name and number of arguments. Integers are decimal or 0x-prefixed hex,
+// bytes are hex, and arrays are written [a,b,...]. Tuple arguments are not
+// supported.
+func EncodeCall(abis []abi.ABI, call string) ([]byte, error) {
+	call = strings.TrimSpace(call)
+	open := strings.Index(call, "(")
+	if open <= 0 || !strings.HasSuffix(call, ")") {
+		return nil, fmt.Errorf("invalid call %q: expected name(arguments)", call)
+	}
+	name := strings.TrimSpace(call[:open])
+	args, err := splitArguments(call[open+1 : len(call)-1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid call %q: %w", call, err)
+	}
+
+	for _, parsed := range abis {
+		for _, method := range parsed.Methods {
+			if method.RawName != name || len(method.Inputs) != len(args) {
+				continue
+			}
+			values := make([]interface{}, len(args))
+			for i, arg := range args {
+				value, err := parseArgument(method.Inputs[i].Type, arg)
+				if err != nil {
+					return nil, fmt.Errorf("invalid argument %d of %s: %w", i+1, method.Sig, err)
+				}
+				values[i] = value.Interface()
+			}
+			return parsed.Pack(method.Name, values...)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s with %d arguments", ErrMethodNotFound, name, len(args))
+}
+
+// splitArguments splits a comma-separated argument list, keeping the commas
+// inside brackets and quotes
+func splitArguments(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+
+	var args []string
+	depth, start, quoted := 0, 0, false
+	for i, c := range list {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth < 0 {
+				return nil, errors.New("unbalanced brackets")
+			}
+		case c == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(list[start:i]))
+			start = i + 1
+		}
+	}
+	if depth != 0 || quoted {
+		return nil, errors.New("unbalanced brackets or quotes")
+	}
+	return append(args, strings.TrimSpace(list[start:])), nil
+}
+
+// parseArgument converts a written argument to the Go value abi.Pack expects for typ
+func parseArgument(typ abi.Type, arg string) (reflect.Value, error) {
+	value := reflect.New(typ.GetType()).Elem()
+
+	switch typ.T {
+	case abi.AddressTy:
+		if !common.IsHexAddress(arg) {
+			return value, fmt.Errorf("invalid address: %s", arg)
+		}
+		value.Set(reflect.ValueOf(common.HexToAddress(arg)))
+
+	case abi.UintTy, abi.IntTy:
+		n, ok := new(big.Int).SetString(arg, 0)
+		if !ok {
+			return value, fmt.Errorf("invalid integer: %s", arg)
+		}
+		if !inRange(n, typ) {
+			return value, fmt.Errorf("%s out of range for %s", arg, typ)
+		}
+		switch value.Kind() {
+		case reflect.Ptr:
+			value.Set(reflect.ValueOf(n))
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			value.SetUint(n.Uint64())
+		default:
+			value.SetInt(n.Int64())
+		}
+
+	case abi.BoolTy:
+		b, err := strconv.ParseBool(arg)
+		if err != nil {
+			return value, fmt.Errorf("invalid bool: %s", arg)
+		}
+		value.SetBool(b)
+
+	case abi.StringTy:
+		if unquoted, err := strconv.Unquote(arg); err == nil {
+			arg = unquoted
+		}
+		value.SetString(arg)
+
+	case abi.BytesTy, abi.FixedBytesTy:
+		data, err := hexutil.Decode(arg)
+		if err != nil {
+			return value, fmt.Errorf("invalid bytes %s: %w", arg, err)
+		}
+		if typ.T == abi.BytesTy {
+			value.SetBytes(data)
+			break
+		}
+		if len(data) != typ.Size {
+			return value, fmt.Errorf("expected %d bytes for %s, got %d", typ.Size, typ, len(data))
+		}
+		reflect.Copy(value, reflect.ValueOf(data))
+
+	case abi.SliceTy, abi.ArrayTy:
+		if !strings.HasPrefix(arg, "[") || !strings.HasSuffix(arg, "]") {
+			return value, fmt.Errorf("expected an array [...] for %s, got %s", typ, arg)
+		}
+		elems, err := splitArguments(arg[1 : len(arg)-1])
+		if err != nil {
+			return value, err
+		}
+		if typ.T == abi.ArrayTy && len(elems) != typ.Size {
+			return value, fmt.Errorf("expected %d elements for %s, got %d", typ.Size, typ, len(elems))
+		}
+		if typ.T == abi.SliceTy {
+			value.Set(reflect.MakeSlice(value.Type(), len(elems), len(elems)))
+		}
+		for i, elem := range elems {
+			v, err := parseArgument(*typ.Elem, elem)
+			if err != nil {
+				return value, err
+			}
+			value.Index(i).Set(v)
+		}
+
+	default:
+		return value, fmt.Errorf("unsupported argument type: %s", typ)
+	}
+	return value, nil
+}
+
+// inRange reports whether n fits the integer type typ
+func inRange(n *big.Int, typ abi.Type) bool {
+	if typ.T == abi.UintTy {
+		return n.Sign() >= 0 && n.BitLen() <= typ.Size
+	}
+	limit := new(big.Int).Lsh(common.Big1, uint(typ.Size-1))
+	return n.Cmp(new(big.Int).Neg(limit)) >= 0 && n.Cmp(limit) < 0
+}
@@ -0,0 +1,65 @@
+package signatures
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const batchABI = `[{"type":"function","name":"batch","inputs":[{"name":"ids","type":"uint8[]"},{"name":"delta","type":"int8"},{"name":"tag","type":"bytes4"},{"name":"note","type":"string"},{"name":"flag","type":"bool"}],"outputs":[]}]`
+
+func TestEncodeCall(t *testing.T) {
+	erc20, err := abi.JSON(strings.NewReader(erc20ABI))
+	if err != nil {
+		t.Fatalf("failed to parse ABI: %v", err)
+	}
+	to := common.HexToAddress("0x00000000000000000000000000000000000000b1")
+
+	data, err := EncodeCall([]abi.ABI{erc20}, "transfer("+to.Hex()+", 0x64)")
+	if err != nil {
+		t.Fatalf("EncodeCall() error: %v", err)
+	}
+	expected, _ := erc20.Pack("transfer", to, big.NewInt(100))
+	if !bytes.Equal(data, expected) {
+		t.Errorf("Expected %x, got %x", expected, data)
+	}
+
+	batch, err := abi.JSON(strings.NewReader(batchABI))
+	if err != nil {
+		t.Fatalf("failed to parse ABI: %v", err)
+	}
+	data, err = EncodeCall([]abi.ABI{erc20, batch}, `batch([1, 2], -128, 0xdeadbeef, "a, b", true)`)
+	if err != nil {
+		t.Fatalf("EncodeCall() error: %v", err)
+	}
+	expected, _ = batch.Pack("batch", []uint8{1, 2}, int8(-128), [4]byte{0xde, 0xad, 0xbe, 0xef}, "a, b", true)
+	if !bytes.Equal(data, expected) {
+		t.Errorf("Expected %x, got %x", expected, data)
+	}
+}
+
+func TestEncodeCallErrors(t *testing.T) {
+	batch, _ := abi.JSON(strings.NewReader(batchABI))
+	abis := []abi.ABI{batch}
+
+	if _, err := EncodeCall(abis, "missing()"); !errors.Is(err, ErrMethodNotFound) {
+		t.Errorf("Expected ErrMethodNotFound, got %v", err)
+	}
+	for _, call := range []string{
+		`batch([256], 0, 0xdeadbeef, "", true)`,
+		`batch([1], 128, 0xdeadbeef, "", true)`,
+		`batch([1], 0, 0xdead, "", true)`,
+		`batch([1], 0, 0xdeadbeef, "", maybe)`,
+		`batch([1, 0, 0xdeadbeef, "", true)`,
+		`batch`,
+	} {
+		if _, err := EncodeCall(abis, call); err == nil || errors.Is(err, ErrMethodNotFound) {
+			t.Errorf("Expected an invalid argument error for %s, got %v", call, err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package signatures
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// DefaultDirectoryURL is the 4byte signature directory queried in remote mode
+const DefaultDirectoryURL = "https://www.4byte.directory/api/v1/signatures/"
+
+// Resolution modes selectable on the CLI
+const (
+	ModeLocal  = "local"  // Only supplied ABIs
+	ModeRemote = "remote" // Supplied ABIs, then the signature directory
+)
+
+// requestTimeout bounds a single signature directory lookup
+const requestTimeout = 5 * time.Second
+
+// New builds a resolver for the given mode from the ABI files. When offline is set
+// the signature directory is never queried, whatever the mode.
+func New(mode string, abiFiles []string, offline bool, directoryURL string) (tracer.SignatureResolver, error) {
+	if mode != ModeLocal && mode != ModeRemote {
+		return nil, fmt.Errorf("unknown signature mode: %s", mode)
+	}
+
+	local := NewABIResolver()
+	for _, path := range abiFiles {
+		if err := local.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if mode == ModeLocal || offline {
+		return local, nil
+	}
+	return Chain{local, NewRemoteResolver(directoryURL)}, nil
+}
+
+// ABIResolver resolves selectors from locally supplied contract ABIs
+type ABIResolver struct {
+	methods map[[4]byte]string
+}
+
+// NewABIResolver creates an empty ABI resolver
+func NewABIResolver() *ABIResolver {
+	return &ABIResolver{methods: make(map[[4]byte]string)}
+}
+
+// LoadFile adds the methods of an ABI file. Both plain ABI arrays and
+// Hardhat/Foundry artifacts with an "abi" field are accepted.
+func (r *ABIResolver) LoadFile(path string) error {
+	parsed, err := ReadABI(path)
+	if err != nil {
+		return err
+	}
+
+	r.Add(parsed)
+	return nil
+}
+
+// ReadABI parses an ABI file, either a plain ABI array or a compiler artifact
+func ReadABI(path string) (abi.ABI, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return abi.ABI{}, fmt.Errorf("failed to read ABI: %w", err)
+	}
+
+	// Unwrap compiler artifacts
+	var artifact struct {
+		ABI json.RawMessage `json:"abi"`
+	}
+	if err := json.Unmarshal(data, &artifact); err == nil && len(artifact.ABI) > 0 {
+		data = artifact.ABI
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(string(data)))
+	if err != nil {
+		return abi.ABI{}, fmt.Errorf("failed to parse ABI %s: %w", path, err)
+	}
+	return parsed, nil
+}
+
+// Add registers the methods of a parsed ABI
+func (r *ABIResolver) Add(parsed abi.ABI) {
+	for _, method := range parsed.Methods {
+		var selector [4]byte
+		copy(selector[:], method.ID)
+		r.methods[selector] = method.Sig
+	}
+}
+
+// ResolveSelector implements tracer.SignatureResolver. ABI signatures are verified.
+func (r *ABIResolver) ResolveSelector(selector [4]byte) (string, bool, bool) {
+	signature, ok := r.methods[selector]
+	return signature, true, ok
+}
+
+// RemoteResolver guesses signatures from a 4byte signature directory, caching
+// every lookup including misses
+type RemoteResolver struct {
+	baseURL string
+	client  *http.Client
+
+	mu    sync.Mutex
+	cache map[[4]byte]string
+}
+
+// NewRemoteResolver creates a resolver for the directory at baseURL
+func NewRemoteResolver(baseURL string) *RemoteResolver {
+	return &RemoteResolver{
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: requestTimeout},
+		cache:   make(map[[4]byte]string),
+	}
+}
+
+// ResolveSelector implements tracer.SignatureResolver. Directory signatures are guesses,
+// since unrelated functions can share a selector.
+func (r *RemoteResolver) ResolveSelector(selector [4]byte) (string, bool, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	signature, cached := r.cache[selector]
+	if !cached {
+		// Lookup failures are cached as misses so a flaky directory is queried once
+		signature, _ = r.lookup(selector)
+		r.cache[selector] = signature
+	}
+	return signature, false, signature != ""
+}
+
+// lookup queries the directory for the oldest signature registered for a selector.
+// The earliest submission is usually the canonical one; later ones are often collisions.
+func (r *RemoteResolver) lookup(selector [4]byte) (string, error) {
+	query := url.Values{}
+	query.Set("hex_signature", hexutil.Encode(selector[:]))
+	query.Set("ordering", "created_at")
+
+	resp, err := r.client.Get(r.baseURL + "?" + query.Encode())
+	if err != nil {
+		return "", fmt.Errorf("signature lookup failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("signature lookup failed: %s", resp.Status)
+	}
+
+	var result struct {
+		Results []struct {
+			TextSignature string `json:"text_signature"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode signature lookup: %w", err)
+	}
+
+	if len(result.Results) == 0 {
+		return "", nil
+	}
+	return result.Results[0].TextSignature, nil
+}
+
+// Chain tries each resolver in order, so verified ABI signatures take precedence over guesses
+type Chain []tracer.SignatureResolver
+
+// ResolveSelector implements tracer.SignatureResolver
+func (c Chain) ResolveSelector(selector [4]byte) (string, bool, bool) {
+	for _, resolver := range c {
+		if signature, verified, ok := resolver.ResolveSelector(selector); ok {
+			return signature, verified, true
+		}
+	}
+	return "", false, false
+}
@@ -0,0 +1,131 @@
+package signatures
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+var transferSelector = [4]byte{0xa9, 0x05, 0x9c, 0xbb}
+
+const erc20ABI = `[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]}]`
+
+// newDirectoryServer serves a 4byte directory that knows only the transfer selector
+func newDirectoryServer(t *testing.T, hits *int32) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("hex_signature") == "0xa9059cbb" {
+			w.Write([]byte(`{"results":[{"text_signature":"transfer(address,uint256)"},{"text_signature":"many_msg_babbage(bytes1)"}]}`))
+			return
+		}
+		w.Write([]byte(`{"results":[]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func writeABI(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "token.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write ABI: %v", err)
+	}
+	return path
+}
+
+func TestRemoteResolver(t *testing.T) {
+	var hits int32
+	server := newDirectoryServer(t, &hits)
+	resolver := NewRemoteResolver(server.URL)
+
+	signature, verified, ok := resolver.ResolveSelector(transferSelector)
+	if !ok {
+		t.Fatal("Expected the directory to resolve the transfer selector")
+	}
+
+	if signature != "transfer(address,uint256)" {
+		t.Errorf("Expected the oldest signature, got '%s'", signature)
+	}
+
+	if verified {
+		t.Error("Expected directory signatures to be marked as guesses")
+	}
+
+	// Repeated lookups, including misses, are served from the cache
+	resolver.ResolveSelector(transferSelector)
+	if _, _, ok := resolver.ResolveSelector([4]byte{1, 2, 3, 4}); ok {
+		t.Error("Expected an unknown selector not to resolve")
+	}
+	resolver.ResolveSelector([4]byte{1, 2, 3, 4})
+
+	if hits != 2 {
+		t.Errorf("Expected 2 directory requests, got %d", hits)
+	}
+}
+
+func TestABIResolverArtifact(t *testing.T) {
+	resolver := NewABIResolver()
+	if err := resolver.LoadFile(writeABI(t, `{"contractName":"Token","abi":`+erc20ABI+`}`)); err != nil {
+		t.Fatalf("LoadFile() error: %v", err)
+	}
+
+	signature, verified, ok := resolver.ResolveSelector(transferSelector)
+	if !ok || !verified || signature != "transfer(address,uint256)" {
+		t.Errorf("Expected verified transfer(address,uint256), got '%s' (verified %v, ok %v)", signature, verified, ok)
+	}
+}
+
+func TestNewPrefersABI(t *testing.T) {
+	var hits int32
+	server := newDirectoryServer(t, &hits)
+
+	resolver, err := New(ModeRemote, []string{writeABI(t, erc20ABI)}, false, server.URL)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	if _, verified, _ := resolver.ResolveSelector(transferSelector); !verified {
+		t.Error("Expected the ABI signature to take precedence over the directory")
+	}
+
+	if hits != 0 {
+		t.Errorf("Expected no directory requests for an ABI match, got %d", hits)
+	}
+
+	if _, _, ok := resolver.ResolveSelector([4]byte{1, 2, 3, 4}); ok {
+		t.Error("Expected an unknown selector not to resolve")
+	}
+
+	if hits != 1 {
+		t.Errorf("Expected the directory to be queried for an ABI miss, got %d requests", hits)
+	}
+}
+
+func TestNewOffline(t *testing.T) {
+	var hits int32
+	server := newDirectoryServer(t, &hits)
+
+	resolver, err := New(ModeRemote, nil, true, server.URL)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	if _, _, ok := resolver.ResolveSelector(transferSelector); ok {
+		t.Error("Expected no resolution without ABIs in offline mode")
+	}
+
+	if hits != 0 {
+		t.Errorf("Expected offline mode to make no requests, got %d", hits)
+	}
+
+	if _, err := New("bogus", nil, false, server.URL); err == nil {
+		t.Error("Expected an unknown mode to be rejected")
+	}
+}
@@ -0,0 +1,244 @@
+package tracer
+
+import (
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ColdAccess is the first access to an address or storage slot that was not yet warm
+type ColdAccess struct {
+	PC      uint64
+	Op      string
+	Address common.Address
+	Slot    common.Hash
+	IsSlot  bool // Whether the access was to a storage slot of Address
+	Depth   int
+}
+
+// seedAccessList mirrors the EIP-2929 addresses warmed before execution starts.
+// Entries from the transaction's own access list are not visible to the tracer.
+func (t *GasOptimizationTracer) seedAccessList(env *vm.EVM, rules params.Rules, from, to common.Address) {
+	t.accessListActive = rules.IsBerlin
+	if !t.accessListActive {
+		return
+	}
+
+	t.warmAddresses[from] = true
+	t.warmAddresses[to] = true
+	for _, addr := range vm.ActivePrecompiles(rules) {
+		t.warmAddresses[addr] = true
+	}
+	if rules.IsShanghai {
+		t.warmAddresses[env.Context.Coinbase] = true
+	}
+}
+
+// trackAccess records cold address and storage slot accesses.
+// The interpreter warms an access before the tracer sees it, so storage and
+// fixed-cost account accesses are classified by their EIP-2929 charge, while
+// calls rely on the mirrored warm set.
+func (t *GasOptimizationTracer) trackAccess(pc uint64, op vm.OpCode, cost uint64, depth int, scope *vm.ScopeContext) {
+	if !t.accessListActive {
+		return
+	}
+
+	switch op {
+	case vm.SLOAD:
+		t.recordSlotAccess(pc, op, depth, scope, cost == params.ColdSloadCostEIP2929)
+
+	case vm.SSTORE:
+		cold := cost == params.ColdSloadCostEIP2929+params.WarmStorageReadCostEIP2929 ||
+			cost == params.ColdSloadCostEIP2929+params.SstoreSetGasEIP2200 ||
+			cost == params.SstoreResetGasEIP2200
+		t.recordSlotAccess(pc, op, depth, scope, cold)
+
+	case vm.BALANCE, vm.EXTCODESIZE, vm.EXTCODEHASH:
+		addr := common.Address(scope.Stack.Back(0).Bytes20())
+		t.recordAddressAccess(pc, op, depth, addr, cost == params.ColdAccountAccessCostEIP2929)
+
+	case vm.EXTCODECOPY, vm.SELFDESTRUCT:
+		addr := common.Address(scope.Stack.Back(0).Bytes20())
+		t.recordAddressAccess(pc, op, depth, addr, !t.warmAddresses[addr])
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		addr := common.Address(scope.Stack.Back(1).Bytes20())
+		t.recordAddressAccess(pc, op, depth, addr, !t.warmAddresses[addr])
+	}
+}
+
+// warmEntry is an addition to the warm address or storage slot set
+type warmEntry struct {
+	addr   common.Address
+	slot   common.Hash
+	isSlot bool
+}
+
+// warmAddress adds addr to the warm set, reporting whether it was cold
+func (t *GasOptimizationTracer) warmAddress(addr common.Address) bool {
+	if t.warmAddresses[addr] {
+		return false
+	}
+	t.warmAddresses[addr] = true
+	t.warmJournal = append(t.warmJournal, warmEntry{addr: addr})
+	return true
+}
+
+// warmSlot adds a storage slot of addr to the warm set, reporting whether it was cold
+func (t *GasOptimizationTracer) warmSlot(addr common.Address, slot common.Hash) bool {
+	slots, ok := t.warmSlots[addr]
+	if !ok {
+		slots = make(map[common.Hash]bool)
+		t.warmSlots[addr] = slots
+	}
+	if slots[slot] {
+		return false
+	}
+	slots[slot] = true
+	t.warmJournal = append(t.warmJournal, warmEntry{addr: addr, slot: slot, isSlot: true})
+	return true
+}
+
+// revertWarm undoes the additions to the warm sets made since the journal had length mark
+func (t *GasOptimizationTracer) revertWarm(mark int) {
+	for _, entry := range t.warmJournal[mark:] {
+		if entry.isSlot {
+			delete(t.warmSlots[entry.addr], entry.slot)
+		} else {
+			delete(t.warmAddresses, entry.addr)
+		}
+	}
+	t.warmJournal = t.warmJournal[:mark]
+}
+
+func (t *GasOptimizationTracer) recordAddressAccess(pc uint64, op vm.OpCode, depth int, addr common.Address, cold bool) {
+	if !t.warmAddress(addr) {
+		return
+	}
+
+	if cold {
+		t.ColdAccesses = append(t.ColdAccesses, ColdAccess{PC: pc, Op: op.String(), Address: addr, Depth: depth})
+	}
+}
+
+func (t *GasOptimizationTracer) recordSlotAccess(pc uint64, op vm.OpCode, depth int, scope *vm.ScopeContext, cold bool) {
+	addr := contractAddress(scope)
+	slot := common.Hash(scope.Stack.Back(0).Bytes32())
+	if !t.warmSlot(addr, slot) {
+		return
+	}
+
+	if cold {
+		t.ColdAccesses = append(t.ColdAccesses, ColdAccess{PC: pc, Op: op.String(), Address: addr, Slot: slot, IsSlot: true, Depth: depth})
+	}
+}
+
+// accessListTuple accumulates the cold accesses belonging to one address
+type accessListTuple struct {
+	address common.Address
+	cold    bool // Whether the address itself was accessed cold
+	slots   []common.Hash
+}
+
+// net returns the gas saved by listing this tuple in an access list
+func (a accessListTuple) net(model GasModel) int64 {
+	savings := int64(len(a.slots)) * model.accessListSlotSavings()
+	if a.cold {
+		return savings + model.accessListAddressSavings()
+	}
+	// A warm address still pays the listing cost to host its slots
+	return savings - int64(params.TxAccessListAddressGas)
+}
+
+// accessListTuples groups cold accesses by address in order of first access.
+// Accesses made cold again by a revert are listed once.
+func (t *GasOptimizationTracer) accessListTuples() []accessListTuple {
+	var tuples []accessListTuple
+	index := make(map[common.Address]int)
+	listed := make(map[storageSlot]bool)
+
+	for _, access := range t.ColdAccesses {
+		i, ok := index[access.Address]
+		if !ok {
+			i = len(tuples)
+			index[access.Address] = i
+			tuples = append(tuples, accessListTuple{address: access.Address})
+		}
+		if access.IsSlot {
+			slot := storageSlot{contract: access.Address, key: access.Slot}
+			if listed[slot] {
+				continue
+			}
+			listed[slot] = true
+			tuples[i].slots = append(tuples[i].slots, access.Slot)
+		} else {
+			tuples[i].cold = true
+		}
+	}
+	return tuples
+}
+
+// suggestedAccessList returns the access list entries that save gas, with their net savings
+func (t *GasOptimizationTracer) suggestedAccessList() (types.AccessList, int64) {
+	list := types.AccessList{}
+	saved := int64(0)
+
+	for _, tuple := range t.accessListTuples() {
+		net := tuple.net(t.gasModel)
+		if net <= 0 {
+			continue
+		}
+		list = append(list, types.AccessTuple{Address: tuple.address, StorageKeys: append([]common.Hash{}, tuple.slots...)})
+		saved += net
+	}
+	return list, saved
+}
+
+// SuggestedAccessList returns an EIP-2930 access list pre-warming the cold
+// addresses and slots whose listing saves gas
+func (t *GasOptimizationTracer) SuggestedAccessList() types.AccessList {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	list, _ := t.suggestedAccessList()
+	return list
+}
+
+// AccessListJSON returns the suggested access list in the JSON form accepted by transaction tooling
+func (t *GasOptimizationTracer) AccessListJSON() (string, error) {
+	data, err := json.MarshalIndent(t.SuggestedAccessList(), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// analyzeAccessList suggests an access list when pre-warming cold accesses saves gas
+func (t *GasOptimizationTracer) analyzeAccessList() {
+	list, saved := t.suggestedAccessList()
+	if len(list) == 0 {
+		return
+	}
+
+	slots := 0
+	for _, tuple := range list {
+		slots += len(tuple.StorageKeys)
+	}
+
+	t.Optimizations = append(t.Optimizations, Optimization{
+		Type:        "missing_access_list",
+		Severity:    "low",
+		Description: "Cold storage and account accesses could be pre-warmed with an EIP-2930 access list",
+		Location:    ScopeLocation(ScopeTransaction),
+		GasSavings:  uint64(saved),
+		Confidence:  "exact",
+		Details: map[string]interface{}{
+			"addresses":    len(list),
+			"storage_keys": slots,
+			"cold_access":  len(t.ColdAccesses),
+		},
+	})
+}
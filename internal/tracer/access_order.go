@@ -0,0 +1,149 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// maxAccessOrder is the number of accesses kept in a slot's recorded order
+const maxAccessOrder = 64
+
+// SlotAccessOrder is the order in which a storage slot was read and written
+type SlotAccessOrder struct {
+	Contract    common.Address
+	Key         common.Hash
+	FirstAccess string // "read" or "write"
+	Order       string // R or W per access in execution order, ending in "…" when truncated
+	Reads       int
+	Writes      int
+}
+
+// slotAccesses follows the reads and writes of a storage slot
+type slotAccesses struct {
+	order  []byte
+	reads  int
+	writes int
+
+	// The first access, when it is a read
+	readPC     uint64
+	readCost   uint64
+	readUnused bool   // Whether the read value was discarded by the next instruction
+	writePC    uint64 // PC of the first write after the read
+}
+
+// pendingRead is a first SLOAD of a slot whose result is consumed by the next step
+type pendingRead struct {
+	slot  *slotAccesses
+	depth int
+}
+
+// trackAccessOrder records the order of storage reads and writes per slot, and
+// whether the value of a slot's first read is discarded straight away
+func (t *GasOptimizationTracer) trackAccessOrder(pc uint64, op vm.OpCode, cost uint64, depth int, scope *vm.ScopeContext) {
+	if read := t.pendingRead; read != nil {
+		t.pendingRead = nil
+		read.slot.readUnused = op == vm.POP && depth == read.depth
+	}
+
+	var access byte
+	switch op {
+	case vm.SLOAD:
+		access = 'R'
+	case vm.SSTORE:
+		access = 'W'
+	default:
+		return
+	}
+	if len(scope.Stack.Data()) == 0 {
+		return
+	}
+
+	slot := storageSlot{contract: contractAddress(scope), key: common.Hash(scope.Stack.Back(0).Bytes32())}
+	accesses, ok := t.slotAccesses[slot]
+	if !ok {
+		accesses = &slotAccesses{}
+		t.slotAccesses[slot] = accesses
+		t.slotAccessOrder = append(t.slotAccessOrder, slot)
+
+		if access == 'R' {
+			accesses.readPC = pc
+			accesses.readCost = cost
+			t.pendingRead = &pendingRead{slot: accesses, depth: depth}
+		}
+	}
+
+	if len(accesses.order) <= maxAccessOrder {
+		accesses.order = append(accesses.order, access)
+	}
+	if access == 'R' {
+		accesses.reads++
+		return
+	}
+	if accesses.writes == 0 {
+		accesses.writePC = pc
+	}
+	accesses.writes++
+}
+
+// analyzeAccessOrder records each slot's access order and flags slots whose first
+// read is discarded and which are then overwritten, so the read can be removed.
+// The cold access surcharge of a cold read moves to the write in that case; a
+// read of a slot already warm, e.g. from the access list, is saved whole.
+func (t *GasOptimizationTracer) analyzeAccessOrder() {
+	t.SlotAccessOrders = t.SlotAccessOrders[:0]
+
+	for _, slot := range t.slotAccessOrder {
+		accesses := t.slotAccesses[slot]
+
+		order := string(accesses.order)
+		if len(order) > maxAccessOrder {
+			order = order[:maxAccessOrder] + "…"
+		}
+		first := "write"
+		if accesses.order[0] == 'R' {
+			first = "read"
+		}
+		t.SlotAccessOrders = append(t.SlotAccessOrders, SlotAccessOrder{
+			Contract:    slot.contract,
+			Key:         slot.key,
+			FirstAccess: first,
+			Order:       order,
+			Reads:       accesses.reads,
+			Writes:      accesses.writes,
+		})
+
+		if first != "read" || !accesses.readUnused || accesses.writes == 0 {
+			continue
+		}
+
+		savings := accesses.readCost
+		if t.accessListActive && savings >= t.gasModel.ColdSloadGas {
+			savings -= t.gasModel.ColdSloadGas
+		}
+		t.Optimizations = append(t.Optimizations, Optimization{
+			Type:        "unused_read_before_write",
+			Severity:    "low",
+			Description: "Storage slot read and then overwritten without using the read value - the SLOAD can be removed",
+			Location:    ContractPCLocation(slot.contract, accesses.readPC),
+			GasSavings:  savings,
+			Confidence:  "estimated",
+			Details: map[string]interface{}{
+				"contract":     slot.contract.Hex(),
+				"storage_key":  slot.key.Hex(),
+				"read_gas":     accesses.readCost,
+				"write_pc":     formatPC(accesses.writePC),
+				"access_order": order,
+			},
+		})
+	}
+}
+
+// GetSlotAccessOrders returns a copy of the read/write order of each storage slot
+func (t *GasOptimizationTracer) GetSlotAccessOrders() []SlotAccessOrder {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	orders := make([]SlotAccessOrder, len(t.SlotAccessOrders))
+	copy(orders, t.SlotAccessOrders)
+	return orders
+}
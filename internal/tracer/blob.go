@@ -0,0 +1,108 @@
+package tracer
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// BlobAnalysis summarizes the EIP-4844 blobs carried by a type-3 transaction.
+// Blob gas is priced by its own fee market and is not part of the execution gas.
+type BlobAnalysis struct {
+	Blobs       int
+	BlobGas     uint64   // Blob gas used (131072 per blob)
+	BlobBaseFee *big.Int // Blob base fee of the block in wei, nil when the block predates Cancun
+	BlobFeeCap  *big.Int // Maximum blob fee per gas the sender agreed to pay
+	Cost        *big.Int // Blob gas cost at the blob base fee in wei, nil without a blob base fee
+	Referenced  []bool   // Whether execution read each blob's versioned hash with BLOBHASH
+}
+
+// analyzeBlobs describes the blobs of the transaction in env, or returns nil when it carries none
+func analyzeBlobs(env *vm.EVM) *BlobAnalysis {
+	if env == nil || len(env.TxContext.BlobHashes) == 0 {
+		return nil
+	}
+
+	blobs := len(env.TxContext.BlobHashes)
+	analysis := &BlobAnalysis{
+		Blobs:      blobs,
+		BlobGas:    uint64(blobs) * params.BlobTxBlobGasPerBlob,
+		Referenced: make([]bool, blobs),
+	}
+	if env.TxContext.BlobFeeCap != nil {
+		analysis.BlobFeeCap = new(big.Int).Set(env.TxContext.BlobFeeCap)
+	}
+	if env.Context.BlobBaseFee != nil {
+		analysis.BlobBaseFee = new(big.Int).Set(env.Context.BlobBaseFee)
+		analysis.Cost = new(big.Int).Mul(new(big.Int).SetUint64(analysis.BlobGas), analysis.BlobBaseFee)
+	}
+	return analysis
+}
+
+// trackBlobHash marks the blob whose versioned hash a BLOBHASH reads
+func (t *GasOptimizationTracer) trackBlobHash(op vm.OpCode, scope *vm.ScopeContext) {
+	if op != vm.BLOBHASH || t.Blobs == nil || len(scope.Stack.Data()) == 0 {
+		return
+	}
+	index := scope.Stack.Back(0)
+	if index.IsUint64() && index.Uint64() < uint64(t.Blobs.Blobs) {
+		t.Blobs.Referenced[index.Uint64()] = true
+	}
+}
+
+// analyzeBlobUsage notes blobs whose versioned hash execution never read.
+// Contracts normally verify the blobs they consume through BLOBHASH, so an
+// unread blob pays blob gas without being tied to the transaction's effects.
+func (t *GasOptimizationTracer) analyzeBlobUsage() {
+	if t.Blobs == nil {
+		return
+	}
+
+	var unread []int
+	for i, read := range t.Blobs.Referenced {
+		if !read {
+			unread = append(unread, i)
+		}
+	}
+	if len(unread) == 0 {
+		return
+	}
+
+	unreadGas := uint64(len(unread)) * params.BlobTxBlobGasPerBlob
+	details := map[string]interface{}{
+		"blobs":           t.Blobs.Blobs,
+		"unread_blobs":    unread,
+		"blob_gas":        t.Blobs.BlobGas,
+		"unread_blob_gas": unreadGas,
+	}
+	if t.Blobs.BlobBaseFee != nil {
+		details["unread_blob_cost_wei"] = new(big.Int).Mul(new(big.Int).SetUint64(unreadGas), t.Blobs.BlobBaseFee).String()
+	}
+
+	t.Optimizations = append(t.Optimizations, Optimization{
+		Type:        "unreferenced_blob",
+		Severity:    "low",
+		Description: "Blob carried by the transaction was never read with BLOBHASH - consider packing the data into fewer blobs",
+		Location:    ScopeLocation(ScopeTransaction),
+		GasSavings:  0,
+		Confidence:  "heuristic",
+		Details:     details,
+	})
+}
+
+// blobReport returns the blob gas section of the report
+func (t *GasOptimizationTracer) blobReport() map[string]interface{} {
+	section := map[string]interface{}{
+		"blobs":    t.Blobs.Blobs,
+		"blob_gas": t.Blobs.BlobGas,
+	}
+	if t.Blobs.BlobBaseFee != nil {
+		section["blob_base_fee"] = t.Blobs.BlobBaseFee.String()
+		section["blob_cost_wei"] = t.Blobs.Cost.String()
+	}
+	if t.Blobs.BlobFeeCap != nil {
+		section["blob_fee_cap"] = t.Blobs.BlobFeeCap.String()
+	}
+	return section
+}
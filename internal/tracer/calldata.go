@@ -0,0 +1,35 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// CalldataAnalysis summarizes the size and cost of a transaction's calldata
+type CalldataAnalysis struct {
+	TotalBytes   int
+	ZeroBytes    int
+	NonZeroBytes int
+	L1Gas        uint64 // Calldata gas under L1 rules (4 gas per zero byte, 16 per non-zero byte)
+	L2Gas        uint64 // Estimated calldata gas after rollup compression
+}
+
+// AnalyzeCalldata computes byte counts and gas estimates for calldata.
+// The compression ratio is the fraction of calldata gas remaining after
+// rollup batch compression (1.0 means no compression).
+func AnalyzeCalldata(data []byte, compressionRatio float64) CalldataAnalysis {
+	analysis := CalldataAnalysis{TotalBytes: len(data)}
+
+	for _, b := range data {
+		if b == 0 {
+			analysis.ZeroBytes++
+		} else {
+			analysis.NonZeroBytes++
+		}
+	}
+
+	analysis.L1Gas = uint64(analysis.ZeroBytes)*params.TxDataZeroGas +
+		uint64(analysis.NonZeroBytes)*params.TxDataNonZeroGasEIP2028
+	analysis.L2Gas = uint64(float64(analysis.L1Gas) * compressionRatio)
+
+	return analysis
+}
@@ -0,0 +1,172 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	u256 "github.com/holiman/uint256"
+)
+
+// maxFrameCalldataCopies is the number of copies tracked per call frame for
+// overlaps and reads; later copies are counted but never flagged as unread
+const maxFrameCalldataCopies = 64
+
+// calldataCopy is a CALLDATACOPY executed in a call frame
+type calldataCopy struct {
+	site       memorySite
+	destOffset uint64 // Memory offset copied to
+	offset     uint64 // Calldata offset copied from
+	size       uint64 // Bytes copied
+	readEnd    uint64 // Bytes of the copy, from its start, up to the last one read since
+}
+
+// calldataCopyUsage aggregates the copies made at one CALLDATACOPY site
+type calldataCopyUsage struct {
+	first        *calldataCopy
+	copies       int
+	repeated     int    // Copies overlapping an earlier copy's source range
+	overlapBytes uint64 // Bytes copied again by those copies
+	unreadCopies int    // Copies with at least a word never read
+	unreadBytes  uint64 // Bytes copied but never read
+}
+
+// trackCalldataCopy records the operands of a CALLDATACOPY and, for the other
+// opcodes, which bytes of the frame's earlier copies they read from memory
+func (t *GasOptimizationTracer) trackCalldataCopy(pc uint64, op vm.OpCode, scope *vm.ScopeContext) {
+	frame := t.currentNode()
+	if op != vm.CALLDATACOPY {
+		start, size, ok := memoryRead(op, scope.Stack)
+		if !ok || size == 0 {
+			return
+		}
+		for _, c := range t.calldataCopies[frame] {
+			if start < c.destOffset+c.size && start+size > c.destOffset {
+				c.readEnd = max(c.readEnd, min(start+size, c.destOffset+c.size)-c.destOffset)
+			}
+		}
+		return
+	}
+
+	destOffset, offset, size := scope.Stack.Back(0), scope.Stack.Back(1), scope.Stack.Back(2)
+	if destOffset == nil || offset == nil || size == nil || !destOffset.IsUint64() || !size.IsUint64() || size.IsZero() {
+		return
+	}
+	// Offsets beyond the calldata copy only zeros
+	if !offset.IsUint64() {
+		return
+	}
+
+	c := &calldataCopy{
+		site:       memorySite{contract: contractAddress(scope), pc: pc, op: op},
+		destOffset: destOffset.Uint64(),
+		offset:     offset.Uint64(),
+		size:       size.Uint64(),
+	}
+	usage, ok := t.calldataCopySites[c.site]
+	if !ok {
+		usage = &calldataCopyUsage{first: c}
+		t.calldataCopySites[c.site] = usage
+		t.calldataCopyOrder = append(t.calldataCopyOrder, c.site)
+	}
+	usage.copies++
+
+	var overlap uint64
+	for _, earlier := range t.calldataCopies[frame] {
+		from, to := max(c.offset, earlier.offset), min(c.offset+c.size, earlier.offset+earlier.size)
+		if to > from {
+			overlap = max(overlap, to-from)
+		}
+	}
+	if overlap > 0 {
+		usage.repeated++
+		usage.overlapBytes += overlap
+	}
+	if len(t.calldataCopies[frame]) < maxFrameCalldataCopies {
+		t.calldataCopies[frame] = append(t.calldataCopies[frame], c)
+	}
+}
+
+// settleCalldataCopies counts the bytes of a frame's copies it never read back,
+// once the frame can read no more, and stops tracking them
+func (t *GasOptimizationTracer) settleCalldataCopies(frame *CallNode) {
+	for _, c := range t.calldataCopies[frame] {
+		if unread := c.size - c.readEnd; unread >= wordSize {
+			usage := t.calldataCopySites[c.site]
+			usage.unreadCopies++
+			usage.unreadBytes += unread
+		}
+	}
+	delete(t.calldataCopies, frame)
+}
+
+// memoryRead returns the memory range read by op, for the opcodes that read memory
+func memoryRead(op vm.OpCode, stack *vm.Stack) (uint64, uint64, bool) {
+	var offset, size *u256.Int
+	switch op {
+	case vm.MLOAD:
+		offset, size = stack.Back(0), u256.NewInt(wordSize)
+	case vm.KECCAK256, vm.RETURN, vm.REVERT, vm.LOG0, vm.LOG1, vm.LOG2, vm.LOG3, vm.LOG4:
+		offset, size = stack.Back(0), stack.Back(1)
+	case vm.MCOPY:
+		offset, size = stack.Back(1), stack.Back(2)
+	case vm.CREATE, vm.CREATE2:
+		offset, size = stack.Back(1), stack.Back(2)
+	case vm.CALL, vm.CALLCODE:
+		offset, size = stack.Back(3), stack.Back(4)
+	case vm.DELEGATECALL, vm.STATICCALL:
+		offset, size = stack.Back(2), stack.Back(3)
+	default:
+		return 0, 0, false
+	}
+	if offset == nil || size == nil || !offset.IsUint64() || !size.IsUint64() {
+		return 0, 0, false
+	}
+	return offset.Uint64(), size.Uint64(), true
+}
+
+// analyzeCalldataCopies reports CALLDATACOPY sites that copied calldata already
+// copied to memory earlier in the frame, or copied more than the frame read back.
+// Only the copy cost of the bytes involved is counted as savings; the memory
+// expansion a copy paid for may be needed anyway.
+func (t *GasOptimizationTracer) analyzeCalldataCopies() {
+	// Frames still tracked are the top-level call and any cut short by an interrupt
+	for frame := range t.calldataCopies {
+		t.settleCalldataCopies(frame)
+	}
+
+	for _, site := range t.calldataCopyOrder {
+		usage := t.calldataCopySites[site]
+		if usage.repeated > 0 {
+			t.Optimizations = append(t.Optimizations, calldataCopyFinding(usage, "repeated_copy",
+				"CALLDATACOPY copies calldata already copied to memory in this call - copy it once and reuse the memory",
+				usage.repeated, usage.overlapBytes))
+		}
+		if usage.unreadCopies > 0 {
+			t.Optimizations = append(t.Optimizations, calldataCopyFinding(usage, "unread_copy",
+				"CALLDATACOPY copies more calldata than is read back - copy only the bytes used, or read them with CALLDATALOAD",
+				usage.unreadCopies, usage.unreadBytes))
+		}
+	}
+}
+
+// calldataCopyFinding builds an inefficient_calldatacopy finding for a site,
+// where the given number of its copies wasted the given bytes
+func calldataCopyFinding(usage *calldataCopyUsage, pattern, description string, copies int, wasted uint64) Optimization {
+	first := usage.first
+	return Optimization{
+		Type:        "inefficient_calldatacopy",
+		Severity:    "low",
+		Description: description,
+		Location:    ContractPCLocation(first.site.contract, first.site.pc),
+		GasSavings:  params.CopyGas * ((wasted + 31) / 32),
+		Confidence:  "estimated",
+		Details: map[string]interface{}{
+			"pattern":      pattern,
+			"dest_offset":  first.destOffset,
+			"offset":       first.offset,
+			"size":         first.size,
+			"copies":       usage.copies,
+			"flagged":      copies,
+			"wasted_bytes": wasted,
+		},
+	}
+}
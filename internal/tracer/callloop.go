@@ -0,0 +1,133 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	u256 "github.com/holiman/uint256"
+)
+
+// callSite identifies a call opcode at one code location
+type callSite struct {
+	contract common.Address
+	pc       uint64
+}
+
+// callKey identifies the target, value and calldata of a call
+type callKey struct {
+	to    common.Address
+	value u256.Int
+	input common.Hash
+}
+
+// siteCall is a call made at a call site
+type siteCall struct {
+	index  int  // Index into CallOps
+	noOp   bool // Zero-value CALL with empty calldata
+	repeat bool // Same target and calldata as an earlier valueless call from the site
+}
+
+// callSiteUsage holds the calls made at a call site
+type callSiteUsage struct {
+	op    string
+	calls []siteCall
+	seen  map[callKey]bool
+}
+
+// trackCallSite records a call about to be appended to CallOps so that calls
+// repeated inside loops can be recognized
+func (t *GasOptimizationTracer) trackCallSite(pc uint64, op vm.OpCode, scope *vm.ScopeContext) {
+	addr := scope.Stack.Back(1)
+	key := callKey{to: common.BytesToAddress(addr.Bytes())}
+
+	argsIndex := 2
+	if op == vm.CALL || op == vm.CALLCODE {
+		key.value = *scope.Stack.Back(2)
+		argsIndex = 3
+	}
+	argsOffset, argsLength := scope.Stack.Back(argsIndex), scope.Stack.Back(argsIndex+1)
+	input, ok := readMemory(scope.Memory, argsOffset, argsLength)
+	if !ok {
+		return
+	}
+	key.input = crypto.Keccak256Hash(input)
+
+	site := callSite{contract: contractAddress(scope), pc: pc}
+	usage, ok := t.callSites[site]
+	if !ok {
+		usage = &callSiteUsage{op: op.String(), seen: make(map[callKey]bool)}
+		t.callSites[site] = usage
+		t.contractCallSites[site.contract] = append(t.contractCallSites[site.contract], site)
+	}
+
+	// Repeated value transfers move funds each time, so only valueless calls count as repeats
+	usage.calls = append(usage.calls, siteCall{
+		index:  len(t.CallOps),
+		noOp:   op == vm.CALL && key.value.IsZero() && len(input) == 0,
+		repeat: key.value.IsZero() && usage.seen[key],
+	})
+	usage.seen[key] = true
+}
+
+// analyzeCallsInLoops flags call sites inside detected loops that make no-op
+// zero-value calls or repeat an identical call on each iteration. The savings
+// are the call overhead and callee gas of every such call, as if it were
+// dropped or hoisted out of the loop.
+func (t *GasOptimizationTracer) analyzeCallsInLoops() {
+	for _, loop := range t.Loops {
+		if loop.Iterations < 2 {
+			continue
+		}
+
+		for _, site := range t.contractCallSites[loop.Contract] {
+			if site.pc < loop.StartPC || site.pc > loop.EndPC {
+				continue
+			}
+
+			usage := t.callSites[site]
+			if len(usage.calls) < 2 {
+				continue
+			}
+
+			noOps, repeats := 0, 0
+			var wasted uint64
+			for _, call := range usage.calls {
+				if !call.noOp && !call.repeat {
+					continue
+				}
+				if call.noOp {
+					noOps++
+				} else {
+					repeats++
+				}
+				wasted += t.gasModel.CallGas + t.CallOps[call.index].GasUsed
+			}
+			if noOps+repeats == 0 {
+				continue
+			}
+
+			description := "Identical call repeated on every loop iteration - make it once before the loop"
+			if noOps > 0 {
+				description = "Zero-value call with empty calldata inside a loop does nothing - remove it"
+			}
+
+			t.Optimizations = append(t.Optimizations, Optimization{
+				Type:        "call_in_loop",
+				Severity:    "high",
+				Description: description,
+				Location:    ContractPCLocation(site.contract, site.pc),
+				GasSavings:  wasted,
+				Confidence:  "estimated",
+				Details: map[string]interface{}{
+					"call_type":      usage.op,
+					"to":             t.CallOps[usage.calls[0].index].To.Hex(),
+					"loop_range":     formatPC(loop.StartPC) + "-" + formatPC(loop.EndPC),
+					"iterations":     loop.Iterations,
+					"calls":          len(usage.calls),
+					"no_op_calls":    noOps,
+					"repeated_calls": repeats,
+				},
+			})
+		}
+	}
+}
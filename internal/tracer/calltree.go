@@ -0,0 +1,160 @@
+package tracer
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// CallNode is one frame of the transaction's call tree
+type CallNode struct {
+	Type      string         // CALL, CREATE, DELEGATECALL, ...
+	From      common.Address // Caller of the frame
+	To        common.Address // Contract executed by the frame
+	Value     *big.Int       // Value transferred, nil when the call type carries none
+	Input     []byte         // Calldata, or init code for creations
+	Gas       uint64         // Gas made available to the frame
+	GasUsed   uint64         // Gas used by the frame, including its subcalls
+	Output    []byte         // Return or revert data
+	Error     string         // Error the frame ended with, empty on success
+	Signature string         // Function signature of the input's selector, when resolved
+	FromName  string         // Name of the caller, when resolved
+	ToName    string         // Name of the executed contract, when resolved
+	Children  []*CallNode    // Frames entered from this frame, in execution order
+}
+
+// Reverted reports whether the frame reverted or failed
+func (n *CallNode) Reverted() bool {
+	return n.Error != ""
+}
+
+// Selector returns the 4-byte function selector of the frame's input, if it has one
+func (n *CallNode) Selector() ([4]byte, bool) {
+	var selector [4]byte
+	if len(n.Input) < 4 || n.Type == "CREATE" || n.Type == "CREATE2" {
+		return selector, false
+	}
+	copy(selector[:], n.Input[:4])
+	return selector, true
+}
+
+// SelfGas returns the gas used by the frame itself, excluding its subcalls
+func (n *CallNode) SelfGas() uint64 {
+	self := n.GasUsed
+	for _, child := range n.Children {
+		if child.GasUsed > self {
+			return 0
+		}
+		self -= child.GasUsed
+	}
+	return self
+}
+
+// copy returns a deep copy of the node and its descendants
+func (n *CallNode) copy() *CallNode {
+	c := *n
+	if n.Value != nil {
+		c.Value = new(big.Int).Set(n.Value)
+	}
+	c.Input = common.CopyBytes(n.Input)
+	c.Output = common.CopyBytes(n.Output)
+	c.Children = make([]*CallNode, len(n.Children))
+	for i, child := range n.Children {
+		c.Children[i] = child.copy()
+	}
+	return &c
+}
+
+// enterNode adds a frame to the call tree under parent, or as the root when parent is nil
+func (t *GasOptimizationTracer) enterNode(parent *CallNode, typ string, from, to common.Address, input []byte, gas uint64, value *big.Int) *CallNode {
+	node := &CallNode{
+		Type:  typ,
+		From:  from,
+		To:    to,
+		Input: common.CopyBytes(input),
+		Gas:   gas,
+	}
+	if value != nil {
+		node.Value = new(big.Int).Set(value)
+	}
+
+	if parent == nil {
+		t.CallTree = node
+	} else {
+		parent.Children = append(parent.Children, node)
+	}
+	return node
+}
+
+// exitNode records the outcome of a call tree frame
+func exitNode(node *CallNode, output []byte, gasUsed uint64, err error) {
+	if node == nil {
+		return
+	}
+	node.GasUsed = gasUsed
+	node.Output = common.CopyBytes(output)
+	if err != nil {
+		node.Error = err.Error()
+	}
+}
+
+// currentNode returns the call tree frame currently executing, or nil
+func (t *GasOptimizationTracer) currentNode() *CallNode {
+	if len(t.frames) > 0 {
+		return t.frames[len(t.frames)-1].node
+	}
+	return t.CallTree
+}
+
+// resolveNode attaches function signatures to node and its descendants
+func resolveNode(node *CallNode, resolver SignatureResolver) {
+	if selector, ok := node.Selector(); ok {
+		if signature, _, ok := resolver.ResolveSelector(selector); ok {
+			node.Signature = signature
+		}
+	}
+	for _, child := range node.Children {
+		resolveNode(child, resolver)
+	}
+}
+
+// GetCallTree returns a copy of the call tree, or nil if no transaction was traced
+func (t *GasOptimizationTracer) GetCallTree() *CallNode {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.CallTree == nil {
+		return nil
+	}
+	return t.CallTree.copy()
+}
+
+// callTreeReport converts a call tree frame and its descendants for the JSON report
+func callTreeReport(node *CallNode) map[string]interface{} {
+	entry := map[string]interface{}{
+		"type":     node.Type,
+		"from":     node.From.Hex(),
+		"to":       node.To.Hex(),
+		"input":    hexutil.Encode(node.Input),
+		"gas":      node.Gas,
+		"gas_used": node.GasUsed,
+		"output":   hexutil.Encode(node.Output),
+	}
+	if node.Value != nil {
+		entry["value"] = node.Value.String()
+	}
+	if node.Error != "" {
+		entry["error"] = node.Error
+	}
+	if node.Signature != "" {
+		entry["signature"] = node.Signature
+	}
+
+	calls := make([]map[string]interface{}, 0, len(node.Children))
+	for _, child := range node.Children {
+		calls = append(calls, callTreeReport(child))
+	}
+	entry["calls"] = calls
+	return entry
+}
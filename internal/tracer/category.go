@@ -0,0 +1,96 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// Opcode categories that gas is rolled up into
+const (
+	CategoryArithmetic  = "arithmetic"   // Arithmetic, comparison and bitwise operations
+	CategoryStack       = "stack"        // PUSH, DUP, SWAP and POP
+	CategoryMemory      = "memory"       // Memory reads, writes and copies into memory
+	CategoryStorage     = "storage"      // Persistent and transient storage
+	CategoryControlFlow = "control-flow" // Jumps and frame termination
+	CategoryEnvironment = "environment"  // Transaction, block and account context
+	CategoryCalls       = "calls"        // Message calls, contract creation and SELFDESTRUCT
+	CategoryLogging     = "logging"      // LOG0-LOG4
+	CategoryHashing     = "hashing"      // KECCAK256
+	CategoryOther       = "other"        // Opcodes not listed in any category
+)
+
+// categoryOpcodes is the opcode table behind the gas rollup. Copies into memory
+// (CALLDATACOPY, CODECOPY, ...) are memory operations since their cost scales
+// with the bytes written to memory, and calls include the gas they forward.
+var categoryOpcodes = map[string][]vm.OpCode{
+	CategoryArithmetic: {
+		vm.ADD, vm.MUL, vm.SUB, vm.DIV, vm.SDIV, vm.MOD, vm.SMOD, vm.ADDMOD, vm.MULMOD, vm.EXP, vm.SIGNEXTEND,
+		vm.LT, vm.GT, vm.SLT, vm.SGT, vm.EQ, vm.ISZERO,
+		vm.AND, vm.OR, vm.XOR, vm.NOT, vm.BYTE, vm.SHL, vm.SHR, vm.SAR,
+	},
+	CategoryStack: stackOpcodes(),
+	CategoryMemory: {
+		vm.MLOAD, vm.MSTORE, vm.MSTORE8, vm.MSIZE, vm.MCOPY,
+		vm.CALLDATACOPY, vm.CODECOPY, vm.EXTCODECOPY, vm.RETURNDATACOPY,
+	},
+	CategoryStorage: {vm.SLOAD, vm.SSTORE, vm.TLOAD, vm.TSTORE},
+	CategoryControlFlow: {
+		vm.STOP, vm.JUMP, vm.JUMPI, vm.JUMPDEST, vm.PC, vm.RETURN, vm.REVERT, vm.INVALID,
+	},
+	CategoryEnvironment: {
+		vm.ADDRESS, vm.BALANCE, vm.ORIGIN, vm.CALLER, vm.CALLVALUE, vm.CALLDATALOAD, vm.CALLDATASIZE,
+		vm.CODESIZE, vm.GASPRICE, vm.EXTCODESIZE, vm.RETURNDATASIZE, vm.EXTCODEHASH, vm.GAS,
+		vm.BLOCKHASH, vm.COINBASE, vm.TIMESTAMP, vm.NUMBER, vm.PREVRANDAO, vm.GASLIMIT, vm.CHAINID,
+		vm.SELFBALANCE, vm.BASEFEE, vm.BLOBHASH, vm.BLOBBASEFEE,
+	},
+	CategoryCalls: {
+		vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL, vm.CREATE, vm.CREATE2, vm.SELFDESTRUCT,
+	},
+	CategoryLogging: {vm.LOG0, vm.LOG1, vm.LOG2, vm.LOG3, vm.LOG4},
+	CategoryHashing: {vm.KECCAK256},
+}
+
+// opcodeCategories maps each opcode name to its category
+var opcodeCategories = indexCategories()
+
+// stackOpcodes returns PUSH0-PUSH32, DUP1-DUP16, SWAP1-SWAP16 and POP
+func stackOpcodes() []vm.OpCode {
+	ops := []vm.OpCode{vm.POP, vm.PUSH0}
+	for op := vm.PUSH1; op <= vm.PUSH32; op++ {
+		ops = append(ops, op)
+	}
+	for op := vm.OpCode(vm.DUP1); op <= vm.DUP16; op++ {
+		ops = append(ops, op)
+	}
+	for op := vm.OpCode(vm.SWAP1); op <= vm.SWAP16; op++ {
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// indexCategories builds the opcode name to category index from categoryOpcodes
+func indexCategories() map[string]string {
+	index := make(map[string]string)
+	for category, ops := range categoryOpcodes {
+		for _, op := range ops {
+			index[op.String()] = category
+		}
+	}
+	return index
+}
+
+// OpcodeCategory returns the category of the named opcode, or CategoryOther
+func OpcodeCategory(opcode string) string {
+	if category, ok := opcodeCategories[opcode]; ok {
+		return category
+	}
+	return CategoryOther
+}
+
+// GasByCategory sums per-opcode gas into opcode categories
+func GasByCategory(gasPerOpcode map[string]uint64) map[string]uint64 {
+	categories := make(map[string]uint64)
+	for opcode, gas := range gasPerOpcode {
+		categories[OpcodeCategory(opcode)] += gas
+	}
+	return categories
+}
@@ -0,0 +1,66 @@
+package tracer
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+func TestGasByCategory(t *testing.T) {
+	categories := GasByCategory(map[string]uint64{
+		"SLOAD":                   2100,
+		"SSTORE":                  20000,
+		"TLOAD":                   100,
+		"ADD":                     3,
+		"PUSH1":                   6,
+		"LOG1":                    750,
+		"opcode 0xef not defined": 1,
+	})
+
+	if categories[CategoryStorage] != 22200 {
+		t.Errorf("Expected 22200 storage gas, got %d", categories[CategoryStorage])
+	}
+	if categories[CategoryArithmetic] != 3 || categories[CategoryStack] != 6 || categories[CategoryLogging] != 750 {
+		t.Errorf("Expected arithmetic 3, stack 6 and logging 750, got %v", categories)
+	}
+	if categories[CategoryOther] != 1 {
+		t.Errorf("Expected unknown opcodes under other, got %v", categories)
+	}
+}
+
+func TestEveryOpcodeCategorized(t *testing.T) {
+	// Every opcode go-ethereum names has a category
+	for i := 0; i < 256; i++ {
+		op := vm.OpCode(i)
+		if vm.StringToOp(op.String()) != op {
+			continue
+		}
+		if OpcodeCategory(op.String()) == CategoryOther {
+			t.Errorf("Expected %s to have a category", op)
+		}
+	}
+}
+
+func TestReportGasByCategory(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	code := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	data, err := tracer.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+	report, err := ParseReport([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseReport() error: %v", err)
+	}
+
+	storage := tracer.GasPerOpcode["SLOAD"] + tracer.GasPerOpcode["SSTORE"]
+	if report.GasByCategory[CategoryStorage] != storage {
+		t.Errorf("Expected %d storage gas in the report, got %d", storage, report.GasByCategory[CategoryStorage])
+	}
+}
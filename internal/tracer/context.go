@@ -0,0 +1,117 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// contextReadThreshold is the number of evaluations of one context opcode in a
+// frame from which the reads are reported as redundant
+const contextReadThreshold = 3
+
+// frameConstantOps are context opcodes whose result cannot change within a frame
+var frameConstantOps = map[vm.OpCode]bool{
+	vm.ADDRESS:      true,
+	vm.ORIGIN:       true,
+	vm.CALLER:       true,
+	vm.CALLVALUE:    true,
+	vm.CALLDATASIZE: true,
+	vm.CODESIZE:     true,
+	vm.GASPRICE:     true,
+	vm.COINBASE:     true,
+	vm.TIMESTAMP:    true,
+	vm.NUMBER:       true,
+	vm.DIFFICULTY:   true,
+	vm.GASLIMIT:     true,
+	vm.CHAINID:      true,
+	vm.BASEFEE:      true,
+}
+
+// contextRead identifies a context opcode evaluated in one call frame
+type contextRead struct {
+	frame *CallNode
+	op    vm.OpCode
+}
+
+// contextSite identifies a code location in a contract
+type contextSite struct {
+	contract common.Address
+	pc       uint64
+}
+
+// trackContextRead counts context opcode evaluations per frame and flags
+// BALANCE(ADDRESS), which SELFBALANCE (EIP-1884) computes for less gas
+func (t *GasOptimizationTracer) trackContextRead(pc uint64, op vm.OpCode, cost uint64, depth int, scope *vm.ScopeContext) {
+	prevOp, prevDepth := t.prevOp, t.prevDepth
+	t.prevOp, t.prevDepth = op, depth
+
+	if op == vm.BALANCE && prevOp == vm.ADDRESS && prevDepth == depth && t.selfBalanceActive {
+		if len(scope.Stack.Data()) > 0 && common.Address(scope.Stack.Back(0).Bytes20()) == contractAddress(scope) {
+			t.recordSelfBalance(pc, cost, scope)
+		}
+		return
+	}
+
+	if !frameConstantOps[op] {
+		return
+	}
+
+	read := contextRead{frame: t.currentNode(), op: op}
+	t.contextReads[read]++
+	count := t.contextReads[read]
+	if count < contextReadThreshold {
+		return
+	}
+
+	// Update the existing finding for this frame rather than adding another
+	if idx, ok := t.contextFindings[read]; ok {
+		t.Optimizations[idx].Details["read_count"] = count
+		return
+	}
+
+	t.contextFindings[read] = len(t.Optimizations)
+	t.Optimizations = append(t.Optimizations, Optimization{
+		Type:        "redundant_context_read",
+		Severity:    "low",
+		Description: "Context value that is constant within the call evaluated repeatedly - read it once and reuse it",
+		Location:    ContractPCLocation(contractAddress(scope), pc),
+		GasSavings:  0,
+		Confidence:  "heuristic",
+		Details: map[string]interface{}{
+			"opcode":     op.String(),
+			"contract":   contractAddress(scope).Hex(),
+			"read_count": count,
+		},
+	})
+}
+
+// recordSelfBalance adds or updates the use_selfbalance finding for a BALANCE(ADDRESS) site
+func (t *GasOptimizationTracer) recordSelfBalance(pc uint64, cost uint64, scope *vm.ScopeContext) {
+	// ADDRESS and BALANCE together cost more than a single SELFBALANCE
+	savings := uint64(0)
+	if paid := vm.GasQuickStep + cost; paid > vm.GasFastStep {
+		savings = paid - vm.GasFastStep
+	}
+
+	site := contextSite{contract: contractAddress(scope), pc: pc}
+	if idx, ok := t.selfBalanceFindings[site]; ok {
+		t.Optimizations[idx].GasSavings += savings
+		t.Optimizations[idx].Details["occurrences"] = t.Optimizations[idx].Details["occurrences"].(int) + 1
+		return
+	}
+
+	t.selfBalanceFindings[site] = len(t.Optimizations)
+	t.Optimizations = append(t.Optimizations, Optimization{
+		Type:        "use_selfbalance",
+		Severity:    "low",
+		Description: "BALANCE(ADDRESS) reads the contract's own balance - use SELFBALANCE (address(this).balance in Solidity 0.8+)",
+		Location:    ContractPCLocation(site.contract, pc),
+		GasSavings:  savings,
+		Confidence:  "exact",
+		Details: map[string]interface{}{
+			"contract":    site.contract.Hex(),
+			"balance_gas": cost,
+			"occurrences": 1,
+		},
+	})
+}
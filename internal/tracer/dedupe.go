@@ -0,0 +1,47 @@
+package tracer
+
+import "fmt"
+
+// primaryDetailKeys are the detail keys identifying what a finding is about,
+// in order of preference
+var primaryDetailKeys = []string{"storage_key", "slot", "hash", "address", "to", "contract", "proxy", "opcode", "pattern"}
+
+// dedupKey identifies a logical issue
+type dedupKey struct {
+	typ      string
+	location string
+	primary  string
+}
+
+// findingKey returns the logical key of a finding: its type, location and primary detail
+func findingKey(opt Optimization) dedupKey {
+	key := dedupKey{typ: opt.Type, location: opt.Location.String()}
+	for _, name := range primaryDetailKeys {
+		if value, ok := opt.Details[name]; ok {
+			key.primary = fmt.Sprint(value)
+			break
+		}
+	}
+	return key
+}
+
+// dedupeOptimizations collapses findings emitted repeatedly for one issue, such
+// as a check firing on every iteration of a loop, into the one with the highest
+// savings, kept at the position of the first
+func (t *GasOptimizationTracer) dedupeOptimizations() {
+	deduped := make([]Optimization, 0, len(t.Optimizations))
+	index := make(map[dedupKey]int)
+
+	for _, opt := range t.Optimizations {
+		key := findingKey(opt)
+		if i, ok := index[key]; ok {
+			if opt.GasSavings > deduped[i].GasSavings {
+				deduped[i] = opt
+			}
+			continue
+		}
+		index[key] = len(deduped)
+		deduped = append(deduped, opt)
+	}
+	t.Optimizations = deduped
+}
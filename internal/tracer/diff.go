@@ -0,0 +1,77 @@
+package tracer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ReportDiff compares a trace against a previously saved report
+type ReportDiff struct {
+	BaselineGas uint64
+	CurrentGas  uint64
+	New         []Optimization // Findings of the current trace missing from the baseline
+	Resolved    []Optimization // Findings of the baseline missing from the current trace
+	Unchanged   int            // Findings present in both
+}
+
+// GasDelta returns the change in total gas from the baseline, negative when gas went down
+func (d ReportDiff) GasDelta() int64 {
+	return int64(d.CurrentGas) - int64(d.BaselineGas)
+}
+
+// LoadReport reads and validates a saved JSON report
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	report, err := ParseReport(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return report, nil
+}
+
+// GetTypedReport returns the report of the trace in its typed form
+func (t *GasOptimizationTracer) GetTypedReport() (*Report, error) {
+	t.mu.Lock()
+	data, err := json.Marshal(t.reportData())
+	t.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return ParseReport(data)
+}
+
+// DiffReports classifies the findings of current against baseline. Findings are
+// matched by type, location and subject, like duplicate findings are; a finding
+// reported more often than in the baseline counts as new for each extra occurrence.
+func DiffReports(baseline, current *Report) ReportDiff {
+	diff := ReportDiff{BaselineGas: baseline.TotalGasUsed, CurrentGas: current.TotalGasUsed}
+
+	remaining := make(map[dedupKey]int)
+	for _, opt := range baseline.Optimizations {
+		remaining[findingKey(opt)]++
+	}
+
+	for _, opt := range current.Optimizations {
+		key := findingKey(opt)
+		if remaining[key] > 0 {
+			remaining[key]--
+			diff.Unchanged++
+			continue
+		}
+		diff.New = append(diff.New, opt)
+	}
+
+	// Keep resolved findings in baseline order
+	for _, opt := range baseline.Optimizations {
+		key := findingKey(opt)
+		if remaining[key] > 0 {
+			remaining[key]--
+			diff.Resolved = append(diff.Resolved, opt)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,135 @@
+package tracer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// Instruction is a single disassembled EVM instruction
+type Instruction struct {
+	PC      uint64
+	Op      vm.OpCode
+	Operand []byte // Immediate bytes of a PUSH, possibly truncated at the end of the code
+}
+
+// String formats the instruction as "0x1a: PUSH1 0x20"
+func (i Instruction) String() string {
+	s := fmt.Sprintf("%s: %s", formatPC(i.PC), i.Op)
+	if i.Op.IsPush() && i.Op != vm.PUSH0 {
+		s += " 0x" + common.Bytes2Hex(i.Operand)
+	}
+	return s
+}
+
+// Disassemble decodes code into instructions, skipping over PUSH operand bytes
+func Disassemble(code []byte) []Instruction {
+	instructions := make([]Instruction, 0, len(code))
+	for pc := 0; pc < len(code); pc++ {
+		op := vm.OpCode(code[pc])
+		ins := Instruction{PC: uint64(pc), Op: op}
+		if op >= vm.PUSH1 && op <= vm.PUSH32 {
+			end := pc + 1 + int(op-vm.PUSH1) + 1
+			if end > len(code) {
+				end = len(code)
+			}
+			ins.Operand = code[pc+1 : end]
+			pc = end - 1
+		}
+		instructions = append(instructions, ins)
+	}
+	return instructions
+}
+
+// DisassembleWindow returns the instruction at pc together with up to n
+// instructions before and after it, or nil if pc is not an instruction boundary
+func DisassembleWindow(code []byte, pc uint64, n int) []Instruction {
+	instructions := Disassemble(code)
+	for i, ins := range instructions {
+		if ins.PC == pc {
+			return instructions[max(0, i-n):min(len(instructions), i+n+1)]
+		}
+	}
+	return nil
+}
+
+// SetDisassembly attaches a disassembled snippet of n instructions either side
+// of each optimization's location to its details. Passing 0 disables it.
+func (t *GasOptimizationTracer) SetDisassembly(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.disasmWindow = n
+}
+
+// recordCode remembers the code executing in scope for annotating findings
+// raised after execution
+func (t *GasOptimizationTracer) recordCode(scope *vm.ScopeContext) {
+	addr := contractAddress(scope)
+	if _, ok := t.contractCode[addr]; !ok && scope.Contract != nil {
+		t.contractCode[addr] = scope.Contract.Code
+	}
+}
+
+// annotateFindings attaches disassembly to optimizations from index from onwards
+// using the code executing in scope
+func (t *GasOptimizationTracer) annotateFindings(from int, scope *vm.ScopeContext) {
+	if scope.Contract == nil {
+		return
+	}
+	for i := from; i < len(t.Optimizations); i++ {
+		t.attachDisassembly(&t.Optimizations[i], scope.Contract.Code)
+	}
+}
+
+// annotateRemaining attaches disassembly to optimizations raised during the final
+// analysis, using the contract of their location or named in their details, or
+// the entry contract
+func (t *GasOptimizationTracer) annotateRemaining() {
+	for i := range t.Optimizations {
+		opt := &t.Optimizations[i]
+		if _, ok := opt.Details["disassembly"]; ok {
+			continue
+		}
+
+		addr := t.entryContract
+		if opt.Location.Contract != nil {
+			addr = *opt.Location.Contract
+		} else if contract, ok := opt.Details["contract"].(string); ok && common.IsHexAddress(contract) {
+			addr = common.HexToAddress(contract)
+		}
+		if code, ok := t.contractCode[addr]; ok {
+			t.attachDisassembly(opt, code)
+		}
+	}
+}
+
+// attachDisassembly adds the snippet around the optimization's location, marking
+// the instruction at the location with "> "
+func (t *GasOptimizationTracer) attachDisassembly(opt *Optimization, code []byte) {
+	if !opt.Location.IsPC() {
+		return
+	}
+	pc := opt.Location.PC
+
+	window := DisassembleWindow(code, pc, t.disasmWindow)
+	if window == nil {
+		return
+	}
+
+	lines := make([]string, len(window))
+	for i, ins := range window {
+		prefix := "  "
+		if ins.PC == pc {
+			prefix = "> "
+		}
+		lines[i] = prefix + ins.String()
+	}
+
+	if opt.Details == nil {
+		opt.Details = make(map[string]interface{})
+	}
+	opt.Details["disassembly"] = strings.Join(lines, "\n")
+}
@@ -0,0 +1,84 @@
+package tracer
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+func TestDisassembleWindow(t *testing.T) {
+	code := []byte{
+		byte(vm.PUSH2), 0x01, 0x02, // 0x00
+		byte(vm.PUSH1), 0x5b, // 0x03, operand looks like JUMPDEST
+		byte(vm.ADD),         // 0x05
+		byte(vm.PUSH0),       // 0x06
+		byte(vm.SLOAD),       // 0x07
+		byte(vm.POP),         // 0x08
+		byte(vm.PUSH4), 0xaa, // 0x09, truncated operand
+	}
+
+	window := DisassembleWindow(code, 0x05, 2)
+	if len(window) != 5 {
+		t.Fatalf("Expected 5 instructions, got %d", len(window))
+	}
+
+	expected := []string{
+		"0x: PUSH2 0x0102",
+		"0x03: PUSH1 0x5b",
+		"0x05: ADD",
+		"0x06: PUSH0",
+		"0x07: SLOAD",
+	}
+	for i, ins := range window {
+		if ins.String() != expected[i] {
+			t.Errorf("Expected %q, got %q", expected[i], ins.String())
+		}
+	}
+
+	if DisassembleWindow(code, 0x04, 2) != nil {
+		t.Error("Expected no window for a PC inside PUSH operand bytes")
+	}
+
+	last := Disassemble(code)
+	if tail := last[len(last)-1]; tail.Op != vm.PUSH4 || len(tail.Operand) != 1 {
+		t.Errorf("Expected a truncated PUSH4 at the end, got %s", tail)
+	}
+}
+
+func TestDisassemblyDetails(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	tracer.SetDisassembly(1)
+
+	code := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	var found *Optimization
+	for i := range tracer.Optimizations {
+		if tracer.Optimizations[i].Type == "redundant_sload" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+
+	if found == nil {
+		t.Fatal("Expected redundant_sload optimization")
+	}
+
+	disassembly, _ := found.Details["disassembly"].(string)
+	expected := "  0x08: PUSH1 0x01\n> 0x0a: SLOAD\n  0x0b: POP"
+	if disassembly != expected {
+		t.Errorf("Expected disassembly %q, got %q", expected, disassembly)
+	}
+
+	tracer = NewGasOptimizationTracer()
+	runCode(t, tracer, code, nil)
+	for _, opt := range tracer.Optimizations {
+		if _, ok := opt.Details["disassembly"]; ok {
+			t.Error("Expected no disassembly unless enabled")
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package tracer
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Explanation describes an optimization type in more depth than a finding's
+// one-line description
+type Explanation struct {
+	Type    string
+	Summary string // What the finding means
+	Why     string // Why it matters
+	GasCost string // Where the gas goes and how savings are estimated
+	Example string // A code pattern that triggers the finding, and its fix
+}
+
+// explanations is the catalog behind Explain, with one entry for every
+// optimization type reported by the tracer and by the account analysis.
+// A new optimization type needs an entry here.
+var explanations = map[string]Explanation{
+	"redundant_sload": {
+		Summary: "The same storage slot was read more than once within the transaction.",
+		Why:     "Every read after the first pays for an SLOAD although the value is already known.",
+		GasCost: "Repeated reads cost the warm SLOAD price (100 gas since Berlin, 800 under Istanbul). Savings count each repeated read at the traced fork's price.",
+		Example: "for (...) { total += balances[msg.sender]; }  ->  uint256 bal = balances[msg.sender]; for (...) { total += bal; }",
+	},
+	"storage_in_loop": {
+		Summary: "A storage slot was read or written on every iteration of a loop.",
+		Why:     "Storage is the most expensive state the EVM touches; a loop multiplies that cost by its iteration count.",
+		GasCost: "Each iteration after the first pays a warm SLOAD, and each write another SSTORE. Savings assume the slot is cached in memory and written once after the loop.",
+		Example: "for (i...) { counter += 1; }  ->  uint256 c = counter; for (i...) { c += 1; } counter = c;",
+	},
+	"call_in_loop": {
+		Summary: "A call inside a loop did nothing (zero value, empty calldata) or repeated an identical valueless call.",
+		Why:     "The call's overhead and the callee's gas are paid on every iteration with no new effect.",
+		GasCost: "Savings are the per-call overhead of the traced fork plus the gas each wasted call used.",
+		Example: "for (...) { uint256 d = token.decimals(); ... }  ->  uint256 d = token.decimals(); for (...) { ... }",
+	},
+	"missing_access_list": {
+		Summary: "Addresses or storage slots were first accessed cold, which an EIP-2930 access list would have pre-warmed.",
+		Why:     "Cold accesses cost more than the per-item price of declaring them in the transaction's access list.",
+		GasCost: "A cold account access costs 2600 gas and a cold SLOAD 2100, against 2400 and 1900 per access list entry. Savings are the exact difference for the accesses traced.",
+		Example: "Send the transaction with the list written by --access-list-out, e.g. eth_sendTransaction({..., accessList: [...]}).",
+	},
+	"unused_read_before_write": {
+		Summary: "A storage slot was read, its value discarded, and the slot then overwritten.",
+		Why:     "The read buys nothing: the value is never used before being replaced.",
+		GasCost: "Savings are the cost of the discarded SLOAD at the traced fork's prices.",
+		Example: "uint256 old = value; value = newValue; (old never used)  ->  value = newValue;",
+	},
+	"use_transient_storage": {
+		Summary: "A storage slot was set and restored to its original value within one transaction, such as a reentrancy lock.",
+		Why:     "Transient storage (EIP-1153) holds values for the transaction only, at a fraction of the cost of persistent storage.",
+		GasCost: "TSTORE and TLOAD cost 100 gas each, against SSTOREs that pay for dirtying a slot and restoring it. Savings compare the traced SSTOREs with their transient equivalents.",
+		Example: "bool locked; modifier nonReentrant { locked = true; _; locked = false; }  ->  use tstore/tload in assembly or a transient variable (Solidity 0.8.24+).",
+	},
+	"wasted_gas_on_revert": {
+		Summary: "Subcalls reverted, so all the gas they burned bought no state change.",
+		Why:     "A revert undoes the callee's work but not its gas cost.",
+		GasCost: "Savings are the gas used by the reverted frames, which checking the revert condition before calling avoids.",
+		Example: "try pool.swap(...) {} catch {}  ->  check the pool's limits first and skip the call when it would fail.",
+	},
+	"execution_fault": {
+		Summary: "An opcode stopped its frame with an error: out of gas, an invalid opcode or a stack error.",
+		Why:     "A faulting frame consumes all the gas it was given and reverts its changes.",
+		GasCost: "The gas forwarded to the faulting frame is lost. The finding points at the faulting PC so the cause can be fixed.",
+		Example: "addr.call{gas: 2300}(data) running out of gas  ->  forward enough gas, or restructure the callee.",
+	},
+	"multiple_calls": {
+		Summary: "The transaction made many external calls.",
+		Why:     "Every call pays a fixed overhead on top of the callee's work.",
+		GasCost: "Savings assume batching saves the per-call overhead of the traced fork for each call beyond the first.",
+		Example: "token.transfer(a, 1); token.transfer(b, 2);  ->  a single batchTransfer([a, b], [1, 2]) when the callee supports it.",
+	},
+	"redundant_hash": {
+		Summary: "KECCAK256 was computed repeatedly over identical input.",
+		Why:     "Hashing is deterministic, so every repeat recomputes a value already known.",
+		GasCost: "KECCAK256 costs 30 gas plus 6 per word hashed. Savings count each repeated hash.",
+		Example: "keccak256(abi.encode(a, b)) computed in two places  ->  compute it once into a local variable.",
+	},
+	"redundant_mapping_access": {
+		Summary: "The same mapping entry was located repeatedly, recomputing keccak256(key . slot) before each access.",
+		Why:     "The slot of a mapping entry is a hash; every access recomputes it unless a storage pointer is kept.",
+		GasCost: "Each recomputation costs a 64-byte KECCAK256 (42 gas) plus the memory writes that prepare it.",
+		Example: "users[id].balance -= x; users[id].nonce++;  ->  User storage u = users[id]; u.balance -= x; u.nonce++;",
+	},
+	"redundant_account_access": {
+		Summary: "BALANCE, EXTCODESIZE or EXTCODEHASH queried the same account repeatedly.",
+		Why:     "Account properties do not change within the query sequence, so repeats pay for known values.",
+		GasCost: "Repeated queries cost the warm access price (100 gas since Berlin). Savings count each repeat at the traced fork's price.",
+		Example: "if (addr.code.length > 0) {...} ... addr.code.length  ->  bool isContract = addr.code.length > 0;",
+	},
+	"log_data_heavy": {
+		Summary: "Event data accounted for a large share of the transaction's gas.",
+		Why:     "Log data costs 8 gas per byte and is paid by every transaction that emits it.",
+		GasCost: "Savings estimate logging a hash instead of the data, or moving the data to calldata that indexers can read.",
+		Example: "emit Stored(bigBytes);  ->  emit Stored(keccak256(bigBytes));",
+	},
+	"use_mcopy": {
+		Summary: "A loop copied memory one word at a time with MLOAD and MSTORE.",
+		Why:     "MCOPY (EIP-5656, Cancun) copies a region in a single instruction.",
+		GasCost: "A word-by-word copy pays an MLOAD, an MSTORE and the loop overhead per word; MCOPY costs 3 gas plus 3 per word.",
+		Example: "for (i...) mstore(add(dst, i), mload(add(src, i)))  ->  mcopy(dst, src, len) (compile for Cancun).",
+	},
+	"use_push0": {
+		Summary: "Zero was pushed with PUSH1 0x00 on a chain where PUSH0 exists.",
+		Why:     "PUSH0 (EIP-3855, Shanghai) pushes zero for less gas and one byte less code.",
+		GasCost: "Each PUSH0 saves 1 gas (2 instead of 3) and one byte of code.",
+		Example: "Recompile with evmVersion shanghai or later.",
+	},
+	"use_selfbalance": {
+		Summary: "The contract read its own balance with BALANCE(ADDRESS).",
+		Why:     "SELFBALANCE (EIP-1884) returns the same value at a fixed low price.",
+		GasCost: "SELFBALANCE costs 5 gas, against a BALANCE access of 100 gas or more since Berlin. Savings are exact.",
+		Example: "balance(address())  ->  selfbalance() (address(this).balance compiles to it since Istanbul).",
+	},
+	"redundant_context_read": {
+		Summary: "A context value constant within a call (CALLER, ADDRESS, TIMESTAMP, ...) was read repeatedly in one frame.",
+		Why:     "The value cannot change within the frame, so each repeat is avoidable work.",
+		GasCost: "Context opcodes are cheap (2 gas) but common; caching them on the stack saves the repeats and their stack shuffling.",
+		Example: "require(msg.sender == owner); emit E(msg.sender);  ->  address sender = msg.sender;",
+	},
+	"precompile_call": {
+		Summary: "The transaction called a precompiled contract (ecrecover, sha256, ...).",
+		Why:     "Precompiles have their own pricing, which can dominate a transaction's gas.",
+		GasCost: "The finding aggregates the gas of the calls. No savings are estimated; check whether the calls can be avoided or batched.",
+		Example: "Verifying many ECDSA signatures one by one  ->  an aggregated signature scheme where available.",
+	},
+	"use_unchecked": {
+		Summary: "Checked-arithmetic overflow guards ran inside a loop where the bound rules out overflow.",
+		Why:     "Solidity 0.8 checks every operation; a loop counter bounded by the loop condition cannot overflow.",
+		GasCost: "Each guard costs a few comparison and jump instructions per iteration. The savings are a heuristic.",
+		Example: "for (uint256 i; i < n; i++)  ->  for (uint256 i; i < n;) { ... unchecked { ++i; } }",
+	},
+	"long_revert_string": {
+		Summary: "A REVERT carried an error string longer than 32 bytes.",
+		Why:     "Long strings grow the bytecode and the revert data; custom errors encode as a 4-byte selector.",
+		GasCost: "The cost is mostly deployment gas and memory for the string. The savings are a heuristic.",
+		Example: "require(ok, \"Insufficient balance for this operation\");  ->  error InsufficientBalance(); if (!ok) revert InsufficientBalance();",
+	},
+	"large_initcode": {
+		Summary: "A contract deployment's init code is close to or over the EIP-3860 limit.",
+		Why:     "Init code above 49152 bytes is rejected, and every 32-byte word costs 2 gas.",
+		GasCost: "Init code pays 2 gas per word on top of the deployment's execution.",
+		Example: "One large contract  ->  split logic into libraries or separately deployed modules.",
+	},
+	"insufficient_gas_forwarded": {
+		Summary: "A call forwarded a fixed amount of gas that may be too low for the callee's code.",
+		Why:     "Fixed stipends such as 2300 gas break when the callee needs more, for example after a fork reprices opcodes.",
+		GasCost: "No gas is saved; the finding flags a likely failure. The threshold is --min-forwarded-gas.",
+		Example: "payable(to).transfer(amount);  ->  (bool ok, ) = to.call{value: amount}(\"\"); require(ok);",
+	},
+	"gas_forwarding": {
+		Summary: "An external call forwarded all available gas.",
+		Why:     "An untrusted callee can spend everything it is given, and forwarding all gas widens reentrancy.",
+		GasCost: "No gas is saved directly. Capping the gas bounds what a callee can consume.",
+		Example: "target.call(data)  ->  target.call{gas: limit}(data) for untrusted callees.",
+	},
+	"self_call_overhead": {
+		Summary: "A contract made a CALL, CALLCODE or STATICCALL to its own address.",
+		Why:     "Calling yourself pays for a new call frame, ABI encoding and decoding, and a fresh memory; an internal function call runs the same code with a jump.",
+		GasCost: "Each self-call costs at least the 100 gas warm call plus calldata encoding. No savings are estimated. DELEGATECALL to self, as used by multicall, is not flagged.",
+		Example: "this.helper(x)  ->  make helper internal or public and call helper(x) directly.",
+	},
+	"inefficient_calldatacopy": {
+		Summary: "A CALLDATACOPY copied calldata already copied to memory earlier in the call, or copied at least a word the call never read back from memory.",
+		Why:     "Copying calldata costs gas per word plus the memory it expands into; copying a range twice or copying bytes nothing reads pays that cost for nothing.",
+		GasCost: "Savings count the 3 gas per word copied again or never read. Memory expansion the copy paid for is not counted. Reads are MLOAD, KECCAK256, LOG, MCOPY, call and create inputs and RETURN/REVERT data.",
+		Example: "bytes memory data = msg.data used for its first word  ->  take bytes calldata and read it with calldataload.",
+	},
+	"memory_expansion": {
+		Summary: "Memory grew beyond 10000 bytes.",
+		Why:     "Memory cost is quadratic in its size, so large memory gets expensive quickly.",
+		GasCost: "Memory of w words costs 3*w + w*w/512 gas in total. The finding is a heuristic.",
+		Example: "Building a large bytes array in memory  ->  process the data in chunks or read it from calldata.",
+	},
+	"sparse_memory_access": {
+		Summary: "An MLOAD, MSTORE or MSTORE8 accessed an offset far beyond the memory in use.",
+		Why:     "Memory is paid for up to the highest offset touched, so one access at a large offset pays for all the memory below it.",
+		GasCost: "Savings are the expansion cost to the accessed offset minus the cost of growing memory by one word.",
+		Example: "mstore(0x10000, value)  ->  let ptr := mload(0x40) mstore(ptr, value) mstore(0x40, add(ptr, 0x20))",
+	},
+	"redundant_zero_init": {
+		Summary: "An MSTORE or MSTORE8 wrote zero to memory that was already zero, such as fresh memory never written in the frame.",
+		Why:     "Memory starts zeroed in every call frame, so initializing it to zero changes nothing.",
+		GasCost: "Each redundant store costs 3 gas plus the instructions that push its operands. Savings count the 3 gas per store.",
+		Example: "mstore(ptr, 0) on freshly allocated memory  ->  drop the store and rely on memory starting zeroed.",
+	},
+	"expensive_opcode": {
+		Summary: "One opcode (CREATE, KECCAK256, LOG, ...) accounted for a large share of the transaction's gas.",
+		Why:     "Concentrated gas usage shows where optimization effort pays off most.",
+		GasCost: "The finding reports the opcode's gas and share of the total. No savings are estimated.",
+		Example: "Many CREATEs of the same contract  ->  minimal proxy clones (EIP-1167).",
+	},
+	"calldata_heavy": {
+		Summary: "Calldata accounted for a large share of the transaction's cost.",
+		Why:     "Calldata costs 16 gas per non-zero byte and 4 per zero byte, and dominates rollup fees.",
+		GasCost: "The finding reports the L1 calldata gas and an estimate after rollup compression (--l2-compression-ratio).",
+		Example: "Passing full uint256 values for small numbers  ->  pack arguments into fewer bytes.",
+	},
+	"multiple_transfers": {
+		Summary: "The transaction made many plain ETH transfers.",
+		Why:     "Each transfer pays the call overhead and the 9000 gas value transfer cost.",
+		GasCost: "No savings are estimated; batching or letting recipients withdraw spreads or avoids the cost.",
+		Example: "for (...) payable(r[i]).transfer(a[i]);  ->  credit balances and let each recipient withdraw (pull payments).",
+	},
+	"proxy_delegatecall": {
+		Summary: "A DELEGATECALL went to an implementation address loaded from storage, the proxy pattern.",
+		Why:     "Every call through the proxy pays a storage read and the delegatecall overhead.",
+		GasCost: "The proxy overhead is a cold SLOAD and a DELEGATECALL per call. The finding names the implementation.",
+		Example: "Upgradeable proxies  ->  immutable implementation addresses where upgrades are not needed.",
+	},
+	"safemath_overhead": {
+		Summary: "SafeMath-style overflow guards were found around arithmetic.",
+		Why:     "Solidity 0.8 checks arithmetic natively and more cheaply than library calls.",
+		GasCost: "Each guard costs a comparison, a jump and often an internal call. The savings are a heuristic.",
+		Example: "using SafeMath for uint256; a.add(b)  ->  a + b with Solidity 0.8 or later.",
+	},
+	"write_only_storage": {
+		Summary: "A storage slot was written but never read within the transaction.",
+		Why:     "The write may be unnecessary, though later transactions may still read it.",
+		GasCost: "Writes cost up to 20000 gas for a new value. The finding lists the slots and is a heuristic.",
+		Example: "lastUpdated = block.timestamp; (never read)  ->  emit an event instead, or drop the write.",
+	},
+	"unreferenced_blob": {
+		Summary: "The transaction carried EIP-4844 blobs that execution never read with BLOBHASH.",
+		Why:     "Blobs unused by the contract may be intended for off-chain consumers only, or be a mistake.",
+		GasCost: "Blob gas is priced separately from execution gas and reported in the blob gas section.",
+		Example: "A rollup batch posted without verifying its blob hash  ->  check blobhash(i) against the commitment.",
+	},
+	"stack_thrashing": {
+		Summary: "A long run of DUP/SWAP instructions executed repeatedly, especially inside a loop.",
+		Why:     "Long stack shuffles point at poor stack scheduling, typically too many live variables.",
+		GasCost: "DUP and SWAP cost 3 gas each. The finding reports the gas the run spent; savings cannot be estimated from a trace.",
+		Example: "A function juggling many local variables  ->  group them in a struct in memory, or compile with via-IR.",
+	},
+	"storage_contention": {
+		Summary: "Storage slots were accessed by several of the analyzed transactions to a contract (analyze-account).",
+		Why:     "Hot shared slots serialize transactions and are candidates for batching or reordering writes.",
+		GasCost: "No savings are estimated; the finding lists the contended slots and their transactions.",
+		Example: "A global counter updated by every user  ->  per-user counters aggregated off-chain.",
+	},
+}
+
+// Explain returns the catalog entry of an optimization type
+func Explain(typ string) (Explanation, error) {
+	explanation, ok := explanations[typ]
+	if !ok {
+		return Explanation{}, fmt.Errorf("unknown optimization type: %s", typ)
+	}
+	explanation.Type = typ
+	return explanation, nil
+}
+
+// ExplainedTypes lists the optimization types in the catalog, sorted
+func ExplainedTypes() []string {
+	types := make([]string, 0, len(explanations))
+	for typ := range explanations {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
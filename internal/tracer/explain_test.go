@@ -0,0 +1,29 @@
+package tracer
+
+import "testing"
+
+func TestExplain(t *testing.T) {
+	explanation, err := Explain("redundant_sload")
+	if err != nil {
+		t.Fatalf("Explain() error: %v", err)
+	}
+	if explanation.Type != "redundant_sload" || explanation.Summary == "" || explanation.Why == "" ||
+		explanation.GasCost == "" || explanation.Example == "" {
+		t.Errorf("Expected a complete explanation, got %+v", explanation)
+	}
+
+	if _, err := Explain("no_such_type"); err == nil {
+		t.Error("Expected an error for an unknown optimization type")
+	}
+}
+
+func TestExplainCoversEveryType(t *testing.T) {
+	for typ := range expectedConfidence {
+		if _, err := Explain(typ); err != nil {
+			t.Errorf("Expected an explanation for %s", typ)
+		}
+	}
+	if len(ExplainedTypes()) != len(expectedConfidence)+1 {
+		t.Errorf("Expected every explained type except storage_contention to be traced, got %d types for %d traced", len(ExplainedTypes()), len(expectedConfidence))
+	}
+}
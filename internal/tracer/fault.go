@@ -0,0 +1,96 @@
+package tracer
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// Fault is an opcode that stopped its frame with an error other than REVERT
+type Fault struct {
+	PC       uint64
+	Op       string
+	Depth    int
+	Contract common.Address
+	Gas      uint64 // Gas remaining in the frame before the opcode
+	Cost     uint64 // Cost of the opcode
+	Error    string
+	OutOfGas bool
+}
+
+// Location returns the fault's PC formatted like optimization locations
+func (f Fault) Location() string {
+	return formatPC(f.PC)
+}
+
+// recordFault records the error raised by an opcode. Reverts are reported
+// through the call tree and reverted call counts instead.
+func (t *GasOptimizationTracer) recordFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+	if errors.Is(err, vm.ErrExecutionReverted) {
+		return
+	}
+	t.Faults = append(t.Faults, Fault{
+		PC:       pc,
+		Op:       op.String(),
+		Depth:    depth,
+		Contract: contractAddress(scope),
+		Gas:      gas,
+		Cost:     cost,
+		Error:    err.Error(),
+		OutOfGas: errors.Is(err, vm.ErrOutOfGas) || errors.Is(err, vm.ErrGasUintOverflow),
+	})
+}
+
+// faultKey identifies a fault site and its error
+type faultKey struct {
+	contract common.Address
+	pc       uint64
+	err      string
+}
+
+// analyzeFaults reports each distinct fault as a high-severity diagnostic
+func (t *GasOptimizationTracer) analyzeFaults() {
+	findings := make(map[faultKey]int)
+
+	for _, fault := range t.Faults {
+		key := faultKey{contract: fault.Contract, pc: fault.PC, err: fault.Error}
+		if idx, ok := findings[key]; ok {
+			t.Optimizations[idx].Details["occurrences"] = t.Optimizations[idx].Details["occurrences"].(int) + 1
+			continue
+		}
+
+		description := "Execution faulted at this opcode (" + fault.Error + ") - the frame's remaining gas was consumed"
+		if fault.OutOfGas {
+			description = "Execution ran out of gas at this opcode - the operation is too expensive for the gas provided; reduce the work done or raise the gas limit"
+		}
+
+		findings[key] = len(t.Optimizations)
+		t.Optimizations = append(t.Optimizations, Optimization{
+			Type:        "execution_fault",
+			Severity:    "high",
+			Description: description,
+			Location:    ContractPCLocation(fault.Contract, fault.PC),
+			GasSavings:  0,
+			Confidence:  "heuristic",
+			Details: map[string]interface{}{
+				"opcode":      fault.Op,
+				"error":       fault.Error,
+				"depth":       fault.Depth,
+				"contract":    fault.Contract.Hex(),
+				"gas":         fault.Gas,
+				"cost":        fault.Cost,
+				"out_of_gas":  fault.OutOfGas,
+				"occurrences": 1,
+			},
+		})
+	}
+}
+
+// GetFaults returns the opcodes that stopped a frame with an error
+func (t *GasOptimizationTracer) GetFaults() []Fault {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]Fault(nil), t.Faults...)
+}
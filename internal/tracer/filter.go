@@ -0,0 +1,150 @@
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+)
+
+// severityRanks orders severity levels from least to most important
+var severityRanks = map[string]int{
+	"low":    1,
+	"medium": 2,
+	"high":   3,
+}
+
+// SeverityRank returns the ordering rank of a severity level, or 0 if unknown
+func SeverityRank(severity string) int {
+	return severityRanks[severity]
+}
+
+// FilterCriteria selects which optimizations are reported
+type FilterCriteria struct {
+	MinSeverity  string        // Drop optimizations below this severity; empty keeps all
+	OnlyTypes    []string      // Keep only these types; empty keeps all
+	ExcludeTypes []string      // Drop these types; takes precedence over OnlyTypes
+	Suppress     []Suppression // Drop the accepted findings these match
+}
+
+// Suppression matches accepted findings, such as those of a CI baseline, so that
+// only new findings are reported. Type and Location are patterns in which *
+// matches any run of characters and ? any one character; an empty pattern
+// matches anything.
+type Suppression struct {
+	Type     string
+	Location string
+}
+
+// Matches reports whether the suppression covers opt
+func (s Suppression) Matches(opt Optimization) bool {
+	return matchPattern(s.Type, opt.Type) && matchPattern(s.Location, opt.Location.String())
+}
+
+// matchPattern reports whether value matches a suppression pattern
+func matchPattern(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
+}
+
+// Validate checks that the criteria reference known severity levels and that
+// the suppressions are well-formed
+func (c FilterCriteria) Validate() error {
+	if c.MinSeverity != "" && SeverityRank(c.MinSeverity) == 0 {
+		return fmt.Errorf("unknown severity: %s", c.MinSeverity)
+	}
+	for _, s := range c.Suppress {
+		if s.Type == "" && s.Location == "" {
+			return errors.New("suppression needs a type or a location")
+		}
+		for _, pattern := range []string{s.Type, s.Location} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid suppression pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// FilterOptimizations returns the optimizations matching the criteria, preserving order
+func FilterOptimizations(optimizations []Optimization, criteria FilterCriteria) []Optimization {
+	minRank := SeverityRank(criteria.MinSeverity)
+	only := toSet(criteria.OnlyTypes)
+	exclude := toSet(criteria.ExcludeTypes)
+
+	filtered := make([]Optimization, 0, len(optimizations))
+	for _, opt := range optimizations {
+		if SeverityRank(opt.Severity) < minRank {
+			continue
+		}
+		if len(only) > 0 && !only[opt.Type] {
+			continue
+		}
+		if exclude[opt.Type] || suppressed(opt, criteria.Suppress) {
+			continue
+		}
+		filtered = append(filtered, opt)
+	}
+	return filtered
+}
+
+// suppressed reports whether any of the suppressions covers opt
+func suppressed(opt Optimization, suppressions []Suppression) bool {
+	for _, s := range suppressions {
+		if s.Matches(opt) {
+			return true
+		}
+	}
+	return false
+}
+
+// SortOptimizations orders optimizations by severity (highest first), then by
+// code location, then by type. Locations that are not a PC sort after PCs.
+// Ties keep their original order.
+func SortOptimizations(optimizations []Optimization) {
+	sort.SliceStable(optimizations, func(i, j int) bool {
+		a, b := optimizations[i], optimizations[j]
+		if ra, rb := SeverityRank(a.Severity), SeverityRank(b.Severity); ra != rb {
+			return ra > rb
+		}
+
+		okA, okB := a.Location.IsPC(), b.Location.IsPC()
+		switch {
+		case okA != okB:
+			return okA
+		case okA && a.Location.PC != b.Location.PC:
+			return a.Location.PC < b.Location.PC
+		case !okA && a.Location.String() != b.Location.String():
+			return a.Location.String() < b.Location.String()
+		}
+		return a.Type < b.Type
+	})
+}
+
+// sortedOptimizations returns a copy of the identified optimizations in stable order
+func (t *GasOptimizationTracer) sortedOptimizations() []Optimization {
+	optimizations := make([]Optimization, len(t.Optimizations))
+	copy(optimizations, t.Optimizations)
+	SortOptimizations(optimizations)
+	return optimizations
+}
+
+// ApplyFilter drops identified optimizations that do not match the criteria,
+// so that every output format reports the same filtered set
+func (t *GasOptimizationTracer) ApplyFilter(criteria FilterCriteria) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.Optimizations = FilterOptimizations(t.Optimizations, criteria)
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
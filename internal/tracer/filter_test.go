@@ -0,0 +1,168 @@
+package tracer
+
+import "testing"
+
+func filterFixture() []Optimization {
+	return []Optimization{
+		{Type: "redundant_sload", Severity: "high"},
+		{Type: "multiple_calls", Severity: "medium"},
+		{Type: "gas_forwarding", Severity: "low"},
+		{Type: "storage_in_loop", Severity: "high"},
+	}
+}
+
+func TestFilterMinSeverity(t *testing.T) {
+	filtered := FilterOptimizations(filterFixture(), FilterCriteria{MinSeverity: "high"})
+
+	if len(filtered) != 2 {
+		t.Fatalf("Expected 2 optimizations, got %d", len(filtered))
+	}
+
+	for _, opt := range filtered {
+		if opt.Severity != "high" {
+			t.Errorf("Expected only high severity, got '%s'", opt.Severity)
+		}
+	}
+
+	filtered = FilterOptimizations(filterFixture(), FilterCriteria{MinSeverity: "medium"})
+	if len(filtered) != 3 {
+		t.Errorf("Expected 3 optimizations at medium and above, got %d", len(filtered))
+	}
+}
+
+func TestFilterTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		criteria FilterCriteria
+		expected []string
+	}{
+		{
+			name:     "no criteria",
+			criteria: FilterCriteria{},
+			expected: []string{"redundant_sload", "multiple_calls", "gas_forwarding", "storage_in_loop"},
+		},
+		{
+			name:     "only",
+			criteria: FilterCriteria{OnlyTypes: []string{"gas_forwarding", "multiple_calls"}},
+			expected: []string{"multiple_calls", "gas_forwarding"},
+		},
+		{
+			name:     "exclude",
+			criteria: FilterCriteria{ExcludeTypes: []string{"redundant_sload"}},
+			expected: []string{"multiple_calls", "gas_forwarding", "storage_in_loop"},
+		},
+		{
+			name: "exclude wins over only",
+			criteria: FilterCriteria{
+				OnlyTypes:    []string{"redundant_sload", "storage_in_loop"},
+				ExcludeTypes: []string{"redundant_sload"},
+			},
+			expected: []string{"storage_in_loop"},
+		},
+		{
+			name: "only combined with min severity",
+			criteria: FilterCriteria{
+				MinSeverity: "medium",
+				OnlyTypes:   []string{"gas_forwarding", "multiple_calls"},
+			},
+			expected: []string{"multiple_calls"},
+		},
+	}
+
+	for _, tt := range tests {
+		filtered := FilterOptimizations(filterFixture(), tt.criteria)
+		if len(filtered) != len(tt.expected) {
+			t.Errorf("%s: expected %d optimizations, got %d", tt.name, len(tt.expected), len(filtered))
+			continue
+		}
+		for i, opt := range filtered {
+			if opt.Type != tt.expected[i] {
+				t.Errorf("%s: expected type '%s' at %d, got '%s'", tt.name, tt.expected[i], i, opt.Type)
+			}
+		}
+	}
+}
+
+func TestFilterSuppressions(t *testing.T) {
+	optimizations := []Optimization{
+		{Type: "redundant_sload", Severity: "high", Location: PCLocation(0x1a)},
+		{Type: "redundant_sload", Severity: "high", Location: PCLocation(0x2b)},
+		{Type: "storage_in_loop", Severity: "high", Location: PCLocation(0x1c)},
+		{Type: "gas_forwarding", Severity: "low", Location: PCLocation(0x40)},
+	}
+
+	filtered := FilterOptimizations(optimizations, FilterCriteria{Suppress: []Suppression{
+		{Type: "redundant_sload", Location: "0x1*"},
+		{Type: "gas_*"},
+	}})
+	if len(filtered) != 2 || filtered[0].Location.String() != "0x2b" || filtered[1].Type != "storage_in_loop" {
+		t.Errorf("Expected the suppressed findings to be dropped, got %+v", filtered)
+	}
+
+	filtered = FilterOptimizations(optimizations, FilterCriteria{Suppress: []Suppression{{Location: "0x1?"}}})
+	if len(filtered) != 2 {
+		t.Errorf("Expected a location-only suppression to match every type, got %+v", filtered)
+	}
+}
+
+func TestFilterCriteriaValidate(t *testing.T) {
+	if err := (FilterCriteria{MinSeverity: "critical"}).Validate(); err == nil {
+		t.Error("Expected error for unknown severity")
+	}
+
+	if err := (FilterCriteria{Suppress: []Suppression{{}}}).Validate(); err == nil {
+		t.Error("Expected error for a suppression matching everything")
+	}
+	if err := (FilterCriteria{Suppress: []Suppression{{Location: "0x[1"}}}).Validate(); err == nil {
+		t.Error("Expected error for an invalid suppression pattern")
+	}
+
+	if err := (FilterCriteria{MinSeverity: "low"}).Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestApplyFilter(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	tracer.Optimizations = filterFixture()
+
+	tracer.ApplyFilter(FilterCriteria{ExcludeTypes: []string{"gas_forwarding"}})
+
+	report, err := tracer.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+
+	if contains(report, "gas_forwarding") {
+		t.Error("Expected filtered type to be absent from the JSON report")
+	}
+}
+
+func TestSortOptimizations(t *testing.T) {
+	optimizations := []Optimization{
+		{Type: "expensive_opcode", Severity: "medium", Location: MultipleLocation()},
+		{Type: "redundant_sload", Severity: "high", Location: PCLocation(0x1a)},
+		{Type: "calldata_heavy", Severity: "medium", Location: ScopeLocation(ScopeCalldata)},
+		{Type: "redundant_hash", Severity: "medium", Location: PCLocation(0x0c)},
+		{Type: "redundant_sload", Severity: "high", Location: PCLocation(0x0)},
+		{Type: "gas_forwarding", Severity: "low", Location: PCLocation(0x05)},
+		{Type: "multiple_calls", Severity: "medium", Location: PCLocation(0x0c)},
+	}
+
+	SortOptimizations(optimizations)
+
+	expected := []string{
+		"redundant_sload@0x",
+		"redundant_sload@0x1a",
+		"multiple_calls@0x0c",
+		"redundant_hash@0x0c",
+		"calldata_heavy@calldata",
+		"expensive_opcode@multiple",
+		"gas_forwarding@0x05",
+	}
+	for i, opt := range optimizations {
+		if got := opt.Type + "@" + opt.Location.String(); got != expected[i] {
+			t.Errorf("Expected %s at position %d, got %s", expected[i], i, got)
+		}
+	}
+}
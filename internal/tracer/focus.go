@@ -0,0 +1,53 @@
+package tracer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// ParseOpcodes converts opcode names such as "SLOAD" into opcodes, case-insensitively
+func ParseOpcodes(names []string) ([]vm.OpCode, error) {
+	ops := make([]vm.OpCode, 0, len(names))
+	for _, name := range names {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		op := vm.StringToOp(name)
+		// Unknown names map to STOP
+		if op == vm.STOP && name != "STOP" {
+			return nil, fmt.Errorf("unknown opcode: %s", name)
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// SetOpcodeFocus restricts the detailed per-step work of CaptureState, such as
+// detectors and operation records, to the focus opcodes (all opcodes when focus
+// is empty) minus the ignored ones. Gas and opcode counts are still accumulated
+// for every step, and the call tree is still built.
+func (t *GasOptimizationTracer) SetOpcodeFocus(focus, ignore []vm.OpCode) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(focus) == 0 && len(ignore) == 0 {
+		t.opFocus = nil
+		return
+	}
+
+	t.opFocus = new([256]bool)
+	for op := range t.opFocus {
+		t.opFocus[op] = len(focus) == 0
+	}
+	for _, op := range focus {
+		t.opFocus[op] = true
+	}
+	for _, op := range ignore {
+		t.opFocus[op] = false
+	}
+}
+
+// focused reports whether CaptureState does detailed work for op
+func (t *GasOptimizationTracer) focused(op vm.OpCode) bool {
+	return t.opFocus == nil || t.opFocus[op]
+}
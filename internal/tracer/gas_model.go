@@ -0,0 +1,98 @@
+package tracer
+
+import "github.com/ethereum/go-ethereum/params"
+
+// GasModel holds the gas costs that savings estimates are based on for one fork
+type GasModel struct {
+	Fork string // Newest fork active on the traced chain
+
+	SloadGas       uint64 // Cost of an SLOAD repeating an earlier read (warm since Berlin)
+	AccountGas     uint64 // Cost of a BALANCE/EXTCODESIZE/EXTCODEHASH repeating an earlier query
+	CallGas        uint64 // Per-call overhead assumed saved by batching calls
+	ColdSloadGas   uint64 // Cost of an SLOAD of a slot not yet accessed, 0 before Berlin
+	ColdAccountGas uint64 // Cost of the first access to an account, 0 before Berlin
+	SstoreSetGas   uint64 // SSTORE of a clean zero slot to non-zero
+	SstoreResetGas uint64 // SSTORE of a clean non-zero slot to another value, before any Berlin deduction
+}
+
+// GasModelForRules returns the gas model of the newest fork enabled in rules
+func GasModelForRules(rules params.Rules) GasModel {
+	model := GasModel{
+		Fork:           forkName(rules),
+		SloadGas:       params.SloadGasFrontier,
+		AccountGas:     params.BalanceGasFrontier,
+		CallGas:        params.CallGasFrontier,
+		SstoreSetGas:   params.SstoreSetGas,
+		SstoreResetGas: params.SstoreResetGas,
+	}
+
+	switch {
+	case rules.IsBerlin:
+		model.SloadGas = params.WarmStorageReadCostEIP2929
+		model.AccountGas = params.WarmStorageReadCostEIP2929
+		model.CallGas = 2100
+		model.ColdSloadGas = params.ColdSloadCostEIP2929
+		model.ColdAccountGas = params.ColdAccountAccessCostEIP2929
+	case rules.IsIstanbul:
+		model.SloadGas = params.SloadGasEIP2200
+		model.AccountGas = params.BalanceGasEIP1884
+		model.CallGas = params.CallGasEIP150
+	case rules.IsEIP150:
+		model.SloadGas = params.SloadGasEIP150
+		model.AccountGas = params.BalanceGasEIP150
+		model.CallGas = params.CallGasEIP150
+	}
+	return model
+}
+
+// DefaultGasModel returns the gas model used before a trace reveals its chain,
+// matching the latest fork
+func DefaultGasModel() GasModel {
+	return GasModelForRules(params.Rules{
+		IsEIP150: true, IsIstanbul: true, IsBerlin: true, IsLondon: true,
+		IsMerge: true, IsShanghai: true, IsCancun: true,
+	})
+}
+
+// forkName returns the name of the newest fork enabled in rules
+func forkName(rules params.Rules) string {
+	switch {
+	case rules.IsPrague:
+		return "prague"
+	case rules.IsCancun:
+		return "cancun"
+	case rules.IsShanghai:
+		return "shanghai"
+	case rules.IsMerge:
+		return "merge"
+	case rules.IsLondon:
+		return "london"
+	case rules.IsBerlin:
+		return "berlin"
+	case rules.IsIstanbul:
+		return "istanbul"
+	case rules.IsPetersburg:
+		return "petersburg"
+	case rules.IsConstantinople:
+		return "constantinople"
+	case rules.IsByzantium:
+		return "byzantium"
+	case rules.IsEIP158:
+		return "spurious_dragon"
+	case rules.IsEIP150:
+		return "tangerine_whistle"
+	case rules.IsHomestead:
+		return "homestead"
+	}
+	return "frontier"
+}
+
+// accessListAddressSavings is the net gas saved by listing an account in an access list
+func (m GasModel) accessListAddressSavings() int64 {
+	return int64(m.ColdAccountGas-m.AccountGas) - int64(params.TxAccessListAddressGas)
+}
+
+// accessListSlotSavings is the net gas saved by listing a storage slot in an access list
+func (m GasModel) accessListSlotSavings() int64 {
+	return int64(m.ColdSloadGas-m.SloadGas) - int64(params.TxAccessListStorageKeyGas)
+}
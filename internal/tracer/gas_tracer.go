@@ -2,55 +2,336 @@ package tracer
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"math/big"
+	"sort"
 	"sync"
+	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	u256 "github.com/holiman/uint256"
 )
 
+// batchTransferThreshold is the number of ETH transfers in one transaction above which batching is suggested
+const batchTransferThreshold = 3
+
+// largeInitCodeSize is the init code size above which a deployment is flagged.
+// It is half of the EIP-3860 initcode limit.
+const largeInitCodeSize = params.MaxCodeSize
+
+// Config holds the tunable thresholds used by the tracer's heuristics
+type Config struct {
+	// MinForwardedGas is the gas floor at or below which a fixed-gas call
+	// into a contract is flagged as likely insufficient
+	MinForwardedGas uint64
+
+	// L2CompressionRatio is the fraction of calldata gas assumed to remain
+	// after rollup compression when estimating L2 calldata cost
+	L2CompressionRatio float64
+}
+
+// DefaultConfig returns the default tracer configuration
+func DefaultConfig() Config {
+	return Config{
+		MinForwardedGas:    params.CallStipend,
+		L2CompressionRatio: 0.3,
+	}
+}
+
 // GasOptimizationTracer is a custom tracer that tracks gas optimization opportunities
 type GasOptimizationTracer struct {
-	mu sync.Mutex
+	mu       sync.Mutex
+	config   Config
+	gasModel GasModel // Gas costs of the traced chain's fork
+	env      *vm.EVM
 
 	// Tracking data
-	StorageReads  map[common.Hash]int  // Track repeated SLOAD operations
-	StorageWrites map[common.Hash]int  // Track SSTORE operations
-	MemoryOps     []MemoryOperation    // Track memory operations
-	CallOps       []CallOperation      // Track call operations
-	Loops         []LoopDetection      // Detect potential loops
-	ExpensiveOps  []ExpensiveOperation // Track expensive operations
-	GasPerOpcode  map[string]uint64    // Gas used per opcode
+	StorageReads         map[common.Hash]int    // Track repeated SLOAD operations
+	StorageWrites        map[common.Hash]int    // Track SSTORE operations
+	TransientReads       map[common.Hash]int    // Track TLOAD operations
+	TransientWrites      map[common.Hash]int    // Track TSTORE operations
+	MemoryOps            []MemoryOperation      // Track memory operations
+	CallOps              []CallOperation        // Track call operations
+	StorageAccesses      int                    // Number of SLOAD/SSTORE operations, aggregated by location
+	Loops                []LoopDetection        // Detect potential loops
+	ExpensiveOps         []ExpensiveOperation   // Track expensive operations
+	GasPerOpcode         map[string]uint64      // Gas used per opcode
+	OpcodeCounts         map[string]uint64      // Execution count per opcode
+	HashCounts           map[common.Hash]int    // Track repeated KECCAK256 results
+	AccountChecks        map[common.Address]int // Track BALANCE/EXTCODESIZE/EXTCODEHASH queries per address
+	LogOps               []LogOperation         // Track LOG operations with their operands
+	LogGas               uint64                 // Total intrinsic gas spent on LOG operations
+	ColdAccesses         []ColdAccess           // First cold access to each address and storage slot
+	WriteOnlySlots       []WriteOnlySlot        // Slots written but never read, populated at CaptureEnd
+	ProxyImplementations []ProxyImplementation  // DELEGATECALL targets loaded from storage
+	SlotAccessOrders     []SlotAccessOrder      // Read/write order of each storage slot, populated at CaptureEnd
+	CallTree             *CallNode              // Root frame of the call tree, nil before CaptureStart
 
 	// Current state
 	Stack        []uint256 // Current stack state
 	Memory       []byte    // Current memory
 	PC           uint64    // Program counter
-	Gas          uint64    // Remaining gas
+	Gas          uint64    // Remaining gas in the executing frame as of the latest step
+	GasLimit     uint64    // Gas limit of the transaction, set by CaptureTxStart
 	Depth        int       // Call depth
 	TotalGasUsed uint64    // Total gas used
 
+	// GasAccountingDelta is the difference between the per-step running total and
+	// the authoritative gas used reported by CaptureEnd. It is zero for a consistent trace.
+	GasAccountingDelta int64
+
+	// SimulatedState names the state a simulated execution ran against, such as
+	// StatePending, and is empty for replays of mined transactions
+	SimulatedState string
+
+	// Faults lists the opcodes that stopped a frame with an error, such as running out of gas
+	Faults []Fault
+
+	// Timeline samples the cumulative gas consumed against step index when enabled
+	Timeline []TimelinePoint
+
+	// Partial explains why execution stopped before the trace completed, and is
+	// empty for a complete trace
+	Partial string
+
+	// Deployment tracking
+	IsCreation     bool           // Whether the transaction deploys a contract
+	CreatedAddress common.Address // Address of the deployed contract
+	InitCodeSize   int            // Size of the init code in bytes
+	InitCodeGas    uint64         // Gas spent executing init code
+
+	// Reverted subcalls
+	RevertedGas   uint64 // Gas burned by reverted subcall frames, counting nested reverts once
+	RevertedCalls int    // Number of reverted subcall frames
+
+	// Calldata analysis
+	Calldata CalldataAnalysis // Size and cost of the transaction's calldata
+
+	// Blob analysis
+	Blobs *BlobAnalysis // Blobs carried by a type-3 transaction, nil for other transactions
+
 	// Analysis results
 	Optimizations []Optimization // Identified optimizations
+
+	// Call frame correlation
+	frames      []callFrame // Currently entered call frames
+	pendingCall int         // Index into CallOps of a call awaiting CaptureEnter, or -1
+	pendingOpt  int         // Index into Optimizations flagged for the pending call, or -1
+
+	// Finding correlation
+	sloadFindings   map[common.Hash]int    // Index into Optimizations of each redundant_sload finding
+	hashFindings    map[common.Hash]int    // Index into Optimizations of each redundant_hash finding
+	accountFindings map[common.Address]int // Index into Optimizations of each redundant_account_access finding
+	loopStates      map[loopKey]*loopState // Bookkeeping for each detected loop
+
+	// Proxy detection
+	pendingLoad   *storageLoad                // SLOAD whose result is not yet on the stack, or nil
+	loadedTargets map[loadedValue]common.Hash // Slot each address-sized value was loaded from
+	proxyFindings map[proxyKey]int            // Index into Optimizations of each proxy_delegatecall finding
+
+	// SafeMath guard matching
+	guard            *arithmeticGuard    // Arithmetic op awaiting its overflow guard, or nil
+	safeMathFindings map[safeMathKey]int // Index into Optimizations of each safemath_overhead finding
+
+	// Overflow guards, for unchecked block suggestions
+	overflowCheck      *overflowCheck      // Comparison awaiting the ADD it may guard, or nil
+	overflowGuards     []overflowGuard     // Addition overflow guard sites in order of first execution
+	overflowGuardIndex map[safeMathKey]int // Index into overflowGuards of each site
+
+	// Incremental aggregates, maintained during tracing so the final analysis
+	// does not rescan the recorded operations
+	storageSites  map[storageSite]*siteUsage       // Storage accesses aggregated by code location
+	contractSites map[common.Address][]storageSite // Storage sites per contract, in order of first access
+	maxGasOpcode  string                           // Opcode with the most gas in GasPerOpcode
+
+	// Transient use of storage
+	slotWrites     map[storageSlot]*slotHistory // Values written to each storage slot
+	slotWriteOrder []storageSlot                // Written slots in order of first write
+
+	// Storage access ordering
+	slotAccesses    map[storageSlot]*slotAccesses // Reads and writes of each storage slot
+	slotAccessOrder []storageSlot                 // Accessed slots in order of first access
+	pendingRead     *pendingRead                  // First SLOAD of a slot awaiting its consumer, or nil
+
+	// Memory copy detection
+	mcopyActive         bool                            // Whether MCOPY (EIP-5656) is available on the traced chain
+	memorySites         map[memorySite]*memoryStride    // Offsets accessed by each MLOAD/MSTORE site
+	contractMemorySites map[common.Address][]memorySite // Memory sites per contract, in order of first access
+
+	// Redundant zero-initialization of memory
+	zeroInitSites map[memorySite]int // Zero writes to already-zero memory at each MSTORE/MSTORE8 site
+	zeroInitOrder []memorySite       // Sites with such writes, in order of first write
+
+	// Call loop detection
+	callSites         map[callSite]*callSiteUsage   // Calls made at each call site
+	contractCallSites map[common.Address][]callSite // Call sites per contract, in order of first call
+
+	// Self-call detection
+	selfCallSites map[callSite]*selfCallUsage // Calls to the executing contract at each call site
+	selfCallOrder []callSite                  // Sites with such calls, in order of first call
+
+	// Calldata copy detection
+	calldataCopies    map[*CallNode][]*calldataCopy     // CALLDATACOPYs tracked in each executing call frame
+	calldataCopySites map[memorySite]*calldataCopyUsage // Copies made at each CALLDATACOPY site
+	calldataCopyOrder []memorySite                      // CALLDATACOPY sites in order of first copy
+
+	// Stack thrashing detection
+	stackRun      stackRun                        // Run of DUP/SWAP instructions currently executing
+	stackRunSites map[stackRunSite]*stackRunUsage // Executions of each long run
+	stackRunOrder []stackRunSite                  // Long runs in order of first execution
+
+	// Context opcode tracking
+	prevOp              vm.OpCode           // Opcode of the previous step
+	prevDepth           int                 // Depth of the previous step
+	selfBalanceActive   bool                // Whether SELFBALANCE (EIP-1884) is available on the traced chain
+	contextReads        map[contextRead]int // Evaluations of each frame-constant context opcode per frame
+	contextFindings     map[contextRead]int // Index into Optimizations of each redundant_context_read finding
+	selfBalanceFindings map[contextSite]int // Index into Optimizations of each use_selfbalance finding
+
+	// PUSH0 detection
+	push0Active   bool              // Whether PUSH0 (EIP-3855) is available on the traced chain
+	pushZeroCount int               // Number of PUSH1 0x00 instructions executed
+	pushZeroSites map[pushSite]bool // Distinct code locations of the executed PUSH1 0x00 instructions
+
+	// Timeline sampling
+	timelineMax    int    // Bound on the timeline points kept, or zero when disabled
+	timelineStride uint64 // Steps between timeline samples
+
+	// Opcode focus
+	opFocus *[256]bool // Opcodes that CaptureState does detailed work for, or nil for all
+
+	// Mapping access detection
+	mappingInputs   map[common.Hash]mappingInput   // Key and slot of each 64-byte KECCAK256 input, by hash
+	mappingAccesses map[storageSlot]*mappingAccess // Storage accesses to slots computed by KECCAK256
+	mappingOrder    []storageSlot                  // Keys of mappingAccesses in order of first access
+
+	// Revert string detection
+	revertStrings []revertString // REVERTs whose data is a long ASCII message
+
+	// Access list tracking
+	accessListActive bool                                    // Whether EIP-2929 access lists apply to this transaction
+	warmAddresses    map[common.Address]bool                 // Addresses warm at the current point of execution
+	warmSlots        map[common.Address]map[common.Hash]bool // Storage slots warm at the current point of execution
+	warmJournal      []warmEntry                             // Additions to the warm sets in order, undone when their frame reverts
+
+	// Progress reporting
+	steps atomic.Uint64 // Number of steps executed, readable while tracing
+
+	// Step limit
+	maxSteps  uint64      // Steps traced before execution is aborted, or zero
+	truncated atomic.Bool // Whether execution was aborted by the step limit
+
+	// What-if analysis
+	baseline GasSchedule // Alternate gas schedule compared against in the report, or nil
+
+	// Report sections enabled on demand
+	internalTxs bool     // Whether the report lists internal transactions
+	omittedKeys []string // Keys left out of the report, see OmitReportKeys
+
+	// Report loaded by RestoreReport in place of a trace, or nil
+	restored map[string]json.RawMessage
+
+	// Minimal mode, see SetMinimal
+	minimal     bool
+	minimalGas  [256]uint64 // Gas per opcode accumulated by CaptureState, flushed into GasPerOpcode at CaptureEnd
+	minimalSeen [256]bool   // Opcodes executed, so that free ones such as STOP are still listed
+
+	// Sampling of minimal traces, see SetSampleRate
+	sampleRate   uint64                     // One step recorded out of every sampleRate when above 1
+	sampledSteps uint64                     // Steps recorded
+	samplePCs    map[sampleSite]*sampleHits // Samples of each code location
+
+	// Disassembly of finding locations
+	disasmWindow  int                       // Instructions shown either side of a location, 0 when disabled
+	contractCode  map[common.Address][]byte // Code executed by each contract
+	entryContract common.Address            // Contract the transaction executes first
+
+	// Step streaming
+	stepEncoder *json.Encoder // Encoder for JSON-lines step output, nil when disabled
+	stepErr     error         // First error encountered while streaming steps
+}
+
+// Step is a single EVM execution step as streamed in JSON-lines output
+type Step struct {
+	PC    uint64 `json:"pc"`
+	Op    string `json:"op"`
+	Gas   uint64 `json:"gas"`
+	Cost  uint64 `json:"cost"`
+	Depth int    `json:"depth"`
+}
+
+// callFrame links an entered call frame back to the call site that opened it
+type callFrame struct {
+	op        vm.OpCode // Opcode that opened the frame
+	callIndex int       // Index into CallOps, or -1 if not opened by a tracked call
+	optIndex  int       // Index into Optimizations of a finding tied to the call, or -1
+	startGas  uint64    // TotalGasUsed when the frame was entered
+	allowance uint64    // Gas made available to the frame
+	wasted    uint64    // Gas burned by reverted descendant frames
+	warmMark  int       // Length of warmJournal when the frame was entered
+	node      *CallNode // Call tree node of the frame
+}
+
+// chargedAllowance returns the part of the frame's allowance that the opening
+// step's cost already included. A call's dynamic gas covers the gas it forwards,
+// while CREATE and CREATE2 deduct the allowance outside their step cost.
+func (f callFrame) chargedAllowance() uint64 {
+	switch f.op {
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		return f.allowance
+	}
+	return 0
 }
 
 type MemoryOperation struct {
-	PC    uint64
-	Op    string
-	Size  uint64
-	Gas   uint64
-	Depth int
+	PC     uint64
+	Op     string
+	Offset uint64 // Offset operand, saturated at the maximum uint64
+	Size   uint64
+	Gas    uint64
+	Depth  int
 }
 
 type CallOperation struct {
-	PC      uint64
-	Op      string
-	To      common.Address
-	Value   *big.Int
-	Gas     uint64
-	GasUsed uint64
-	Success bool
-	Depth   int
+	PC         uint64
+	Op         string
+	To         common.Address
+	Value      *big.Int
+	Gas        uint64
+	GasUsed    uint64 // Cost of the call step, replaced by the callee frame's gas used once it exits
+	Success    bool
+	Depth      int
+	IsTransfer bool   // Value-bearing call with empty calldata (plain ETH transfer)
+	Precompile string // Name of the precompiled contract called, e.g. "ecrecover", or empty
+
+	// Function selector decoding
+	Selector          [4]byte // First four bytes of the calldata
+	HasSelector       bool    // Whether the calldata was long enough to carry a selector
+	Signature         string  // Resolved function signature, e.g. "transfer(address,uint256)"
+	SignatureVerified bool    // Whether Signature came from a supplied ABI rather than a guess
+
+	// Call data, capped at maxCallData bytes each
+	Input      []byte // Calldata read from memory at the call site
+	InputSize  uint64 // Length of the full calldata
+	Output     []byte // Return or revert data of the callee frame
+	OutputSize int    // Length of the full return data
+}
+
+// maxCallData bounds the calldata and return data kept per call operation
+const maxCallData = 4096
+
+type StorageOperation struct {
+	PC       uint64
+	Op       string
+	Key      common.Hash
+	Gas      uint64
+	Depth    int
+	Contract common.Address
 }
 
 type LoopDetection struct {
@@ -58,6 +339,7 @@ type LoopDetection struct {
 	EndPC      uint64
 	Iterations int
 	GasPerLoop uint64
+	Contract   common.Address
 }
 
 type ExpensiveOperation struct {
@@ -72,28 +354,272 @@ type Optimization struct {
 	Type        string
 	Severity    string // "high", "medium", "low"
 	Description string
-	Location    string
+	Location    Location
 	GasSavings  uint64
+	Confidence  string // "exact", "estimated", "heuristic"
 	Details     map[string]interface{}
 }
 
+// Stats is a point-in-time summary of the data collected by the tracer
+type Stats struct {
+	Steps         uint64 // Execution steps traced
+	TotalGasUsed  uint64 // Total gas used
+	Depth         int    // Current call depth
+	StorageReads  int    // SLOAD operations
+	StorageWrites int    // SSTORE operations
+	CallOps       int    // Call operations
+	MemoryOps     int    // Memory operations
+	ExpensiveOps  int    // Expensive operations
+	LogOps        int    // LOG operations
+	RevertedCalls int    // Reverted subcall frames
+	RevertedGas   uint64 // Gas burned by reverted subcall frames
+	Optimizations int    // Optimizations identified so far
+}
+
 type uint256 [32]byte
 
 // NewGasOptimizationTracer creates a new gas optimization tracer
 func NewGasOptimizationTracer() *GasOptimizationTracer {
+	return NewGasOptimizationTracerWithConfig(DefaultConfig())
+}
+
+// NewGasOptimizationTracerWithConfig creates a new gas optimization tracer with custom thresholds
+func NewGasOptimizationTracerWithConfig(config Config) *GasOptimizationTracer {
 	return &GasOptimizationTracer{
-		StorageReads:  make(map[common.Hash]int),
-		StorageWrites: make(map[common.Hash]int),
-		MemoryOps:     make([]MemoryOperation, 0),
-		CallOps:       make([]CallOperation, 0),
-		Loops:         make([]LoopDetection, 0),
-		ExpensiveOps:  make([]ExpensiveOperation, 0),
-		GasPerOpcode:  make(map[string]uint64),
-		Optimizations: make([]Optimization, 0),
-		Stack:         make([]uint256, 0),
+		config:               config,
+		gasModel:             DefaultGasModel(),
+		pendingCall:          -1,
+		pendingOpt:           -1,
+		sloadFindings:        make(map[common.Hash]int),
+		hashFindings:         make(map[common.Hash]int),
+		mappingInputs:        make(map[common.Hash]mappingInput),
+		mappingAccesses:      make(map[storageSlot]*mappingAccess),
+		accountFindings:      make(map[common.Address]int),
+		loopStates:           make(map[loopKey]*loopState),
+		pushZeroSites:        make(map[pushSite]bool),
+		contextReads:         make(map[contextRead]int),
+		contextFindings:      make(map[contextRead]int),
+		selfBalanceFindings:  make(map[contextSite]int),
+		safeMathFindings:     make(map[safeMathKey]int),
+		overflowGuardIndex:   make(map[safeMathKey]int),
+		loadedTargets:        make(map[loadedValue]common.Hash),
+		proxyFindings:        make(map[proxyKey]int),
+		storageSites:         make(map[storageSite]*siteUsage),
+		contractSites:        make(map[common.Address][]storageSite),
+		memorySites:          make(map[memorySite]*memoryStride),
+		contractMemorySites:  make(map[common.Address][]memorySite),
+		zeroInitSites:        make(map[memorySite]int),
+		callSites:            make(map[callSite]*callSiteUsage),
+		contractCallSites:    make(map[common.Address][]callSite),
+		selfCallSites:        make(map[callSite]*selfCallUsage),
+		calldataCopies:       make(map[*CallNode][]*calldataCopy),
+		calldataCopySites:    make(map[memorySite]*calldataCopyUsage),
+		stackRunSites:        make(map[stackRunSite]*stackRunUsage),
+		samplePCs:            make(map[sampleSite]*sampleHits),
+		contractCode:         make(map[common.Address][]byte),
+		warmAddresses:        make(map[common.Address]bool),
+		warmSlots:            make(map[common.Address]map[common.Hash]bool),
+		StorageReads:         make(map[common.Hash]int),
+		StorageWrites:        make(map[common.Hash]int),
+		TransientReads:       make(map[common.Hash]int),
+		TransientWrites:      make(map[common.Hash]int),
+		slotWrites:           make(map[storageSlot]*slotHistory),
+		slotAccesses:         make(map[storageSlot]*slotAccesses),
+		MemoryOps:            make([]MemoryOperation, 0),
+		CallOps:              make([]CallOperation, 0),
+		Loops:                make([]LoopDetection, 0),
+		ExpensiveOps:         make([]ExpensiveOperation, 0),
+		GasPerOpcode:         make(map[string]uint64),
+		OpcodeCounts:         make(map[string]uint64),
+		HashCounts:           make(map[common.Hash]int),
+		AccountChecks:        make(map[common.Address]int),
+		LogOps:               make([]LogOperation, 0),
+		ColdAccesses:         make([]ColdAccess, 0),
+		WriteOnlySlots:       make([]WriteOnlySlot, 0),
+		ProxyImplementations: make([]ProxyImplementation, 0),
+		Optimizations:        make([]Optimization, 0),
+		Stack:                make([]uint256, 0),
 	}
 }
 
+// StatePending labels a trace simulated on top of the pending block
+const StatePending = "pending"
+
+// StateEmpty labels a replay run against empty state because the node could not
+// serve the historical state
+const StateEmpty = "empty"
+
+// SetSimulatedState labels the trace as simulated against the named state
+func (t *GasOptimizationTracer) SetSimulatedState(state string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.SimulatedState = state
+}
+
+// SetPartial labels the trace as partial, giving the reason execution stopped
+func (t *GasOptimizationTracer) SetPartial(reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.Partial = reason
+}
+
+// SetConfig replaces the tracer's thresholds; call it before tracing
+func (t *GasOptimizationTracer) SetConfig(config Config) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.config = config
+}
+
+// SetStepWriter streams every execution step to w as one JSON object per line.
+// Passing nil disables streaming.
+func (t *GasOptimizationTracer) SetStepWriter(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stepEncoder = nil
+	t.stepErr = nil
+	if w != nil {
+		t.stepEncoder = json.NewEncoder(w)
+	}
+}
+
+// StepWriterError returns the first error encountered while streaming steps
+func (t *GasOptimizationTracer) StepWriterError() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.stepErr
+}
+
+// StepCount returns the number of execution steps traced so far. It is safe to
+// call from another goroutine while a trace is running.
+func (t *GasOptimizationTracer) StepCount() uint64 {
+	return t.steps.Load()
+}
+
+// Reset clears all collected data so the tracer can be reused for another transaction.
+// Allocated maps and slices are retained to reduce allocations. When reusing a tracer,
+// Reset must be called between transactions.
+func (t *GasOptimizationTracer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	clear(t.StorageReads)
+	clear(t.StorageWrites)
+	clear(t.TransientReads)
+	clear(t.TransientWrites)
+	clear(t.slotWrites)
+	t.slotWriteOrder = t.slotWriteOrder[:0]
+	clear(t.slotAccesses)
+	t.slotAccessOrder = t.slotAccessOrder[:0]
+	t.pendingRead = nil
+	clear(t.GasPerOpcode)
+	t.maxGasOpcode = ""
+	clear(t.OpcodeCounts)
+	clear(t.HashCounts)
+	clear(t.AccountChecks)
+
+	t.MemoryOps = t.MemoryOps[:0]
+	t.CallOps = t.CallOps[:0]
+	t.StorageAccesses = 0
+	t.Loops = t.Loops[:0]
+	t.ExpensiveOps = t.ExpensiveOps[:0]
+	t.LogOps = t.LogOps[:0]
+	t.ColdAccesses = t.ColdAccesses[:0]
+	t.WriteOnlySlots = t.WriteOnlySlots[:0]
+	t.ProxyImplementations = t.ProxyImplementations[:0]
+	t.SlotAccessOrders = t.SlotAccessOrders[:0]
+	t.CallTree = nil
+	t.Optimizations = t.Optimizations[:0]
+	t.Stack = t.Stack[:0]
+	t.Memory = t.Memory[:0]
+
+	t.env = nil
+	t.restored = nil
+	t.gasModel = DefaultGasModel()
+	t.PC = 0
+	t.Gas = 0
+	t.GasLimit = 0
+	t.Depth = 0
+	t.TotalGasUsed = 0
+	t.GasAccountingDelta = 0
+	t.RevertedGas = 0
+	t.RevertedCalls = 0
+	t.LogGas = 0
+	t.steps.Store(0)
+	t.truncated.Store(false)
+
+	t.SimulatedState = ""
+	t.Partial = ""
+	t.Faults = t.Faults[:0]
+	t.Timeline = t.Timeline[:0]
+	t.timelineStride = 1
+	t.revertStrings = t.revertStrings[:0]
+	t.minimalGas = [256]uint64{}
+	t.minimalSeen = [256]bool{}
+	t.sampledSteps = 0
+	clear(t.samplePCs)
+	t.IsCreation = false
+	t.CreatedAddress = common.Address{}
+	t.InitCodeSize = 0
+	t.InitCodeGas = 0
+	t.Calldata = CalldataAnalysis{}
+	t.Blobs = nil
+
+	t.frames = t.frames[:0]
+	t.pendingCall = -1
+	t.pendingOpt = -1
+	clear(t.sloadFindings)
+	clear(t.hashFindings)
+	clear(t.mappingInputs)
+	clear(t.mappingAccesses)
+	t.mappingOrder = t.mappingOrder[:0]
+	clear(t.accountFindings)
+	clear(t.loopStates)
+	t.guard = nil
+	t.pendingLoad = nil
+	clear(t.loadedTargets)
+	clear(t.proxyFindings)
+	clear(t.safeMathFindings)
+	t.overflowCheck = nil
+	t.overflowGuards = t.overflowGuards[:0]
+	clear(t.overflowGuardIndex)
+	clear(t.storageSites)
+	clear(t.contractSites)
+	t.mcopyActive = false
+	t.push0Active = false
+	t.prevOp, t.prevDepth = vm.STOP, 0
+	t.selfBalanceActive = false
+	clear(t.contextReads)
+	clear(t.contextFindings)
+	clear(t.selfBalanceFindings)
+	t.pushZeroCount = 0
+	clear(t.pushZeroSites)
+	clear(t.memorySites)
+	clear(t.contractMemorySites)
+	clear(t.zeroInitSites)
+	t.zeroInitOrder = t.zeroInitOrder[:0]
+	clear(t.callSites)
+	clear(t.contractCallSites)
+	clear(t.selfCallSites)
+	t.selfCallOrder = t.selfCallOrder[:0]
+	clear(t.calldataCopies)
+	clear(t.calldataCopySites)
+	t.calldataCopyOrder = t.calldataCopyOrder[:0]
+	t.stackRun = stackRun{}
+	clear(t.stackRunSites)
+	t.stackRunOrder = t.stackRunOrder[:0]
+	clear(t.contractCode)
+	t.entryContract = common.Address{}
+	t.accessListActive = false
+	clear(t.warmAddresses)
+	clear(t.warmSlots)
+	t.warmJournal = t.warmJournal[:0]
+}
+
 // CaptureStart implements the EVMLogger interface
 func (t *GasOptimizationTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
 	t.mu.Lock()
@@ -101,20 +627,145 @@ func (t *GasOptimizationTracer) CaptureStart(env *vm.EVM, from common.Address, t
 
 	t.Gas = gas
 	t.Depth = 0
+	t.env = env
+	if t.minimal {
+		return
+	}
+
+	t.entryContract = to
+	t.Calldata = AnalyzeCalldata(input, t.config.L2CompressionRatio)
+	t.Blobs = analyzeBlobs(env)
+	typ := "CALL"
+	if create {
+		typ = "CREATE"
+	}
+	t.enterNode(nil, typ, from, to, input, gas, value)
+	if env != nil {
+		rules := env.ChainConfig().Rules(env.Context.BlockNumber, env.Context.Random != nil, env.Context.Time)
+		t.gasModel = GasModelForRules(rules)
+		t.mcopyActive = rules.IsCancun
+		t.push0Active = rules.IsShanghai
+		t.selfBalanceActive = rules.IsIstanbul
+		t.seedAccessList(env, rules, from, to)
+	}
+
+	if create {
+		t.IsCreation = true
+		t.CreatedAddress = to
+		t.InitCodeSize = len(input)
+
+		// Check for oversized init code (EIP-3860 charges per word and caps the size)
+		if len(input) > largeInitCodeSize {
+			words := (uint64(len(input)) + 31) / 32
+			t.Optimizations = append(t.Optimizations, Optimization{
+				Type:        "large_initcode",
+				Severity:    "medium",
+				Description: "Large init code increases deployment cost",
+				Location:    ScopeLocation(ScopeInitCode),
+				GasSavings:  0,
+				Confidence:  "heuristic",
+				Details: map[string]interface{}{
+					"initcode_size":     len(input),
+					"initcode_limit":    params.MaxInitCodeSize,
+					"initcode_word_gas": words * params.InitCodeWordGas,
+				},
+			})
+		}
+	}
 }
 
 // CaptureState implements the EVMLogger interface
 func (t *GasOptimizationTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if t.stepLimitReached() {
+		return
+	}
+	if t.minimal {
+		step := t.steps.Add(1)
+		if t.sampleRate > 1 {
+			if (step-1)%t.sampleRate == 0 {
+				t.recordSample(pc, op, cost, scope)
+			}
+			return
+		}
+		t.minimalGas[op] += cost
+		t.minimalSeen[op] = true
+		return
+	}
+
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	t.PC = pc
 	t.Gas = gas
 	t.Depth = depth
+
+	// A step logged with an error failed before executing, e.g. out of gas
+	if err != nil {
+		t.recordFault(pc, op, gas, cost, scope, depth, err)
+		return
+	}
+
+	t.recordTimeline(t.steps.Load(), depth)
 	t.TotalGasUsed += cost
+	t.steps.Add(1)
+
+	// Annotate findings raised by this step with the code around them
+	if t.disasmWindow > 0 {
+		t.recordCode(scope)
+		defer t.annotateFindings(len(t.Optimizations), scope)
+	}
+
+	// Any call recorded by a previous step that never entered a frame has failed early
+	t.pendingCall = -1
+	t.pendingOpt = -1
 
 	opName := op.String()
-	t.GasPerOpcode[opName] += cost
+	t.addOpcodeGas(opName, cost)
+	t.OpcodeCounts[opName]++
+
+	// Stream the raw step, stopping at the first write error
+	if t.stepEncoder != nil {
+		if err := t.stepEncoder.Encode(Step{PC: pc, Op: opName, Gas: gas, Cost: cost, Depth: depth}); err != nil {
+			t.stepErr = err
+			t.stepEncoder = nil
+		}
+	}
+
+	// Attribute gas spent in the deployment frame to init code
+	if t.IsCreation && depth == 1 {
+		t.InitCodeGas += cost
+	}
+
+	// Skip the detailed work for opcodes outside the focus
+	if !t.focused(op) {
+		return
+	}
+
+	// Track cold accesses for access list suggestions
+	t.trackAccess(pc, op, cost, depth, scope)
+
+	// Match SafeMath overflow guards around arithmetic
+	t.trackSafeMath(pc, op, cost, depth, scope)
+	t.trackOverflowCheck(pc, op, cost, depth, scope)
+
+	// Match DELEGATECALL targets loaded from storage
+	t.trackProxy(pc, op, depth, scope)
+	t.trackAccessOrder(pc, op, cost, depth, scope)
+
+	// Track which blobs execution verified
+	t.trackBlobHash(op, scope)
+
+	// Count zero pushes that PUSH0 would replace
+	t.trackPushZero(pc, op, scope)
+
+	// Measure runs of consecutive DUP/SWAP instructions
+	t.trackStackRun(pc, op, cost, depth, scope)
+
+	// Count context reads and match BALANCE(ADDRESS)
+	t.trackContextRead(pc, op, cost, depth, scope)
+
+	// Track calldata copied to memory and how much of it is read back
+	t.trackCalldataCopy(pc, op, scope)
 
 	// Track storage operations
 	switch op {
@@ -124,20 +775,12 @@ func (t *GasOptimizationTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost
 		if key != nil {
 			keyHash := common.BytesToHash(key.Bytes())
 			t.StorageReads[keyHash]++
+			t.recordStorageOp(pc, opName, keyHash, cost, depth, scope)
+			t.trackMappingAccess(pc, op, keyHash, scope)
 
 			// Check for redundant SLOADs
 			if t.StorageReads[keyHash] > 2 {
-				t.Optimizations = append(t.Optimizations, Optimization{
-					Type:        "redundant_sload",
-					Severity:    "high",
-					Description: "Multiple SLOAD operations for the same storage slot",
-					Location:    formatPC(pc),
-					GasSavings:  (uint64(t.StorageReads[keyHash]) - 1) * 100, // SLOAD warm cost ~100 gas
-					Details: map[string]interface{}{
-						"storage_key": keyHash.Hex(),
-						"read_count":  t.StorageReads[keyHash],
-					},
-				})
+				t.recordRedundantSload(pc, contractAddress(scope), keyHash)
 			}
 		}
 
@@ -146,15 +789,32 @@ func (t *GasOptimizationTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost
 		if key != nil {
 			keyHash := common.BytesToHash(key.Bytes())
 			t.StorageWrites[keyHash]++
+			t.recordStorageOp(pc, opName, keyHash, cost, depth, scope)
+			t.trackMappingAccess(pc, op, keyHash, scope)
+			t.trackSlotWrite(pc, cost, scope)
+		}
+
+	case vm.TLOAD:
+		if key := scope.Stack.Back(0); key != nil {
+			t.TransientReads[common.BytesToHash(key.Bytes())]++
+		}
+
+	case vm.TSTORE:
+		if key := scope.Stack.Back(0); key != nil {
+			t.TransientWrites[common.BytesToHash(key.Bytes())]++
 		}
 
 	case vm.MLOAD, vm.MSTORE, vm.MSTORE8:
+		t.trackMemoryCopy(pc, op, scope)
+		t.trackSparseMemory(pc, op, scope)
+		t.trackZeroInit(pc, op, scope)
 		t.MemoryOps = append(t.MemoryOps, MemoryOperation{
-			PC:    pc,
-			Op:    opName,
-			Size:  uint64(len(scope.Memory.Data())),
-			Gas:   cost,
-			Depth: depth,
+			PC:     pc,
+			Op:     opName,
+			Offset: memoryOffset(scope),
+			Size:   uint64(len(scope.Memory.Data())),
+			Gas:    cost,
+			Depth:  depth,
 		})
 
 	case vm.CALL, vm.STATICCALL, vm.DELEGATECALL, vm.CALLCODE:
@@ -170,23 +830,75 @@ func (t *GasOptimizationTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost
 		addr := scope.Stack.Back(1)
 		if gasLimit != nil && addr != nil {
 			callOp.To = common.BytesToAddress(addr.Bytes())
+			callOp.Precompile, _ = PrecompileName(callOp.To)
+
+			// CALL and CALLCODE carry a value operand ahead of the calldata operands
+			argsIndex := 2
+			if op == vm.CALL || op == vm.CALLCODE {
+				value := scope.Stack.Back(2)
+				argsLength := scope.Stack.Back(4)
+				callOp.Value = value.ToBig()
+				callOp.IsTransfer = !value.IsZero() && argsLength.IsZero()
+				argsIndex = 3
+			}
 
-			// Check for inefficient gas forwarding
-			if gasLimit.Uint64() == gas-gas/64 {
+			// Capture the calldata and its function selector
+			argsOffset, argsLength := scope.Stack.Back(argsIndex), scope.Stack.Back(argsIndex+1)
+			if argsLength.IsUint64() {
+				callOp.InputSize = argsLength.Uint64()
+				if input, ok := readMemory(scope.Memory, argsOffset, u256.NewInt(min(callOp.InputSize, maxCallData))); ok {
+					callOp.Input = input
+				}
+			}
+			if len(callOp.Input) >= 4 {
+				copy(callOp.Selector[:], callOp.Input)
+				callOp.HasSelector = true
+			}
+
+			// Check for a fixed gas stipend too small for the callee's code
+			if gasLimit.IsUint64() && gasLimit.Uint64() <= t.config.MinForwardedGas && t.env != nil {
+				if codeSize := t.env.StateDB.GetCodeSize(callOp.To); codeSize > 0 {
+					t.pendingOpt = len(t.Optimizations)
+					t.Optimizations = append(t.Optimizations, Optimization{
+						Type:        "insufficient_gas_forwarded",
+						Severity:    "medium",
+						Description: "Fixed gas forwarded to a contract may be too low for the callee",
+						Location:    ContractPCLocation(contractAddress(scope), pc),
+						GasSavings:  0,
+						Confidence:  "heuristic",
+						Details: map[string]interface{}{
+							"call_type":     opName,
+							"to":            callOp.To.Hex(),
+							"forwarded_gas": gasLimit.Uint64(),
+							"code_size":     codeSize,
+						},
+					})
+				}
+			}
+
+			// Check for inefficient gas forwarding: requesting at least the 63/64 cap
+			// of the frame's remaining gas (EIP-150) forwards everything available
+			if !gasLimit.IsUint64() || gasLimit.Uint64() >= gas-gas/64 {
 				t.Optimizations = append(t.Optimizations, Optimization{
 					Type:        "gas_forwarding",
 					Severity:    "low",
 					Description: "Forwarding all available gas to external call",
-					Location:    formatPC(pc),
+					Location:    ContractPCLocation(contractAddress(scope), pc),
 					GasSavings:  0,
+					Confidence:  "heuristic",
 					Details: map[string]interface{}{
-						"call_type": opName,
-						"to":        callOp.To.Hex(),
+						"call_type":     opName,
+						"to":            callOp.To.Hex(),
+						"available_gas": gas,
 					},
 				})
 			}
+
+			t.trackCallSite(pc, op, scope)
+			t.trackSelfCall(pc, op, scope, callOp.To)
 		}
 
+		t.pendingCall = len(t.CallOps)
 		t.CallOps = append(t.CallOps, callOp)
 
 	case vm.CREATE, vm.CREATE2:
@@ -207,12 +919,18 @@ func (t *GasOptimizationTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost
 			Depth:       depth,
 		})
 
-	case vm.JUMPDEST:
-		// Track potential loops
-		// Simple heuristic: if we see the same JUMPDEST multiple times in quick succession
-		// This is a simplified loop detection
+	case vm.JUMP:
+		// Track potential loops: a jump back to an earlier PC closes a loop body
+		t.trackJump(pc, scope.Stack.Back(0), contractAddress(scope))
+
+	case vm.JUMPI:
+		if cond := scope.Stack.Back(1); !cond.IsZero() {
+			t.trackJump(pc, scope.Stack.Back(0), contractAddress(scope))
+		}
 
 	case vm.LOG0, vm.LOG1, vm.LOG2, vm.LOG3, vm.LOG4:
+		t.recordLog(pc, op, depth, scope)
+
 		if cost > 1000 {
 			t.ExpensiveOps = append(t.ExpensiveOps, ExpensiveOperation{
 				PC:          pc,
@@ -223,7 +941,22 @@ func (t *GasOptimizationTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost
 			})
 		}
 
+	case vm.REVERT:
+		t.recordRevert(pc, depth, scope)
+
+	case vm.BALANCE, vm.EXTCODESIZE, vm.EXTCODEHASH:
+		addr := common.Address(scope.Stack.Back(0).Bytes20())
+		t.trackAccountCheck(pc, contractAddress(scope), opName, addr)
+
 	case vm.KECCAK256:
+		offset := scope.Stack.Back(0)
+		size := scope.Stack.Back(1)
+		if data, ok := readMemory(scope.Memory, offset, size); ok {
+			hash := crypto.Keccak256Hash(data)
+			t.trackHash(pc, contractAddress(scope), hash, uint64(len(data)))
+			t.recordMappingHash(hash, data)
+		}
+
 		if cost > 500 {
 			t.ExpensiveOps = append(t.ExpensiveOps, ExpensiveOperation{
 				PC:          pc,
@@ -243,8 +976,9 @@ func (t *GasOptimizationTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost
 				Type:        "memory_expansion",
 				Severity:    "medium",
 				Description: "Large memory expansion detected",
-				Location:    formatPC(pc),
+				Location:    ContractPCLocation(contractAddress(scope), pc),
 				GasSavings:  0,
+				Confidence:  "heuristic",
 				Details: map[string]interface{}{
 					"memory_size": memSize,
 				},
@@ -255,24 +989,102 @@ func (t *GasOptimizationTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost
 
 // CaptureEnter implements the EVMLogger interface
 func (t *GasOptimizationTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.minimal {
+		return
+	}
+
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	t.Depth++
+	t.Gas = gas
+
+	// The created address is warmed before the frame's snapshot, so it stays warm
+	// even when the creation fails
+	if t.accessListActive && (typ == vm.CREATE || typ == vm.CREATE2) {
+		t.warmAddress(to)
+	}
+
+	t.frames = append(t.frames, callFrame{
+		op:        typ,
+		callIndex: t.pendingCall,
+		optIndex:  t.pendingOpt,
+		startGas:  t.TotalGasUsed,
+		allowance: gas,
+		warmMark:  len(t.warmJournal),
+		node:      t.enterNode(t.currentNode(), typ.String(), from, to, input, gas, value),
+	})
+	t.pendingCall = -1
+	t.pendingOpt = -1
 }
 
 // CaptureExit implements the EVMLogger interface
 func (t *GasOptimizationTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if t.minimal {
+		return
+	}
+
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	t.Depth--
-	t.TotalGasUsed += gasUsed
+
+	if len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+	exitNode(frame.node, output, gasUsed, err)
+	t.settleCalldataCopies(frame.node)
+
+	// The opening step's cost of a call already included the frame's full allowance
+	// and every step inside the frame added its own cost. Replace both with the gas
+	// actually used.
+	base := uint64(0)
+	if charged := frame.chargedAllowance(); frame.startGas > charged {
+		base = frame.startGas - charged
+	}
+	t.TotalGasUsed = base + gasUsed
+
+	if frame.callIndex >= 0 {
+		callOp := &t.CallOps[frame.callIndex]
+		callOp.Success = err == nil
+		callOp.GasUsed = gasUsed
+		callOp.Output = common.CopyBytes(output[:min(len(output), maxCallData)])
+		callOp.OutputSize = len(output)
+	}
+
+	// A reverted frame burns all of its gas, including that of reverted descendants,
+	// and its accesses no longer count as warm (EIP-2929)
+	wasted := frame.wasted
+	if err != nil {
+		t.revertWarm(frame.warmMark)
+		t.RevertedCalls++
+		t.RevertedGas += gasUsed - frame.wasted
+		wasted = gasUsed
+	}
+	if len(t.frames) > 0 {
+		t.frames[len(t.frames)-1].wasted += wasted
+	}
+
+	// Correlate a low-gas finding with the callee running out of gas
+	if frame.optIndex >= 0 && errors.Is(err, vm.ErrOutOfGas) {
+		opt := &t.Optimizations[frame.optIndex]
+		opt.Details["out_of_gas"] = true
+		opt.Description = "Fixed gas forwarded to a contract was too low and the call ran out of gas"
+	}
 }
 
 // CaptureFault implements the EVMLogger interface
 func (t *GasOptimizationTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
-	// Track faults for analysis
+	if t.minimal {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.recordFault(pc, op, gas, cost, scope, depth, err)
 }
 
 // CaptureEnd implements the EVMLogger interface
@@ -280,15 +1092,34 @@ func (t *GasOptimizationTracer) CaptureEnd(output []byte, gasUsed uint64, err er
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	if t.minimal {
+		t.TotalGasUsed = gasUsed
+		t.flushMinimalGas()
+		return
+	}
+
+	// Reconcile the running total against the authoritative gas used
+	t.GasAccountingDelta = int64(t.TotalGasUsed) - int64(gasUsed)
 	t.TotalGasUsed = gasUsed
+	exitNode(t.CallTree, output, gasUsed, err)
+	if t.timelineMax > 0 {
+		t.appendTimeline(TimelinePoint{Step: t.steps.Load(), Gas: gasUsed, Depth: 1})
+	}
 
 	// Final analysis
 	t.analyzePatterns()
+	t.dedupeOptimizations()
+	if t.disasmWindow > 0 {
+		t.annotateRemaining()
+	}
 }
 
 // CaptureTxStart implements the EVMLogger interface
 func (t *GasOptimizationTracer) CaptureTxStart(gasLimit uint64) {
-	t.Gas = gasLimit
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.GasLimit = gasLimit
 }
 
 // CaptureTxEnd implements the EVMLogger interface
@@ -298,15 +1129,21 @@ func (t *GasOptimizationTracer) CaptureTxEnd(restGas uint64) {
 
 // analyzePatterns performs final analysis to identify optimization patterns
 func (t *GasOptimizationTracer) analyzePatterns() {
-	// Analyze opcode usage
-	for opcode, gasUsed := range t.GasPerOpcode {
-		if gasUsed > t.TotalGasUsed/10 { // If opcode uses >10% of total gas
+	// Analyze opcode usage in a stable order, unless even the most expensive
+	// opcode stays below the threshold
+	threshold := t.TotalGasUsed / 10 // If opcode uses >10% of total gas
+	for _, opcode := range t.expensiveOpcodes(threshold) {
+		if !t.focused(vm.StringToOp(opcode)) {
+			continue
+		}
+		if gasUsed := t.GasPerOpcode[opcode]; gasUsed > threshold {
 			t.Optimizations = append(t.Optimizations, Optimization{
 				Type:        "expensive_opcode",
 				Severity:    "medium",
 				Description: "Opcode consumes significant gas",
-				Location:    "multiple",
+				Location:    MultipleLocation(),
 				GasSavings:  0,
+				Confidence:  "heuristic",
 				Details: map[string]interface{}{
 					"opcode":     opcode,
 					"gas_used":   gasUsed,
@@ -316,27 +1153,357 @@ func (t *GasOptimizationTracer) analyzePatterns() {
 		}
 	}
 
-	// Analyze call patterns
+	// Analyze storage accessed inside loops
+	t.analyzeStorageInLoops()
+
+	// Analyze manual memory copy loops that MCOPY would replace
+	t.analyzeMemoryCopyLoops()
+
+	// Analyze no-op and repeated calls inside loops
+	t.analyzeCallsInLoops()
+
+	// Analyze calls a contract makes to itself
+	t.analyzeSelfCalls()
+
+	// Analyze repeated and partly read CALLDATACOPYs
+	t.analyzeCalldataCopies()
+
+	// Analyze long DUP/SWAP runs executed repeatedly
+	t.analyzeStackThrashing()
+
+	// Analyze zero writes to memory that is already zero
+	t.analyzeZeroInit()
+
+	// Analyze storage that is written but never read
+	t.analyzeWriteOnlyStorage()
+
+	// Analyze storage used only for the duration of the transaction
+	t.analyzeTransientUse()
+
+	// Analyze the order of storage reads and writes
+	t.analyzeAccessOrder()
+
+	// Analyze cold accesses that an access list would pre-warm
+	t.analyzeAccessList()
+
+	// Analyze event logging cost
+	t.analyzeLogs()
+
+	// Analyze blobs that execution never referenced
+	t.analyzeBlobUsage()
+
+	// Analyze zero pushes that PUSH0 would replace
+	t.analyzePushZero()
+
+	// Note the costs of precompiles called
+	t.analyzePrecompiles()
+
+	// Analyze overflow guards repeated inside loops
+	t.analyzeUnchecked()
+
+	// Report opcodes that stopped a frame with an error
+	t.analyzeFaults()
+
+	// Reclassify recomputed hashes that locate mapping entries
+	t.analyzeMappingAccess()
+
+	// Suggest custom errors for long revert strings
+	t.analyzeRevertStrings()
+
+	// Analyze calldata cost relative to execution
+	if t.Calldata.L1Gas > 0 && t.Calldata.L1Gas >= t.TotalGasUsed {
+		t.Optimizations = append(t.Optimizations, Optimization{
+			Type:        "calldata_heavy",
+			Severity:    "medium",
+			Description: "Calldata dominates transaction cost - consider tighter encoding",
+			Location:    ScopeLocation(ScopeCalldata),
+			GasSavings:  0,
+			Confidence:  "heuristic",
+			Details: map[string]interface{}{
+				"calldata_bytes": t.Calldata.TotalBytes,
+				"calldata_gas":   t.Calldata.L1Gas,
+				"execution_gas":  t.TotalGasUsed,
+				"zero_bytes":     t.Calldata.ZeroBytes,
+			},
+		})
+	}
+
+	// Analyze ETH transfers
+	if transfers := t.countTransfers(); transfers > batchTransferThreshold {
+		t.Optimizations = append(t.Optimizations, Optimization{
+			Type:        "multiple_transfers",
+			Severity:    "low",
+			Description: "Multiple plain ETH transfers in one transaction - consider batching or a pull-payment pattern",
+			Location:    MultipleLocation(),
+			GasSavings:  0,
+			Confidence:  "heuristic",
+			Details: map[string]interface{}{
+				"transfer_count": transfers,
+			},
+		})
+	}
+
+	// Analyze gas burned by reverted subcalls
+	if t.RevertedGas > 0 {
+		t.Optimizations = append(t.Optimizations, Optimization{
+			Type:        "wasted_gas_on_revert",
+			Severity:    "high",
+			Description: "Gas spent in subcalls that reverted - fix or pre-check the revert cause to avoid it entirely",
+			Location:    MultipleLocation(),
+			GasSavings:  t.RevertedGas,
+			Confidence:  "estimated",
+			Details: map[string]interface{}{
+				"reverted_calls": t.RevertedCalls,
+				"wasted_gas":     t.RevertedGas,
+			},
+		})
+	}
+
+	// Analyze call patterns, counting batching savings only for calls that succeeded
 	if len(t.CallOps) > 5 {
+		successful := 0
+		for _, call := range t.CallOps {
+			if call.Success {
+				successful++
+			}
+		}
+
 		t.Optimizations = append(t.Optimizations, Optimization{
 			Type:        "multiple_calls",
 			Severity:    "medium",
 			Description: "Multiple external calls detected - consider batching",
-			Location:    "multiple",
-			GasSavings:  uint64(len(t.CallOps)) * 2100, // Base call cost savings
+			Location:    MultipleLocation(),
+			GasSavings:  uint64(successful) * t.gasModel.CallGas,
+			Confidence:  "estimated",
 			Details: map[string]interface{}{
-				"call_count": len(t.CallOps),
+				"call_count":       len(t.CallOps),
+				"successful_calls": successful,
+				"failed_calls":     len(t.CallOps) - successful,
 			},
 		})
 	}
 }
 
-// GetOptimizations returns all identified optimizations
+// trackHash counts KECCAK256 results and flags hashes recomputed over identical input
+func (t *GasOptimizationTracer) trackHash(pc uint64, contract common.Address, hash common.Hash, size uint64) {
+	t.HashCounts[hash]++
+	count := t.HashCounts[hash]
+	if count < 2 {
+		return
+	}
+
+	hashGas := params.Keccak256Gas + params.Keccak256WordGas*((size+31)/32)
+	savings := uint64(count-1) * hashGas
+
+	// Update the existing finding for this input rather than adding another
+	if idx, ok := t.hashFindings[hash]; ok {
+		t.Optimizations[idx].GasSavings = savings
+		t.Optimizations[idx].Details["hash_count"] = count
+		return
+	}
+
+	t.hashFindings[hash] = len(t.Optimizations)
+	t.Optimizations = append(t.Optimizations, Optimization{
+		Type:        "redundant_hash",
+		Severity:    "medium",
+		Description: "KECCAK256 computed multiple times over identical input - consider caching the result",
+		Location:    ContractPCLocation(contract, pc),
+		GasSavings:  savings,
+		Confidence:  "estimated",
+		Details: map[string]interface{}{
+			"hash":       hash.Hex(),
+			"input_size": size,
+			"hash_count": count,
+		},
+	})
+}
+
+// recordRedundantSload adds or updates the redundant_sload finding for a slot
+func (t *GasOptimizationTracer) recordRedundantSload(pc uint64, contract common.Address, key common.Hash) {
+	count := t.StorageReads[key]
+	savings := (uint64(count) - 1) * t.gasModel.SloadGas
+
+	// Update the existing finding for this slot rather than adding another
+	if idx, ok := t.sloadFindings[key]; ok {
+		t.Optimizations[idx].GasSavings = savings
+		t.Optimizations[idx].Details["read_count"] = count
+		return
+	}
+
+	t.sloadFindings[key] = len(t.Optimizations)
+	t.Optimizations = append(t.Optimizations, Optimization{
+		Type:        "redundant_sload",
+		Severity:    "high",
+		Description: "Multiple SLOAD operations for the same storage slot",
+		Location:    ContractPCLocation(contract, pc),
+		GasSavings:  savings,
+		Confidence:  "exact",
+		Details: map[string]interface{}{
+			"storage_key": key.Hex(),
+			"read_count":  count,
+		},
+	})
+}
+
+// trackAccountCheck counts account queries and flags addresses queried repeatedly
+func (t *GasOptimizationTracer) trackAccountCheck(pc uint64, contract common.Address, op string, addr common.Address) {
+	t.AccountChecks[addr]++
+	count := t.AccountChecks[addr]
+	if count < 2 {
+		return
+	}
+
+	// Every repeated query pays at least the warm access cost
+	savings := uint64(count-1) * t.gasModel.AccountGas
+
+	// Update the existing finding for this address rather than adding another
+	if idx, ok := t.accountFindings[addr]; ok {
+		t.Optimizations[idx].GasSavings = savings
+		t.Optimizations[idx].Details["query_count"] = count
+		return
+	}
+
+	t.accountFindings[addr] = len(t.Optimizations)
+	t.Optimizations = append(t.Optimizations, Optimization{
+		Type:        "redundant_account_access",
+		Severity:    "medium",
+		Description: "Same account queried repeatedly with BALANCE/EXTCODESIZE/EXTCODEHASH - consider caching the result",
+		Location:    ContractPCLocation(contract, pc),
+		GasSavings:  savings,
+		Confidence:  "estimated",
+		Details: map[string]interface{}{
+			"address":     addr.Hex(),
+			"opcode":      op,
+			"query_count": count,
+		},
+	})
+}
+
+// recordStorageOp records a storage access along with its location
+func (t *GasOptimizationTracer) recordStorageOp(pc uint64, op string, key common.Hash, cost uint64, depth int, scope *vm.ScopeContext) {
+	t.indexStorageOp(StorageOperation{
+		PC:       pc,
+		Op:       op,
+		Key:      key,
+		Gas:      cost,
+		Depth:    depth,
+		Contract: contractAddress(scope),
+	})
+}
+
+// addOpcodeGas adds gas used by an opcode, keeping the max-gas opcode current
+func (t *GasOptimizationTracer) addOpcodeGas(opcode string, gas uint64) {
+	t.GasPerOpcode[opcode] += gas
+	if t.maxGasOpcode == "" || t.GasPerOpcode[opcode] > t.GasPerOpcode[t.maxGasOpcode] {
+		t.maxGasOpcode = opcode
+	}
+}
+
+// countTransfers returns the number of calls classified as plain ETH transfers
+func (t *GasOptimizationTracer) countTransfers() int {
+	count := 0
+	for _, call := range t.CallOps {
+		if call.IsTransfer {
+			count++
+		}
+	}
+	return count
+}
+
+// expensiveOpcodes returns the executed opcodes in sorted order, or none when
+// the max-gas opcode does not exceed threshold. It runs in time proportional to
+// the distinct opcodes executed.
+func (t *GasOptimizationTracer) expensiveOpcodes(threshold uint64) []string {
+	if t.GasPerOpcode[t.maxGasOpcode] <= threshold {
+		return nil
+	}
+	opcodes := make([]string, 0, len(t.GasPerOpcode))
+	for opcode := range t.GasPerOpcode {
+		opcodes = append(opcodes, opcode)
+	}
+	sort.Strings(opcodes)
+	return opcodes
+}
+
+// GetOptimizations returns all identified optimizations in stable order
 func (t *GasOptimizationTracer) GetOptimizations() []Optimization {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	return t.Optimizations
+	return t.sortedOptimizations()
+}
+
+// GetCallOps returns a copy of the recorded call operations
+func (t *GasOptimizationTracer) GetCallOps() []CallOperation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	calls := make([]CallOperation, len(t.CallOps))
+	copy(calls, t.CallOps)
+	for i := range calls {
+		if calls[i].Value != nil {
+			calls[i].Value = new(big.Int).Set(calls[i].Value)
+		}
+	}
+	return calls
+}
+
+// GetMemoryOps returns a copy of the recorded memory operations
+func (t *GasOptimizationTracer) GetMemoryOps() []MemoryOperation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ops := make([]MemoryOperation, len(t.MemoryOps))
+	copy(ops, t.MemoryOps)
+	return ops
+}
+
+// GetExpensiveOps returns a copy of the recorded expensive operations
+func (t *GasOptimizationTracer) GetExpensiveOps() []ExpensiveOperation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ops := make([]ExpensiveOperation, len(t.ExpensiveOps))
+	copy(ops, t.ExpensiveOps)
+	return ops
+}
+
+// GetGasPerOpcode returns a copy of the gas used per opcode
+func (t *GasOptimizationTracer) GetGasPerOpcode() map[string]uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	gas := make(map[string]uint64, len(t.GasPerOpcode))
+	for op, used := range t.GasPerOpcode {
+		gas[op] = used
+	}
+	return gas
+}
+
+// GetStats returns a snapshot of the tracer's counters
+func (t *GasOptimizationTracer) GetStats() Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := Stats{
+		Steps:         t.steps.Load(),
+		TotalGasUsed:  t.TotalGasUsed,
+		Depth:         t.Depth,
+		CallOps:       len(t.CallOps),
+		MemoryOps:     len(t.MemoryOps),
+		ExpensiveOps:  len(t.ExpensiveOps),
+		LogOps:        len(t.LogOps),
+		RevertedCalls: t.RevertedCalls,
+		RevertedGas:   t.RevertedGas,
+		Optimizations: len(t.Optimizations),
+	}
+	for _, count := range t.StorageReads {
+		stats.StorageReads += count
+	}
+	for _, count := range t.StorageWrites {
+		stats.StorageWrites += count
+	}
+	return stats
 }
 
 // GetReport generates a JSON report of the trace
@@ -344,18 +1511,21 @@ func (t *GasOptimizationTracer) GetReport() (string, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	report := map[string]interface{}{
-		"total_gas_used":    t.TotalGasUsed,
-		"storage_reads":     len(t.StorageReads),
-		"storage_writes":    len(t.StorageWrites),
-		"memory_operations": len(t.MemoryOps),
-		"call_operations":   len(t.CallOps),
-		"expensive_ops":     len(t.ExpensiveOps),
-		"optimizations":     t.Optimizations,
-		"gas_by_opcode":     t.GasPerOpcode,
+	data, err := json.MarshalIndent(t.outputReport(), "", "  ")
+	if err != nil {
+		return "", err
 	}
 
-	data, err := json.MarshalIndent(report, "", "  ")
+	return string(data), nil
+}
+
+// GetCompactReport generates the JSON report of GetReport on a single line,
+// for machine consumption
+func (t *GasOptimizationTracer) GetCompactReport() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	data, err := json.Marshal(t.outputReport())
 	if err != nil {
 		return "", err
 	}
@@ -363,6 +1533,233 @@ func (t *GasOptimizationTracer) GetReport() (string, error) {
 	return string(data), nil
 }
 
+// outputReport returns the report data without the keys left out by OmitReportKeys.
+// The caller must hold t.mu.
+func (t *GasOptimizationTracer) outputReport() map[string]interface{} {
+	report := t.reportData()
+	for _, key := range t.omittedKeys {
+		delete(report, key)
+	}
+	return report
+}
+
+// reportData collects the contents of the JSON report. The caller must hold t.mu.
+func (t *GasOptimizationTracer) reportData() map[string]interface{} {
+	if t.restored != nil {
+		return t.restoredReport()
+	}
+
+	optimizations := t.sortedOptimizations()
+
+	report := map[string]interface{}{
+		"schema_version":       ReportSchemaVersion,
+		"total_gas_used":       t.TotalGasUsed,
+		"gas_limit":            t.GasLimit,
+		"fork":                 t.gasModel.Fork,
+		"gas_accounting_delta": t.GasAccountingDelta,
+		"storage_reads":        len(t.StorageReads),
+		"storage_writes":       len(t.StorageWrites),
+		"transient_reads":      len(t.TransientReads),
+		"transient_writes":     len(t.TransientWrites),
+		"memory_operations":    len(t.MemoryOps),
+		"call_operations":      len(t.CallOps),
+		"loops":                len(t.Loops),
+		"eth_transfers":        t.countTransfers(),
+		"contract_calls":       len(t.CallOps) - t.countTransfers(),
+		"expensive_ops":        len(t.ExpensiveOps),
+		"reverted_calls":       t.RevertedCalls,
+		"reverted_gas":         t.RevertedGas,
+		"log_operations":       len(t.LogOps),
+		"log_gas":              t.LogGas,
+		"optimizations":        optimizations,
+		"gas_by_opcode":        t.GasPerOpcode,
+		"gas_by_category":      GasByCategory(t.GasPerOpcode),
+		"opcode_counts":        t.OpcodeCounts,
+		"is_creation":          t.IsCreation,
+		"calldata": map[string]interface{}{
+			"total_bytes":    t.Calldata.TotalBytes,
+			"zero_bytes":     t.Calldata.ZeroBytes,
+			"non_zero_bytes": t.Calldata.NonZeroBytes,
+			"l1_gas":         t.Calldata.L1Gas,
+			"l2_gas":         t.Calldata.L2Gas,
+		},
+	}
+
+	t.setReportSavings(report, optimizations)
+
+	writeOnly := make([]map[string]interface{}, 0, len(t.WriteOnlySlots))
+	for _, slot := range t.WriteOnlySlots {
+		writeOnly = append(writeOnly, map[string]interface{}{
+			"contract": slot.Contract.Hex(),
+			"slot":     slot.Key.Hex(),
+			"writes":   slot.Writes,
+		})
+	}
+	report["write_only_slots"] = writeOnly
+
+	proxies := make([]map[string]interface{}, 0, len(t.ProxyImplementations))
+	for _, p := range t.ProxyImplementations {
+		proxies = append(proxies, map[string]interface{}{
+			"proxy":          p.Proxy.Hex(),
+			"implementation": p.Implementation.Hex(),
+			"storage_key":    p.StorageKey.Hex(),
+			"pc":             formatPC(p.PC),
+		})
+	}
+	report["proxy_implementations"] = proxies
+
+	accessOrder := make([]map[string]interface{}, 0, len(t.SlotAccessOrders))
+	for _, slot := range t.SlotAccessOrders {
+		accessOrder = append(accessOrder, map[string]interface{}{
+			"contract":     slot.Contract.Hex(),
+			"slot":         slot.Key.Hex(),
+			"first_access": slot.FirstAccess,
+			"order":        slot.Order,
+			"reads":        slot.Reads,
+			"writes":       slot.Writes,
+		})
+	}
+	report["slot_access_order"] = accessOrder
+
+	if t.Partial != "" {
+		report["partial"] = t.Partial
+	}
+	if t.Truncated() {
+		report["truncated"] = true
+	}
+	if len(t.Faults) > 0 {
+		faults := make([]map[string]interface{}, 0, len(t.Faults))
+		for _, fault := range t.Faults {
+			faults = append(faults, map[string]interface{}{
+				"pc":         fault.Location(),
+				"op":         fault.Op,
+				"depth":      fault.Depth,
+				"contract":   fault.Contract.Hex(),
+				"gas":        fault.Gas,
+				"cost":       fault.Cost,
+				"error":      fault.Error,
+				"out_of_gas": fault.OutOfGas,
+			})
+		}
+		report["faults"] = faults
+	}
+	if t.timelineMax > 0 {
+		report["timeline"] = t.timelineReport()
+	}
+	if t.SimulatedState != "" {
+		report["simulated_against"] = t.SimulatedState
+	}
+
+	if t.Blobs != nil {
+		report["blob_gas"] = t.blobReport()
+	}
+
+	if t.sampling() {
+		report["sampled_profile"] = t.sampledProfileReport()
+	}
+
+	if t.baseline != nil {
+		whatIf := t.compareSchedule(t.baseline)
+		opcodes := make([]map[string]interface{}, 0, len(whatIf.Opcodes))
+		for _, c := range whatIf.Opcodes {
+			opcodes = append(opcodes, map[string]interface{}{
+				"opcode":       c.Opcode,
+				"count":        c.Count,
+				"current_gas":  c.CurrentGas,
+				"baseline_gas": c.BaselineGas,
+			})
+		}
+		report["what_if"] = map[string]interface{}{
+			"current_total":  whatIf.CurrentTotal,
+			"baseline_total": whatIf.BaselineTotal,
+			"opcodes":        opcodes,
+		}
+	}
+
+	calls := make([]map[string]interface{}, 0, len(t.CallOps))
+	for _, call := range t.CallOps {
+		entry := map[string]interface{}{
+			"pc":          formatPC(call.PC),
+			"op":          call.Op,
+			"to":          call.To.Hex(),
+			"gas_used":    call.GasUsed,
+			"success":     call.Success,
+			"is_transfer": call.IsTransfer,
+		}
+		if call.Precompile != "" {
+			entry["precompile"] = call.Precompile
+		}
+		if call.HasSelector {
+			entry["selector"] = hexutil.Encode(call.Selector[:])
+		}
+		if call.Signature != "" {
+			entry["signature"] = call.Signature
+			entry["signature_verified"] = call.SignatureVerified
+		}
+		calls = append(calls, entry)
+	}
+	report["calls"] = calls
+
+	if usage := t.precompileUsage(); len(usage) > 0 {
+		precompiles := make([]map[string]interface{}, 0, len(usage))
+		for _, u := range usage {
+			precompiles = append(precompiles, map[string]interface{}{
+				"name":     u.Name,
+				"address":  u.Address.Hex(),
+				"calls":    u.Calls,
+				"gas_used": u.Gas,
+			})
+		}
+		report["precompiles"] = precompiles
+	}
+
+	if t.CallTree != nil {
+		report["call_tree"] = callTreeReport(t.CallTree)
+	}
+
+	if t.internalTxs {
+		report["internal_transactions"] = t.internalTxsReport()
+	}
+
+	if list, _ := t.suggestedAccessList(); len(list) > 0 {
+		report["suggested_access_list"] = list
+	}
+
+	if t.IsCreation {
+		report["deployment"] = map[string]interface{}{
+			"contract_address": t.CreatedAddress.Hex(),
+			"init_code_size":   t.InitCodeSize,
+			"init_code_gas":    t.InitCodeGas,
+		}
+	}
+
+	return report
+}
+
+// maxMemoryRead bounds how much memory is copied when inspecting operands
+const maxMemoryRead = 1 << 16
+
+// readMemory copies a memory region described by offset and size stack operands.
+// Tracing happens before memory expansion, so bytes beyond the current memory are zero.
+func readMemory(mem *vm.Memory, offset, size *u256.Int) ([]byte, bool) {
+	if !offset.IsUint64() || !size.IsUint64() || size.Uint64() > maxMemoryRead {
+		return nil, false
+	}
+
+	start, length := offset.Uint64(), size.Uint64()
+	data := make([]byte, length)
+
+	memLen := uint64(mem.Len())
+	if start < memLen {
+		end := start + length
+		if end > memLen {
+			end = memLen
+		}
+		copy(data, mem.Data()[start:end])
+	}
+	return data, true
+}
+
 func formatPC(pc uint64) string {
 	return "0x" + common.Bytes2Hex(big.NewInt(int64(pc)).Bytes())
 }
@@ -1,11 +1,80 @@
 package tracer
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 )
 
+// runCode executes code in an in-memory EVM with the tracer attached.
+// Additional contracts can be deployed up front via accounts.
+func runCode(t testing.TB, tracer *GasOptimizationTracer, code []byte, accounts map[common.Address][]byte) {
+	t.Helper()
+	runCodeOnChain(t, tracer, code, accounts, nil)
+}
+
+// runCodeOnChain executes code like runCode under the given chain configuration,
+// or the runtime's default London configuration when chainConfig is nil
+func runCodeOnChain(t testing.TB, tracer *GasOptimizationTracer, code []byte, accounts map[common.Address][]byte, chainConfig *params.ChainConfig) {
+	t.Helper()
+
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	for addr, accountCode := range accounts {
+		statedb.SetCode(addr, accountCode)
+	}
+
+	// Fund the executing contract so it can send value
+	statedb.AddBalance(common.BytesToAddress([]byte("contract")), big.NewInt(1e18))
+
+	cfg := &runtime.Config{
+		ChainConfig: chainConfig,
+		State:       statedb,
+		GasLimit:    1000000,
+		EVMConfig:   vm.Config{Tracer: tracer},
+	}
+	if chainConfig != nil && chainConfig.TerminalTotalDifficulty != nil {
+		cfg.Random = &common.Hash{}
+	}
+	runtime.Execute(code, nil, cfg)
+}
+
+// callCode assembles a CALL forwarding gas to the target with no value or data
+func callCode(gas uint16, to common.Address) []byte {
+	return valueCallCode(gas, to, 0)
+}
+
+// valueCallCode assembles a CALL forwarding gas and value to the target with no data
+func valueCallCode(gas uint16, to common.Address, value byte) []byte {
+	code := []byte{
+		byte(vm.PUSH1), 0x00, // retLength
+		byte(vm.PUSH1), 0x00, // retOffset
+		byte(vm.PUSH1), 0x00, // argsLength
+		byte(vm.PUSH1), 0x00, // argsOffset
+		byte(vm.PUSH1), value, // value
+		byte(vm.PUSH20),
+	}
+	code = append(code, to.Bytes()...)
+	code = append(code, byte(vm.PUSH2), byte(gas>>8), byte(gas))
+	return append(code, byte(vm.CALL))
+}
+
 func TestNewGasOptimizationTracer(t *testing.T) {
 	tracer := NewGasOptimizationTracer()
 
@@ -40,7 +109,7 @@ func TestGetOptimizations(t *testing.T) {
 		Type:        "test",
 		Severity:    "high",
 		Description: "Test optimization",
-		Location:    "0x42",
+		Location:    PCLocation(0x42),
 		GasSavings:  100,
 	})
 
@@ -112,6 +181,217 @@ func TestGetReport(t *testing.T) {
 	}
 }
 
+func TestGetCompactReport(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	code := []byte{
+		byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	pretty, err := tracer.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+	compact, err := tracer.GetCompactReport()
+	if err != nil {
+		t.Fatalf("GetCompactReport() error: %v", err)
+	}
+
+	if strings.Contains(compact, "\n") {
+		t.Error("Expected the compact report to have no newlines")
+	}
+
+	if len(compact) >= len(pretty) {
+		t.Errorf("Expected the compact report to be shorter than the pretty one, got %d and %d bytes", len(compact), len(pretty))
+	}
+
+	var prettyValue, compactValue interface{}
+	if err := json.Unmarshal([]byte(pretty), &prettyValue); err != nil {
+		t.Fatalf("Failed to parse the pretty report: %v", err)
+	}
+	if err := json.Unmarshal([]byte(compact), &compactValue); err != nil {
+		t.Fatalf("Failed to parse the compact report: %v", err)
+	}
+
+	if !reflect.DeepEqual(prettyValue, compactValue) {
+		t.Error("Expected the compact and pretty reports to hold the same object")
+	}
+}
+
+func TestContractCreation(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// PUSH1 0 PUSH1 0 RETURN - deploys an empty contract
+	initCode := []byte{byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.RETURN)}
+
+	_, addr, _, err := runtime.Create(initCode, &runtime.Config{
+		EVMConfig: vm.Config{Tracer: tracer},
+	})
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	if !tracer.IsCreation {
+		t.Error("Expected transaction to be flagged as a creation")
+	}
+
+	if tracer.CreatedAddress != addr {
+		t.Errorf("Expected created address %s, got %s", addr.Hex(), tracer.CreatedAddress.Hex())
+	}
+
+	if tracer.InitCodeSize != len(initCode) {
+		t.Errorf("Expected init code size %d, got %d", len(initCode), tracer.InitCodeSize)
+	}
+
+	if tracer.InitCodeGas == 0 {
+		t.Error("Expected init code gas to be attributed")
+	}
+
+	report, err := tracer.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+
+	if !contains(report, `"is_creation": true`) {
+		t.Error("Report does not flag the transaction as a creation")
+	}
+
+	if !contains(report, "deployment") {
+		t.Error("Report missing 'deployment'")
+	}
+}
+
+func TestLargeInitCode(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// Return immediately, followed by padding that is never executed
+	initCode := make([]byte, largeInitCodeSize+1)
+	copy(initCode, []byte{byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.RETURN)})
+
+	if _, _, _, err := runtime.Create(initCode, &runtime.Config{
+		EVMConfig: vm.Config{Tracer: tracer},
+	}); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	found := false
+	for _, opt := range tracer.GetOptimizations() {
+		if opt.Type == "large_initcode" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Error("Expected large_initcode optimization")
+	}
+}
+
+func TestInsufficientGasForwarded(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// Callee spins forever so any fixed stipend runs out
+	callee := common.HexToAddress("0xca11ee")
+	loop := []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0x00, byte(vm.JUMP)}
+
+	code := append(callCode(2300, callee), byte(vm.STOP))
+	runCode(t, tracer, code, map[common.Address][]byte{callee: loop})
+
+	var found *Optimization
+	optimizations := tracer.GetOptimizations()
+	for i := range optimizations {
+		if optimizations[i].Type == "insufficient_gas_forwarded" {
+			found = &optimizations[i]
+		}
+	}
+
+	if found == nil {
+		t.Fatal("Expected insufficient_gas_forwarded optimization")
+	}
+
+	if found.Severity != "medium" {
+		t.Errorf("Expected severity 'medium', got '%s'", found.Severity)
+	}
+
+	if found.Details["forwarded_gas"] != uint64(2300) {
+		t.Errorf("Expected forwarded_gas 2300, got %v", found.Details["forwarded_gas"])
+	}
+
+	if found.Details["out_of_gas"] != true {
+		t.Error("Expected finding to be correlated with the out-of-gas revert")
+	}
+
+	if len(tracer.CallOps) != 1 || tracer.CallOps[0].Success {
+		t.Error("Expected a single failed call to be recorded")
+	}
+}
+
+func TestInsufficientGasForwardedIgnoresEOA(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	code := append(callCode(2300, common.HexToAddress("0xe0a")), byte(vm.STOP))
+	runCode(t, tracer, code, nil)
+
+	for _, opt := range tracer.GetOptimizations() {
+		if opt.Type == "insufficient_gas_forwarded" {
+			t.Error("Did not expect a finding for a call to an account without code")
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	callee := common.HexToAddress("0xca11ee")
+	loop := []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0x00, byte(vm.JUMP)}
+	code := append(callCode(2300, callee), byte(vm.STOP))
+	runCode(t, tracer, code, map[common.Address][]byte{callee: loop})
+
+	tracer.StorageReads[common.HexToHash("0x01")] = 3
+	tracer.StorageWrites[common.HexToHash("0x02")] = 1
+	tracer.MemoryOps = append(tracer.MemoryOps, MemoryOperation{Op: "MSTORE"})
+	tracer.ExpensiveOps = append(tracer.ExpensiveOps, ExpensiveOperation{Op: "CREATE"})
+	tracer.Loops = append(tracer.Loops, LoopDetection{Iterations: 2})
+	tracer.IsCreation = true
+	tracer.InitCodeSize = 10
+	tracer.InitCodeGas = 100
+
+	if len(tracer.CallOps) == 0 || len(tracer.GasPerOpcode) == 0 || len(tracer.Optimizations) == 0 {
+		t.Fatal("Expected tracer to be populated before reset")
+	}
+
+	tracer.Reset()
+
+	if len(tracer.StorageReads) != 0 || len(tracer.StorageWrites) != 0 || len(tracer.GasPerOpcode) != 0 {
+		t.Error("Expected maps to be empty after reset")
+	}
+
+	if len(tracer.MemoryOps) != 0 || len(tracer.CallOps) != 0 || len(tracer.Loops) != 0 ||
+		len(tracer.ExpensiveOps) != 0 || len(tracer.Optimizations) != 0 {
+		t.Error("Expected slices to be empty after reset")
+	}
+
+	if tracer.PC != 0 || tracer.Gas != 0 || tracer.Depth != 0 || tracer.TotalGasUsed != 0 {
+		t.Error("Expected scalar fields to be zeroed after reset")
+	}
+
+	if tracer.IsCreation || tracer.InitCodeSize != 0 || tracer.InitCodeGas != 0 {
+		t.Error("Expected deployment fields to be zeroed after reset")
+	}
+
+	if tracer.StorageReads == nil || tracer.GasPerOpcode == nil {
+		t.Error("Expected maps to remain allocated after reset")
+	}
+
+	// The tracer should be usable for another transaction
+	runCode(t, tracer, []byte{byte(vm.PUSH1), 0x01, byte(vm.STOP)}, nil)
+	if tracer.GasPerOpcode["PUSH1"] != 3 {
+		t.Errorf("Expected PUSH1 gas 3 after reuse, got %d", tracer.GasPerOpcode["PUSH1"])
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) > 0 && len(substr) > 0 &&
 		(s == substr || len(s) >= len(substr) &&
@@ -131,3 +411,3111 @@ func containsRecursive(s, substr string) bool {
 	}
 	return false
 }
+
+func TestAnalyzeCalldata(t *testing.T) {
+	data := []byte{0x00, 0x00, 0x01, 0x02, 0xff}
+
+	analysis := AnalyzeCalldata(data, 0.5)
+
+	if analysis.TotalBytes != 5 {
+		t.Errorf("Expected 5 total bytes, got %d", analysis.TotalBytes)
+	}
+
+	if analysis.ZeroBytes != 2 || analysis.NonZeroBytes != 3 {
+		t.Errorf("Expected 2 zero and 3 non-zero bytes, got %d and %d", analysis.ZeroBytes, analysis.NonZeroBytes)
+	}
+
+	// 2 * 4 + 3 * 16
+	if analysis.L1Gas != 56 {
+		t.Errorf("Expected L1 gas 56, got %d", analysis.L1Gas)
+	}
+
+	if analysis.L2Gas != 28 {
+		t.Errorf("Expected L2 gas 28, got %d", analysis.L2Gas)
+	}
+}
+
+func TestCalldataHeavy(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	input := make([]byte, 256)
+	for i := range input {
+		input[i] = 0xff
+	}
+
+	// STOP immediately so calldata dominates the cost
+	runtime.Execute([]byte{byte(vm.STOP)}, input, &runtime.Config{
+		EVMConfig: vm.Config{Tracer: tracer},
+	})
+
+	if tracer.Calldata.TotalBytes != 256 {
+		t.Errorf("Expected 256 calldata bytes, got %d", tracer.Calldata.TotalBytes)
+	}
+
+	found := false
+	for _, opt := range tracer.GetOptimizations() {
+		if opt.Type == "calldata_heavy" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Error("Expected calldata_heavy optimization")
+	}
+
+	report, err := tracer.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+
+	if !contains(report, `"calldata"`) {
+		t.Error("Report missing 'calldata'")
+	}
+}
+
+func TestRedundantHash(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	code := []byte{
+		byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0x00, byte(vm.MSTORE), // mem[0:32] = 0x2a
+		byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x00, byte(vm.KECCAK256), byte(vm.POP),
+		byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x00, byte(vm.KECCAK256), byte(vm.POP),
+		byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x20, byte(vm.KECCAK256), byte(vm.POP), // different input
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	var findings []Optimization
+	for _, opt := range tracer.GetOptimizations() {
+		if opt.Type == "redundant_hash" {
+			findings = append(findings, opt)
+		}
+	}
+
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 redundant_hash optimization, got %d", len(findings))
+	}
+
+	if findings[0].Details["hash_count"] != 2 {
+		t.Errorf("Expected hash_count 2, got %v", findings[0].Details["hash_count"])
+	}
+
+	// One avoidable hash of a single word: 30 + 6
+	if findings[0].GasSavings != 36 {
+		t.Errorf("Expected savings 36, got %d", findings[0].GasSavings)
+	}
+}
+
+func TestOpcodeCounts(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	code := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x02, byte(vm.ADD),
+		byte(vm.PUSH1), 0x03, byte(vm.ADD),
+		byte(vm.POP), byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	expected := map[string]uint64{"PUSH1": 3, "ADD": 2, "POP": 1, "STOP": 1}
+	for op, count := range expected {
+		if tracer.OpcodeCounts[op] != count {
+			t.Errorf("Expected %s count %d, got %d", op, count, tracer.OpcodeCounts[op])
+		}
+	}
+
+	if tracer.GasPerOpcode["PUSH1"] != 9 {
+		t.Errorf("Expected PUSH1 gas 9, got %d", tracer.GasPerOpcode["PUSH1"])
+	}
+}
+
+func TestGasAccountingNoDoubleCount(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	scope := &vm.ScopeContext{Memory: vm.NewMemory(), Stack: &vm.Stack{}}
+
+	tracer.CaptureStart(nil, common.Address{}, common.Address{}, false, nil, 1000, nil)
+
+	// Opening step charged 3 gas plus a 100 gas allowance for the callee
+	tracer.CaptureState(0, vm.GAS, 1000, 103, scope, nil, 1, nil)
+	tracer.CaptureEnter(vm.CALL, common.Address{}, common.Address{}, nil, 100, nil)
+	tracer.CaptureState(0, vm.GAS, 100, 3, scope, nil, 2, nil)
+	tracer.CaptureExit(nil, 3, nil)
+
+	// The callee only used 3 gas of its allowance
+	if tracer.TotalGasUsed != 6 {
+		t.Errorf("Expected running total 6 after exit, got %d", tracer.TotalGasUsed)
+	}
+
+	tracer.CaptureState(1, vm.GAS, 894, 3, scope, nil, 1, nil)
+	tracer.CaptureEnd(nil, 9, nil)
+
+	if tracer.TotalGasUsed != 9 {
+		t.Errorf("Expected total gas 9, got %d", tracer.TotalGasUsed)
+	}
+
+	if tracer.GasAccountingDelta != 0 {
+		t.Errorf("Expected no accounting delta, got %d", tracer.GasAccountingDelta)
+	}
+}
+
+func TestGasAccountingMatchesExecution(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+
+	// Caller forwards gas to a callee that writes storage
+	callee := common.HexToAddress("0xca11ee")
+	statedb.SetCode(callee, []byte{byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.SSTORE), byte(vm.STOP)})
+	caller := common.HexToAddress("0xca11e7")
+	statedb.SetCode(caller, append(callCode(0xffff, callee), byte(vm.STOP)))
+
+	const gasLimit = 1000000
+	_, leftOver, err := runtime.Call(caller, nil, &runtime.Config{
+		State:     statedb,
+		GasLimit:  gasLimit,
+		EVMConfig: vm.Config{Tracer: tracer},
+	})
+	if err != nil {
+		t.Fatalf("Call() error: %v", err)
+	}
+
+	if tracer.TotalGasUsed != gasLimit-leftOver {
+		t.Errorf("Expected total gas %d, got %d", gasLimit-leftOver, tracer.TotalGasUsed)
+	}
+
+	if tracer.GasAccountingDelta != 0 {
+		t.Errorf("Expected no accounting delta, got %d", tracer.GasAccountingDelta)
+	}
+}
+
+func TestGasAccountingCreate(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	tracer.SetTimeline(DefaultTimelinePoints)
+
+	// Deploy init code that writes storage and returns no runtime code
+	initCode := []byte{byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.SSTORE), byte(vm.STOP)}
+	code := append([]byte{byte(vm.PUSH6)}, initCode...)
+	code = append(code,
+		byte(vm.PUSH1), 0x00, byte(vm.MSTORE),
+		byte(vm.PUSH1), byte(len(initCode)), byte(vm.PUSH1), byte(32-len(initCode)), byte(vm.PUSH1), 0x00, // size, offset, value
+		byte(vm.CREATE), byte(vm.POP), byte(vm.STOP))
+	runCode(t, tracer, code, nil)
+
+	if tracer.GasAccountingDelta != 0 {
+		t.Errorf("Expected no accounting delta, got %d", tracer.GasAccountingDelta)
+	}
+
+	// Points inside the deployment count the init code's SSTORE
+	var before, inside uint64
+	for _, point := range tracer.GetTimeline() {
+		if point.Depth == 1 && inside == 0 {
+			before = point.Gas
+		}
+		if point.Depth == 2 {
+			inside = point.Gas
+		}
+	}
+	if inside < before+params.SstoreSetGasEIP2200 {
+		t.Errorf("Expected timeline gas inside the CREATE to include the SSTORE, got %d after %d", inside, before)
+	}
+}
+
+func TestStepWriter(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	var buf bytes.Buffer
+	tracer.SetStepWriter(&buf)
+
+	code := []byte{byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x02, byte(vm.ADD), byte(vm.STOP)}
+	runCode(t, tracer, code, nil)
+
+	if err := tracer.StepWriterError(); err != nil {
+		t.Fatalf("StepWriterError() = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 steps, got %d", len(lines))
+	}
+
+	expectedOps := []string{"PUSH1", "PUSH1", "ADD", "STOP"}
+	for i, line := range lines {
+		var step Step
+		if err := json.Unmarshal([]byte(line), &step); err != nil {
+			t.Fatalf("line %d is not valid JSON: %v", i, err)
+		}
+		if step.Op != expectedOps[i] {
+			t.Errorf("Expected step %d op %s, got %s", i, expectedOps[i], step.Op)
+		}
+		if step.Depth != 1 {
+			t.Errorf("Expected step %d depth 1, got %d", i, step.Depth)
+		}
+	}
+
+	if !contains(lines[2], `"pc":4`) || !contains(lines[2], `"cost":3`) {
+		t.Errorf("Unexpected ADD step: %s", lines[2])
+	}
+}
+
+func TestStepWriterDisabled(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	tracer.SetStepWriter(nil)
+
+	runCode(t, tracer, []byte{byte(vm.STOP)}, nil)
+
+	if tracer.StepWriterError() != nil {
+		t.Error("Expected no error when streaming is disabled")
+	}
+}
+
+func TestETHTransferClassification(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	recipient := common.HexToAddress("0xbeef")
+	callee := common.HexToAddress("0xca11ee")
+
+	code := valueCallCode(0, recipient, 1)
+	code = append(code, byte(vm.POP))
+	code = append(code, callCode(0xffff, callee)...)
+	code = append(code, byte(vm.STOP))
+	runCode(t, tracer, code, map[common.Address][]byte{callee: {byte(vm.STOP)}})
+
+	if len(tracer.CallOps) != 2 {
+		t.Fatalf("Expected 2 calls, got %d", len(tracer.CallOps))
+	}
+
+	transfer := tracer.CallOps[0]
+	if !transfer.IsTransfer {
+		t.Error("Expected value-bearing zero-data call to be classified as a transfer")
+	}
+	if transfer.Value == nil || transfer.Value.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("Expected transfer value 1, got %v", transfer.Value)
+	}
+
+	if tracer.CallOps[1].IsTransfer {
+		t.Error("Expected zero-value call not to be classified as a transfer")
+	}
+
+	report, err := tracer.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+	if !contains(report, `"eth_transfers": 1`) || !contains(report, `"contract_calls": 1`) {
+		t.Error("Report does not distinguish transfers from contract calls")
+	}
+}
+
+func TestLoopDetection(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// Count down from 3, reading slot 0 each iteration:
+	// 0: PUSH1 3
+	// 2: JUMPDEST
+	// 3: PUSH1 0 SLOAD POP
+	// 7: PUSH1 1 SWAP1 SUB
+	// 11: DUP1 PUSH1 2 JUMPI
+	// 15: STOP
+	code := []byte{
+		byte(vm.PUSH1), 0x03,
+		byte(vm.JUMPDEST),
+		byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x01, byte(vm.SWAP1), byte(vm.SUB),
+		byte(vm.DUP1), byte(vm.PUSH1), 0x02, byte(vm.JUMPI),
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	if len(tracer.Loops) != 1 {
+		t.Fatalf("Expected 1 loop, got %d", len(tracer.Loops))
+	}
+
+	loop := tracer.Loops[0]
+	if loop.StartPC != 2 || loop.EndPC != 14 {
+		t.Errorf("Expected loop range 2-14, got %d-%d", loop.StartPC, loop.EndPC)
+	}
+
+	// The body runs three times and jumps back twice
+	if loop.Iterations != 2 {
+		t.Errorf("Expected 2 iterations, got %d", loop.Iterations)
+	}
+
+	if loop.GasPerLoop == 0 {
+		t.Error("Expected per-iteration gas to be measured")
+	}
+
+	found := false
+	for _, opt := range tracer.GetOptimizations() {
+		if opt.Type == "storage_in_loop" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected storage_in_loop optimization")
+	}
+}
+
+func TestStorageInLoop(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	contract := common.HexToAddress("0xc0de")
+	slot := common.HexToHash("0x05")
+
+	tracer.Loops = append(tracer.Loops, LoopDetection{
+		StartPC:    0x10,
+		EndPC:      0x40,
+		Iterations: 5,
+		Contract:   contract,
+	})
+
+	// One cold and one warm read inside the loop, one read outside it
+	tracer.indexStorageOp(StorageOperation{PC: 0x20, Op: "SLOAD", Key: slot, Gas: 2100, Contract: contract})
+	tracer.indexStorageOp(StorageOperation{PC: 0x20, Op: "SLOAD", Key: slot, Gas: 100, Contract: contract})
+	tracer.indexStorageOp(StorageOperation{PC: 0x50, Op: "SLOAD", Key: slot, Gas: 100, Contract: contract})
+
+	tracer.CaptureEnd(nil, 10000, nil)
+
+	var found *Optimization
+	for i, opt := range tracer.Optimizations {
+		if opt.Type == "storage_in_loop" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+
+	if found == nil {
+		t.Fatal("Expected storage_in_loop optimization")
+	}
+
+	if found.Severity != "high" {
+		t.Errorf("Expected severity 'high', got '%s'", found.Severity)
+	}
+
+	if found.Details["storage_key"] != slot.Hex() {
+		t.Errorf("Expected storage_key %s, got %v", slot.Hex(), found.Details["storage_key"])
+	}
+
+	// Average 1100 gas per iteration, hoisting saves four of five iterations
+	if found.GasSavings != 4400 {
+		t.Errorf("Expected savings 4400, got %d", found.GasSavings)
+	}
+
+	if found.Details["loop_storage_gas"] != uint64(5500) {
+		t.Errorf("Expected loop_storage_gas 5500, got %v", found.Details["loop_storage_gas"])
+	}
+}
+
+// callLoopCode assembles a loop making the call on each of the iterations;
+// the call starts at PC 3 and the loop jumps back to PC 2
+func callLoopCode(iterations byte, call []byte) []byte {
+	code := []byte{
+		byte(vm.PUSH1), iterations, // 0: remaining iterations
+		byte(vm.JUMPDEST), // 2: loop
+	}
+	code = append(code, call...)
+	return append(code,
+		byte(vm.POP),
+		byte(vm.PUSH1), 0x01,
+		byte(vm.SWAP1),
+		byte(vm.SUB),
+		byte(vm.DUP1),
+		byte(vm.PUSH1), 0x02,
+		byte(vm.JUMPI),
+		byte(vm.STOP),
+	)
+}
+
+func TestCallInLoop(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	to := common.HexToAddress("0xdead")
+	call := callCode(5000, to)
+	runCode(t, tracer, callLoopCode(4, call), nil)
+
+	var found *Optimization
+	for i, opt := range tracer.Optimizations {
+		if opt.Type == "call_in_loop" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+	if found == nil {
+		t.Fatal("Expected call_in_loop optimization")
+	}
+
+	// The CALL is the last instruction of the call sequence, inside the loop range
+	callPC := uint64(2 + len(call))
+	if found.Location.String() != formatPC(callPC) {
+		t.Errorf("Expected location %s, got %s", formatPC(callPC), found.Location)
+	}
+	if found.Severity != "high" {
+		t.Errorf("Expected severity 'high', got '%s'", found.Severity)
+	}
+	if expected := formatPC(2) + "-" + formatPC(callPC+9); found.Details["loop_range"] != expected {
+		t.Errorf("Expected loop range %s, got %v", expected, found.Details["loop_range"])
+	}
+
+	// Every call is a zero-value call with no data to an account without code,
+	// so the overhead of all four is wasted
+	if found.Details["calls"] != 4 || found.Details["no_op_calls"] != 4 {
+		t.Errorf("Expected 4 no-op calls, got %v of %v", found.Details["no_op_calls"], found.Details["calls"])
+	}
+	if expected := 4 * tracer.gasModel.CallGas; found.GasSavings != expected {
+		t.Errorf("Expected savings %d, got %d", expected, found.GasSavings)
+	}
+}
+
+func TestCallInLoopRepeatedCall(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	to := common.HexToAddress("0xc0de")
+	callee := []byte{byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.RETURN)}
+
+	// STATICCALL with the same 32 bytes of (zero) calldata on each iteration
+	call := []byte{
+		byte(vm.PUSH1), 0x00, // retLength
+		byte(vm.PUSH1), 0x00, // retOffset
+		byte(vm.PUSH1), 0x20, // argsLength
+		byte(vm.PUSH1), 0x00, // argsOffset
+		byte(vm.PUSH2), 0xc0, 0xde,
+		byte(vm.PUSH2), 0x13, 0x88,
+		byte(vm.STATICCALL),
+	}
+	runCode(t, tracer, callLoopCode(3, call), map[common.Address][]byte{to: callee})
+
+	var found *Optimization
+	for i, opt := range tracer.Optimizations {
+		if opt.Type == "call_in_loop" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+	if found == nil {
+		t.Fatal("Expected call_in_loop optimization")
+	}
+
+	// The first call is needed, the two repeats are wasted
+	if found.Details["repeated_calls"] != 2 || found.Details["no_op_calls"] != 0 {
+		t.Errorf("Expected 2 repeated calls, got %v (%v no-op)", found.Details["repeated_calls"], found.Details["no_op_calls"])
+	}
+	if len(tracer.CallOps) != 3 {
+		t.Fatalf("Expected 3 calls, got %d", len(tracer.CallOps))
+	}
+	if expected := 2*tracer.gasModel.CallGas + tracer.CallOps[1].GasUsed + tracer.CallOps[2].GasUsed; found.GasSavings != expected {
+		t.Errorf("Expected savings %d, got %d", expected, found.GasSavings)
+	}
+}
+
+func TestCallInLoopValueTransfer(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	runCode(t, tracer, callLoopCode(3, valueCallCode(5000, common.HexToAddress("0xdead"), 1)), nil)
+
+	if hasOptimization(tracer, "call_in_loop") {
+		t.Error("Did not expect call_in_loop for repeated value transfers")
+	}
+}
+
+func TestLogDataHeavy(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// LOG1 with one topic and 2000 bytes of data
+	code := []byte{
+		byte(vm.PUSH1), 0x01, // topic
+		byte(vm.PUSH2), 0x07, 0xd0, // size
+		byte(vm.PUSH1), 0x00, // offset
+		byte(vm.LOG1),
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	if len(tracer.LogOps) != 1 {
+		t.Fatalf("Expected 1 log operation, got %d", len(tracer.LogOps))
+	}
+
+	logOp := tracer.LogOps[0]
+	if logOp.Topics != 1 || logOp.DataSize != 2000 || logOp.Offset != 0 {
+		t.Errorf("Expected 1 topic and 2000 bytes at offset 0, got %d topics and %d bytes at %d",
+			logOp.Topics, logOp.DataSize, logOp.Offset)
+	}
+
+	expectedGas := uint64(375 + 375 + 2000*8)
+	if logOp.Gas != expectedGas || tracer.LogGas != expectedGas {
+		t.Errorf("Expected log gas %d, got %d (total %d)", expectedGas, logOp.Gas, tracer.LogGas)
+	}
+
+	var found *Optimization
+	for i := range tracer.Optimizations {
+		if tracer.Optimizations[i].Type == "log_data_heavy" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+
+	if found == nil {
+		t.Fatal("Expected log_data_heavy optimization")
+	}
+
+	if found.GasSavings != (2000-32)*8 {
+		t.Errorf("Expected savings %d, got %d", (2000-32)*8, found.GasSavings)
+	}
+}
+
+func TestLogTopicsOnlyNotFlagged(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// LOG2 with no data costs only topic gas
+	code := []byte{
+		byte(vm.PUSH1), 0x02,
+		byte(vm.PUSH1), 0x01,
+		byte(vm.PUSH1), 0x00,
+		byte(vm.PUSH1), 0x00,
+		byte(vm.LOG2),
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	for _, opt := range tracer.Optimizations {
+		if opt.Type == "log_data_heavy" {
+			t.Error("Did not expect log_data_heavy for a topic-only log")
+		}
+	}
+}
+
+func TestWarmSetsRevert(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	callee := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	queried := common.HexToAddress("0x00000000000000000000000000000000000000dd")
+
+	// The callee reads a slot and queries an account, then reverts
+	calleeCode := []byte{byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP), byte(vm.PUSH20)}
+	calleeCode = append(calleeCode, queried.Bytes()...)
+	calleeCode = append(calleeCode, byte(vm.BALANCE), byte(vm.POP), byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.REVERT))
+
+	// The caller calls it twice, then copies the queried account's code
+	code := append(callCode(0xffff, callee), byte(vm.POP))
+	code = append(code, callCode(0xffff, callee)...)
+	code = append(code, byte(vm.POP), byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH20))
+	code = append(code, queried.Bytes()...)
+	code = append(code, byte(vm.EXTCODECOPY), byte(vm.STOP))
+
+	runCode(t, tracer, code, map[common.Address][]byte{callee: calleeCode})
+
+	// Each revert makes the slot and account cold again
+	counts := make(map[string]int)
+	for _, access := range tracer.ColdAccesses {
+		counts[access.Op]++
+	}
+	if counts["SLOAD"] != 2 || counts["BALANCE"] != 2 || counts["EXTCODECOPY"] != 1 {
+		t.Errorf("Expected 2 cold SLOADs, 2 cold BALANCEs and 1 cold EXTCODECOPY, got %v", counts)
+	}
+	// The callee stays warm, as the calls warmed it before their frames began
+	if counts["CALL"] != 1 {
+		t.Errorf("Expected 1 cold CALL, got %d", counts["CALL"])
+	}
+
+	for _, entry := range tracer.SuggestedAccessList() {
+		if entry.Address == callee && len(entry.StorageKeys) != 1 {
+			t.Errorf("Expected the callee's slot listed once, got %v", entry.StorageKeys)
+		}
+	}
+}
+
+func TestWarmSetsCreatedAddress(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	created := crypto.CreateAddress(common.BytesToAddress([]byte("contract")), 0)
+
+	// Deploy empty code, then call the new contract
+	code := []byte{byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.CREATE), byte(vm.POP)}
+	code = append(code, callCode(0xffff, created)...)
+	code = append(code, byte(vm.STOP))
+	runCode(t, tracer, code, nil)
+
+	for _, access := range tracer.ColdAccesses {
+		if access.Address == created {
+			t.Errorf("Expected the created address to be warm, got a cold %s", access.Op)
+		}
+	}
+}
+
+func TestSuggestedAccessList(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	callee := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+
+	// Callee reads two distinct slots, the second one twice
+	calleeCode := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x02, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x02, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.STOP),
+	}
+
+	// The caller reads one of its own slots, then calls the callee
+	code := []byte{byte(vm.PUSH1), 0x07, byte(vm.SLOAD), byte(vm.POP)}
+	code = append(code, callCode(0xffff, callee)...)
+	code = append(code, byte(vm.STOP))
+
+	runCode(t, tracer, code, map[common.Address][]byte{callee: calleeCode})
+
+	if len(tracer.ColdAccesses) != 4 {
+		t.Fatalf("Expected 4 cold accesses, got %d", len(tracer.ColdAccesses))
+	}
+
+	list := tracer.SuggestedAccessList()
+	if len(list) != 1 {
+		t.Fatalf("Expected 1 access list entry, got %d", len(list))
+	}
+
+	// The caller is already warm as the transaction target, so listing one slot does not pay off
+	if list[0].Address != callee {
+		t.Errorf("Expected access list entry for %s, got %s", callee.Hex(), list[0].Address.Hex())
+	}
+
+	expectedSlots := []common.Hash{common.BigToHash(big.NewInt(1)), common.BigToHash(big.NewInt(2))}
+	if len(list[0].StorageKeys) != len(expectedSlots) {
+		t.Fatalf("Expected %d storage keys, got %d", len(expectedSlots), len(list[0].StorageKeys))
+	}
+	for i, slot := range expectedSlots {
+		if list[0].StorageKeys[i] != slot {
+			t.Errorf("Expected storage key %s, got %s", slot.Hex(), list[0].StorageKeys[i].Hex())
+		}
+	}
+
+	var found *Optimization
+	for i := range tracer.Optimizations {
+		if tracer.Optimizations[i].Type == "missing_access_list" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+
+	if found == nil {
+		t.Fatal("Expected missing_access_list optimization")
+	}
+
+	// One cold address and two cold slots each save 100 gas net
+	if found.GasSavings != 300 {
+		t.Errorf("Expected savings 300, got %d", found.GasSavings)
+	}
+
+	listJSON, err := tracer.AccessListJSON()
+	if err != nil {
+		t.Fatalf("AccessListJSON() error: %v", err)
+	}
+
+	var decoded types.AccessList
+	if err := json.Unmarshal([]byte(listJSON), &decoded); err != nil {
+		t.Fatalf("Access list is not valid JSON: %v", err)
+	}
+	if len(decoded) != 1 || decoded[0].Address != callee {
+		t.Errorf("Expected decoded access list for %s, got %v", callee.Hex(), decoded)
+	}
+
+	report, _ := tracer.GetReport()
+	if !contains(report, "suggested_access_list") {
+		t.Error("Expected report to contain suggested_access_list")
+	}
+}
+
+func TestRedundantAccountAccess(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	target := common.HexToAddress("0x00000000000000000000000000000000000000dd")
+
+	var code []byte
+	for i := 0; i < 3; i++ {
+		code = append(code, byte(vm.PUSH20))
+		code = append(code, target.Bytes()...)
+		code = append(code, byte(vm.EXTCODESIZE), byte(vm.POP))
+	}
+	code = append(code, byte(vm.STOP))
+
+	runCode(t, tracer, code, map[common.Address][]byte{target: {byte(vm.STOP)}})
+
+	if tracer.AccountChecks[target] != 3 {
+		t.Errorf("Expected 3 queries of the target, got %d", tracer.AccountChecks[target])
+	}
+
+	count := 0
+	for _, opt := range tracer.Optimizations {
+		if opt.Type != "redundant_account_access" {
+			continue
+		}
+		count++
+
+		if opt.GasSavings != 200 {
+			t.Errorf("Expected savings 200, got %d", opt.GasSavings)
+		}
+
+		if opt.Details["query_count"] != 3 {
+			t.Errorf("Expected query_count 3, got %v", opt.Details["query_count"])
+		}
+	}
+
+	if count != 1 {
+		t.Errorf("Expected 1 redundant_account_access finding, got %d", count)
+	}
+}
+
+func TestSafeMathMulGuard(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// c = a * b; require(c / a == b)
+	code := []byte{
+		byte(vm.PUSH1), 0x07, // b
+		byte(vm.PUSH1), 0x06, // a
+		byte(vm.DUP2), byte(vm.DUP2), byte(vm.MUL), // c
+		byte(vm.DUP2), byte(vm.DUP2), byte(vm.DIV), // c / a
+		byte(vm.DUP4), byte(vm.EQ), // == b
+		byte(vm.PUSH1), 0x11, byte(vm.JUMPI),
+		byte(vm.INVALID),
+		byte(vm.INVALID),
+		byte(vm.JUMPDEST), // 0x11
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	var found *Optimization
+	for i := range tracer.Optimizations {
+		if tracer.Optimizations[i].Type == "safemath_overhead" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+
+	if found == nil {
+		t.Fatal("Expected safemath_overhead optimization")
+	}
+
+	if found.Details["operation"] != "MUL" {
+		t.Errorf("Expected operation MUL, got %v", found.Details["operation"])
+	}
+
+	if found.Details["pc_range"] != "0x06-0x0e" {
+		t.Errorf("Expected pc_range 0x06-0x0e, got %v", found.Details["pc_range"])
+	}
+}
+
+func TestSafeMathAddGuard(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// c = a + b; require(c >= a)
+	code := []byte{
+		byte(vm.PUSH1), 0x07, // b
+		byte(vm.PUSH1), 0x06, // a
+		byte(vm.DUP2), byte(vm.DUP2), byte(vm.ADD), // c
+		byte(vm.DUP2), byte(vm.DUP2), byte(vm.LT), // c < a
+		byte(vm.ISZERO),
+		byte(vm.PUSH1), 0x10, byte(vm.JUMPI),
+		byte(vm.INVALID),
+		byte(vm.INVALID),
+		byte(vm.JUMPDEST), // 0x10
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	count := 0
+	for _, opt := range tracer.Optimizations {
+		if opt.Type == "safemath_overhead" {
+			count++
+		}
+	}
+
+	if count != 1 {
+		t.Errorf("Expected 1 safemath_overhead optimization, got %d", count)
+	}
+}
+
+func TestSafeMathIgnoresLoopCondition(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// i + 1 < n compares the sum against an unrelated bound
+	code := []byte{
+		byte(vm.PUSH1), 0x0a, // n
+		byte(vm.PUSH1), 0x01,
+		byte(vm.PUSH1), 0x02, // i
+		byte(vm.ADD),
+		byte(vm.LT),
+		byte(vm.PUSH1), 0x0d, byte(vm.JUMPI),
+		byte(vm.INVALID),
+		byte(vm.INVALID),
+		byte(vm.JUMPDEST), // 0x0d
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	for _, opt := range tracer.Optimizations {
+		if opt.Type == "safemath_overhead" {
+			t.Error("Did not expect safemath_overhead for a loop condition")
+		}
+	}
+}
+
+// selectorResolver resolves selectors from a fixed table
+type selectorResolver map[[4]byte]string
+
+func (r selectorResolver) ResolveSelector(selector [4]byte) (string, bool, bool) {
+	signature, ok := r[selector]
+	return signature, false, ok
+}
+
+func TestResolveSignatures(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	callee := common.HexToAddress("0x00000000000000000000000000000000000000ee")
+
+	// Store the transfer selector at memory offset 0 and call with 4 bytes of calldata
+	code := []byte{
+		byte(vm.PUSH4), 0xa9, 0x05, 0x9c, 0xbb,
+		byte(vm.PUSH1), 0xe0, byte(vm.SHL),
+		byte(vm.PUSH1), 0x00, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x00, // retLength
+		byte(vm.PUSH1), 0x00, // retOffset
+		byte(vm.PUSH1), 0x04, // argsLength
+		byte(vm.PUSH1), 0x00, // argsOffset
+		byte(vm.PUSH1), 0x00, // value
+		byte(vm.PUSH20),
+	}
+	code = append(code, callee.Bytes()...)
+	code = append(code, byte(vm.PUSH2), 0xff, 0xff, byte(vm.CALL), byte(vm.STOP))
+
+	runCode(t, tracer, code, map[common.Address][]byte{callee: {byte(vm.STOP)}})
+
+	if len(tracer.CallOps) != 1 {
+		t.Fatalf("Expected 1 call, got %d", len(tracer.CallOps))
+	}
+
+	selector := [4]byte{0xa9, 0x05, 0x9c, 0xbb}
+	if !tracer.CallOps[0].HasSelector || tracer.CallOps[0].Selector != selector {
+		t.Fatalf("Expected selector 0xa9059cbb, got %x", tracer.CallOps[0].Selector)
+	}
+
+	tracer.ResolveSignatures(selectorResolver{selector: "transfer(address,uint256)"})
+
+	if tracer.CallOps[0].Signature != "transfer(address,uint256)" {
+		t.Errorf("Expected resolved signature, got '%s'", tracer.CallOps[0].Signature)
+	}
+
+	if tracer.CallOps[0].SignatureVerified {
+		t.Error("Expected a guessed signature not to be marked verified")
+	}
+
+	tree := tracer.GetCallTree()
+	if len(tree.Children) != 1 || tree.Children[0].Signature != "transfer(address,uint256)" {
+		t.Errorf("Expected the call tree frame to carry the signature, got %+v", tree.Children)
+	}
+
+	report, _ := tracer.GetReport()
+	if !contains(report, `"signature": "transfer(address,uint256)"`) {
+		t.Error("Expected the report to include the call signature")
+	}
+}
+
+func TestCallInputOutputRecorded(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	callee := common.HexToAddress("0x00000000000000000000000000000000000000ee")
+
+	// The callee returns the 32-byte word 0x2a
+	calleeCode := []byte{
+		byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0x00, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x00, byte(vm.RETURN),
+	}
+
+	// Store 0x1234 at the end of the first word and call with 8 KiB of calldata,
+	// more than the recorded cap
+	code := []byte{
+		byte(vm.PUSH2), 0x12, 0x34, byte(vm.PUSH1), 0x00, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x00, // retLength
+		byte(vm.PUSH1), 0x00, // retOffset
+		byte(vm.PUSH2), 0x20, 0x00, // argsLength
+		byte(vm.PUSH1), 0x00, // argsOffset
+		byte(vm.PUSH1), 0x00, // value
+		byte(vm.PUSH20),
+	}
+	code = append(code, callee.Bytes()...)
+	code = append(code, byte(vm.PUSH2), 0xff, 0xff, byte(vm.CALL), byte(vm.STOP))
+
+	runCode(t, tracer, code, map[common.Address][]byte{callee: calleeCode})
+
+	if len(tracer.CallOps) != 1 {
+		t.Fatalf("Expected 1 call, got %d", len(tracer.CallOps))
+	}
+	call := tracer.CallOps[0]
+
+	if call.InputSize != 0x2000 || len(call.Input) != maxCallData {
+		t.Errorf("Expected 8192 bytes of calldata capped at %d, got %d of %d", maxCallData, len(call.Input), call.InputSize)
+	}
+	if len(call.Input) >= 32 && (call.Input[30] != 0x12 || call.Input[31] != 0x34) {
+		t.Errorf("Expected calldata bytes 0x1234 at offset 30, got %x", call.Input[:32])
+	}
+
+	if call.OutputSize != 32 || len(call.Output) != 32 || call.Output[31] != 0x2a {
+		t.Errorf("Expected the 32-byte return word 0x2a, got %x", call.Output)
+	}
+}
+
+func TestWastedGasOnRevert(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	ok := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	reverting := common.HexToAddress("0x00000000000000000000000000000000000000a2")
+
+	// Read a cold slot, then revert
+	revertCode := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.REVERT),
+	}
+
+	code := callCode(0xffff, ok)
+	code = append(code, byte(vm.POP))
+	code = append(code, callCode(0xffff, reverting)...)
+	code = append(code, byte(vm.POP), byte(vm.STOP))
+
+	runCode(t, tracer, code, map[common.Address][]byte{
+		ok:        {byte(vm.STOP)},
+		reverting: revertCode,
+	})
+
+	if len(tracer.CallOps) != 2 {
+		t.Fatalf("Expected 2 calls, got %d", len(tracer.CallOps))
+	}
+
+	if !tracer.CallOps[0].Success || tracer.CallOps[1].Success {
+		t.Errorf("Expected the first call to succeed and the second to revert, got %v and %v",
+			tracer.CallOps[0].Success, tracer.CallOps[1].Success)
+	}
+
+	if tracer.RevertedCalls != 1 {
+		t.Errorf("Expected 1 reverted call, got %d", tracer.RevertedCalls)
+	}
+
+	// The reverted frame paid three PUSH1, a POP and a cold SLOAD
+	expected := uint64(3*3 + 2 + 2100)
+	if tracer.RevertedGas != expected {
+		t.Errorf("Expected %d wasted gas, got %d", expected, tracer.RevertedGas)
+	}
+
+	var found *Optimization
+	for i := range tracer.Optimizations {
+		if tracer.Optimizations[i].Type == "wasted_gas_on_revert" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+
+	if found == nil {
+		t.Fatal("Expected wasted_gas_on_revert optimization")
+	}
+
+	if found.Severity != "high" || found.GasSavings != expected {
+		t.Errorf("Expected high severity with %d savings, got %s with %d", expected, found.Severity, found.GasSavings)
+	}
+}
+
+func TestWastedGasOnNestedRevert(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	tracer.CaptureStart(nil, common.Address{}, common.Address{}, false, nil, 100000, big.NewInt(0))
+
+	// An outer frame reverts after a nested frame already reverted
+	tracer.CaptureEnter(vm.CALL, common.Address{}, common.Address{}, nil, 50000, nil)
+	tracer.CaptureEnter(vm.CALL, common.Address{}, common.Address{}, nil, 20000, nil)
+	tracer.CaptureExit(nil, 300, vm.ErrExecutionReverted)
+	tracer.CaptureExit(nil, 1000, vm.ErrExecutionReverted)
+
+	if tracer.RevertedCalls != 2 {
+		t.Errorf("Expected 2 reverted calls, got %d", tracer.RevertedCalls)
+	}
+
+	if tracer.RevertedGas != 1000 {
+		t.Errorf("Expected nested reverted gas to be counted once (1000), got %d", tracer.RevertedGas)
+	}
+}
+
+func TestWriteOnlyStorage(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// Slot 5 is written twice and never read; slot 6 is written and read back
+	code := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x05, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x02, byte(vm.PUSH1), 0x05, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x06, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x06, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	if len(tracer.WriteOnlySlots) != 1 {
+		t.Fatalf("Expected 1 write-only slot, got %d", len(tracer.WriteOnlySlots))
+	}
+
+	slot := tracer.WriteOnlySlots[0]
+	if slot.Key != common.BigToHash(big.NewInt(5)) || slot.Writes != 2 {
+		t.Errorf("Expected slot 5 written twice, got %s written %d times", slot.Key.Hex(), slot.Writes)
+	}
+
+	if slot.Contract != common.BytesToAddress([]byte("contract")) {
+		t.Errorf("Expected the executing contract, got %s", slot.Contract.Hex())
+	}
+
+	var found *Optimization
+	for i := range tracer.Optimizations {
+		if tracer.Optimizations[i].Type == "write_only_storage" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+
+	if found == nil {
+		t.Fatal("Expected write_only_storage optimization")
+	}
+
+	if found.Severity != "low" {
+		t.Errorf("Expected severity 'low', got '%s'", found.Severity)
+	}
+
+	report, _ := tracer.GetReport()
+	if !contains(report, "write_only_slots") {
+		t.Error("Expected report to list write-only slots")
+	}
+}
+
+func TestAccessorsConcurrentWithTrace(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	target := common.HexToAddress("0x2000")
+
+	var code []byte
+	for i := 0; i < 50; i++ {
+		code = append(code, callCode(100, target)...)
+		code = append(code, byte(vm.POP), byte(vm.PUSH1), 0x01, byte(vm.PUSH1), byte(i), byte(vm.MSTORE))
+	}
+	code = append(code, byte(vm.STOP))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runCode(t, tracer, code, nil)
+	}()
+
+	// Read while the trace writes; the race detector flags unsynchronized access
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		tracer.GetCallOps()
+		tracer.GetMemoryOps()
+		tracer.GetExpensiveOps()
+		tracer.GetGasPerOpcode()
+		tracer.GetStats()
+	}
+
+	stats := tracer.GetStats()
+	if stats.CallOps != 50 {
+		t.Errorf("Expected 50 calls in stats, got %d", stats.CallOps)
+	}
+
+	if stats.Steps == 0 || stats.TotalGasUsed == 0 {
+		t.Errorf("Expected steps and gas in stats, got %+v", stats)
+	}
+
+	calls := tracer.GetCallOps()
+	if len(calls) != 50 {
+		t.Fatalf("Expected 50 call operations, got %d", len(calls))
+	}
+
+	// Returned data is a copy
+	calls[0].To = common.Address{}
+	tracer.GetGasPerOpcode()["CALL"] = 0
+	if tracer.CallOps[0].To != target {
+		t.Error("Expected GetCallOps to return a copy")
+	}
+	if tracer.GasPerOpcode["CALL"] == 0 {
+		t.Error("Expected GetGasPerOpcode to return a copy")
+	}
+}
+
+// cancunChainConfig returns a chain configuration with every fork through Cancun active
+func cancunChainConfig() *params.ChainConfig {
+	zero := uint64(0)
+	return &params.ChainConfig{
+		ChainID:                       big.NewInt(1),
+		HomesteadBlock:                new(big.Int),
+		EIP150Block:                   new(big.Int),
+		EIP155Block:                   new(big.Int),
+		EIP158Block:                   new(big.Int),
+		ByzantiumBlock:                new(big.Int),
+		ConstantinopleBlock:           new(big.Int),
+		PetersburgBlock:               new(big.Int),
+		IstanbulBlock:                 new(big.Int),
+		MuirGlacierBlock:              new(big.Int),
+		BerlinBlock:                   new(big.Int),
+		LondonBlock:                   new(big.Int),
+		TerminalTotalDifficulty:       new(big.Int),
+		TerminalTotalDifficultyPassed: true,
+		ShanghaiTime:                  &zero,
+		CancunTime:                    &zero,
+	}
+}
+
+// istanbulChainConfig returns a chain configuration with every fork through Istanbul active
+func istanbulChainConfig() *params.ChainConfig {
+	return &params.ChainConfig{
+		ChainID:             big.NewInt(1),
+		HomesteadBlock:      new(big.Int),
+		EIP150Block:         new(big.Int),
+		EIP155Block:         new(big.Int),
+		EIP158Block:         new(big.Int),
+		ByzantiumBlock:      new(big.Int),
+		ConstantinopleBlock: new(big.Int),
+		PetersburgBlock:     new(big.Int),
+		IstanbulBlock:       new(big.Int),
+	}
+}
+
+// memoryCopyLoop copies eight words from offset 0 to offset 0x200 one word at a time
+var memoryCopyLoop = []byte{
+	byte(vm.PUSH1), 0x00, // i
+	byte(vm.JUMPDEST),
+	byte(vm.DUP1), byte(vm.MLOAD),
+	byte(vm.DUP2), byte(vm.PUSH2), 0x02, 0x00, byte(vm.ADD),
+	byte(vm.MSTORE),
+	byte(vm.PUSH1), 0x20, byte(vm.ADD),
+	byte(vm.DUP1), byte(vm.PUSH2), 0x01, 0x00, byte(vm.GT),
+	byte(vm.PUSH1), 0x02, byte(vm.JUMPI),
+	byte(vm.STOP),
+}
+
+func TestUseMcopy(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	runCodeOnChain(t, tracer, memoryCopyLoop, nil, cancunChainConfig())
+
+	var found *Optimization
+	for i := range tracer.Optimizations {
+		if tracer.Optimizations[i].Type == "use_mcopy" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+
+	if found == nil {
+		t.Fatal("Expected use_mcopy optimization")
+	}
+
+	if found.Details["words_copied"] != uint64(8) {
+		t.Errorf("Expected 8 words copied, got %v", found.Details["words_copied"])
+	}
+
+	if found.Details["loop_range"] != "0x02-0x15" {
+		t.Errorf("Expected loop range 0x02-0x15, got %v", found.Details["loop_range"])
+	}
+
+	if found.GasSavings == 0 {
+		t.Error("Expected non-zero gas savings")
+	}
+}
+
+func TestUseMcopyPreCancun(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	runCode(t, tracer, memoryCopyLoop, nil)
+
+	if len(tracer.Loops) == 0 {
+		t.Fatal("Expected the copy loop to be detected")
+	}
+
+	for _, opt := range tracer.Optimizations {
+		if opt.Type == "use_mcopy" {
+			t.Error("Expected no use_mcopy optimization before Cancun")
+		}
+	}
+}
+
+func TestSparseMemoryAccess(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// One word is in use when the second MSTORE writes at 0x2000
+	code := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x01, byte(vm.PUSH2), 0x20, 0x00, byte(vm.MSTORE),
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	var found *Optimization
+	for i := range tracer.Optimizations {
+		if tracer.Optimizations[i].Type == "sparse_memory_access" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+	if found == nil {
+		t.Fatal("Expected sparse_memory_access optimization")
+	}
+
+	if found.Location.String() != formatPC(10) || found.Details["offset"] != uint64(0x2000) || found.Details["memory_size"] != uint64(32) {
+		t.Errorf("Expected the MSTORE at offset 0x2000 with 32 bytes in use, got %s %v", found.Location, found.Details)
+	}
+
+	// Growing to 257 words costs 257*3 + 257^2/512 = 900 gas, against 3 for the word in use
+	if found.Details["expanded_size"] != uint64(257*32) || found.Details["expansion_gas"] != uint64(897) {
+		t.Errorf("Expected 897 gas to expand to 8224 bytes, got %v", found.Details)
+	}
+
+	// Writing the second word instead would have cost 3 gas of expansion
+	if found.GasSavings != 894 {
+		t.Errorf("Expected 894 gas savings, got %d", found.GasSavings)
+	}
+
+	// The EVM charges the same expansion on top of the 3 gas MSTORE
+	if len(tracer.MemoryOps) != 2 || tracer.MemoryOps[1].Offset != 0x2000 || tracer.MemoryOps[1].Gas != 900 {
+		t.Errorf("Expected the MSTORE at offset 0x2000 to cost 900 gas, got %+v", tracer.MemoryOps)
+	}
+}
+
+func TestSparseMemoryAccessContiguous(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	runCode(t, tracer, memoryCopyLoop, nil)
+
+	if hasOptimization(tracer, "sparse_memory_access") {
+		t.Error("Did not expect sparse_memory_access for accesses near the memory in use")
+	}
+}
+
+// stackThrashingLoop runs eight DUP1/SWAP1 instructions on each of three loop iterations
+var stackThrashingLoop = []byte{
+	byte(vm.PUSH1), 0x03, // i
+	byte(vm.JUMPDEST),
+	byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1),
+	byte(vm.SWAP1), byte(vm.SWAP1), byte(vm.SWAP1), byte(vm.SWAP1),
+	byte(vm.POP), byte(vm.POP), byte(vm.POP), byte(vm.POP),
+	byte(vm.PUSH1), 0x01, byte(vm.SWAP1), byte(vm.SUB),
+	byte(vm.DUP1), byte(vm.PUSH1), 0x02, byte(vm.JUMPI),
+	byte(vm.STOP),
+}
+
+func TestStackThrashing(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	runCode(t, tracer, stackThrashingLoop, nil)
+
+	var found *Optimization
+	for i := range tracer.Optimizations {
+		if tracer.Optimizations[i].Type == "stack_thrashing" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+	if found == nil {
+		t.Fatal("Expected stack_thrashing optimization")
+	}
+
+	if found.Details["pc_range"] != formatPC(3)+"-"+formatPC(10) || found.Details["opcodes"] != 8 {
+		t.Errorf("Expected a run of 8 opcodes over 0x3-0xa, got %v", found.Details)
+	}
+
+	if found.Details["executions"] != 3 || found.Details["gas_spent"] != uint64(72) || found.Details["in_loop"] != true {
+		t.Errorf("Expected 3 executions in a loop costing 72 gas, got %v", found.Details)
+	}
+
+	if found.Severity != "low" || found.GasSavings != 0 {
+		t.Errorf("Expected an informational finding, got severity %s and %d gas savings", found.Severity, found.GasSavings)
+	}
+}
+
+func TestStackThrashingSingleRun(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// A long run executed once is not a hotspot
+	code := []byte{byte(vm.PUSH1), 0x01}
+	for i := 0; i < 8; i++ {
+		code = append(code, byte(vm.DUP1))
+	}
+	runCode(t, tracer, append(code, byte(vm.STOP)), nil)
+
+	if hasOptimization(tracer, "stack_thrashing") {
+		t.Error("Did not expect stack_thrashing for a run executed once")
+	}
+}
+
+func TestRedundantZeroInit(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// Zero written to fresh memory at 0x40, then a byte of zero past it
+	code := []byte{
+		byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x40, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x70, byte(vm.MSTORE8),
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	var found []Optimization
+	for _, opt := range tracer.Optimizations {
+		if opt.Type == "redundant_zero_init" {
+			found = append(found, opt)
+		}
+	}
+	if len(found) != 2 {
+		t.Fatalf("Expected 2 redundant_zero_init optimizations, got %d", len(found))
+	}
+
+	if found[0].Location.String() != formatPC(4) || found[0].Details["opcode"] != "MSTORE" || found[0].GasSavings != 3 {
+		t.Errorf("Expected the MSTORE at 0x4 saving 3 gas, got %s %v (%d gas)", found[0].Location, found[0].Details, found[0].GasSavings)
+	}
+	if found[1].Details["opcode"] != "MSTORE8" {
+		t.Errorf("Expected the MSTORE8 to be flagged, got %v", found[1].Details)
+	}
+}
+
+func TestRedundantZeroInitOverwrite(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// A nonzero write, then zero over the nonzero word, then a nonzero write to fresh memory
+	code := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x02, byte(vm.PUSH1), 0x40, byte(vm.MSTORE),
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	if hasOptimization(tracer, "redundant_zero_init") {
+		t.Error("Did not expect redundant_zero_init when zeroing written memory or writing nonzero values")
+	}
+}
+
+func TestSelfCallOverhead(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	self := common.BytesToAddress([]byte("contract"))
+
+	// Call the executing contract, which stops at once when called by itself
+	code := []byte{
+		byte(vm.CALLER), byte(vm.ADDRESS), byte(vm.EQ), byte(vm.PUSH1), 42, byte(vm.JUMPI),
+	}
+	code = append(code, callCode(0x1000, self)...)
+	code = append(code, byte(vm.STOP), byte(vm.JUMPDEST), byte(vm.STOP))
+	runCode(t, tracer, code, nil)
+
+	var found []Optimization
+	for _, opt := range tracer.Optimizations {
+		if opt.Type == "self_call_overhead" {
+			found = append(found, opt)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("Expected 1 self_call_overhead optimization, got %d", len(found))
+	}
+	if found[0].Location.String() != formatPC(40) || found[0].Details["call_type"] != "CALL" || found[0].Details["to"] != self.Hex() {
+		t.Errorf("Expected the CALL at 0x28 to %s, got %s %v", self.Hex(), found[0].Location, found[0].Details)
+	}
+	if found[0].Details["calls"] != 1 {
+		t.Errorf("Expected 1 call, got %v", found[0].Details["calls"])
+	}
+}
+
+func TestSelfCallOverheadIgnoresDelegateCall(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	self := common.BytesToAddress([]byte("contract"))
+	other := common.HexToAddress("0x5555555555555555555555555555555555555555")
+
+	// DELEGATECALL to the executing contract with one byte of calldata, which
+	// stops at once when called with calldata, then a CALL to another contract
+	code := []byte{
+		byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0x00, byte(vm.JUMPI),
+		byte(vm.PUSH1), 0x00, // retLength
+		byte(vm.PUSH1), 0x00, // retOffset
+		byte(vm.PUSH1), 0x01, // argsLength
+		byte(vm.PUSH1), 0x00, // argsOffset
+		byte(vm.PUSH20),
+	}
+	code = append(code, self.Bytes()...)
+	code = append(code, byte(vm.PUSH2), 0x10, 0x00, byte(vm.DELEGATECALL), byte(vm.POP))
+	code = append(code, callCode(0x1000, other)...)
+	code = append(code, byte(vm.STOP), byte(vm.JUMPDEST), byte(vm.STOP))
+	code[2] = byte(len(code) - 2)
+	runCode(t, tracer, code, map[common.Address][]byte{other: {byte(vm.STOP)}})
+
+	if len(tracer.CallOps) < 2 {
+		t.Fatalf("Expected the DELEGATECALL and CALL to run, got %d calls", len(tracer.CallOps))
+	}
+	if hasOptimization(tracer, "self_call_overhead") {
+		t.Error("Did not expect self_call_overhead for a DELEGATECALL to self or a call to another contract")
+	}
+}
+
+func TestInefficientCalldataCopyRepeated(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// Copy calldata bytes 0-63 and 32-95, overlapping in 32-63, reading
+	// back every copied word
+	code := []byte{
+		byte(vm.PUSH1), 0x40, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.CALLDATACOPY),
+		byte(vm.PUSH1), 0x00, byte(vm.MLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x20, byte(vm.MLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x40, byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x40, byte(vm.CALLDATACOPY),
+		byte(vm.PUSH1), 0x40, byte(vm.MLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x60, byte(vm.MLOAD), byte(vm.POP),
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	var found []Optimization
+	for _, opt := range tracer.Optimizations {
+		if opt.Type == "inefficient_calldatacopy" {
+			found = append(found, opt)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("Expected 1 inefficient_calldatacopy optimization, got %d", len(found))
+	}
+	opt := found[0]
+	if opt.Location.String() != formatPC(21) || opt.Details["pattern"] != "repeated_copy" {
+		t.Errorf("Expected a repeated_copy at 0x15, got %s %v", opt.Location, opt.Details["pattern"])
+	}
+	if opt.Details["dest_offset"] != uint64(0x40) || opt.Details["offset"] != uint64(0x20) || opt.Details["size"] != uint64(0x40) {
+		t.Errorf("Expected the copy operands 0x40, 0x20, 0x40, got %v", opt.Details)
+	}
+	if opt.Details["wasted_bytes"] != uint64(32) || opt.GasSavings != params.CopyGas {
+		t.Errorf("Expected 32 bytes copied again saving %d gas, got %v and %d", params.CopyGas, opt.Details["wasted_bytes"], opt.GasSavings)
+	}
+}
+
+func TestInefficientCalldataCopyUnread(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// Copy 128 bytes of calldata, then read only the first word back
+	code := []byte{
+		byte(vm.PUSH1), 0x80, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.CALLDATACOPY),
+		byte(vm.PUSH1), 0x00, byte(vm.MLOAD), byte(vm.POP),
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	var found []Optimization
+	for _, opt := range tracer.Optimizations {
+		if opt.Type == "inefficient_calldatacopy" {
+			found = append(found, opt)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("Expected 1 inefficient_calldatacopy optimization, got %d", len(found))
+	}
+	if found[0].Details["pattern"] != "unread_copy" || found[0].Details["wasted_bytes"] != uint64(96) {
+		t.Errorf("Expected an unread_copy of 96 bytes, got %v", found[0].Details)
+	}
+	if found[0].GasSavings != 3*params.CopyGas {
+		t.Errorf("Expected %d gas savings, got %d", 3*params.CopyGas, found[0].GasSavings)
+	}
+}
+
+func TestInefficientCalldataCopyBounded(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// Copy the same 128 bytes of calldata 100 times in a loop without reading them
+	code := []byte{
+		byte(vm.PUSH1), 0x64,
+		byte(vm.JUMPDEST),
+		byte(vm.PUSH1), 0x80, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.CALLDATACOPY),
+		byte(vm.PUSH1), 0x01, byte(vm.SWAP1), byte(vm.SUB),
+		byte(vm.DUP1), byte(vm.PUSH1), 0x02, byte(vm.JUMPI),
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	details := make(map[string]map[string]interface{})
+	for _, opt := range tracer.Optimizations {
+		if opt.Type == "inefficient_calldatacopy" {
+			details[opt.Details["pattern"].(string)] = opt.Details
+		}
+	}
+	if repeated := details["repeated_copy"]; repeated == nil || repeated["copies"] != 100 || repeated["flagged"] != 99 {
+		t.Errorf("Expected 99 of 100 copies repeated, got %v", repeated)
+	}
+	// Only the copies tracked per frame can be found unread
+	if unread := details["unread_copy"]; unread == nil || unread["flagged"] != maxFrameCalldataCopies {
+		t.Errorf("Expected %d unread copies, got %v", maxFrameCalldataCopies, unread)
+	}
+	if len(tracer.calldataCopies) != 0 {
+		t.Errorf("Expected no frames still tracked, got %d", len(tracer.calldataCopies))
+	}
+}
+
+func TestInefficientCalldataCopyCallee(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// The callee copies 128 bytes of its empty calldata and reads none of them
+	callee := common.HexToAddress("0x00000000000000000000000000000000000000c1")
+	calleeCode := []byte{
+		byte(vm.PUSH1), 0x80, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.CALLDATACOPY),
+		byte(vm.STOP),
+	}
+	code := append(callCode(0xffff, callee), byte(vm.STOP))
+	runCode(t, tracer, code, map[common.Address][]byte{callee: calleeCode})
+
+	var found []Optimization
+	for _, opt := range tracer.Optimizations {
+		if opt.Type == "inefficient_calldatacopy" {
+			found = append(found, opt)
+		}
+	}
+	if len(found) != 1 || found[0].Details["pattern"] != "unread_copy" || found[0].Details["wasted_bytes"] != uint64(128) {
+		t.Fatalf("Expected an unread_copy of 128 bytes in the callee, got %v", found)
+	}
+	if found[0].Location.String() != ContractPCLocation(callee, 6).String() {
+		t.Errorf("Expected the callee's copy at 0x06, got %s", found[0].Location)
+	}
+}
+
+func TestTransientStorageCounters(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	code := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x01, byte(vm.TSTORE),
+		byte(vm.PUSH1), 0x02, byte(vm.PUSH1), 0x01, byte(vm.TSTORE),
+		byte(vm.PUSH1), 0x01, byte(vm.TLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x02, byte(vm.TLOAD), byte(vm.POP),
+		byte(vm.STOP),
+	}
+	runCodeOnChain(t, tracer, code, nil, cancunChainConfig())
+
+	slot1 := common.BigToHash(big.NewInt(1))
+	if tracer.TransientWrites[slot1] != 2 {
+		t.Errorf("Expected 2 TSTOREs to slot 1, got %d", tracer.TransientWrites[slot1])
+	}
+
+	if len(tracer.TransientReads) != 2 || tracer.TransientReads[slot1] != 1 {
+		t.Errorf("Expected TLOADs of 2 slots, got %v", tracer.TransientReads)
+	}
+
+	if len(tracer.StorageReads) != 0 || len(tracer.StorageWrites) != 0 {
+		t.Error("Expected transient operations to be counted separately from storage")
+	}
+
+	report, _ := tracer.GetReport()
+	if !contains(report, "transient_writes") {
+		t.Error("Expected report to include transient counters")
+	}
+}
+
+func TestUseTransientStorage(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// Slot 0 is locked and released like a reentrancy guard; slot 3 keeps its new value
+	code := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x03, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x02, byte(vm.PUSH1), 0x03, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.SSTORE),
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	var found []Optimization
+	for _, opt := range tracer.Optimizations {
+		if opt.Type == "use_transient_storage" {
+			found = append(found, opt)
+		}
+	}
+
+	if len(found) != 1 {
+		t.Fatalf("Expected 1 use_transient_storage optimization, got %d", len(found))
+	}
+
+	if found[0].Details["storage_key"] != (common.Hash{}).Hex() {
+		t.Errorf("Expected slot 0 to be flagged, got %v", found[0].Details["storage_key"])
+	}
+
+	// The set-and-restore pays the cold surcharge that transient storage avoids
+	if found[0].GasSavings != 2100 {
+		t.Errorf("Expected 2100 gas savings, got %d", found[0].GasSavings)
+	}
+}
+
+func TestOptimizationOrderDeterministic(t *testing.T) {
+	// Repeated reads, writes and hashes produce findings of every severity
+	code := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x00, byte(vm.KECCAK256), byte(vm.POP),
+		byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x00, byte(vm.KECCAK256), byte(vm.POP),
+		byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x02, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x02, byte(vm.PUSH1), 0x02, byte(vm.SSTORE),
+		byte(vm.STOP),
+	}
+
+	var orders [2][]string
+	var reports [2]string
+	for run := range orders {
+		tracer := NewGasOptimizationTracer()
+		runCode(t, tracer, code, nil)
+
+		for _, opt := range tracer.GetOptimizations() {
+			orders[run] = append(orders[run], opt.Severity+":"+opt.Type+"@"+opt.Location.String())
+		}
+		reports[run], _ = tracer.GetReport()
+	}
+
+	if len(orders[0]) < 3 {
+		t.Fatalf("Expected several optimizations, got %v", orders[0])
+	}
+
+	if strings.Join(orders[0], ",") != strings.Join(orders[1], ",") {
+		t.Errorf("Expected identical ordering, got %v and %v", orders[0], orders[1])
+	}
+
+	if reports[0] != reports[1] {
+		t.Error("Expected identical reports for the same trace")
+	}
+
+	for i := 1; i < len(orders[0]); i++ {
+		prev := strings.SplitN(orders[0][i-1], ":", 2)[0]
+		cur := strings.SplitN(orders[0][i], ":", 2)[0]
+		if SeverityRank(cur) > SeverityRank(prev) {
+			t.Errorf("Expected severity to be non-increasing, got %v", orders[0])
+		}
+	}
+}
+
+func TestGasModelByFork(t *testing.T) {
+	istanbul := GasModelForRules(params.Rules{IsEIP150: true, IsIstanbul: true})
+	berlin := GasModelForRules(params.Rules{IsEIP150: true, IsIstanbul: true, IsBerlin: true})
+
+	if istanbul.SloadGas == berlin.SloadGas {
+		t.Errorf("Expected warm SLOAD cost to differ between forks, both are %d", berlin.SloadGas)
+	}
+
+	if istanbul.SloadGas != 800 || berlin.SloadGas != 100 {
+		t.Errorf("Expected SLOAD costs 800 and 100, got %d and %d", istanbul.SloadGas, berlin.SloadGas)
+	}
+
+	if istanbul.Fork != "istanbul" || berlin.Fork != "berlin" {
+		t.Errorf("Expected forks istanbul and berlin, got %s and %s", istanbul.Fork, berlin.Fork)
+	}
+
+	if istanbul.ColdSloadGas != 0 {
+		t.Errorf("Expected no cold access cost before Berlin, got %d", istanbul.ColdSloadGas)
+	}
+}
+
+func TestRedundantSloadSavingsFollowFork(t *testing.T) {
+	code := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.STOP),
+	}
+
+	istanbul := istanbulChainConfig()
+	for _, tc := range []struct {
+		config  *params.ChainConfig
+		savings uint64
+	}{
+		{istanbul, 2 * 800},
+		{nil, 2 * 100},
+	} {
+		tracer := NewGasOptimizationTracer()
+		runCodeOnChain(t, tracer, code, nil, tc.config)
+
+		found := false
+		for _, opt := range tracer.Optimizations {
+			if opt.Type != "redundant_sload" {
+				continue
+			}
+			found = true
+			if opt.GasSavings != tc.savings {
+				t.Errorf("Expected %d gas savings on %s, got %d", tc.savings, tracer.gasModel.Fork, opt.GasSavings)
+			}
+		}
+
+		if !found {
+			t.Errorf("Expected redundant_sload optimization on %s", tracer.gasModel.Fork)
+		}
+	}
+}
+
+// delegateFromSlotCode assembles a DELEGATECALL to the address stored in slot
+func delegateFromSlotCode(slot common.Hash) []byte {
+	code := []byte{
+		byte(vm.PUSH1), 0x00, // retLength
+		byte(vm.PUSH1), 0x00, // retOffset
+		byte(vm.PUSH1), 0x00, // argsLength
+		byte(vm.PUSH1), 0x00, // argsOffset
+		byte(vm.PUSH32),
+	}
+	code = append(code, slot.Bytes()...)
+	return append(code, byte(vm.SLOAD), byte(vm.GAS), byte(vm.DELEGATECALL), byte(vm.POP))
+}
+
+func TestProxyDelegatecall(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	implementation := common.HexToAddress("0x1234")
+
+	// Store the implementation in the EIP-1967 slot, then delegate to it twice
+	code := append([]byte{byte(vm.PUSH20)}, implementation.Bytes()...)
+	code = append(code, byte(vm.PUSH32))
+	code = append(code, eip1967ImplementationSlot.Bytes()...)
+	code = append(code, byte(vm.SSTORE))
+	code = append(code, delegateFromSlotCode(eip1967ImplementationSlot)...)
+	code = append(code, delegateFromSlotCode(eip1967ImplementationSlot)...)
+
+	// A DELEGATECALL to an unrelated constant target is not a proxy
+	library := common.HexToAddress("0x5678")
+	code = append(code, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH20))
+	code = append(code, library.Bytes()...)
+	code = append(code, byte(vm.GAS), byte(vm.DELEGATECALL), byte(vm.POP), byte(vm.STOP))
+
+	runCode(t, tracer, code, map[common.Address][]byte{
+		implementation: {byte(vm.STOP)},
+		library:        {byte(vm.STOP)},
+	})
+
+	var found []Optimization
+	for _, opt := range tracer.Optimizations {
+		if opt.Type == "proxy_delegatecall" {
+			found = append(found, opt)
+		}
+	}
+
+	if len(found) != 1 {
+		t.Fatalf("Expected 1 proxy_delegatecall optimization, got %d", len(found))
+	}
+
+	if found[0].Details["implementation"] != implementation.Hex() {
+		t.Errorf("Expected implementation %s, got %v", implementation.Hex(), found[0].Details["implementation"])
+	}
+
+	if found[0].Details["eip1967"] != true {
+		t.Error("Expected the EIP-1967 implementation slot to be recognized")
+	}
+
+	if found[0].Details["delegatecall_count"] != 2 {
+		t.Errorf("Expected 2 delegatecalls, got %v", found[0].Details["delegatecall_count"])
+	}
+
+	if len(tracer.ProxyImplementations) != 1 || tracer.ProxyImplementations[0].Implementation != implementation {
+		t.Errorf("Expected the implementation to be reported, got %v", tracer.ProxyImplementations)
+	}
+
+	report, _ := tracer.GetReport()
+	if !contains(report, "proxy_implementations") {
+		t.Error("Expected report to list proxy implementations")
+	}
+}
+
+func TestBaselineScheduleWhatIf(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	tracer.SetBaselineSchedule(GasSchedule{"SLOAD": 400})
+
+	// Istanbul charges a flat 800 gas per SLOAD, so the baseline halves it
+	code := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x02, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x03, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.STOP),
+	}
+	runCodeOnChain(t, tracer, code, nil, istanbulChainConfig())
+
+	whatIf, ok := tracer.BaselineWhatIf()
+	if !ok {
+		t.Fatal("Expected a what-if comparison")
+	}
+
+	if len(whatIf.Opcodes) != 1 || whatIf.Opcodes[0].CurrentGas != 2400 || whatIf.Opcodes[0].BaselineGas != 1200 {
+		t.Fatalf("Expected SLOAD projected from 2400 to 1200 gas, got %+v", whatIf.Opcodes)
+	}
+
+	if whatIf.BaselineTotal != tracer.TotalGasUsed-1200 {
+		t.Errorf("Expected projected total %d, got %d", tracer.TotalGasUsed-1200, whatIf.BaselineTotal)
+	}
+
+	report, _ := tracer.GetReport()
+	if !contains(report, "baseline_total") {
+		t.Error("Expected report to include the what-if section")
+	}
+}
+
+func TestLoadGasSchedule(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := dir + "/valid.json"
+	os.WriteFile(valid, []byte(`{"SLOAD": 50, "SSTORE": 1000}`), 0o644)
+	schedule, err := LoadGasSchedule(valid)
+	if err != nil {
+		t.Fatalf("Expected schedule to load, got %v", err)
+	}
+	if schedule["SLOAD"] != 50 || schedule["SSTORE"] != 1000 {
+		t.Errorf("Expected SLOAD 50 and SSTORE 1000, got %v", schedule)
+	}
+
+	unknown := dir + "/unknown.json"
+	os.WriteFile(unknown, []byte(`{"SLOADX": 50}`), 0o644)
+	if _, err := LoadGasSchedule(unknown); err == nil {
+		t.Error("Expected an error for an unknown opcode")
+	}
+}
+
+func TestGasFieldsAcrossCallbackOrder(t *testing.T) {
+	from := common.HexToAddress("0x1")
+	to := common.HexToAddress("0x2")
+
+	// geth reports the transaction limit before the top frame starts
+	tracer := NewGasOptimizationTracer()
+	tracer.CaptureTxStart(100000)
+	tracer.CaptureStart(nil, from, to, false, nil, 79000, big.NewInt(0))
+
+	if tracer.GasLimit != 100000 {
+		t.Errorf("Expected gas limit 100000, got %d", tracer.GasLimit)
+	}
+
+	if tracer.Gas != 79000 {
+		t.Errorf("Expected frame gas 79000, got %d", tracer.Gas)
+	}
+
+	// The fields are independent, so the opposite order gives the same result
+	tracer = NewGasOptimizationTracer()
+	tracer.CaptureStart(nil, from, to, false, nil, 79000, big.NewInt(0))
+	tracer.CaptureTxStart(100000)
+
+	if tracer.GasLimit != 100000 || tracer.Gas != 79000 {
+		t.Errorf("Expected gas limit 100000 and frame gas 79000, got %d and %d", tracer.GasLimit, tracer.Gas)
+	}
+
+	tracer.CaptureEnter(vm.CALL, to, from, nil, 5000, big.NewInt(0))
+	if tracer.Gas != 5000 {
+		t.Errorf("Expected the entered frame's gas 5000, got %d", tracer.Gas)
+	}
+}
+
+func TestGasForwardingAllAvailable(t *testing.T) {
+	callee := common.HexToAddress("0xca11ee")
+	accounts := map[common.Address][]byte{callee: {byte(vm.STOP)}}
+
+	// GAS immediately before CALL requests everything the frame has left
+	code := []byte{
+		byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00,
+		byte(vm.PUSH1), 0x00, byte(vm.PUSH20),
+	}
+	code = append(code, callee.Bytes()...)
+	code = append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.STOP))
+
+	tracer := NewGasOptimizationTracer()
+	runCode(t, tracer, code, accounts)
+
+	if !hasOptimization(tracer, "gas_forwarding") {
+		t.Error("Expected gas_forwarding when all remaining gas is forwarded")
+	}
+
+	// A fixed amount well below the cap is not flagged
+	tracer = NewGasOptimizationTracer()
+	runCode(t, tracer, append(callCode(30000, callee), byte(vm.STOP)), accounts)
+
+	if hasOptimization(tracer, "gas_forwarding") {
+		t.Error("Expected no gas_forwarding for a fixed gas amount")
+	}
+}
+
+// hasOptimization reports whether the tracer identified an optimization of the given type
+func hasOptimization(tracer *GasOptimizationTracer, typ string) bool {
+	for _, opt := range tracer.Optimizations {
+		if opt.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// lockingResolver names every address, reading the tracer on each lookup like a
+// progress display would, and cancels its context after the given lookups
+type lockingResolver struct {
+	tracer  *GasOptimizationTracer
+	cancel  context.CancelFunc
+	limit   int
+	lookups int
+}
+
+func (r *lockingResolver) ReverseResolve(ctx context.Context, addr common.Address) (string, bool) {
+	r.tracer.GetCallTree()
+	r.lookups++
+	if r.lookups == r.limit {
+		r.cancel()
+	}
+	return "name.eth", true
+}
+
+func TestResolveNames(t *testing.T) {
+	callee := common.HexToAddress("0x00000000000000000000000000000000000000c1")
+	code := append(callCode(0xffff, callee), byte(vm.STOP))
+
+	tracer := NewGasOptimizationTracer()
+	runCode(t, tracer, code, map[common.Address][]byte{callee: {byte(vm.STOP)}})
+
+	// Lookups run without the tracer lock held
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resolver := &lockingResolver{tracer: tracer, cancel: cancel}
+	tracer.ResolveNames(ctx, resolver)
+
+	root := tracer.GetCallTree()
+	if root.ToName != "name.eth" || len(root.Children) != 1 || root.Children[0].ToName != "name.eth" {
+		t.Errorf("Expected every frame named, got %+v", root)
+	}
+	// The sender, the contract and the callee are each looked up once
+	if resolver.lookups != 3 {
+		t.Errorf("Expected 3 lookups, got %d", resolver.lookups)
+	}
+
+	// Lookups stop once the context is done
+	tracer = NewGasOptimizationTracer()
+	runCode(t, tracer, code, map[common.Address][]byte{callee: {byte(vm.STOP)}})
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	resolver = &lockingResolver{tracer: tracer, cancel: cancel, limit: 1}
+	tracer.ResolveNames(ctx, resolver)
+	if resolver.lookups != 1 {
+		t.Errorf("Expected lookups to stop after cancellation, got %d", resolver.lookups)
+	}
+}
+
+func TestCallTree(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	caller := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	outer := common.HexToAddress("0x00000000000000000000000000000000000000b1")
+	inner := common.HexToAddress("0x00000000000000000000000000000000000000b2")
+	nested := common.HexToAddress("0x00000000000000000000000000000000000000b3")
+	sibling := common.HexToAddress("0x00000000000000000000000000000000000000b4")
+
+	tracer.CaptureStart(nil, caller, outer, false, []byte{0x01}, 100000, big.NewInt(5))
+	tracer.CaptureEnter(vm.CALL, outer, inner, []byte{0x02}, 50000, big.NewInt(1))
+	tracer.CaptureEnter(vm.DELEGATECALL, inner, nested, nil, 20000, nil)
+	tracer.CaptureExit([]byte{0xee}, 300, vm.ErrExecutionReverted)
+	tracer.CaptureExit([]byte{0xaa}, 1000, nil)
+	tracer.CaptureEnter(vm.STATICCALL, outer, sibling, nil, 10000, nil)
+	tracer.CaptureExit(nil, 700, nil)
+	tracer.CaptureEnd([]byte{0xbb}, 5000, nil)
+
+	root := tracer.GetCallTree()
+	if root == nil {
+		t.Fatal("Expected a call tree")
+	}
+
+	if root.Type != "CALL" || root.From != caller || root.To != outer || root.GasUsed != 5000 || root.Value.Int64() != 5 {
+		t.Errorf("Expected the root CALL from the caller using 5000 gas, got %+v", root)
+	}
+
+	if !bytes.Equal(root.Input, []byte{0x01}) || !bytes.Equal(root.Output, []byte{0xbb}) {
+		t.Errorf("Expected root input 0x01 and output 0xbb, got %x and %x", root.Input, root.Output)
+	}
+
+	if len(root.Children) != 2 {
+		t.Fatalf("Expected 2 subcalls of the root, got %d", len(root.Children))
+	}
+
+	first, second := root.Children[0], root.Children[1]
+	if first.To != inner || first.GasUsed != 1000 || first.Value.Int64() != 1 || first.Reverted() {
+		t.Errorf("Expected a successful CALL to the inner contract, got %+v", first)
+	}
+
+	if second.Type != "STATICCALL" || second.To != sibling || len(second.Children) != 0 {
+		t.Errorf("Expected a STATICCALL leaf to the sibling, got %+v", second)
+	}
+
+	if len(first.Children) != 1 {
+		t.Fatalf("Expected 1 nested call, got %d", len(first.Children))
+	}
+
+	leaf := first.Children[0]
+	if leaf.Type != "DELEGATECALL" || leaf.Value != nil || leaf.Error != vm.ErrExecutionReverted.Error() {
+		t.Errorf("Expected a reverted DELEGATECALL without value, got %+v", leaf)
+	}
+
+	// The returned tree is a copy
+	root.Children = nil
+	if len(tracer.GetCallTree().Children) != 2 {
+		t.Error("Expected GetCallTree to return a copy")
+	}
+
+	report, _ := tracer.GetReport()
+	if !contains(report, `"call_tree"`) || !contains(report, `"error": "execution reverted"`) {
+		t.Error("Expected the report to include the call tree")
+	}
+
+	tracer.Reset()
+	if tracer.GetCallTree() != nil {
+		t.Error("Expected Reset to clear the call tree")
+	}
+}
+
+// expectedConfidence is the savings confidence of each optimization type
+var expectedConfidence = map[string]string{
+	"unreferenced_blob":          "heuristic",
+	"use_push0":                  "estimated",
+	"sparse_memory_access":       "estimated",
+	"stack_thrashing":            "heuristic",
+	"redundant_zero_init":        "estimated",
+	"self_call_overhead":         "heuristic",
+	"inefficient_calldatacopy":   "estimated",
+	"use_selfbalance":            "exact",
+	"redundant_context_read":     "heuristic",
+	"precompile_call":            "heuristic",
+	"use_unchecked":              "heuristic",
+	"execution_fault":            "heuristic",
+	"call_in_loop":               "estimated",
+	"long_revert_string":         "heuristic",
+	"redundant_mapping_access":   "estimated",
+	"unused_read_before_write":   "estimated",
+	"redundant_sload":            "exact",
+	"missing_access_list":        "exact",
+	"wasted_gas_on_revert":       "estimated",
+	"multiple_calls":             "estimated",
+	"redundant_hash":             "estimated",
+	"redundant_account_access":   "estimated",
+	"log_data_heavy":             "estimated",
+	"storage_in_loop":            "estimated",
+	"use_mcopy":                  "estimated",
+	"use_transient_storage":      "estimated",
+	"large_initcode":             "heuristic",
+	"insufficient_gas_forwarded": "heuristic",
+	"gas_forwarding":             "heuristic",
+	"memory_expansion":           "heuristic",
+	"expensive_opcode":           "heuristic",
+	"calldata_heavy":             "heuristic",
+	"multiple_transfers":         "heuristic",
+	"proxy_delegatecall":         "heuristic",
+	"safemath_overhead":          "heuristic",
+	"write_only_storage":         "heuristic",
+}
+
+func TestOptimizationConfidence(t *testing.T) {
+	callee := common.HexToAddress("0x00000000000000000000000000000000000000d1")
+	reverting := common.HexToAddress("0x00000000000000000000000000000000000000d2")
+
+	var code []byte
+	for i := 0; i < 3; i++ {
+		code = append(code, byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP))
+	}
+	for i := 0; i < 2; i++ {
+		code = append(code, byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x00, byte(vm.KECCAK256), byte(vm.POP))
+		code = append(code, byte(vm.PUSH20))
+		code = append(code, callee.Bytes()...)
+		code = append(code, byte(vm.BALANCE), byte(vm.POP))
+	}
+	// Expand memory to 64 KiB
+	code = append(code, byte(vm.PUSH1), 0x01, byte(vm.PUSH3), 0x01, 0x00, 0x00, byte(vm.MSTORE))
+	for i := 0; i < 3; i++ {
+		code = append(code, callCode(0xffff, callee)...)
+		code = append(code, byte(vm.POP))
+	}
+	code = append(code, callCode(0xffff, reverting)...)
+	code = append(code, byte(vm.POP), byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x05, byte(vm.SSTORE), byte(vm.STOP))
+
+	tracer := NewGasOptimizationTracer()
+	runCode(t, tracer, code, map[common.Address][]byte{
+		callee:    {byte(vm.STOP)},
+		reverting: {byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.PUSH1), 0x00, byte(vm.DUP1), byte(vm.REVERT)},
+	})
+
+	seen := make(map[string]bool)
+	for _, opt := range tracer.GetOptimizations() {
+		expected, ok := expectedConfidence[opt.Type]
+		if !ok {
+			t.Errorf("Missing expected confidence for %s", opt.Type)
+			continue
+		}
+		if opt.Confidence != expected {
+			t.Errorf("Expected %s to have %s confidence, got '%s'", opt.Type, expected, opt.Confidence)
+		}
+		seen[opt.Confidence] = true
+	}
+
+	for _, confidence := range []string{"exact", "estimated", "heuristic"} {
+		if !seen[confidence] {
+			t.Errorf("Expected the trace to produce a finding with %s confidence", confidence)
+		}
+	}
+}
+
+func TestSavingsTotalsExcludeHeuristic(t *testing.T) {
+	optimizations := []Optimization{
+		{Type: "redundant_sload", Location: PCLocation(0x04), GasSavings: 200, Confidence: "exact"},
+		{Type: "storage_in_loop", Location: PCLocation(0x10), GasSavings: 500, Confidence: "estimated"},
+		{Type: "memory_expansion", Location: PCLocation(0x20), GasSavings: 1000, Confidence: "heuristic"},
+	}
+
+	total, heuristic := SavingsTotals(optimizations)
+	if total != 700 || heuristic != 1000 {
+		t.Errorf("Expected 700 total and 1000 heuristic savings, got %d and %d", total, heuristic)
+	}
+
+	total, heuristic = SavingsTotals(optimizations[2:])
+	if total != 0 || heuristic != 1000 {
+		t.Errorf("Expected heuristic-only savings to stay out of the total, got %d and %d", total, heuristic)
+	}
+}
+
+func TestSavingsTotalsReconcileOverlap(t *testing.T) {
+	slot := func(key string) map[string]interface{} { return map[string]interface{}{"storage_key": key} }
+	optimizations := []Optimization{
+		// Both count the repeated reads of slot 1, so only the larger counts
+		{Type: "redundant_sload", Location: PCLocation(0x04), GasSavings: 200, Confidence: "exact", Details: slot("0x01")},
+		{Type: "storage_in_loop", Location: PCLocation(0x10), GasSavings: 500, Confidence: "estimated", Details: slot("0x01")},
+		// Another slot in the same loop adds up
+		{Type: "storage_in_loop", Location: PCLocation(0x10), GasSavings: 300, Confidence: "estimated", Details: slot("0x02")},
+		{Type: "use_push0", Location: PCLocation(0x20), GasSavings: 2, Confidence: "estimated"},
+		{Type: "redundant_hash", Location: PCLocation(0x20), GasSavings: 36, Confidence: "estimated"},
+		{Type: "calldata_heavy", Location: ScopeLocation(ScopeCalldata), GasSavings: 40, Confidence: "estimated"},
+		{Type: "log_data_heavy", Location: ScopeLocation(ScopeCalldata), GasSavings: 60, Confidence: "estimated"},
+	}
+
+	total, _ := SavingsTotals(optimizations)
+	if total != 500+300+36+40+60 {
+		t.Errorf("Expected overlapping findings to be counted once for a total of 936, got %d", total)
+	}
+
+	capped, exceeded := CapSavings(total, 800)
+	if capped != 800 || !exceeded {
+		t.Errorf("Expected the total capped at 800 gas, got %d (capped %v)", capped, exceeded)
+	}
+	if capped, exceeded := CapSavings(total, 0); capped != total || exceeded {
+		t.Errorf("Expected no cap without gas used, got %d (capped %v)", capped, exceeded)
+	}
+}
+
+func TestUnusedReadBeforeWrite(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	// Slot 1 is read, the value popped, then written; slot 2 is read and its value stored
+	code := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0x01, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x02, byte(vm.SLOAD),
+		byte(vm.PUSH1), 0x02, byte(vm.SSTORE),
+		byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	var found []Optimization
+	for _, opt := range tracer.GetOptimizations() {
+		if opt.Type == "unused_read_before_write" {
+			found = append(found, opt)
+		}
+	}
+
+	if len(found) != 1 {
+		t.Fatalf("Expected 1 unused_read_before_write optimization, got %d", len(found))
+	}
+
+	opt := found[0]
+	if opt.Location.String() != "0x02" || opt.Details["storage_key"] != common.BigToHash(big.NewInt(1)).Hex() {
+		t.Errorf("Expected the read of slot 1 at 0x02, got %s for %v", opt.Location, opt.Details["storage_key"])
+	}
+
+	if opt.Details["write_pc"] != "0x08" || opt.Details["read_gas"] != uint64(params.ColdSloadCostEIP2929) {
+		t.Errorf("Expected a cold read overwritten at 0x08, got %v", opt.Details)
+	}
+
+	// The cold surcharge moves to the write, so nothing is saved after Berlin
+	if opt.GasSavings != 0 || opt.Severity != "low" {
+		t.Errorf("Expected a low severity finding with no savings, got %s with %d", opt.Severity, opt.GasSavings)
+	}
+
+	if len(tracer.SlotAccessOrders) != 2 {
+		t.Fatalf("Expected 2 slots with an access order, got %d", len(tracer.SlotAccessOrders))
+	}
+
+	for i, expected := range []SlotAccessOrder{
+		{Key: common.BigToHash(big.NewInt(1)), FirstAccess: "read", Order: "RW", Reads: 1, Writes: 1},
+		{Key: common.BigToHash(big.NewInt(2)), FirstAccess: "read", Order: "RW", Reads: 1, Writes: 1},
+	} {
+		expected.Contract = common.BytesToAddress([]byte("contract"))
+		if tracer.SlotAccessOrders[i] != expected {
+			t.Errorf("Expected slot order %+v, got %+v", expected, tracer.SlotAccessOrders[i])
+		}
+	}
+
+	report, _ := tracer.GetReport()
+	if !contains(report, `"slot_access_order"`) || !contains(report, `"first_access": "read"`) {
+		t.Error("Expected the report to include the slot access order")
+	}
+}
+
+func TestUnusedReadBeforeWriteWarmRead(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	code := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0x01, byte(vm.SSTORE),
+		byte(vm.STOP),
+	}
+	contract := common.BytesToAddress([]byte("contract"))
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(contract, code)
+
+	// Slot 1 is warm from the access list, so its first read costs the warm price
+	cfg := &runtime.Config{
+		ChainConfig: params.AllEthashProtocolChanges,
+		State:       statedb,
+		GasLimit:    1000000,
+		BlockNumber: new(big.Int),
+		Difficulty:  new(big.Int),
+		BaseFee:     new(big.Int),
+		GasPrice:    new(big.Int),
+		EVMConfig:   vm.Config{Tracer: tracer},
+	}
+	evm := runtime.NewEnv(cfg)
+	rules := cfg.ChainConfig.Rules(evm.Context.BlockNumber, false, evm.Context.Time)
+	accessList := types.AccessList{{Address: contract, StorageKeys: []common.Hash{common.BigToHash(big.NewInt(1))}}}
+	statedb.Prepare(rules, cfg.Origin, cfg.Coinbase, &contract, vm.ActivePrecompiles(rules), accessList)
+	if _, _, err := evm.Call(vm.AccountRef(cfg.Origin), contract, nil, cfg.GasLimit, new(big.Int)); err != nil {
+		t.Fatalf("Call() error: %v", err)
+	}
+
+	var found []Optimization
+	for _, opt := range tracer.GetOptimizations() {
+		if opt.Type == "unused_read_before_write" {
+			found = append(found, opt)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("Expected 1 unused_read_before_write optimization, got %d", len(found))
+	}
+
+	// The write pays no cold surcharge either way, so the whole warm read is saved
+	if found[0].Details["read_gas"] != uint64(params.WarmStorageReadCostEIP2929) || found[0].GasSavings != params.WarmStorageReadCostEIP2929 {
+		t.Errorf("Expected a warm read of %d gas saved whole, got %d saved for %v", params.WarmStorageReadCostEIP2929, found[0].GasSavings, found[0].Details)
+	}
+}
+
+func TestUnusedReadBeforeWriteSavingsPreBerlin(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+
+	code := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0x01, byte(vm.SSTORE),
+		byte(vm.STOP),
+	}
+	runCodeOnChain(t, tracer, code, nil, istanbulChainConfig())
+
+	if !hasOptimization(tracer, "unused_read_before_write") {
+		t.Fatal("Expected unused_read_before_write optimization")
+	}
+
+	for _, opt := range tracer.GetOptimizations() {
+		if opt.Type == "unused_read_before_write" && opt.GasSavings != params.SloadGasEIP2200 {
+			t.Errorf("Expected the whole Istanbul SLOAD cost (%d) saved, got %d", params.SloadGasEIP2200, opt.GasSavings)
+		}
+	}
+}
+
+func TestRedundantSloadDeduplicated(t *testing.T) {
+	for _, reads := range []int{3, 5} {
+		tracer := NewGasOptimizationTracer()
+		var code []byte
+		for i := 0; i < reads; i++ {
+			code = append(code, byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP))
+		}
+		runCode(t, tracer, append(code, byte(vm.STOP)), nil)
+
+		var found []Optimization
+		for _, opt := range tracer.Optimizations {
+			if opt.Type == "redundant_sload" {
+				found = append(found, opt)
+			}
+		}
+
+		if len(found) != 1 {
+			t.Fatalf("Expected exactly 1 redundant_sload finding for %d reads, got %d", reads, len(found))
+		}
+
+		if found[0].Details["read_count"] != reads {
+			t.Errorf("Expected read_count %d, got %v", reads, found[0].Details["read_count"])
+		}
+
+		if expected := uint64(reads-1) * params.WarmStorageReadCostEIP2929; found[0].GasSavings != expected {
+			t.Errorf("Expected %d gas savings for %d reads, got %d", expected, reads, found[0].GasSavings)
+		}
+	}
+}
+
+func TestDedupeRepeatedFindings(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	// Expand memory past the memory_expansion threshold, then loop three times
+	code := []byte{
+		byte(vm.PUSH1), 0x00, byte(vm.PUSH2), 0x30, 0x00, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x03,
+		byte(vm.JUMPDEST),
+		byte(vm.PUSH1), 0x01, byte(vm.SWAP1), byte(vm.SUB),
+		byte(vm.DUP1), byte(vm.PUSH1), 0x08, byte(vm.JUMPI),
+		byte(vm.POP), byte(vm.STOP),
+	}
+	runCode(t, tracer, code, nil)
+
+	if !hasOptimization(tracer, "memory_expansion") {
+		t.Fatal("Expected memory_expansion findings")
+	}
+
+	seen := make(map[string]bool)
+	for _, opt := range tracer.Optimizations {
+		key := opt.Type + "@" + opt.Location.String()
+		if seen[key] {
+			t.Errorf("Expected one finding per type and location, got a duplicate %s", key)
+		}
+		seen[key] = true
+	}
+}
+
+func TestDedupeKeepsHighestSavings(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	tracer.Optimizations = []Optimization{
+		{Type: "storage_in_loop", Location: PCLocation(0x10), GasSavings: 100, Details: map[string]interface{}{"storage_key": "0x01"}},
+		{Type: "storage_in_loop", Location: PCLocation(0x10), GasSavings: 100, Details: map[string]interface{}{"storage_key": "0x02"}},
+		{Type: "gas_forwarding", Location: PCLocation(0x20)},
+		{Type: "storage_in_loop", Location: PCLocation(0x10), GasSavings: 300, Details: map[string]interface{}{"storage_key": "0x01"}},
+	}
+	tracer.dedupeOptimizations()
+
+	if len(tracer.Optimizations) != 3 {
+		t.Fatalf("Expected 3 findings after deduplication, got %d", len(tracer.Optimizations))
+	}
+
+	if first := tracer.Optimizations[0]; first.GasSavings != 300 || first.Details["storage_key"] != "0x01" {
+		t.Errorf("Expected the highest-savings instance in the first position, got %+v", first)
+	}
+
+	if tracer.Optimizations[1].Details["storage_key"] != "0x02" {
+		t.Errorf("Expected findings with different primary details to be kept, got %+v", tracer.Optimizations[1])
+	}
+}
+
+func TestUsePush0(t *testing.T) {
+	// Two executions of the PUSH1 0x00 at 0x03 and one of the PUSH1 0x00 at 0x0e
+	code := []byte{
+		byte(vm.PUSH1), 0x02, // counter
+		byte(vm.JUMPDEST),
+		byte(vm.PUSH1), 0x00, byte(vm.POP),
+		byte(vm.PUSH1), 0x01, byte(vm.SWAP1), byte(vm.SUB),
+		byte(vm.DUP1), byte(vm.PUSH1), 0x02, byte(vm.JUMPI),
+		byte(vm.PUSH1), 0x00, byte(vm.POP),
+		byte(vm.STOP),
+	}
+
+	tracer := NewGasOptimizationTracer()
+	runCodeOnChain(t, tracer, code, nil, cancunChainConfig())
+
+	var found *Optimization
+	for i := range tracer.Optimizations {
+		if tracer.Optimizations[i].Type == "use_push0" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+	if found == nil {
+		t.Fatal("Expected use_push0 optimization after Shanghai")
+	}
+
+	if found.Details["occurrences"] != 3 || found.Details["sites"] != 2 {
+		t.Errorf("Expected 3 occurrences at 2 sites, got %v", found.Details)
+	}
+
+	if found.GasSavings != 3 {
+		t.Errorf("Expected 3 gas savings, got %d", found.GasSavings)
+	}
+
+	// PUSH0 does not exist before Shanghai
+	tracer = NewGasOptimizationTracer()
+	runCode(t, tracer, code, nil)
+	if hasOptimization(tracer, "use_push0") {
+		t.Error("Expected no use_push0 optimization before Shanghai")
+	}
+}
+
+func TestUseSelfBalance(t *testing.T) {
+	code := []byte{
+		byte(vm.ADDRESS), byte(vm.BALANCE), byte(vm.POP),
+		byte(vm.STOP),
+	}
+
+	tracer := NewGasOptimizationTracer()
+	runCode(t, tracer, code, nil)
+
+	var found *Optimization
+	for i := range tracer.Optimizations {
+		if tracer.Optimizations[i].Type == "use_selfbalance" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+	if found == nil {
+		t.Fatal("Expected use_selfbalance optimization for BALANCE(ADDRESS)")
+	}
+
+	// The executing contract is warm: ADDRESS (2) + warm BALANCE (100) - SELFBALANCE (5)
+	if found.GasSavings != 97 || found.Location.String() != "0x01" {
+		t.Errorf("Expected 97 gas savings at 0x01, got %d at %s", found.GasSavings, found.Location)
+	}
+
+	// BALANCE of another account is not a self-balance read
+	tracer = NewGasOptimizationTracer()
+	runCode(t, tracer, []byte{byte(vm.CALLER), byte(vm.BALANCE), byte(vm.POP), byte(vm.STOP)}, nil)
+	if hasOptimization(tracer, "use_selfbalance") {
+		t.Error("Expected no use_selfbalance optimization for BALANCE(CALLER)")
+	}
+}
+
+func TestRedundantContextRead(t *testing.T) {
+	var code []byte
+	for i := 0; i < 4; i++ {
+		code = append(code, byte(vm.CALLER), byte(vm.POP))
+	}
+	code = append(code, byte(vm.TIMESTAMP), byte(vm.POP), byte(vm.TIMESTAMP), byte(vm.POP), byte(vm.STOP))
+
+	tracer := NewGasOptimizationTracer()
+	runCode(t, tracer, code, nil)
+
+	var found []Optimization
+	for _, opt := range tracer.Optimizations {
+		if opt.Type == "redundant_context_read" {
+			found = append(found, opt)
+		}
+	}
+
+	// TIMESTAMP is read only twice, below the threshold
+	if len(found) != 1 {
+		t.Fatalf("Expected 1 redundant_context_read finding, got %d", len(found))
+	}
+
+	if found[0].Details["opcode"] != "CALLER" || found[0].Details["read_count"] != 4 {
+		t.Errorf("Expected 4 reads of CALLER, got %v", found[0].Details)
+	}
+}
+
+func TestPrecompileCall(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	runCode(t, tracer, callCode(50000, common.BytesToAddress([]byte{0x01})), nil)
+
+	calls := tracer.GetCallOps()
+	if len(calls) != 1 {
+		t.Fatalf("Expected 1 call, got %d", len(calls))
+	}
+	if calls[0].Precompile != "ecrecover" {
+		t.Errorf("Expected call labeled ecrecover, got %q", calls[0].Precompile)
+	}
+
+	var found *Optimization
+	for i, opt := range tracer.Optimizations {
+		if opt.Type == "precompile_call" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+	if found == nil {
+		t.Fatal("Expected a precompile_call finding")
+	}
+	if found.Details["precompile"] != "ecrecover" || found.Details["gas_used"] != uint64(3000) {
+		t.Errorf("Expected 3000 gas used by ecrecover, got %v", found.Details)
+	}
+	if !strings.Contains(found.Description, "caching the recovered address") {
+		t.Errorf("Expected caching note, got %q", found.Description)
+	}
+
+	// A call to an ordinary contract carries no precompile label
+	tracer = NewGasOptimizationTracer()
+	runCode(t, tracer, callCode(50000, common.BytesToAddress([]byte{0x42})), nil)
+	if tracer.GetCallOps()[0].Precompile != "" || hasOptimization(tracer, "precompile_call") {
+		t.Error("Expected no precompile label for address 0x42")
+	}
+}
+
+// checkedCounterLoop assembles a loop incrementing a counter to 4, with the
+// overflow check Solidity 0.8 emits before the increment
+func checkedCounterLoop() []byte {
+	return []byte{
+		byte(vm.PUSH1), 0x00, // 0: i = 0
+		byte(vm.JUMPDEST),    // 2: loop
+		byte(vm.DUP1),        // 3
+		byte(vm.PUSH1), 0x01, // 4
+		byte(vm.NOT),         // 6: not(1), the largest i that can be incremented
+		byte(vm.SWAP1),       // 7
+		byte(vm.GT),          // 8: i > not(1)
+		byte(vm.ISZERO),      // 9
+		byte(vm.PUSH1), 0x0e, // 10
+		byte(vm.JUMPI),       // 12
+		byte(vm.INVALID),     // 13: panic
+		byte(vm.JUMPDEST),    // 14
+		byte(vm.PUSH1), 0x01, // 15
+		byte(vm.ADD),         // 17: i + 1
+		byte(vm.DUP1),        // 18
+		byte(vm.PUSH1), 0x04, // 19
+		byte(vm.GT),          // 21: 4 > i
+		byte(vm.PUSH1), 0x02, // 22
+		byte(vm.JUMPI), // 24
+		byte(vm.STOP),  // 25
+	}
+}
+
+func TestUseUnchecked(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	runCode(t, tracer, checkedCounterLoop(), nil)
+
+	var found *Optimization
+	for i, opt := range tracer.Optimizations {
+		if opt.Type == "use_unchecked" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+	if found == nil {
+		t.Fatal("Expected a use_unchecked optimization")
+	}
+
+	if found.Location.String() != "0x08" || found.Details["loop_range"] != "0x02-0x18" {
+		t.Errorf("Expected the guard at 0x08 in loop 0x02-0x18, got %s in %v", found.Location, found.Details["loop_range"])
+	}
+	if found.Details["executions"] != 4 {
+		t.Errorf("Expected 4 guard executions, got %v", found.Details["executions"])
+	}
+
+	// GT, ISZERO, PUSH1 (3 each) and JUMPI (10) per execution
+	if found.GasSavings != 4*19 {
+		t.Errorf("Expected %d gas savings, got %d", 4*19, found.GasSavings)
+	}
+
+	// The same check outside a loop runs once and is not flagged
+	tracer = NewGasOptimizationTracer()
+	runCode(t, tracer, checkedCounterLoop()[:18], nil)
+	if hasOptimization(tracer, "use_unchecked") {
+		t.Error("Expected no use_unchecked optimization outside a loop")
+	}
+}
+
+func TestOutOfGasFault(t *testing.T) {
+	target := common.BytesToAddress([]byte{0xab})
+	loop := []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0x00, byte(vm.JUMP)}
+
+	tracer := NewGasOptimizationTracer()
+	runCode(t, tracer, callCode(100, target), map[common.Address][]byte{target: loop})
+
+	faults := tracer.GetFaults()
+	if len(faults) != 1 {
+		t.Fatalf("Expected 1 fault, got %d", len(faults))
+	}
+
+	fault := faults[0]
+	if !fault.OutOfGas || fault.Depth != 2 || fault.Contract != target {
+		t.Errorf("Expected out of gas at depth 2 in %s, got %+v", target.Hex(), fault)
+	}
+	if fault.Gas >= fault.Cost {
+		t.Errorf("Expected less gas than the opcode costs, got %d gas for a %d gas %s", fault.Gas, fault.Cost, fault.Op)
+	}
+
+	// A REVERT is not a fault
+	tracer = NewGasOptimizationTracer()
+	runCode(t, tracer, []byte{byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.REVERT)}, nil)
+	if len(tracer.GetFaults()) != 0 {
+		t.Errorf("Expected no faults for a revert, got %v", tracer.GetFaults())
+	}
+}
+
+func TestCaptureFault(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	tracer.CaptureFault(0x2a, vm.SSTORE, 1000, 20000, nil, 1, vm.ErrOutOfGas)
+	tracer.CaptureFault(0x30, vm.REVERT, 500, 0, nil, 1, vm.ErrExecutionReverted)
+
+	faults := tracer.GetFaults()
+	if len(faults) != 1 {
+		t.Fatalf("Expected 1 fault, got %d", len(faults))
+	}
+
+	fault := faults[0]
+	if fault.PC != 0x2a || fault.Op != "SSTORE" || fault.Gas != 1000 || fault.Cost != 20000 || fault.Depth != 1 {
+		t.Errorf("Expected the SSTORE fault at 0x2a, got %+v", fault)
+	}
+	if !fault.OutOfGas || fault.Error != vm.ErrOutOfGas.Error() {
+		t.Errorf("Expected an out of gas fault, got %q", fault.Error)
+	}
+
+	tracer.analyzeFaults()
+	if !hasOptimization(tracer, "execution_fault") {
+		t.Fatal("Expected an execution_fault diagnostic")
+	}
+	if opt := tracer.Optimizations[0]; opt.Severity != "high" || opt.Location.String() != "0x2a" {
+		t.Errorf("Expected a high-severity diagnostic at 0x2a, got %s at %s", opt.Severity, opt.Location)
+	}
+
+	data, err := tracer.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+	if !strings.Contains(data, `"faults"`) {
+		t.Error("Expected faults in the report")
+	}
+}
+
+func TestInvalidOpcodeFault(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	runCode(t, tracer, []byte{byte(vm.PUSH1), 0x00, byte(vm.INVALID)}, nil)
+
+	faults := tracer.GetFaults()
+	if len(faults) != 1 || faults[0].Op != "INVALID" || faults[0].OutOfGas {
+		t.Fatalf("Expected an invalid opcode fault, got %v", faults)
+	}
+	if !hasOptimization(tracer, "execution_fault") {
+		t.Error("Expected an execution_fault diagnostic")
+	}
+}
+
+func TestOpcodeFocus(t *testing.T) {
+	full := NewGasOptimizationTracer()
+	runCode(t, full, storageLoopCode(10), nil)
+
+	focused := NewGasOptimizationTracer()
+	focused.SetOpcodeFocus([]vm.OpCode{vm.SLOAD}, nil)
+	runCode(t, focused, storageLoopCode(10), nil)
+
+	if focused.TotalGasUsed != full.TotalGasUsed {
+		t.Errorf("Expected total gas %d, got %d", full.TotalGasUsed, focused.TotalGasUsed)
+	}
+	if !reflect.DeepEqual(focused.GasPerOpcode, full.GasPerOpcode) {
+		t.Errorf("Expected gas per opcode %v, got %v", full.GasPerOpcode, focused.GasPerOpcode)
+	}
+
+	// Only the storage reads are analyzed
+	for _, opt := range focused.Optimizations {
+		if opt.Type != "redundant_sload" && !(opt.Type == "expensive_opcode" && opt.Details["opcode"] == "SLOAD") {
+			t.Errorf("Expected only storage-read findings, got %s %v", opt.Type, opt.Details)
+		}
+	}
+	if !hasOptimization(focused, "redundant_sload") {
+		t.Error("Expected redundant_sload with focus on SLOAD")
+	}
+	if hasOptimization(full, "storage_in_loop") == hasOptimization(focused, "storage_in_loop") {
+		t.Error("Expected storage_in_loop only without the focus")
+	}
+	if focused.StorageAccesses == 0 || len(focused.MemoryOps) != 0 {
+		t.Errorf("Expected only storage operations recorded, got %d storage and %d memory", focused.StorageAccesses, len(focused.MemoryOps))
+	}
+
+	// Ignoring SLOAD drops its findings while keeping everything else
+	ignoring := NewGasOptimizationTracer()
+	ignoring.SetOpcodeFocus(nil, []vm.OpCode{vm.SLOAD})
+	runCode(t, ignoring, storageLoopCode(10), nil)
+	if hasOptimization(ignoring, "redundant_sload") {
+		t.Error("Expected no redundant_sload when ignoring SLOAD")
+	}
+	if ignoring.TotalGasUsed != full.TotalGasUsed {
+		t.Errorf("Expected total gas %d, got %d", full.TotalGasUsed, ignoring.TotalGasUsed)
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	const maxPoints = 50
+
+	tracer := NewGasOptimizationTracer()
+	tracer.SetTimeline(maxPoints)
+	runCode(t, tracer, storageLoopCode(200), nil)
+
+	steps := tracer.StepCount()
+	if steps < 10*maxPoints {
+		t.Fatalf("Expected a trace much longer than the timeline, got %d steps", steps)
+	}
+	checkTimeline(t, tracer, maxPoints)
+
+	// Subcalls only count the gas they use, not their allowance
+	callee := common.HexToAddress("0x00000000000000000000000000000000000000c1")
+	tracer = NewGasOptimizationTracer()
+	tracer.SetTimeline(maxPoints)
+	code := append(callCode(50000, callee), callCode(50000, callee)...)
+	runCode(t, tracer, append(code, byte(vm.STOP)), map[common.Address][]byte{callee: storageLoopCode(5)})
+	checkTimeline(t, tracer, maxPoints)
+
+	report, err := tracer.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+	parsed, err := ParseReport([]byte(report))
+	if err != nil {
+		t.Fatalf("ParseReport() error: %v", err)
+	}
+	if len(parsed.Timeline) != len(tracer.GetTimeline()) {
+		t.Errorf("Expected %d timeline points in the report, got %d", len(tracer.GetTimeline()), len(parsed.Timeline))
+	}
+}
+
+// checkTimeline asserts the timeline is bounded, ordered by step, monotonic in
+// gas and ends at the total gas used
+func checkTimeline(t *testing.T, tracer *GasOptimizationTracer, maxPoints int) {
+	t.Helper()
+
+	timeline := tracer.GetTimeline()
+	if len(timeline) < 2 || len(timeline) > maxPoints {
+		t.Fatalf("Expected between 2 and %d points, got %d", maxPoints, len(timeline))
+	}
+	if timeline[0].Step != 0 || timeline[0].Gas != 0 {
+		t.Errorf("Expected the timeline to start at step 0 with no gas, got %+v", timeline[0])
+	}
+	for i := 1; i < len(timeline); i++ {
+		if timeline[i].Step <= timeline[i-1].Step {
+			t.Errorf("Expected increasing steps, got %d after %d", timeline[i].Step, timeline[i-1].Step)
+		}
+		if timeline[i].Gas < timeline[i-1].Gas {
+			t.Errorf("Expected monotonic gas, got %d after %d at step %d", timeline[i].Gas, timeline[i-1].Gas, timeline[i].Step)
+		}
+	}
+	if last := timeline[len(timeline)-1]; last.Gas != tracer.TotalGasUsed || last.Step != tracer.StepCount() {
+		t.Errorf("Expected the timeline to end at step %d with %d gas, got %+v", tracer.StepCount(), tracer.TotalGasUsed, last)
+	}
+}
+
+func TestParseOpcodes(t *testing.T) {
+	ops, err := ParseOpcodes([]string{"sload", " CALL", "STOP"})
+	if err != nil {
+		t.Fatalf("ParseOpcodes() error: %v", err)
+	}
+	if !reflect.DeepEqual(ops, []vm.OpCode{vm.SLOAD, vm.CALL, vm.STOP}) {
+		t.Errorf("Expected SLOAD, CALL, STOP, got %v", ops)
+	}
+	if _, err := ParseOpcodes([]string{"SLOADX"}); err == nil {
+		t.Error("Expected an error for an unknown opcode")
+	}
+}
+
+// mappingLoadCode assembles a Solidity-style mapping read: keccak256(key . slot) then SLOAD
+func mappingLoadCode(key, slot byte) []byte {
+	return []byte{
+		byte(vm.PUSH1), key, byte(vm.PUSH1), 0x00, byte(vm.MSTORE),
+		byte(vm.PUSH1), slot, byte(vm.PUSH1), 0x20, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x40, byte(vm.PUSH1), 0x00, byte(vm.KECCAK256),
+		byte(vm.SLOAD), byte(vm.POP),
+	}
+}
+
+func TestRedundantMappingAccess(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	code := append(mappingLoadCode(0x2a, 0x05), mappingLoadCode(0x2a, 0x05)...)
+	runCode(t, tracer, append(code, byte(vm.STOP)), nil)
+
+	var found *Optimization
+	for i := range tracer.Optimizations {
+		if tracer.Optimizations[i].Type == "redundant_mapping_access" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+	if found == nil {
+		t.Fatal("Expected a redundant_mapping_access optimization")
+	}
+	if hasOptimization(tracer, "redundant_hash") {
+		t.Error("Expected the recomputed hash to be reported as a mapping access only")
+	}
+	if found.Details["mapping_slot"] != common.BigToHash(big.NewInt(5)).Hex() || found.Details["mapping_key"] != common.BigToHash(big.NewInt(0x2a)).Hex() {
+		t.Errorf("Expected mapping slot 5 and key 0x2a, got %v and %v", found.Details["mapping_slot"], found.Details["mapping_key"])
+	}
+	if found.Details["loads"] != 2 || found.Details["hash_count"] != 2 {
+		t.Errorf("Expected 2 loads and 2 hashes, got %v and %v", found.Details["loads"], found.Details["hash_count"])
+	}
+	if found.GasSavings != params.Keccak256Gas+2*params.Keccak256WordGas {
+		t.Errorf("Expected savings of one 64-byte hash, got %d", found.GasSavings)
+	}
+
+	// Different keys of the same mapping are separate entries
+	tracer = NewGasOptimizationTracer()
+	code = append(mappingLoadCode(0x2a, 0x05), mappingLoadCode(0x2b, 0x05)...)
+	runCode(t, tracer, append(code, byte(vm.STOP)), nil)
+	if hasOptimization(tracer, "redundant_mapping_access") {
+		t.Error("Expected no redundant_mapping_access for distinct keys")
+	}
+}
+
+// revertCode assembles code that reverts with data stored to memory a word at a time
+func revertCode(data []byte) []byte {
+	var code []byte
+	for offset := 0; offset < len(data); offset += 32 {
+		word := make([]byte, 32)
+		copy(word, data[offset:])
+		code = append(code, byte(vm.PUSH32))
+		code = append(code, word...)
+		code = append(code, byte(vm.PUSH1), byte(offset), byte(vm.MSTORE))
+	}
+	return append(code, byte(vm.PUSH1), byte(len(data)), byte(vm.PUSH1), 0x00, byte(vm.REVERT))
+}
+
+// errorStringData ABI-encodes message as Error(string) revert data
+func errorStringData(message string) []byte {
+	data := append([]byte{}, errorStringSelector...)
+	data = append(data, common.LeftPadBytes([]byte{0x20}, 32)...)
+	data = append(data, common.LeftPadBytes(big.NewInt(int64(len(message))).Bytes(), 32)...)
+	padded := make([]byte, (len(message)+31)/32*32)
+	copy(padded, message)
+	return append(data, padded...)
+}
+
+func TestLongRevertString(t *testing.T) {
+	message := "ERC20: transfer amount exceeds balance of the sender"
+
+	tracer := NewGasOptimizationTracer()
+	runCode(t, tracer, revertCode(errorStringData(message)), nil)
+
+	var found *Optimization
+	for i := range tracer.Optimizations {
+		if tracer.Optimizations[i].Type == "long_revert_string" {
+			found = &tracer.Optimizations[i]
+		}
+	}
+	if found == nil {
+		t.Fatal("Expected a long_revert_string optimization")
+	}
+	if found.Details["message"] != message {
+		t.Errorf("Expected message %q, got %v", message, found.Details["message"])
+	}
+	if found.Details["message_bytes"] != len(message) {
+		t.Errorf("Expected %d message bytes, got %v", len(message), found.Details["message_bytes"])
+	}
+
+	// Short messages fit a single word and are not flagged
+	tracer = NewGasOptimizationTracer()
+	runCode(t, tracer, revertCode(errorStringData("insufficient balance")), nil)
+	if hasOptimization(tracer, "long_revert_string") {
+		t.Error("Expected no long_revert_string for a short message")
+	}
+
+	// Binary revert data, such as a custom error with arguments, is not a string
+	tracer = NewGasOptimizationTracer()
+	runCode(t, tracer, revertCode(append([]byte{0xde, 0xad, 0xbe, 0xef}, bytes.Repeat([]byte{0x01, 0xff}, 32)...)), nil)
+	if hasOptimization(tracer, "long_revert_string") {
+		t.Error("Expected no long_revert_string for binary revert data")
+	}
+}
+
+func TestInternalTxs(t *testing.T) {
+	contract := common.BytesToAddress([]byte("contract"))
+	a := common.BytesToAddress([]byte{0xa1})
+	b := common.BytesToAddress([]byte{0xb1})
+	c := common.BytesToAddress([]byte{0xc1})
+	d := common.BytesToAddress([]byte{0xd1})
+
+	code := append(valueCallCode(50000, a, 5), byte(vm.POP))
+	code = append(code,
+		byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x01, // size, offset, value
+		byte(vm.CREATE), byte(vm.POP))
+	code = append(code, valueCallCode(50000, c, 3)...)
+	code = append(code, byte(vm.POP), byte(vm.STOP))
+
+	// c forwards 1 wei to d, then reverts
+	reverting := append(valueCallCode(10000, d, 1), byte(vm.POP), byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.REVERT))
+
+	tracer := NewGasOptimizationTracer()
+	runCode(t, tracer, code, map[common.Address][]byte{
+		a: append(valueCallCode(20000, b, 2), byte(vm.STOP)),
+		c: reverting,
+	})
+
+	txs := tracer.GetInternalTxs()
+	if len(txs) != 5 {
+		t.Fatalf("Expected 5 internal transactions, got %d: %+v", len(txs), txs)
+	}
+
+	expected := []struct {
+		typ      string
+		from, to common.Address
+		value    int64
+		depth    int
+		success  bool
+	}{
+		{"CALL", contract, a, 5, 1, true},
+		{"CALL", a, b, 2, 2, true},
+		{"CREATE", contract, txs[2].To, 1, 1, true},
+		{"CALL", contract, c, 3, 1, false},
+		{"CALL", c, d, 1, 2, false},
+	}
+	for i, want := range expected {
+		got := txs[i]
+		if got.Type != want.typ || got.From != want.from || got.To != want.to || got.Value.Int64() != want.value || got.Depth != want.depth || got.Success != want.success {
+			t.Errorf("Expected internal transaction %d to be %+v, got %+v", i, want, got)
+		}
+	}
+
+	// Creations carry the deployed address; calls do not
+	if txs[2].ContractAddress == (common.Address{}) || txs[2].ContractAddress != txs[2].To {
+		t.Errorf("Expected the CREATE to carry its contract address, got %s", txs[2].ContractAddress.Hex())
+	}
+	if txs[0].ContractAddress != (common.Address{}) {
+		t.Errorf("Expected no contract address for a CALL, got %s", txs[0].ContractAddress.Hex())
+	}
+
+	// The report lists internal transactions only when enabled
+	data, _ := tracer.GetReport()
+	if strings.Contains(data, "internal_transactions") {
+		t.Error("Expected no internal transactions in the report by default")
+	}
+	tracer.SetInternalTxs(true)
+	data, _ = tracer.GetReport()
+	report, err := ParseReport([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseReport() error: %v", err)
+	}
+	if len(report.InternalTxs) != 5 || report.InternalTxs[2].ContractAddress == "" {
+		t.Errorf("Expected 5 internal transactions in the report, got %+v", report.InternalTxs)
+	}
+}
+
+// storageLoopCode assembles a loop of the given iterations that loads, increments
+// and stores a storage slot and writes the result to memory
+func storageLoopCode(iterations byte) []byte {
+	return []byte{
+		byte(vm.PUSH1), iterations, // 0: remaining iterations
+		byte(vm.JUMPDEST),    // 2: loop
+		byte(vm.PUSH1), 0x00, // 3
+		byte(vm.SLOAD),       // 5
+		byte(vm.PUSH1), 0x01, // 6
+		byte(vm.ADD),         // 8
+		byte(vm.DUP1),        // 9
+		byte(vm.PUSH1), 0x00, // 10
+		byte(vm.MSTORE),      // 12
+		byte(vm.PUSH1), 0x00, // 13
+		byte(vm.SSTORE),      // 15
+		byte(vm.PUSH1), 0x01, // 16
+		byte(vm.SWAP1),       // 18
+		byte(vm.SUB),         // 19
+		byte(vm.DUP1),        // 20
+		byte(vm.PUSH1), 0x02, // 21
+		byte(vm.JUMPI), // 23
+		byte(vm.STOP),  // 24
+	}
+}
+
+func TestMinimalMode(t *testing.T) {
+	full := NewGasOptimizationTracer()
+	runCode(t, full, storageLoopCode(20), nil)
+
+	minimal := NewGasOptimizationTracer()
+	minimal.SetMinimal(true)
+	runCode(t, minimal, storageLoopCode(20), nil)
+
+	if minimal.TotalGasUsed != full.TotalGasUsed {
+		t.Errorf("Expected total gas %d, got %d", full.TotalGasUsed, minimal.TotalGasUsed)
+	}
+	if !reflect.DeepEqual(minimal.GasPerOpcode, full.GasPerOpcode) {
+		t.Errorf("Expected gas per opcode %v, got %v", full.GasPerOpcode, minimal.GasPerOpcode)
+	}
+
+	// Nothing beyond the gas profile is collected
+	if len(minimal.Optimizations) != 0 || minimal.StorageAccesses != 0 || len(minimal.Loops) != 0 || minimal.CallTree != nil {
+		t.Errorf("Expected only the gas profile in minimal mode, got %d optimizations, %d storage ops, %d loops",
+			len(minimal.Optimizations), minimal.StorageAccesses, len(minimal.Loops))
+	}
+	if minimal.StepCount() != full.StepCount() {
+		t.Errorf("Expected %d steps, got %d", full.StepCount(), minimal.StepCount())
+	}
+}
+
+func TestSampledMinimalMode(t *testing.T) {
+	// Each of 100 iterations hashes two words of memory in ten steps
+	code := []byte{
+		byte(vm.PUSH1), 100, // 0
+		byte(vm.JUMPDEST),    // 2: loop
+		byte(vm.PUSH1), 0x40, // 3
+		byte(vm.PUSH1), 0x00, // 5
+		byte(vm.KECCAK256),   // 7
+		byte(vm.POP),         // 8
+		byte(vm.PUSH1), 0x01, // 9
+		byte(vm.SWAP1),       // 11
+		byte(vm.SUB),         // 12
+		byte(vm.DUP1),        // 13
+		byte(vm.PUSH1), 0x02, // 14
+		byte(vm.JUMPI), // 16
+		byte(vm.STOP),  // 17
+	}
+
+	full := NewGasOptimizationTracer()
+	full.SetMinimal(true)
+	runCode(t, full, code, nil)
+
+	sampled := NewGasOptimizationTracer()
+	sampled.SetMinimal(true)
+	sampled.SetSampleRate(7)
+	runCode(t, sampled, code, nil)
+
+	if sampled.TotalGasUsed != full.TotalGasUsed || sampled.StepCount() != full.StepCount() {
+		t.Errorf("Expected exact gas %d and %d steps, got %d and %d", full.TotalGasUsed, full.StepCount(), sampled.TotalGasUsed, sampled.StepCount())
+	}
+
+	profile, ok := sampled.GetSampledProfile()
+	if !ok {
+		t.Fatal("Expected a sampled profile")
+	}
+	if want := (full.StepCount() + 6) / 7; profile.Samples != want {
+		t.Errorf("Expected %d samples, got %d", want, profile.Samples)
+	}
+
+	// KECCAK256 dominates the loop's gas and stays the hottest opcode and location
+	if hottest := hottestOpcode(sampled.GasPerOpcode); hottest != "KECCAK256" {
+		t.Errorf("Expected KECCAK256 to be the hottest sampled opcode, got %s in %v", hottest, sampled.GasPerOpcode)
+	}
+	if len(profile.HotPCs) == 0 || profile.HotPCs[0].Op != "KECCAK256" || profile.HotPCs[0].PC != 7 {
+		t.Errorf("Expected the KECCAK256 at 0x7 to be the hottest location, got %+v", profile.HotPCs)
+	}
+
+	// The estimate lands near the exact figure
+	exact, estimated := full.GasPerOpcode["KECCAK256"], sampled.GasPerOpcode["KECCAK256"]
+	if estimated < exact/2 || estimated > exact*2 {
+		t.Errorf("Expected estimated KECCAK256 gas near %d, got %d", exact, estimated)
+	}
+
+	report, _ := sampled.GetReport()
+	if !contains(report, `"sampled_profile"`) || !contains(report, `"approximate": true`) {
+		t.Error("Expected the report to be marked as sampled")
+	}
+	if _, ok := full.GetSampledProfile(); ok {
+		t.Error("Expected no sampled profile when every step is recorded")
+	}
+}
+
+// hottestOpcode returns the opcode with the most gas
+func hottestOpcode(gasPerOpcode map[string]uint64) string {
+	hottest := ""
+	for op, gas := range gasPerOpcode {
+		if hottest == "" || gas > gasPerOpcode[hottest] {
+			hottest = op
+		}
+	}
+	return hottest
+}
+
+func benchmarkTraceMode(b *testing.B, minimal bool, sampleRate uint64) {
+	code := storageLoopCode(200)
+	tracer := NewGasOptimizationTracer()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tracer.Reset()
+		tracer.SetMinimal(minimal)
+		tracer.SetSampleRate(sampleRate)
+		runCode(b, tracer, code, nil)
+	}
+}
+
+func BenchmarkTraceFull(b *testing.B) {
+	benchmarkTraceMode(b, false, 0)
+}
+
+func BenchmarkTraceMinimal(b *testing.B) {
+	benchmarkTraceMode(b, true, 0)
+}
+
+func BenchmarkTraceSampled(b *testing.B) {
+	benchmarkTraceMode(b, true, 16)
+}
+
+func TestMaxStepsTruncatesTrace(t *testing.T) {
+	loop := []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0x00, byte(vm.JUMP)}
+
+	for _, minimal := range []bool{false, true} {
+		tracer := NewGasOptimizationTracer()
+		tracer.SetMinimal(minimal)
+		tracer.SetMaxSteps(100)
+		runCode(t, tracer, loop, nil)
+
+		if !tracer.Truncated() {
+			t.Fatalf("Expected the trace to be truncated (minimal %v)", minimal)
+		}
+		if steps := tracer.StepCount(); steps != 100 {
+			t.Errorf("Expected 100 steps before the limit (minimal %v), got %d", minimal, steps)
+		}
+
+		data, err := tracer.GetReport()
+		if err != nil {
+			t.Fatalf("GetReport() error: %v", err)
+		}
+		var report map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &report); err != nil {
+			t.Fatalf("failed to decode report: %v", err)
+		}
+		if report["truncated"] != true {
+			t.Errorf("Expected truncated in the report (minimal %v), got %v", minimal, report["truncated"])
+		}
+	}
+}
+
+func TestMaxStepsNotReached(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	tracer.SetMaxSteps(100)
+	runCode(t, tracer, storageLoopCode(3), nil)
+
+	if tracer.Truncated() {
+		t.Errorf("Expected a trace within the limit not to be truncated")
+	}
+}
@@ -0,0 +1,222 @@
+package tracer
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// OpcodeCost is the gas an opcode costs on one fork
+type OpcodeCost struct {
+	Op  string `json:"opcode"`
+	Gas uint64 `json:"gas"` // Executed with zero operands, including any cold access
+
+	// RepeatGas is the cost when repeated with the same operands, set only when
+	// cheaper than Gas: a warm SLOAD after EIP-2929, or an MLOAD of memory that
+	// is already expanded
+	RepeatGas uint64 `json:"repeat_gas,omitempty"`
+}
+
+// forks lists the forks GasReference measures, oldest first
+var forks = []string{
+	"frontier", "homestead", "tangerine_whistle", "spurious_dragon", "byzantium",
+	"constantinople", "petersburg", "istanbul", "berlin", "london", "merge",
+	"shanghai", "cancun",
+}
+
+// ForkNames lists the forks GasReference accepts, oldest first
+func ForkNames() []string {
+	return append([]string(nil), forks...)
+}
+
+// GasReference measures the gas cost of every opcode defined on the fork by
+// executing it in an in-memory EVM, so the costs are those of the EVM's own gas
+// schedule. Each opcode runs with zero operands: memory, copy and data costs
+// that grow with the operands are not included.
+func GasReference(fork string) ([]OpcodeCost, error) {
+	table, err := lookupInstructionSet(fork)
+	if err != nil {
+		return nil, err
+	}
+
+	var costs []OpcodeCost
+	for i := 0; i < 256; i++ {
+		op := vm.OpCode(i)
+		if op != vm.STOP && !table[op].HasCost() {
+			continue
+		}
+		cost, err := measureOpcode(fork, op, table)
+		if err != nil {
+			return nil, err
+		}
+		costs = append(costs, cost)
+	}
+	return costs, nil
+}
+
+// OpcodeGas measures the gas cost of the named opcode on the fork like GasReference
+func OpcodeGas(fork, name string) (OpcodeCost, error) {
+	name = strings.ToUpper(name)
+	op := vm.StringToOp(name)
+	if op.String() != name {
+		return OpcodeCost{}, fmt.Errorf("unknown opcode: %s", name)
+	}
+
+	table, err := lookupInstructionSet(fork)
+	if err != nil {
+		return OpcodeCost{}, err
+	}
+	if op != vm.STOP && !table[op].HasCost() {
+		return OpcodeCost{}, fmt.Errorf("%s is not available on %s", name, fork)
+	}
+	return measureOpcode(fork, op, table)
+}
+
+// lookupInstructionSet returns the EVM's jump table for the fork
+func lookupInstructionSet(fork string) (vm.JumpTable, error) {
+	config, err := ForkChainConfig(fork)
+	if err != nil {
+		return vm.JumpTable{}, err
+	}
+	return vm.LookupInstructionSet(config.Rules(common.Big0, config.TerminalTotalDifficulty != nil, 0))
+}
+
+// ForkChainConfig returns a chain configuration with every fork up to and
+// including the named one active from genesis. Forks from the merge on also
+// need a PREVRANDAO value in the block context to activate.
+func ForkChainConfig(fork string) (*params.ChainConfig, error) {
+	index := -1
+	for i, name := range forks {
+		if name == fork {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("unknown fork: %s (available: %s)", fork, strings.Join(forks, ", "))
+	}
+
+	config := &params.ChainConfig{ChainID: big.NewInt(1)}
+	active := func(name string) bool {
+		for i := 0; i <= index; i++ {
+			if forks[i] == name {
+				return true
+			}
+		}
+		return false
+	}
+	block := func(name string) *big.Int {
+		if active(name) {
+			return new(big.Int)
+		}
+		return nil
+	}
+	time := func(name string) *uint64 {
+		if active(name) {
+			return new(uint64)
+		}
+		return nil
+	}
+
+	config.HomesteadBlock = block("homestead")
+	config.EIP150Block = block("tangerine_whistle")
+	config.EIP155Block = block("spurious_dragon")
+	config.EIP158Block = block("spurious_dragon")
+	config.ByzantiumBlock = block("byzantium")
+	config.ConstantinopleBlock = block("constantinople")
+	config.PetersburgBlock = block("petersburg")
+	config.IstanbulBlock = block("istanbul")
+	config.BerlinBlock = block("berlin")
+	config.LondonBlock = block("london")
+	if active("merge") {
+		config.TerminalTotalDifficulty = new(big.Int)
+		config.TerminalTotalDifficultyPassed = true
+	}
+	config.ShanghaiTime = time("shanghai")
+	config.CancunTime = time("cancun")
+	return config, nil
+}
+
+// measureOpcode executes op twice with zero operands and returns the cost of
+// both executions. Opcodes that halt or jump away run once.
+func measureOpcode(fork string, op vm.OpCode, table vm.JumpTable) (OpcodeCost, error) {
+	config, err := ForkChainConfig(fork)
+	if err != nil {
+		return OpcodeCost{}, err
+	}
+
+	pops, maxStack := table[op].Stack()
+	pushes := int(params.StackLimit) + pops - maxStack
+
+	var code []byte
+	for i := 0; i < 2; i++ {
+		for j := 0; j < pops; j++ {
+			code = append(code, byte(vm.PUSH1), 0x00)
+		}
+		code = append(code, byte(op))
+		if op.IsPush() {
+			code = append(code, make([]byte, int(op-vm.PUSH0))...)
+		}
+		for j := 0; j < pushes; j++ {
+			code = append(code, byte(vm.POP))
+		}
+	}
+	code = append(code, byte(vm.STOP))
+
+	// The zero address must stay cold, so the origin and the coinbase, both
+	// warmed by the transaction, are moved off it
+	recorder := &costRecorder{op: op}
+	cfg := &runtime.Config{
+		ChainConfig: config,
+		Origin:      common.BytesToAddress([]byte("origin")),
+		Coinbase:    common.BytesToAddress([]byte("coinbase")),
+		GasLimit:    10_000_000,
+		EVMConfig:   vm.Config{Tracer: recorder},
+	}
+	if config.TerminalTotalDifficulty != nil {
+		cfg.Random = &common.Hash{}
+	}
+	runtime.Execute(code, nil, cfg)
+
+	if len(recorder.costs) == 0 {
+		return OpcodeCost{}, fmt.Errorf("failed to execute %s on %s", op, fork)
+	}
+	cost := OpcodeCost{Op: op.String(), Gas: recorder.costs[0]}
+	if len(recorder.costs) > 1 && recorder.costs[1] < cost.Gas {
+		cost.RepeatGas = recorder.costs[1]
+	}
+	return cost, nil
+}
+
+// costRecorder records the cost of each execution of one opcode in the outermost frame
+type costRecorder struct {
+	op    vm.OpCode
+	costs []uint64
+}
+
+func (r *costRecorder) CaptureTxStart(gasLimit uint64) {}
+
+func (r *costRecorder) CaptureTxEnd(restGas uint64) {}
+
+func (r *costRecorder) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+}
+
+func (r *costRecorder) CaptureEnd(output []byte, gasUsed uint64, err error) {}
+
+func (r *costRecorder) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+}
+
+func (r *costRecorder) CaptureExit(output []byte, gasUsed uint64, err error) {}
+
+func (r *costRecorder) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if op == r.op && depth == 1 {
+		r.costs = append(r.costs, cost)
+	}
+}
+
+func (r *costRecorder) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
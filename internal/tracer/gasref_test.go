@@ -0,0 +1,60 @@
+package tracer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestOpcodeGasSload(t *testing.T) {
+	cost, err := OpcodeGas(DefaultGasModel().Fork, "sload")
+	if err != nil {
+		t.Fatalf("OpcodeGas() error: %v", err)
+	}
+	if cost.Op != "SLOAD" || cost.Gas != params.ColdSloadCostEIP2929 || cost.RepeatGas != params.WarmStorageReadCostEIP2929 {
+		t.Errorf("Expected SLOAD to cost %d cold and %d warm, got %+v", params.ColdSloadCostEIP2929, params.WarmStorageReadCostEIP2929, cost)
+	}
+
+	// Before Berlin every SLOAD costs the same
+	cost, err = OpcodeGas("istanbul", "SLOAD")
+	if err != nil {
+		t.Fatalf("OpcodeGas() error: %v", err)
+	}
+	if cost.Gas != params.SloadGasEIP2200 || cost.RepeatGas != 0 {
+		t.Errorf("Expected SLOAD to cost %d on istanbul, got %+v", params.SloadGasEIP2200, cost)
+	}
+}
+
+func TestOpcodeGasErrors(t *testing.T) {
+	if _, err := OpcodeGas("london", "PUSH0"); err == nil || !strings.Contains(err.Error(), "not available on london") {
+		t.Errorf("Expected PUSH0 to be unavailable before Shanghai, got %v", err)
+	}
+	if _, err := OpcodeGas("cancun", "NOPE"); err == nil || !strings.Contains(err.Error(), "unknown opcode") {
+		t.Errorf("Expected an unknown opcode error, got %v", err)
+	}
+	if _, err := GasReference("nofork"); err == nil || !strings.Contains(err.Error(), "unknown fork") {
+		t.Errorf("Expected an unknown fork error, got %v", err)
+	}
+}
+
+func TestGasReference(t *testing.T) {
+	costs, err := GasReference("cancun")
+	if err != nil {
+		t.Fatalf("GasReference() error: %v", err)
+	}
+
+	byOp := make(map[string]OpcodeCost)
+	for _, cost := range costs {
+		byOp[cost.Op] = cost
+	}
+	if byOp["ADD"].Gas != 3 || byOp["TLOAD"].Gas != params.WarmStorageReadCostEIP2929 {
+		t.Errorf("Expected ADD to cost 3 and TLOAD 100, got %+v and %+v", byOp["ADD"], byOp["TLOAD"])
+	}
+	if call := byOp["CALL"]; call.Gas != params.ColdAccountAccessCostEIP2929 || call.RepeatGas != params.WarmStorageReadCostEIP2929 {
+		t.Errorf("Expected a cold then warm CALL, got %+v", call)
+	}
+	if _, ok := byOp["INVALID"]; ok {
+		t.Error("Did not expect undefined opcodes")
+	}
+}
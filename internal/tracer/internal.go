@@ -0,0 +1,109 @@
+package tracer
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// InternalTx is a value transfer or contract creation made by a subcall, as
+// listed by block explorers under internal transactions
+type InternalTx struct {
+	Type            string // CALL, CALLCODE, CREATE, CREATE2 or SELFDESTRUCT
+	From            common.Address
+	To              common.Address
+	Value           *big.Int       // Value transferred in wei, zero for creations without value
+	Depth           int            // Call depth of the frame, 1 for calls made by the transaction's target
+	Success         bool           // Whether the frame and all of its callers completed without reverting
+	ContractAddress common.Address // Address deployed by a successful CREATE or CREATE2, zero otherwise
+	FromName        string         // Name of the sender, when resolved
+	ToName          string         // Name of the recipient, when resolved
+}
+
+// SetInternalTxs includes the internal transactions list in the report when enabled
+func (t *GasOptimizationTracer) SetInternalTxs(enabled bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.internalTxs = enabled
+}
+
+// InternalTxsEnabled reports whether the internal transactions list is part of the report
+func (t *GasOptimizationTracer) InternalTxsEnabled() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.internalTxs
+}
+
+// GetInternalTxs returns the value-bearing subcalls and creations of the traced
+// transaction in execution order
+func (t *GasOptimizationTracer) GetInternalTxs() []InternalTx {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.internalTransactions()
+}
+
+// internalTransactions walks the call tree below the root frame for internal transactions
+func (t *GasOptimizationTracer) internalTransactions() []InternalTx {
+	var txs []InternalTx
+	if t.CallTree == nil {
+		return txs
+	}
+
+	var walk func(node *CallNode, depth int, parentOK bool)
+	walk = func(node *CallNode, depth int, parentOK bool) {
+		ok := parentOK && !node.Reverted()
+		creation := node.Type == "CREATE" || node.Type == "CREATE2"
+		if creation || (node.Value != nil && node.Value.Sign() > 0) {
+			tx := InternalTx{
+				Type:     node.Type,
+				From:     node.From,
+				To:       node.To,
+				Value:    new(big.Int),
+				Depth:    depth,
+				Success:  ok,
+				FromName: node.FromName,
+				ToName:   node.ToName,
+			}
+			if node.Value != nil {
+				tx.Value.Set(node.Value)
+			}
+			if creation && ok {
+				tx.ContractAddress = node.To
+			}
+			txs = append(txs, tx)
+		}
+		for _, child := range node.Children {
+			walk(child, depth+1, ok)
+		}
+	}
+
+	root := t.CallTree
+	for _, child := range root.Children {
+		walk(child, 1, !root.Reverted())
+	}
+	return txs
+}
+
+// internalTxsReport converts the internal transactions for the JSON report
+func (t *GasOptimizationTracer) internalTxsReport() []map[string]interface{} {
+	txs := t.internalTransactions()
+	entries := make([]map[string]interface{}, 0, len(txs))
+	for _, tx := range txs {
+		entry := map[string]interface{}{
+			"type":    tx.Type,
+			"from":    tx.From.Hex(),
+			"to":      tx.To.Hex(),
+			"value":   tx.Value.String(),
+			"depth":   tx.Depth,
+			"success": tx.Success,
+		}
+		if tx.ContractAddress != (common.Address{}) {
+			entry["contract_address"] = tx.ContractAddress.Hex()
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
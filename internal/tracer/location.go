@@ -0,0 +1,138 @@
+package tracer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Scopes of findings about a part of the transaction rather than its code
+const (
+	ScopeTransaction = "transaction" // The transaction as a whole, e.g. its access list
+	ScopeCalldata    = "calldata"    // The transaction's calldata
+	ScopeInitCode    = "initcode"    // The init code of a contract deployment
+)
+
+// Location is where a finding was observed: one instruction, instructions at
+// several program counters, or a part of the transaction outside the code
+type Location struct {
+	PC       uint64          // Program counter of the instruction, for a PC location
+	Multiple bool            // The finding covers instructions at several program counters
+	Scope    string          // Part of the transaction the finding is about, see the Scope constants
+	Contract *common.Address // Contract whose code holds the instruction, when known
+}
+
+// PCLocation returns the location of the instruction at pc
+func PCLocation(pc uint64) Location {
+	return Location{PC: pc}
+}
+
+// ContractPCLocation returns the location of the instruction at pc in the code of contract
+func ContractPCLocation(contract common.Address, pc uint64) Location {
+	return Location{PC: pc, Contract: &contract}
+}
+
+// MultipleLocation returns the location of a finding covering several instructions
+func MultipleLocation() Location {
+	return Location{Multiple: true}
+}
+
+// ScopeLocation returns the location of a finding about a part of the transaction
+func ScopeLocation(scope string) Location {
+	return Location{Scope: scope}
+}
+
+// IsPC reports whether the location is a single instruction
+func (l Location) IsPC() bool {
+	return !l.Multiple && l.Scope == ""
+}
+
+// String returns the readable form of the location: the PC in hex such as
+// "0x2a", "multiple", or the scope
+func (l Location) String() string {
+	switch {
+	case l.Multiple:
+		return "multiple"
+	case l.Scope != "":
+		return l.Scope
+	}
+	return formatPC(l.PC)
+}
+
+// ParseLocation parses the readable form of a location produced by String
+func ParseLocation(s string) (Location, error) {
+	switch s {
+	case "multiple":
+		return MultipleLocation(), nil
+	case ScopeTransaction, ScopeCalldata, ScopeInitCode:
+		return ScopeLocation(s), nil
+	}
+	if pc, ok := locationPC(s); ok {
+		return PCLocation(pc), nil
+	}
+	return Location{}, fmt.Errorf("invalid location %q", s)
+}
+
+// locationPC parses a program counter produced by formatPC
+func locationPC(location string) (uint64, bool) {
+	digits, ok := strings.CutPrefix(location, "0x")
+	if !ok {
+		return 0, false
+	}
+	if digits == "" {
+		return 0, true
+	}
+	pc, err := strconv.ParseUint(digits, 16, 64)
+	return pc, err == nil
+}
+
+// locationJSON is the JSON form of a Location
+type locationJSON struct {
+	Text     string          `json:"text"`
+	PC       *uint64         `json:"pc,omitempty"`
+	Multiple bool            `json:"multiple,omitempty"`
+	Scope    string          `json:"scope,omitempty"`
+	Contract *common.Address `json:"contract,omitempty"`
+}
+
+// MarshalJSON encodes the location as an object holding its readable form in
+// "text" alongside its fields
+func (l Location) MarshalJSON() ([]byte, error) {
+	out := locationJSON{Text: l.String(), Multiple: l.Multiple, Scope: l.Scope, Contract: l.Contract}
+	if l.IsPC() {
+		pc := l.PC
+		out.PC = &pc
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a location object, or the plain string written by
+// reports of schema version 1
+func (l *Location) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		parsed, err := ParseLocation(text)
+		if err != nil {
+			return err
+		}
+		*l = parsed
+		return nil
+	}
+
+	var in locationJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*l = Location{Multiple: in.Multiple, Scope: in.Scope, Contract: in.Contract}
+	if in.PC != nil {
+		l.PC = *in.PC
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// logHeavyPercent is the share of total gas spent on LOG operations above which logging is flagged
+const logHeavyPercent = 20
+
+// logDataHashSize is the size of a hash that could be logged in place of large event data
+const logDataHashSize = 32
+
+type LogOperation struct {
+	PC       uint64
+	Op       string
+	Topics   int
+	Offset   uint64
+	DataSize uint64
+	Gas      uint64 // Intrinsic LOG gas, excluding memory expansion
+	Depth    int
+	Contract common.Address
+}
+
+// logGas returns the intrinsic gas of a LOG operation with the given topic count and data size
+func logGas(topics int, size uint64) uint64 {
+	return params.LogGas + uint64(topics)*params.LogTopicGas + size*params.LogDataGas
+}
+
+// recordLog captures the operands of a LOG0-LOG4 operation and its precise gas
+func (t *GasOptimizationTracer) recordLog(pc uint64, op vm.OpCode, depth int, scope *vm.ScopeContext) {
+	offset := scope.Stack.Back(0)
+	size := scope.Stack.Back(1)
+	if !offset.IsUint64() || !size.IsUint64() {
+		return
+	}
+
+	topics := int(op - vm.LOG0)
+	logOp := LogOperation{
+		PC:       pc,
+		Op:       op.String(),
+		Topics:   topics,
+		Offset:   offset.Uint64(),
+		DataSize: size.Uint64(),
+		Gas:      logGas(topics, size.Uint64()),
+		Depth:    depth,
+		Contract: contractAddress(scope),
+	}
+	t.LogOps = append(t.LogOps, logOp)
+	t.LogGas += logOp.Gas
+}
+
+// analyzeLogs flags transactions where event logging is a significant share of total gas
+func (t *GasOptimizationTracer) analyzeLogs() {
+	if t.TotalGasUsed == 0 || t.LogGas*100 < t.TotalGasUsed*logHeavyPercent {
+		return
+	}
+
+	var dataBytes, dataGas, savings uint64
+	var largest LogOperation
+	for _, logOp := range t.LogOps {
+		dataBytes += logOp.DataSize
+		dataGas += logOp.DataSize * params.LogDataGas
+		if logOp.DataSize > largest.DataSize {
+			largest = logOp
+		}
+
+		// Logging a hash of the data instead keeps it verifiable off-chain
+		if logOp.DataSize > logDataHashSize {
+			savings += (logOp.DataSize - logDataHashSize) * params.LogDataGas
+		}
+	}
+
+	// Topic-only logging is already cheap; only flag when the data drives the cost
+	if savings == 0 {
+		return
+	}
+
+	t.Optimizations = append(t.Optimizations, Optimization{
+		Type:        "log_data_heavy",
+		Severity:    "medium",
+		Description: "Event data is a significant share of gas - consider logging a hash or moving large data out of logs, and index fields that are filtered on",
+		Location:    ContractPCLocation(largest.Contract, largest.PC),
+		GasSavings:  savings,
+		Confidence:  "estimated",
+		Details: map[string]interface{}{
+			"log_count":        len(t.LogOps),
+			"log_gas":          t.LogGas,
+			"log_data_bytes":   dataBytes,
+			"log_data_gas":     dataGas,
+			"largest_log_size": largest.DataSize,
+			"percentage":       float64(t.LogGas) / float64(t.TotalGasUsed) * 100,
+		},
+	})
+}
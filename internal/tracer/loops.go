@@ -0,0 +1,153 @@
+package tracer
+
+import (
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	u256 "github.com/holiman/uint256"
+)
+
+// loopKey identifies a loop by its contract and backward-jump PC range
+type loopKey struct {
+	contract common.Address
+	start    uint64
+	end      uint64
+}
+
+// storageSite identifies a storage access at one code location
+type storageSite struct {
+	contract common.Address
+	pc       uint64
+	op       string
+	key      common.Hash
+}
+
+// siteUsage aggregates the accesses made at a storage site
+type siteUsage struct {
+	first    int // Number of storage accesses before the first one at the site
+	accesses int
+	gas      uint64
+}
+
+// loopState holds per-loop bookkeeping not exposed on LoopDetection
+type loopState struct {
+	index   int    // Index into Loops
+	lastGas uint64 // TotalGasUsed at the previous backward jump
+}
+
+// trackJump records a loop when a jump targets an earlier PC in the same code
+func (t *GasOptimizationTracer) trackJump(pc uint64, dest *u256.Int, contract common.Address) {
+	if !dest.IsUint64() || dest.Uint64() >= pc {
+		return
+	}
+
+	key := loopKey{contract: contract, start: dest.Uint64(), end: pc}
+	state, ok := t.loopStates[key]
+	if !ok {
+		t.loopStates[key] = &loopState{index: len(t.Loops), lastGas: t.TotalGasUsed}
+		t.Loops = append(t.Loops, LoopDetection{
+			StartPC:    key.start,
+			EndPC:      key.end,
+			Iterations: 1,
+			Contract:   contract,
+		})
+		return
+	}
+
+	loop := &t.Loops[state.index]
+	loop.Iterations++
+	loop.GasPerLoop = t.TotalGasUsed - state.lastGas
+	state.lastGas = t.TotalGasUsed
+}
+
+// indexStorageOp aggregates a storage access by code location, so that the
+// accesses themselves need not be kept for the final analysis
+func (t *GasOptimizationTracer) indexStorageOp(op StorageOperation) {
+	site := storageSite{contract: op.Contract, pc: op.PC, op: op.Op, key: op.Key}
+	usage, ok := t.storageSites[site]
+	if !ok {
+		usage = &siteUsage{first: t.StorageAccesses}
+		t.storageSites[site] = usage
+		t.contractSites[op.Contract] = append(t.contractSites[op.Contract], site)
+	}
+	usage.accesses++
+	usage.gas += op.Gas
+	t.StorageAccesses++
+}
+
+// analyzeStorageInLoops flags storage slots accessed repeatedly inside detected loops.
+// It runs in time proportional to the distinct storage sites, not the trace length.
+func (t *GasOptimizationTracer) analyzeStorageInLoops() {
+	type slotUsage struct {
+		op       string
+		first    int
+		accesses int
+		gas      uint64
+	}
+
+	for _, loop := range t.Loops {
+		if loop.Iterations < 2 {
+			continue
+		}
+
+		usage := make(map[common.Hash]*slotUsage)
+		order := make([]common.Hash, 0)
+		for _, site := range t.contractSites[loop.Contract] {
+			if site.pc < loop.StartPC || site.pc > loop.EndPC {
+				continue
+			}
+
+			s := t.storageSites[site]
+			u, ok := usage[site.key]
+			if !ok {
+				u = &slotUsage{op: site.op, first: s.first}
+				usage[site.key] = u
+				order = append(order, site.key)
+			} else if s.first < u.first {
+				// Report the operation that first touched the slot
+				u.op, u.first = site.op, s.first
+			}
+			u.accesses += s.accesses
+			u.gas += s.gas
+		}
+
+		sort.Slice(order, func(i, j int) bool {
+			return order[i].Hex() < order[j].Hex()
+		})
+
+		for _, key := range order {
+			u := usage[key]
+			if u.accesses < 2 {
+				continue
+			}
+
+			// Hoisting the access out of the loop leaves a single access
+			perIteration := u.gas / uint64(u.accesses)
+			t.Optimizations = append(t.Optimizations, Optimization{
+				Type:        "storage_in_loop",
+				Severity:    "high",
+				Description: "Storage accessed on every loop iteration - cache the slot in memory",
+				Location:    ContractPCLocation(loop.Contract, loop.StartPC),
+				GasSavings:  perIteration * uint64(loop.Iterations-1),
+				Confidence:  "estimated",
+				Details: map[string]interface{}{
+					"storage_key":       key.Hex(),
+					"operation":         u.op,
+					"loop_range":        formatPC(loop.StartPC) + "-" + formatPC(loop.EndPC),
+					"iterations":        loop.Iterations,
+					"per_iteration_gas": perIteration,
+					"loop_storage_gas":  perIteration * uint64(loop.Iterations),
+				},
+			})
+		}
+	}
+}
+
+// contractAddress returns the address of the executing contract, if known
+func contractAddress(scope *vm.ScopeContext) common.Address {
+	if scope == nil || scope.Contract == nil {
+		return common.Address{}
+	}
+	return scope.Contract.Address()
+}
@@ -0,0 +1,232 @@
+package tracer
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	u256 "github.com/holiman/uint256"
+)
+
+// syntheticIterations is the loop iterations of the benchmark trace, 1M steps in total
+const syntheticIterations = 100000
+
+// syntheticLoopTrace feeds the tracer steps of loops whose bodies read and write storage.
+// Each iteration is ten steps, so iterations*10 steps are traced in total. With retain,
+// every storage access is also returned, as the end-of-trace analysis used to keep them.
+func syntheticLoopTrace(tracer *GasOptimizationTracer, contracts []common.Address, iterations int, retain bool) []StorageOperation {
+	body := []struct {
+		pc      uint64
+		op      string
+		cost    uint64
+		storage bool
+	}{
+		{0x10, "JUMPDEST", 1, false},
+		{0x11, "PUSH1", 3, false},
+		{0x13, "SLOAD", 100, true},
+		{0x14, "PUSH1", 3, false},
+		{0x16, "ADD", 3, false},
+		{0x17, "PUSH1", 3, false},
+		{0x19, "SSTORE", 100, true},
+		{0x1a, "PUSH1", 3, false},
+		{0x1c, "PUSH1", 3, false},
+		{0x1e, "JUMP", 8, false},
+	}
+
+	var ops []StorageOperation
+	dest := u256.NewInt(0x10)
+	for i := 0; i < iterations; i++ {
+		contract := contracts[i%len(contracts)]
+		slot := common.BigToHash(u256.NewInt(uint64(i % 4)).ToBig())
+
+		for _, step := range body {
+			tracer.TotalGasUsed += step.cost
+			tracer.addOpcodeGas(step.op, step.cost)
+			tracer.OpcodeCounts[step.op]++
+
+			if step.storage {
+				op := StorageOperation{
+					PC:       step.pc,
+					Op:       step.op,
+					Key:      slot,
+					Gas:      step.cost,
+					Depth:    1,
+					Contract: contract,
+				}
+				if retain {
+					ops = append(ops, op)
+				}
+				tracer.indexStorageOp(op)
+			}
+		}
+		tracer.trackJump(0x1e, dest, contract)
+	}
+	return ops
+}
+
+// scanExpensiveOpcodes is the end-of-trace opcode analysis that checks every
+// executed opcode, kept as a reference for the running max-gas opcode
+func scanExpensiveOpcodes(t *GasOptimizationTracer) []string {
+	var opcodes []string
+	for opcode, gasUsed := range t.GasPerOpcode {
+		if gasUsed > t.TotalGasUsed/10 {
+			opcodes = append(opcodes, opcode)
+		}
+	}
+	sort.Strings(opcodes)
+	return opcodes
+}
+
+// scanStorageInLoops is the end-of-trace analysis that rescans every storage
+// access for every loop, kept as a reference for the incremental version
+func scanStorageInLoops(t *GasOptimizationTracer, ops []StorageOperation) []Optimization {
+	type slotUsage struct {
+		op       string
+		accesses int
+		gas      uint64
+	}
+
+	var optimizations []Optimization
+	for _, loop := range t.Loops {
+		if loop.Iterations < 2 {
+			continue
+		}
+
+		usage := make(map[common.Hash]*slotUsage)
+		order := make([]common.Hash, 0)
+		for _, op := range ops {
+			if op.Contract != loop.Contract || op.PC < loop.StartPC || op.PC > loop.EndPC {
+				continue
+			}
+
+			u, ok := usage[op.Key]
+			if !ok {
+				u = &slotUsage{op: op.Op}
+				usage[op.Key] = u
+				order = append(order, op.Key)
+			}
+			u.accesses++
+			u.gas += op.Gas
+		}
+
+		sort.Slice(order, func(i, j int) bool {
+			return order[i].Hex() < order[j].Hex()
+		})
+
+		for _, key := range order {
+			u := usage[key]
+			if u.accesses < 2 {
+				continue
+			}
+
+			perIteration := u.gas / uint64(u.accesses)
+			optimizations = append(optimizations, Optimization{
+				Type:        "storage_in_loop",
+				Severity:    "high",
+				Description: "Storage accessed on every loop iteration - cache the slot in memory",
+				Location:    ContractPCLocation(loop.Contract, loop.StartPC),
+				GasSavings:  perIteration * uint64(loop.Iterations-1),
+				Confidence:  "estimated",
+				Details: map[string]interface{}{
+					"storage_key":       key.Hex(),
+					"operation":         u.op,
+					"loop_range":        formatPC(loop.StartPC) + "-" + formatPC(loop.EndPC),
+					"iterations":        loop.Iterations,
+					"per_iteration_gas": perIteration,
+					"loop_storage_gas":  perIteration * uint64(loop.Iterations),
+				},
+			})
+		}
+	}
+	return optimizations
+}
+
+func TestStorageInLoopMatchesScan(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	contracts := []common.Address{common.HexToAddress("0xc0de"), common.HexToAddress("0xbeef")}
+	ops := syntheticLoopTrace(tracer, contracts, 100, true)
+
+	expected := scanStorageInLoops(tracer, ops)
+	if len(expected) == 0 {
+		t.Fatal("Expected the synthetic trace to produce storage_in_loop findings")
+	}
+
+	tracer.analyzeStorageInLoops()
+
+	if !reflect.DeepEqual(tracer.Optimizations, expected) {
+		t.Errorf("Incremental analysis differs from full scan:\ngot:  %v\nwant: %v", tracer.Optimizations, expected)
+	}
+}
+
+func TestMaxGasOpcodeMatchesScan(t *testing.T) {
+	tracer := NewGasOptimizationTracer()
+	syntheticLoopTrace(tracer, []common.Address{common.HexToAddress("0xc0de")}, 100, false)
+
+	var maxOpcode string
+	for opcode, gasUsed := range tracer.GasPerOpcode {
+		if gasUsed > tracer.GasPerOpcode[maxOpcode] {
+			maxOpcode = opcode
+		}
+	}
+	if tracer.GasPerOpcode[tracer.maxGasOpcode] != tracer.GasPerOpcode[maxOpcode] {
+		t.Errorf("Expected max-gas opcode %s, got %s", maxOpcode, tracer.maxGasOpcode)
+	}
+
+	threshold := tracer.TotalGasUsed / 10
+	if got, want := tracer.expensiveOpcodes(threshold), scanExpensiveOpcodes(tracer); len(want) == 0 || len(got) < len(want) {
+		t.Errorf("Expected candidates covering %v, got %v", want, got)
+	}
+	// No opcode can exceed a threshold above the max-gas opcode
+	if got := tracer.expensiveOpcodes(tracer.GasPerOpcode[tracer.maxGasOpcode]); got != nil {
+		t.Errorf("Expected no candidates above the max-gas opcode, got %v", got)
+	}
+}
+
+func BenchmarkTraceScan(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		syntheticLoopTrace(NewGasOptimizationTracer(), []common.Address{common.HexToAddress("0xc0de")}, syntheticIterations, true)
+	}
+}
+
+func BenchmarkTraceIncremental(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		syntheticLoopTrace(NewGasOptimizationTracer(), []common.Address{common.HexToAddress("0xc0de")}, syntheticIterations, false)
+	}
+}
+
+func BenchmarkEndOfTraceScan(b *testing.B) {
+	tracer := NewGasOptimizationTracer()
+	ops := syntheticLoopTrace(tracer, []common.Address{common.HexToAddress("0xc0de")}, syntheticIterations, true)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		scanExpensiveOpcodes(tracer)
+		scanStorageInLoops(tracer, ops)
+	}
+}
+
+func BenchmarkEndOfTraceIncremental(b *testing.B) {
+	tracer := NewGasOptimizationTracer()
+	syntheticLoopTrace(tracer, []common.Address{common.HexToAddress("0xc0de")}, syntheticIterations, false)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tracer.Optimizations = tracer.Optimizations[:0]
+		tracer.expensiveOpcodes(tracer.TotalGasUsed / 10)
+		tracer.analyzeStorageInLoops()
+	}
+}
+
+func BenchmarkAnalyzePatterns(b *testing.B) {
+	tracer := NewGasOptimizationTracer()
+	syntheticLoopTrace(tracer, []common.Address{common.HexToAddress("0xc0de")}, syntheticIterations, false)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tracer.Optimizations = tracer.Optimizations[:0]
+		tracer.analyzePatterns()
+	}
+}
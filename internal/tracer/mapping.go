@@ -0,0 +1,84 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// mappingInputSize is the size of the KECCAK256 input Solidity hashes to locate
+// a mapping entry: the key followed by the mapping's slot
+const mappingInputSize = 64
+
+// mappingInput is the key and mapping slot hashed into a storage location
+type mappingInput struct {
+	key  common.Hash
+	slot common.Hash
+}
+
+// mappingAccess counts the storage accesses to a location computed by KECCAK256
+type mappingAccess struct {
+	input  mappingInput
+	pc     uint64 // First access
+	loads  int
+	stores int
+}
+
+// recordMappingHash remembers the input of a KECCAK256 that may locate a mapping entry
+func (t *GasOptimizationTracer) recordMappingHash(hash common.Hash, data []byte) {
+	if len(data) != mappingInputSize {
+		return
+	}
+	t.mappingInputs[hash] = mappingInput{
+		key:  common.BytesToHash(data[:32]),
+		slot: common.BytesToHash(data[32:]),
+	}
+}
+
+// trackMappingAccess counts SLOAD and SSTORE accesses to slots computed by KECCAK256
+func (t *GasOptimizationTracer) trackMappingAccess(pc uint64, op vm.OpCode, key common.Hash, scope *vm.ScopeContext) {
+	input, ok := t.mappingInputs[key]
+	if !ok {
+		return
+	}
+
+	slot := storageSlot{contract: contractAddress(scope), key: key}
+	access, ok := t.mappingAccesses[slot]
+	if !ok {
+		access = &mappingAccess{input: input, pc: pc}
+		t.mappingAccesses[slot] = access
+		t.mappingOrder = append(t.mappingOrder, slot)
+	}
+	if op == vm.SLOAD {
+		access.loads++
+	} else {
+		access.stores++
+	}
+}
+
+// analyzeMappingAccess reclassifies redundant_hash findings whose hash is a
+// mapping entry's slot accessed repeatedly: the same key is looked up more than
+// once, so the computed slot or the loaded value can be cached. The savings stay
+// those of the recomputed hashes; repeated loads are reported by redundant_sload.
+func (t *GasOptimizationTracer) analyzeMappingAccess() {
+	for _, slot := range t.mappingOrder {
+		access := t.mappingAccesses[slot]
+		if t.HashCounts[slot.key] < 2 || access.loads+access.stores < 2 {
+			continue
+		}
+		idx, ok := t.hashFindings[slot.key]
+		if !ok || t.Optimizations[idx].Type != "redundant_hash" {
+			continue
+		}
+
+		opt := &t.Optimizations[idx]
+		opt.Type = "redundant_mapping_access"
+		opt.Description = "Mapping entry located with KECCAK256 repeatedly for the same key - cache the computed slot, or the loaded value, in a local variable"
+		opt.Details["contract"] = slot.contract.Hex()
+		opt.Details["storage_key"] = slot.key.Hex()
+		opt.Details["mapping_slot"] = access.input.slot.Hex()
+		opt.Details["mapping_key"] = access.input.key.Hex()
+		opt.Details["loads"] = access.loads
+		opt.Details["stores"] = access.stores
+		opt.Details["first_access"] = formatPC(access.pc)
+	}
+}
@@ -0,0 +1,116 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// wordSize is the number of bytes moved by a single MLOAD or MSTORE
+const wordSize = 32
+
+// memorySite identifies an MLOAD or MSTORE at one code location
+type memorySite struct {
+	contract common.Address
+	pc       uint64
+	op       vm.OpCode
+}
+
+// memoryStride tracks the offsets accessed at a memory site
+type memoryStride struct {
+	accesses int
+	last     uint64
+	stride   int64
+	steady   bool // Whether every access moved the offset by the same word-sized step
+}
+
+// trackMemoryCopy records the offset accessed by an MLOAD or MSTORE so that
+// word-by-word copy loops can be recognized
+func (t *GasOptimizationTracer) trackMemoryCopy(pc uint64, op vm.OpCode, scope *vm.ScopeContext) {
+	if op != vm.MLOAD && op != vm.MSTORE {
+		return
+	}
+
+	offset := scope.Stack.Back(0)
+	if offset == nil || !offset.IsUint64() {
+		return
+	}
+
+	site := memorySite{contract: contractAddress(scope), pc: pc, op: op}
+	s, ok := t.memorySites[site]
+	if !ok {
+		t.memorySites[site] = &memoryStride{accesses: 1, last: offset.Uint64(), steady: true}
+		t.contractMemorySites[site.contract] = append(t.contractMemorySites[site.contract], site)
+		return
+	}
+
+	stride := int64(offset.Uint64() - s.last)
+	if s.accesses == 1 {
+		s.stride = stride
+		s.steady = stride == wordSize || stride == -wordSize
+	} else if stride != s.stride {
+		s.steady = false
+	}
+	s.accesses++
+	s.last = offset.Uint64()
+}
+
+// analyzeMemoryCopyLoops flags loops that copy memory one word at a time with
+// MLOAD and MSTORE at steadily advancing offsets, which a single MCOPY replaces.
+// The suggestion is only made on chains where MCOPY is available.
+func (t *GasOptimizationTracer) analyzeMemoryCopyLoops() {
+	if !t.mcopyActive {
+		return
+	}
+
+	for _, loop := range t.Loops {
+		if loop.Iterations < 2 {
+			continue
+		}
+
+		loads, stores := 0, 0
+		for _, site := range t.contractMemorySites[loop.Contract] {
+			if site.pc < loop.StartPC || site.pc > loop.EndPC {
+				continue
+			}
+
+			s := t.memorySites[site]
+			if !s.steady || s.accesses < 2 {
+				continue
+			}
+			if site.op == vm.MLOAD {
+				loads = max(loads, s.accesses)
+			} else {
+				stores = max(stores, s.accesses)
+			}
+		}
+
+		if loads == 0 || stores == 0 {
+			continue
+		}
+
+		// MCOPY charges a base cost plus a per-word copy cost, with three operand pushes
+		words := uint64(min(loads, stores))
+		mcopyGas := vm.GasFastestStep + params.CopyGas*words + 3*vm.GasFastestStep
+		loopGas := loop.GasPerLoop * uint64(loop.Iterations)
+		if loopGas <= mcopyGas {
+			continue
+		}
+
+		t.Optimizations = append(t.Optimizations, Optimization{
+			Type:        "use_mcopy",
+			Severity:    "medium",
+			Description: "Memory copied word by word in a loop - use MCOPY (EIP-5656)",
+			Location:    ContractPCLocation(loop.Contract, loop.StartPC),
+			GasSavings:  loopGas - mcopyGas,
+			Confidence:  "estimated",
+			Details: map[string]interface{}{
+				"loop_range":   formatPC(loop.StartPC) + "-" + formatPC(loop.EndPC),
+				"iterations":   loop.Iterations,
+				"words_copied": words,
+				"loop_gas":     loopGas,
+				"mcopy_gas":    mcopyGas,
+			},
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// SetMinimal switches the tracer to a profiling-only mode that collects just
+// GasPerOpcode and TotalGasUsed. Every detector, the call tree and the
+// per-operation records are skipped, and CaptureState reduces to an array
+// update without taking the lock. Only StepCount may be read while a minimal
+// trace runs; GasPerOpcode is filled in when the transaction ends. SetSampleRate
+// cuts the overhead further by recording only some steps.
+func (t *GasOptimizationTracer) SetMinimal(enabled bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.minimal = enabled
+}
+
+// flushMinimalGas moves the gas accumulated per opcode in minimal mode into
+// GasPerOpcode, scaling sampled gas by the sample rate
+func (t *GasOptimizationTracer) flushMinimalGas() {
+	scale := uint64(1)
+	if t.sampling() {
+		scale = t.sampleRate
+	}
+	for op, seen := range t.minimalSeen {
+		if seen {
+			t.addOpcodeGas(vm.OpCode(op).String(), t.minimalGas[op]*scale)
+		}
+	}
+	t.minimalGas = [256]uint64{}
+	t.minimalSeen = [256]bool{}
+}
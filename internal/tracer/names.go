@@ -0,0 +1,70 @@
+package tracer
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NameResolver maps addresses to human-readable names, such as ENS reverse records
+type NameResolver interface {
+	// ReverseResolve returns the name of an address, if it has one
+	ReverseResolve(ctx context.Context, addr common.Address) (name string, ok bool)
+}
+
+// ResolveNames attaches names to the senders and targets of the call tree frames.
+// The lookups run without holding the tracer lock and stop once ctx is done,
+// leaving the remaining addresses unnamed.
+func (t *GasOptimizationTracer) ResolveNames(ctx context.Context, resolver NameResolver) {
+	t.mu.Lock()
+	var addrs []common.Address
+	if t.CallTree != nil {
+		addrs = nodeAddresses(t.CallTree, addrs, make(map[common.Address]bool))
+	}
+	t.mu.Unlock()
+
+	names := make(map[common.Address]string)
+	for _, addr := range addrs {
+		if ctx.Err() != nil {
+			break
+		}
+		if name, ok := resolver.ReverseResolve(ctx, addr); ok {
+			names[addr] = name
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.CallTree != nil {
+		nameNode(t.CallTree, names)
+	}
+}
+
+// nodeAddresses appends the distinct senders and targets of node and its
+// descendants to addrs, in call order
+func nodeAddresses(node *CallNode, addrs []common.Address, seen map[common.Address]bool) []common.Address {
+	for _, addr := range []common.Address{node.From, node.To} {
+		if !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	for _, child := range node.Children {
+		addrs = nodeAddresses(child, addrs, seen)
+	}
+	return addrs
+}
+
+// nameNode attaches the resolved names to node and its descendants
+func nameNode(node *CallNode, names map[common.Address]string) {
+	if name, ok := names[node.From]; ok {
+		node.FromName = name
+	}
+	if name, ok := names[node.To]; ok {
+		node.ToName = name
+	}
+	for _, child := range node.Children {
+		nameNode(child, names)
+	}
+}
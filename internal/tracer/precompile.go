@@ -0,0 +1,80 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// precompile describes a precompiled contract and the cost note attached to its use
+type precompile struct {
+	name string
+	note string
+}
+
+// precompiles are the precompiled contracts at addresses 0x01-0x0a
+var precompiles = map[common.Address]precompile{
+	common.BytesToAddress([]byte{0x01}): {"ecrecover", "ecrecover is 3000 gas per call; consider caching the recovered address"},
+	common.BytesToAddress([]byte{0x02}): {"sha256", "sha256 is 60 gas plus 12 per word; KECCAK256 is cheaper where the hash function is free to choose"},
+	common.BytesToAddress([]byte{0x03}): {"ripemd160", "ripemd160 is 600 gas plus 120 per word"},
+	common.BytesToAddress([]byte{0x04}): {"identity", "identity is 15 gas plus 3 per word; MCOPY copies memory for less on Cancun and later"},
+	common.BytesToAddress([]byte{0x05}): {"modexp", "modexp cost grows with the operand sizes and exponent length (EIP-2565)"},
+	common.BytesToAddress([]byte{0x06}): {"ecadd", "ecadd (bn256 addition) is 150 gas per call"},
+	common.BytesToAddress([]byte{0x07}): {"ecmul", "ecmul (bn256 scalar multiplication) is 6000 gas per call"},
+	common.BytesToAddress([]byte{0x08}): {"ecpairing", "ecpairing is 45000 gas plus 34000 per pair; check several pairs in one call"},
+	common.BytesToAddress([]byte{0x09}): {"blake2f", "blake2f is 1 gas per round"},
+	common.BytesToAddress([]byte{0x0a}): {"point_evaluation", "KZG point evaluation is 50000 gas per call"},
+}
+
+// PrecompileName returns the name of the precompiled contract at addr, if any
+func PrecompileName(addr common.Address) (string, bool) {
+	p, ok := precompiles[addr]
+	return p.name, ok
+}
+
+// PrecompileUsage aggregates the calls made to one precompiled contract
+type PrecompileUsage struct {
+	Name    string
+	Address common.Address
+	Calls   int
+	Gas     uint64 // Gas used by the precompile across all calls
+}
+
+// precompileUsage aggregates calls to precompiles in order of first call
+func (t *GasOptimizationTracer) precompileUsage() []PrecompileUsage {
+	var usage []PrecompileUsage
+	index := make(map[string]int)
+
+	for _, call := range t.CallOps {
+		if call.Precompile == "" {
+			continue
+		}
+		i, ok := index[call.Precompile]
+		if !ok {
+			i = len(usage)
+			index[call.Precompile] = i
+			usage = append(usage, PrecompileUsage{Name: call.Precompile, Address: call.To})
+		}
+		usage[i].Calls++
+		usage[i].Gas += call.GasUsed
+	}
+	return usage
+}
+
+// analyzePrecompiles notes the fixed costs of each precompile called
+func (t *GasOptimizationTracer) analyzePrecompiles() {
+	for _, usage := range t.precompileUsage() {
+		t.Optimizations = append(t.Optimizations, Optimization{
+			Type:        "precompile_call",
+			Severity:    "low",
+			Description: precompiles[usage.Address].note,
+			Location:    MultipleLocation(),
+			GasSavings:  0,
+			Confidence:  "heuristic",
+			Details: map[string]interface{}{
+				"precompile": usage.Name,
+				"address":    usage.Address.Hex(),
+				"calls":      usage.Calls,
+				"gas_used":   usage.Gas,
+			},
+		})
+	}
+}
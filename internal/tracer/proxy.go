@@ -0,0 +1,109 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// eip1967ImplementationSlot is the EIP-1967 storage slot holding a proxy's implementation
+var eip1967ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+
+// ProxyImplementation is a DELEGATECALL target that was loaded from storage
+type ProxyImplementation struct {
+	Proxy          common.Address // Contract that delegated
+	Implementation common.Address // Target loaded from the proxy's storage
+	StorageKey     common.Hash    // Slot the target was loaded from
+	PC             uint64         // PC of the first such DELEGATECALL
+}
+
+// storageLoad is an SLOAD whose result is read from the stack on the next step
+type storageLoad struct {
+	contract common.Address
+	key      common.Hash
+	depth    int
+}
+
+// loadedValue is a value a contract loaded from its storage
+type loadedValue struct {
+	contract common.Address
+	value    common.Hash
+}
+
+// proxyKey identifies a proxy and the implementation it delegated to
+type proxyKey struct {
+	proxy          common.Address
+	implementation common.Address
+}
+
+// trackProxy remembers address-sized values loaded from storage and flags
+// DELEGATECALLs whose target is one of them, as in upgradeable proxies.
+// Values are matched, so the target must reach the call unchanged, and a
+// constant target equal to a loaded value is indistinguishable from it.
+func (t *GasOptimizationTracer) trackProxy(pc uint64, op vm.OpCode, depth int, scope *vm.ScopeContext) {
+	// The result of an SLOAD is on top of the stack at the next step in the same frame
+	if load := t.pendingLoad; load != nil {
+		t.pendingLoad = nil
+		if depth == load.depth && len(scope.Stack.Data()) > 0 {
+			value := scope.Stack.Back(0)
+			if value.BitLen() <= 160 && !value.IsZero() {
+				t.loadedTargets[loadedValue{contract: load.contract, value: common.Hash(value.Bytes32())}] = load.key
+			}
+		}
+	}
+
+	switch op {
+	case vm.SLOAD:
+		if len(scope.Stack.Data()) > 0 {
+			t.pendingLoad = &storageLoad{
+				contract: contractAddress(scope),
+				key:      common.Hash(scope.Stack.Back(0).Bytes32()),
+				depth:    depth,
+			}
+		}
+
+	case vm.DELEGATECALL:
+		if len(scope.Stack.Data()) < 2 {
+			return
+		}
+		proxy := contractAddress(scope)
+		target := common.Hash(scope.Stack.Back(1).Bytes32())
+		key, ok := t.loadedTargets[loadedValue{contract: proxy, value: target}]
+		if !ok {
+			return
+		}
+		t.recordProxyCall(pc, proxy, common.BytesToAddress(target.Bytes()), key)
+	}
+}
+
+// recordProxyCall adds or updates the proxy_delegatecall finding for a proxy and implementation
+func (t *GasOptimizationTracer) recordProxyCall(pc uint64, proxy, implementation common.Address, key common.Hash) {
+	pk := proxyKey{proxy: proxy, implementation: implementation}
+	if idx, ok := t.proxyFindings[pk]; ok {
+		t.Optimizations[idx].Details["delegatecall_count"] = t.Optimizations[idx].Details["delegatecall_count"].(int) + 1
+		return
+	}
+
+	t.ProxyImplementations = append(t.ProxyImplementations, ProxyImplementation{
+		Proxy:          proxy,
+		Implementation: implementation,
+		StorageKey:     key,
+		PC:             pc,
+	})
+
+	t.proxyFindings[pk] = len(t.Optimizations)
+	t.Optimizations = append(t.Optimizations, Optimization{
+		Type:        "proxy_delegatecall",
+		Severity:    "low",
+		Description: "DELEGATECALL to an implementation loaded from storage (proxy pattern) - the target can change between transactions",
+		Location:    ContractPCLocation(proxy, pc),
+		GasSavings:  0,
+		Confidence:  "heuristic",
+		Details: map[string]interface{}{
+			"proxy":              proxy.Hex(),
+			"implementation":     implementation.Hex(),
+			"storage_key":        key.Hex(),
+			"eip1967":            key == eip1967ImplementationSlot,
+			"delegatecall_count": 1,
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// push0Savings is the gas saved by each PUSH0 (2 gas) replacing a PUSH1 0x00 (3 gas)
+const push0Savings = vm.GasFastestStep - vm.GasQuickStep
+
+// pushSite identifies a PUSH1 0x00 at one location in a contract's code
+type pushSite struct {
+	codeHash common.Hash
+	pc       uint64
+}
+
+// trackPushZero counts executed PUSH1 0x00 instructions, which code compiled for
+// Shanghai or later replaces with PUSH0 (EIP-3855)
+func (t *GasOptimizationTracer) trackPushZero(pc uint64, op vm.OpCode, scope *vm.ScopeContext) {
+	if op != vm.PUSH1 || !t.push0Active || scope == nil || scope.Contract == nil {
+		return
+	}
+
+	code := scope.Contract.Code
+	if pc+1 >= uint64(len(code)) || code[pc+1] != 0 {
+		return
+	}
+
+	t.pushZeroCount++
+	t.pushZeroSites[pushSite{codeHash: scope.Contract.CodeHash, pc: pc}] = true
+}
+
+// analyzePushZero suggests recompiling for Shanghai when zero was pushed with
+// PUSH1 0x00 on a chain where PUSH0 is available
+func (t *GasOptimizationTracer) analyzePushZero() {
+	if t.pushZeroCount == 0 {
+		return
+	}
+
+	t.Optimizations = append(t.Optimizations, Optimization{
+		Type:        "use_push0",
+		Severity:    "low",
+		Description: "PUSH1 0x00 pushes zero - recompiling for Shanghai or later (PUSH0) saves 1 gas per push and 1 byte of code",
+		Location:    MultipleLocation(),
+		GasSavings:  uint64(t.pushZeroCount) * push0Savings,
+		Confidence:  "estimated",
+		Details: map[string]interface{}{
+			"occurrences": t.pushZeroCount,
+			"sites":       len(t.pushZeroSites),
+		},
+	})
+}
@@ -0,0 +1,282 @@
+package tracer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ReportSchemaVersion is the version of the JSON report layout, bumped on
+// incompatible changes
+const ReportSchemaVersion = 2
+
+// minReportSchemaVersion is the oldest report layout ParseReport still reads.
+// Version 1 wrote optimization locations as plain strings.
+const minReportSchemaVersion = 1
+
+// ErrInvalidReport is returned when a saved report does not match the schema
+var ErrInvalidReport = errors.New("invalid report")
+
+// requiredReportFields are the report keys every consumer may rely on
+var requiredReportFields = []string{
+	"schema_version",
+	"total_gas_used",
+	"gas_limit",
+	"fork",
+	"optimizations",
+	"total_gas_savings",
+	"heuristic_savings",
+	"gas_by_opcode",
+	"opcode_counts",
+	"calldata",
+	"calls",
+}
+
+// OmitReportKeys leaves the given top-level keys out of GetReport and
+// GetCompactReport. GetTypedReport still sees the whole report, but a saved
+// report missing required fields is rejected by ParseReport.
+func (t *GasOptimizationTracer) OmitReportKeys(keys []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.omittedKeys = append([]string(nil), keys...)
+}
+
+// Report is the typed form of the JSON report produced by GetReport
+type Report struct {
+	SchemaVersion      int                `json:"schema_version"`
+	TotalGasUsed       uint64             `json:"total_gas_used"`
+	GasLimit           uint64             `json:"gas_limit"`
+	Fork               string             `json:"fork"`
+	GasAccountingDelta int64              `json:"gas_accounting_delta"`
+	StorageReads       int                `json:"storage_reads"`
+	StorageWrites      int                `json:"storage_writes"`
+	TransientReads     int                `json:"transient_reads"`
+	TransientWrites    int                `json:"transient_writes"`
+	MemoryOperations   int                `json:"memory_operations"`
+	CallOperations     int                `json:"call_operations"`
+	Loops              int                `json:"loops"`
+	ETHTransfers       int                `json:"eth_transfers"`
+	ContractCalls      int                `json:"contract_calls"`
+	ExpensiveOps       int                `json:"expensive_ops"`
+	RevertedCalls      int                `json:"reverted_calls"`
+	RevertedGas        uint64             `json:"reverted_gas"`
+	LogOperations      int                `json:"log_operations"`
+	LogGas             uint64             `json:"log_gas"`
+	Optimizations      []Optimization     `json:"optimizations"`
+	TotalGasSavings    uint64             `json:"total_gas_savings"`
+	HeuristicSavings   uint64             `json:"heuristic_savings"`
+	SavingsCapped      bool               `json:"savings_capped"` // The total was capped at the gas used
+	GasByOpcode        map[string]uint64  `json:"gas_by_opcode"`
+	GasByCategory      map[string]uint64  `json:"gas_by_category,omitempty"`
+	OpcodeCounts       map[string]uint64  `json:"opcode_counts"`
+	IsCreation         bool               `json:"is_creation"`
+	Calldata           ReportCalldata     `json:"calldata"`
+	Calls              []ReportCall       `json:"calls"`
+	Precompiles        []ReportPrecompile `json:"precompiles,omitempty"`
+	CallTree           *ReportCallNode    `json:"call_tree,omitempty"`
+	InternalTxs        []ReportInternalTx `json:"internal_transactions,omitempty"`
+	WriteOnlySlots     []ReportSlot       `json:"write_only_slots"`
+	ProxyImpls         []ReportProxy      `json:"proxy_implementations"`
+	SlotAccessOrder    []ReportSlot       `json:"slot_access_order"`
+	WhatIf             *ReportWhatIf      `json:"what_if,omitempty"`
+	AccessList         types.AccessList   `json:"suggested_access_list,omitempty"`
+	SimulatedAgainst   string             `json:"simulated_against,omitempty"`
+	Partial            string             `json:"partial,omitempty"`
+	Truncated          bool               `json:"truncated,omitempty"`
+	Faults             []ReportFault      `json:"faults,omitempty"`
+	Timeline           []ReportTimeline   `json:"timeline,omitempty"`
+	BlobGas            *ReportBlobGas     `json:"blob_gas,omitempty"`
+	SampledProfile     *ReportSampled     `json:"sampled_profile,omitempty"`
+	Deployment         *ReportDeployment  `json:"deployment,omitempty"`
+}
+
+// ReportCalldata is the calldata section of a report
+type ReportCalldata struct {
+	TotalBytes   int    `json:"total_bytes"`
+	ZeroBytes    int    `json:"zero_bytes"`
+	NonZeroBytes int    `json:"non_zero_bytes"`
+	L1Gas        uint64 `json:"l1_gas"`
+	L2Gas        uint64 `json:"l2_gas"`
+}
+
+// ReportCall is an external call listed in a report
+type ReportCall struct {
+	PC                string `json:"pc"`
+	Op                string `json:"op"`
+	To                string `json:"to"`
+	GasUsed           uint64 `json:"gas_used"`
+	Success           bool   `json:"success"`
+	IsTransfer        bool   `json:"is_transfer"`
+	Precompile        string `json:"precompile,omitempty"`
+	Selector          string `json:"selector,omitempty"`
+	Signature         string `json:"signature,omitempty"`
+	SignatureVerified bool   `json:"signature_verified,omitempty"`
+}
+
+// ReportPrecompile is the aggregated use of a precompiled contract in a report
+type ReportPrecompile struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Calls   int    `json:"calls"`
+	GasUsed uint64 `json:"gas_used"`
+}
+
+// ReportFault is an opcode that stopped its frame with an error in a report
+type ReportFault struct {
+	PC       string `json:"pc"`
+	Op       string `json:"op"`
+	Depth    int    `json:"depth"`
+	Contract string `json:"contract"`
+	Gas      uint64 `json:"gas"`
+	Cost     uint64 `json:"cost"`
+	Error    string `json:"error"`
+	OutOfGas bool   `json:"out_of_gas"`
+}
+
+// ReportTimeline is a point of the cumulative gas timeline in a report
+type ReportTimeline struct {
+	Step  uint64 `json:"step"`
+	Gas   uint64 `json:"gas"`
+	Depth int    `json:"depth"`
+}
+
+// ReportSlot is a storage slot listed in a report. Access order fields are
+// only set in the slot_access_order section.
+type ReportSlot struct {
+	Contract    string `json:"contract"`
+	Slot        string `json:"slot"`
+	Writes      int    `json:"writes"`
+	Reads       int    `json:"reads,omitempty"`
+	FirstAccess string `json:"first_access,omitempty"`
+	Order       string `json:"order,omitempty"`
+}
+
+// ReportProxy is a proxy implementation listed in a report
+type ReportProxy struct {
+	Proxy          string `json:"proxy"`
+	Implementation string `json:"implementation"`
+	StorageKey     string `json:"storage_key"`
+	PC             string `json:"pc"`
+}
+
+// ReportWhatIf is the projection of a report's gas under a baseline schedule
+type ReportWhatIf struct {
+	CurrentTotal  uint64            `json:"current_total"`
+	BaselineTotal uint64            `json:"baseline_total"`
+	Opcodes       []ReportOpcodeGas `json:"opcodes"`
+}
+
+// ReportOpcodeGas is an opcode's gas under the current and baseline schedules
+type ReportOpcodeGas struct {
+	Opcode      string `json:"opcode"`
+	Count       uint64 `json:"count"`
+	CurrentGas  uint64 `json:"current_gas"`
+	BaselineGas uint64 `json:"baseline_gas"`
+}
+
+// ReportCallNode is a frame of the call tree in a report
+type ReportCallNode struct {
+	Type      string            `json:"type"`
+	From      string            `json:"from"`
+	To        string            `json:"to"`
+	Input     string            `json:"input"`
+	Gas       uint64            `json:"gas"`
+	GasUsed   uint64            `json:"gas_used"`
+	Output    string            `json:"output"`
+	Value     string            `json:"value,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	Signature string            `json:"signature,omitempty"`
+	Calls     []*ReportCallNode `json:"calls"`
+}
+
+// ReportInternalTx is an internal transaction listed in a report
+type ReportInternalTx struct {
+	Type            string `json:"type"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	Value           string `json:"value"`
+	Depth           int    `json:"depth"`
+	Success         bool   `json:"success"`
+	ContractAddress string `json:"contract_address,omitempty"`
+}
+
+// ReportBlobGas is the blob gas section of a report for type-3 transactions
+type ReportBlobGas struct {
+	Blobs       int    `json:"blobs"`
+	BlobGas     uint64 `json:"blob_gas"`
+	BlobBaseFee string `json:"blob_base_fee,omitempty"`
+	BlobCostWei string `json:"blob_cost_wei,omitempty"`
+	BlobFeeCap  string `json:"blob_fee_cap,omitempty"`
+}
+
+// ReportSampled is the sampled_profile section of a report for sampled traces,
+// whose gas figures are estimates
+type ReportSampled struct {
+	Approximate bool          `json:"approximate"`
+	SampleRate  uint64        `json:"sample_rate"`
+	Samples     uint64        `json:"samples"`
+	HotPCs      []ReportHotPC `json:"hot_pcs"`
+}
+
+// ReportHotPC is a code location recorded by a sampled trace in a report
+type ReportHotPC struct {
+	Contract     string `json:"contract"`
+	PC           string `json:"pc"`
+	Op           string `json:"op"`
+	Samples      uint64 `json:"samples"`
+	EstimatedGas uint64 `json:"estimated_gas"`
+}
+
+// ReportDeployment is the deployment section of a report for contract creations
+type ReportDeployment struct {
+	ContractAddress string `json:"contract_address"`
+	InitCodeSize    int    `json:"init_code_size"`
+	InitCodeGas     uint64 `json:"init_code_gas"`
+}
+
+// ParseReport decodes a saved JSON report and validates it against the schema
+func ParseReport(data []byte) (*Report, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidReport, err)
+	}
+
+	var missing []string
+	for _, name := range requiredReportFields {
+		if _, ok := fields[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: missing required fields: %v", ErrInvalidReport, missing)
+	}
+
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidReport, err)
+	}
+	if err := report.Validate(); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Validate checks the schema version and the optimizations of a decoded report
+func (r *Report) Validate() error {
+	if r.SchemaVersion < minReportSchemaVersion || r.SchemaVersion > ReportSchemaVersion {
+		return fmt.Errorf("%w: schema version %d is not supported (expected %d to %d)", ErrInvalidReport, r.SchemaVersion, minReportSchemaVersion, ReportSchemaVersion)
+	}
+
+	for i, opt := range r.Optimizations {
+		if opt.Type == "" {
+			return fmt.Errorf("%w: optimization %d has no type", ErrInvalidReport, i)
+		}
+		if SeverityRank(opt.Severity) == 0 {
+			return fmt.Errorf("%w: optimization %d (%s) has unknown severity %q", ErrInvalidReport, i, opt.Type, opt.Severity)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package tracer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// tracedReport returns the JSON report of a call into a contract that reads
+// storage repeatedly, priced against a baseline schedule
+func tracedReport(t *testing.T) string {
+	t.Helper()
+
+	tracer := NewGasOptimizationTracer()
+	tracer.SetBaselineSchedule(GasSchedule{"SLOAD": 400})
+
+	callee := common.HexToAddress("0x00000000000000000000000000000000000000c1")
+	var calleeCode []byte
+	for i := 0; i < 3; i++ {
+		calleeCode = append(calleeCode, byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP))
+	}
+	calleeCode = append(calleeCode, byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.SSTORE), byte(vm.STOP))
+
+	code := append(callCode(50000, callee), byte(vm.STOP))
+	runCode(t, tracer, code, map[common.Address][]byte{callee: calleeCode})
+
+	report, err := tracer.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+	return report
+}
+
+func TestParseReport(t *testing.T) {
+	data := tracedReport(t)
+
+	report, err := ParseReport([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseReport() error: %v", err)
+	}
+
+	if report.SchemaVersion != ReportSchemaVersion {
+		t.Errorf("Expected schema version %d, got %d", ReportSchemaVersion, report.SchemaVersion)
+	}
+
+	if report.TotalGasUsed == 0 || len(report.Optimizations) == 0 || len(report.Calls) != 1 {
+		t.Errorf("Expected gas, optimizations and one call, got %d gas, %d optimizations, %d calls",
+			report.TotalGasUsed, len(report.Optimizations), len(report.Calls))
+	}
+
+	if report.CallTree == nil || len(report.CallTree.Calls) != 1 || report.WhatIf == nil {
+		t.Error("Expected the call tree and what-if sections to be decoded")
+	}
+
+	// Every key written by GetReport has a typed field
+	decoder := json.NewDecoder(bytes.NewReader([]byte(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&Report{}); err != nil {
+		t.Errorf("Expected the typed report to cover every field, got %v", err)
+	}
+}
+
+func TestParseReportRejectsInvalid(t *testing.T) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(tracedReport(t)), &fields); err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+
+	encode := func(fields map[string]interface{}) []byte {
+		data, err := json.Marshal(fields)
+		if err != nil {
+			t.Fatalf("Failed to encode report: %v", err)
+		}
+		return data
+	}
+
+	delete(fields, "total_gas_used")
+	if _, err := ParseReport(encode(fields)); !errors.Is(err, ErrInvalidReport) {
+		t.Errorf("Expected ErrInvalidReport for a missing field, got %v", err)
+	}
+
+	fields["total_gas_used"] = 21000
+	fields["schema_version"] = ReportSchemaVersion + 1
+	if _, err := ParseReport(encode(fields)); !errors.Is(err, ErrInvalidReport) {
+		t.Errorf("Expected ErrInvalidReport for an unsupported schema version, got %v", err)
+	}
+
+	if _, err := ParseReport([]byte("{not json")); !errors.Is(err, ErrInvalidReport) {
+		t.Errorf("Expected ErrInvalidReport for corrupted JSON, got %v", err)
+	}
+}
+
+func TestDiffReports(t *testing.T) {
+	// Save the report of a storage loop as the baseline
+	tracer := NewGasOptimizationTracer()
+	runCode(t, tracer, storageLoopCode(10), nil)
+	data, err := tracer.GetReport()
+	if err != nil {
+		t.Fatalf("GetReport() error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("failed to write baseline: %v", err)
+	}
+	baseline, err := LoadReport(path)
+	if err != nil {
+		t.Fatalf("LoadReport() error: %v", err)
+	}
+	if len(baseline.Optimizations) == 0 {
+		t.Fatal("Expected the baseline to have findings")
+	}
+
+	// Re-tracing the same code changes nothing
+	tracer = NewGasOptimizationTracer()
+	runCode(t, tracer, storageLoopCode(10), nil)
+	current, err := tracer.GetTypedReport()
+	if err != nil {
+		t.Fatalf("GetTypedReport() error: %v", err)
+	}
+	diff := DiffReports(baseline, current)
+	if len(diff.New) != 0 || len(diff.Resolved) != 0 || diff.Unchanged != len(baseline.Optimizations) {
+		t.Errorf("Expected all %d findings unchanged, got %d new, %d resolved, %d unchanged",
+			len(baseline.Optimizations), len(diff.New), len(diff.Resolved), diff.Unchanged)
+	}
+	if diff.GasDelta() != 0 {
+		t.Errorf("Expected no gas change, got %d", diff.GasDelta())
+	}
+
+	// Replacing the loop with a revert resolves its findings and introduces new ones
+	tracer = NewGasOptimizationTracer()
+	runCode(t, tracer, revertCode(errorStringData("ERC20: transfer amount exceeds balance of the sender")), nil)
+	current, err = tracer.GetTypedReport()
+	if err != nil {
+		t.Fatalf("GetTypedReport() error: %v", err)
+	}
+	diff = DiffReports(baseline, current)
+
+	if len(diff.Resolved) != len(baseline.Optimizations) || diff.Unchanged != 0 {
+		t.Errorf("Expected all %d baseline findings resolved, got %d resolved, %d unchanged",
+			len(baseline.Optimizations), len(diff.Resolved), diff.Unchanged)
+	}
+	newTypes := make(map[string]bool)
+	for _, opt := range diff.New {
+		newTypes[opt.Type] = true
+	}
+	if !newTypes["long_revert_string"] {
+		t.Errorf("Expected long_revert_string to be new, got %v", newTypes)
+	}
+	if diff.GasDelta() >= 0 {
+		t.Errorf("Expected gas to go down, got a change of %d", diff.GasDelta())
+	}
+}
+
+func TestLoadReportRejectsInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := os.WriteFile(path, []byte(`{"schema_version": 1}`), 0o644); err != nil {
+		t.Fatalf("failed to write baseline: %v", err)
+	}
+	if _, err := LoadReport(path); !errors.Is(err, ErrInvalidReport) {
+		t.Errorf("Expected ErrInvalidReport, got %v", err)
+	}
+}
+
+func TestRestoreReport(t *testing.T) {
+	saved := tracedReport(t)
+
+	tracer := NewGasOptimizationTracer()
+	if err := tracer.RestoreReport([]byte(saved)); err != nil {
+		t.Fatalf("RestoreReport() error: %v", err)
+	}
+	if report, _ := tracer.GetReport(); report != saved {
+		t.Errorf("Expected the restored report to match the saved one\nsaved:\n%s\nrestored:\n%s", saved, report)
+	}
+
+	var original Report
+	if err := json.Unmarshal([]byte(saved), &original); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if tracer.TotalGasUsed != original.TotalGasUsed || tracer.GasPerOpcode["SLOAD"] != original.GasByOpcode["SLOAD"] {
+		t.Errorf("Expected total gas %d and SLOAD gas %d, got %d and %d",
+			original.TotalGasUsed, original.GasByOpcode["SLOAD"], tracer.TotalGasUsed, tracer.GasPerOpcode["SLOAD"])
+	}
+	if root := tracer.GetCallTree(); root == nil || len(root.Children) != 1 {
+		t.Fatalf("Expected the call tree with one subcall, got %+v", root)
+	}
+
+	// Filters apply to the restored findings and the report
+	tracer.ApplyFilter(FilterCriteria{OnlyTypes: []string{"redundant_sload"}})
+	restored, err := tracer.GetTypedReport()
+	if err != nil {
+		t.Fatalf("GetTypedReport() error: %v", err)
+	}
+	for _, opt := range restored.Optimizations {
+		if opt.Type != "redundant_sload" {
+			t.Errorf("Expected only redundant_sload findings after filtering, got %s", opt.Type)
+		}
+	}
+	if len(restored.Optimizations) == 0 || len(restored.Optimizations) == len(original.Optimizations) {
+		t.Errorf("Expected the filter to keep some of the %d findings, got %d", len(original.Optimizations), len(restored.Optimizations))
+	}
+
+	if err := tracer.RestoreReport([]byte(`{"schema_version": 1}`)); !errors.Is(err, ErrInvalidReport) {
+		t.Errorf("Expected ErrInvalidReport for an incomplete report, got %v", err)
+	}
+}
+
+func TestRestoreReportFaultPC(t *testing.T) {
+	var report map[string]interface{}
+	if err := json.Unmarshal([]byte(tracedReport(t)), &report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	withFaultPC := func(pc string) []byte {
+		report["faults"] = []ReportFault{{PC: pc, Op: "SSTORE", Depth: 1, Error: "out of gas", OutOfGas: true}}
+		data, err := json.Marshal(report)
+		if err != nil {
+			t.Fatalf("failed to encode report: %v", err)
+		}
+		return data
+	}
+
+	tracer := NewGasOptimizationTracer()
+	if err := tracer.RestoreReport(withFaultPC("0x2a")); err != nil {
+		t.Fatalf("RestoreReport() error: %v", err)
+	}
+	if len(tracer.Faults) != 1 || tracer.Faults[0].PC != 0x2a {
+		t.Errorf("Expected the fault at 0x2a, got %+v", tracer.Faults)
+	}
+
+	// Fault PCs follow the same format as finding locations
+	if err := tracer.RestoreReport(withFaultPC("2a")); !errors.Is(err, ErrInvalidReport) {
+		t.Errorf("Expected ErrInvalidReport for a fault pc without 0x, got %v", err)
+	}
+}
+
+func TestLocationJSON(t *testing.T) {
+	contract := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	data, err := json.Marshal(ContractPCLocation(contract, 0x2a))
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	expected := `{"text":"0x2a","pc":42,"contract":"0x1111111111111111111111111111111111111111"}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	var pc Location
+	if err := json.Unmarshal(data, &pc); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if pc.String() != "0x2a" || !pc.IsPC() || pc.PC != 42 || pc.Contract == nil || *pc.Contract != contract {
+		t.Errorf("Expected PC 42 in %s, got %s %+v", contract.Hex(), pc, pc)
+	}
+
+	data, err = json.Marshal(MultipleLocation())
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	if string(data) != `{"text":"multiple","multiple":true}` {
+		t.Errorf("Expected a multiple location object, got %s", data)
+	}
+
+	var multiple Location
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if multiple.String() != "multiple" || !multiple.Multiple || multiple.IsPC() || multiple.Contract != nil {
+		t.Errorf("Expected a multiple location, got %s %+v", multiple, multiple)
+	}
+}
+
+func TestLocationUnmarshalLegacyString(t *testing.T) {
+	var optimizations []Optimization
+	data := `[{"Type": "redundant_sload", "Location": "0x1a"}, {"Type": "calldata_heavy", "Location": "calldata"}]`
+	if err := json.Unmarshal([]byte(data), &optimizations); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if optimizations[0].Location != PCLocation(0x1a) {
+		t.Errorf("Expected PC 0x1a, got %+v", optimizations[0].Location)
+	}
+	if optimizations[1].Location != ScopeLocation(ScopeCalldata) {
+		t.Errorf("Expected the calldata scope, got %+v", optimizations[1].Location)
+	}
+
+	var location Location
+	if err := json.Unmarshal([]byte(`"somewhere"`), &location); err == nil {
+		t.Error("Expected an unknown location string to be rejected")
+	}
+}
@@ -0,0 +1,146 @@
+package tracer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// SnapshotReport returns the complete JSON report of the trace, including the
+// keys left out by OmitReportKeys, for RestoreReport to load later
+func (t *GasOptimizationTracer) SnapshotReport() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return json.Marshal(t.reportData())
+}
+
+// RestoreReport replaces the traced data with a report saved by SnapshotReport, so
+// that a trace can be reported again without executing it. The tracer's settings
+// are kept. The findings, gas per opcode, call tree, faults and timeline are
+// restored for the console views; the JSON report is the saved one, with the
+// findings and call tree as they are now, e.g. after ApplyFilter.
+func (t *GasOptimizationTracer) RestoreReport(data []byte) error {
+	report, err := ParseReport(data)
+	if err != nil {
+		return err
+	}
+	var saved map[string]json.RawMessage
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidReport, err)
+	}
+
+	var root *CallNode
+	if report.CallTree != nil {
+		if root, err = restoreCallNode(report.CallTree); err != nil {
+			return fmt.Errorf("%w: call tree: %v", ErrInvalidReport, err)
+		}
+	}
+	faults := make([]Fault, 0, len(report.Faults))
+	for _, fault := range report.Faults {
+		pc, ok := locationPC(fault.PC)
+		if !ok {
+			return fmt.Errorf("%w: fault pc %q", ErrInvalidReport, fault.PC)
+		}
+		faults = append(faults, Fault{
+			PC:       pc,
+			Op:       fault.Op,
+			Depth:    fault.Depth,
+			Contract: common.HexToAddress(fault.Contract),
+			Gas:      fault.Gas,
+			Cost:     fault.Cost,
+			Error:    fault.Error,
+			OutOfGas: fault.OutOfGas,
+		})
+	}
+
+	t.Reset()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.restored = saved
+	t.TotalGasUsed = report.TotalGasUsed
+	t.GasLimit = report.GasLimit
+	t.Optimizations = append(t.Optimizations, report.Optimizations...)
+	for op, gas := range report.GasByOpcode {
+		t.addOpcodeGas(op, gas)
+	}
+	for op, count := range report.OpcodeCounts {
+		t.OpcodeCounts[op] = count
+	}
+	t.CallTree = root
+	t.Faults = append(t.Faults, faults...)
+	for _, point := range report.Timeline {
+		t.Timeline = append(t.Timeline, TimelinePoint{Step: point.Step, Gas: point.Gas, Depth: point.Depth})
+	}
+	t.Partial = report.Partial
+	t.SimulatedState = report.SimulatedAgainst
+	t.IsCreation = report.IsCreation
+	if report.Deployment != nil {
+		t.CreatedAddress = common.HexToAddress(report.Deployment.ContractAddress)
+		t.InitCodeSize = report.Deployment.InitCodeSize
+		t.InitCodeGas = report.Deployment.InitCodeGas
+	}
+	return nil
+}
+
+// restoredReport returns the report loaded by RestoreReport with the current
+// findings and call tree. The caller must hold t.mu.
+func (t *GasOptimizationTracer) restoredReport() map[string]interface{} {
+	report := make(map[string]interface{}, len(t.restored))
+	for key, value := range t.restored {
+		report[key] = value
+	}
+
+	optimizations := t.sortedOptimizations()
+	report["optimizations"] = optimizations
+	t.setReportSavings(report, optimizations)
+	if t.CallTree != nil {
+		report["call_tree"] = callTreeReport(t.CallTree)
+	}
+	return report
+}
+
+// restoreCallNode converts a report call tree frame and its descendants back
+func restoreCallNode(entry *ReportCallNode) (*CallNode, error) {
+	input, err := hexutil.Decode(entry.Input)
+	if err != nil {
+		return nil, err
+	}
+	output, err := hexutil.Decode(entry.Output)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &CallNode{
+		Type:      entry.Type,
+		From:      common.HexToAddress(entry.From),
+		To:        common.HexToAddress(entry.To),
+		Input:     input,
+		Gas:       entry.Gas,
+		GasUsed:   entry.GasUsed,
+		Output:    output,
+		Error:     entry.Error,
+		Signature: entry.Signature,
+	}
+	if entry.Value != "" {
+		value, ok := new(big.Int).SetString(entry.Value, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid value %q", entry.Value)
+		}
+		node.Value = value
+	}
+
+	for _, call := range entry.Calls {
+		child, err := restoreCallNode(call)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, child)
+	}
+	return node, nil
+}
@@ -0,0 +1,113 @@
+package tracer
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// longRevertStringBytes is the message length above which a revert string is
+// flagged; longer strings no longer fit the single word Solidity packs them in
+const longRevertStringBytes = 32
+
+// errorStringSelector is the selector of Error(string), which Solidity uses to
+// encode require and revert messages
+var errorStringSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+// revertString is a REVERT whose data carries an ASCII error message
+type revertString struct {
+	pc       uint64
+	depth    int
+	contract common.Address
+	size     uint64 // Size of the revert data
+	message  string
+}
+
+// recordRevert captures the data of a REVERT when it holds a long ASCII message
+func (t *GasOptimizationTracer) recordRevert(pc uint64, depth int, scope *vm.ScopeContext) {
+	offset := scope.Stack.Back(0)
+	size := scope.Stack.Back(1)
+	data, ok := readMemory(scope.Memory, offset, size)
+	if !ok {
+		return
+	}
+
+	message, ok := revertMessage(data)
+	if !ok || len(message) <= longRevertStringBytes {
+		return
+	}
+	t.revertStrings = append(t.revertStrings, revertString{
+		pc:       pc,
+		depth:    depth,
+		contract: contractAddress(scope),
+		size:     size.Uint64(),
+		message:  message,
+	})
+}
+
+// revertMessage extracts the error message of revert data, either ABI-encoded as
+// Error(string) or as raw bytes, when it is printable ASCII
+func revertMessage(data []byte) (string, bool) {
+	message := data
+	if len(data) >= 68 && bytes.Equal(data[:4], errorStringSelector) {
+		length := new(big.Int).SetBytes(data[36:68])
+		if !length.IsUint64() || length.Uint64() > uint64(len(data)-68) {
+			return "", false
+		}
+		message = data[68 : 68+length.Uint64()]
+	} else {
+		// Raw messages are padded to whole words
+		message = bytes.TrimRight(data, "\x00")
+	}
+
+	if len(message) == 0 {
+		return "", false
+	}
+	for _, b := range message {
+		if (b < 0x20 || b > 0x7e) && b != '\n' && b != '\t' {
+			return "", false
+		}
+	}
+	return string(message), true
+}
+
+// revertSite identifies the code location of a REVERT
+type revertSite struct {
+	contract common.Address
+	pc       uint64
+}
+
+// analyzeRevertStrings suggests custom errors for each REVERT site carrying a
+// long error string. The string is stored in the bytecode and copied to memory
+// on every revert, while a custom error is a 4-byte selector.
+func (t *GasOptimizationTracer) analyzeRevertStrings() {
+	findings := make(map[revertSite]int)
+
+	for _, revert := range t.revertStrings {
+		site := revertSite{contract: revert.contract, pc: revert.pc}
+		if idx, ok := findings[site]; ok {
+			t.Optimizations[idx].Details["occurrences"] = t.Optimizations[idx].Details["occurrences"].(int) + 1
+			continue
+		}
+
+		findings[site] = len(t.Optimizations)
+		t.Optimizations = append(t.Optimizations, Optimization{
+			Type:        "long_revert_string",
+			Severity:    "low",
+			Description: "Long revert string - use a custom error instead to shrink bytecode and the revert data copied to memory",
+			Location:    ContractPCLocation(revert.contract, revert.pc),
+			GasSavings:  0,
+			Confidence:  "heuristic",
+			Details: map[string]interface{}{
+				"message":       revert.message,
+				"message_bytes": len(revert.message),
+				"revert_bytes":  revert.size,
+				"contract":      revert.contract.Hex(),
+				"depth":         revert.depth,
+				"occurrences":   1,
+			},
+		})
+	}
+}
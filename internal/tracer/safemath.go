@@ -0,0 +1,144 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	u256 "github.com/holiman/uint256"
+)
+
+// safeMathWindow is the number of steps after an arithmetic op within which its guard must complete
+const safeMathWindow = 16
+
+// Progress of a SafeMath guard match
+const (
+	guardArithmetic = iota // Arithmetic seen, waiting for the check
+	guardDivided           // Multiplication divided back by an operand, waiting for the comparison
+	guardCompared          // Result compared against an operand, waiting for the JUMPI
+)
+
+// arithmeticGuard tracks a potential SafeMath overflow check after an ADD or MUL
+type arithmeticGuard struct {
+	op       vm.OpCode
+	pc       uint64
+	depth    int
+	contract common.Address
+	a, b     u256.Int
+	result   u256.Int // Wrapped result of the arithmetic
+	expected u256.Int // Quotient the multiplication check compares against
+	stage    int
+	steps    int
+	gas      uint64 // Gas spent on the guard so far
+}
+
+// safeMathKey identifies a guard site by its contract and PC range
+type safeMathKey struct {
+	contract common.Address
+	start    uint64
+	end      uint64
+}
+
+// trackSafeMath matches the SafeMath add guard (ADD then LT/GT against an operand)
+// and mul guard (MUL, DIV back by an operand, EQ) ending in a JUMPI.
+// Operand values are compared so unrelated arithmetic does not match.
+func (t *GasOptimizationTracer) trackSafeMath(pc uint64, op vm.OpCode, cost uint64, depth int, scope *vm.ScopeContext) {
+	g := t.guard
+	if g != nil && depth == g.depth {
+		g.steps++
+		g.gas += cost
+		if g.steps > safeMathWindow {
+			t.guard = nil
+		} else if t.advanceGuard(pc, op, scope) {
+			return
+		}
+	}
+
+	switch op {
+	case vm.ADD, vm.MUL:
+		g := &arithmeticGuard{op: op, pc: pc, depth: depth, contract: contractAddress(scope)}
+		g.a.Set(scope.Stack.Back(0))
+		g.b.Set(scope.Stack.Back(1))
+		if op == vm.ADD {
+			g.result.Add(&g.a, &g.b)
+		} else {
+			g.result.Mul(&g.a, &g.b)
+		}
+		t.guard = g
+	}
+}
+
+// advanceGuard moves the pending guard match forward, reporting whether op was consumed
+func (t *GasOptimizationTracer) advanceGuard(pc uint64, op vm.OpCode, scope *vm.ScopeContext) bool {
+	g := t.guard
+
+	switch {
+	case g.op == vm.MUL && g.stage == guardArithmetic && op == vm.DIV:
+		// c / a == b: the product divided by one operand should give the other
+		num, den := scope.Stack.Back(0), scope.Stack.Back(1)
+		if !num.Eq(&g.result) {
+			return false
+		}
+		switch {
+		case den.Eq(&g.a):
+			g.expected.Set(&g.b)
+		case den.Eq(&g.b):
+			g.expected.Set(&g.a)
+		default:
+			return false
+		}
+		g.stage = guardDivided
+		return true
+
+	case g.op == vm.MUL && g.stage == guardDivided && op == vm.EQ:
+		if x, y := scope.Stack.Back(0), scope.Stack.Back(1); x.Eq(&g.expected) || y.Eq(&g.expected) {
+			g.stage = guardCompared
+		}
+		return true
+
+	case g.op == vm.ADD && g.stage == guardArithmetic && (op == vm.LT || op == vm.GT):
+		// c >= a: the sum compared against one of its operands
+		x, y := scope.Stack.Back(0), scope.Stack.Back(1)
+		if (x.Eq(&g.result) && (y.Eq(&g.a) || y.Eq(&g.b))) || (y.Eq(&g.result) && (x.Eq(&g.a) || x.Eq(&g.b))) {
+			g.stage = guardCompared
+		}
+		return true
+
+	case g.stage == guardCompared && op == vm.JUMPI:
+		t.recordSafeMath(g, pc)
+		t.guard = nil
+		return true
+	}
+	return false
+}
+
+// recordSafeMath adds or updates the safemath_overhead finding for a guard site
+func (t *GasOptimizationTracer) recordSafeMath(g *arithmeticGuard, end uint64) {
+	key := safeMathKey{contract: g.contract, start: g.pc, end: end}
+	if g.op == vm.ADD {
+		// Newer Solidity versions check additions the same way, after the ADD
+		t.recordOverflowGuard(key, g.gas)
+	}
+
+	if idx, ok := t.safeMathFindings[key]; ok {
+		details := t.Optimizations[idx].Details
+		details["executions"] = details["executions"].(int) + 1
+		details["guard_gas"] = details["guard_gas"].(uint64) + g.gas
+		return
+	}
+
+	t.safeMathFindings[key] = len(t.Optimizations)
+	t.Optimizations = append(t.Optimizations, Optimization{
+		Type:        "safemath_overhead",
+		Severity:    "low",
+		Description: "SafeMath-style overflow guard detected - Solidity 0.8+ checked arithmetic is cheaper, and unchecked blocks skip it where overflow is impossible",
+		Location:    ContractPCLocation(g.contract, g.pc),
+		GasSavings:  0,
+		Confidence:  "heuristic",
+		Details: map[string]interface{}{
+			"operation":  g.op.String(),
+			"pc_range":   formatPC(g.pc) + "-" + formatPC(end),
+			"contract":   g.contract.Hex(),
+			"executions": 1,
+			"guard_gas":  g.gas,
+		},
+	})
+}
@@ -0,0 +1,135 @@
+package tracer
+
+import (
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// maxHotPCs bounds the code locations listed in a sampled profile
+const maxHotPCs = 20
+
+// SampledProfile describes a sampled minimal trace, whose gas per opcode is an
+// estimate extrapolated from the recorded steps
+type SampledProfile struct {
+	Rate    uint64  // One step recorded out of every Rate
+	Samples uint64  // Steps recorded
+	HotPCs  []HotPC // Most sampled code locations, highest estimated gas first
+}
+
+// HotPC is a code location recorded by a sampled trace
+type HotPC struct {
+	Contract common.Address
+	PC       uint64
+	Op       string
+	Samples  uint64 // Times the location was recorded
+	Gas      uint64 // Estimated gas: the recorded cost scaled by the sample rate
+}
+
+// sampleSite identifies a code location recorded by a sampled trace
+type sampleSite struct {
+	contract common.Address
+	pc       uint64
+}
+
+// sampleHits holds the samples of one code location
+type sampleHits struct {
+	op      vm.OpCode
+	samples uint64
+	gas     uint64
+}
+
+// SetSampleRate makes minimal traces record only every rate-th step, for traces
+// too long to profile in full. GasPerOpcode is then extrapolated from the
+// recorded steps and the most sampled code locations are kept; TotalGasUsed and
+// StepCount stay exact. A rate of 0 or 1 records every step.
+func (t *GasOptimizationTracer) SetSampleRate(rate uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sampleRate = rate
+}
+
+// sampling reports whether minimal traces skip steps
+func (t *GasOptimizationTracer) sampling() bool {
+	return t.minimal && t.sampleRate > 1
+}
+
+// recordSample records a step of a sampled trace
+func (t *GasOptimizationTracer) recordSample(pc uint64, op vm.OpCode, cost uint64, scope *vm.ScopeContext) {
+	t.minimalGas[op] += cost
+	t.minimalSeen[op] = true
+	t.sampledSteps++
+
+	site := sampleSite{contract: contractAddress(scope), pc: pc}
+	hits, ok := t.samplePCs[site]
+	if !ok {
+		hits = &sampleHits{op: op}
+		t.samplePCs[site] = hits
+	}
+	hits.samples++
+	hits.gas += cost
+}
+
+// GetSampledProfile returns the profile of a sampled trace, or false when
+// every step was recorded
+func (t *GasOptimizationTracer) GetSampledProfile() (SampledProfile, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.sampling() {
+		return SampledProfile{}, false
+	}
+	return t.sampledProfile(), true
+}
+
+// sampledProfile builds the profile of a sampled trace. The caller must hold t.mu.
+func (t *GasOptimizationTracer) sampledProfile() SampledProfile {
+	profile := SampledProfile{Rate: t.sampleRate, Samples: t.sampledSteps}
+	for site, hits := range t.samplePCs {
+		profile.HotPCs = append(profile.HotPCs, HotPC{
+			Contract: site.contract,
+			PC:       site.pc,
+			Op:       hits.op.String(),
+			Samples:  hits.samples,
+			Gas:      hits.gas * t.sampleRate,
+		})
+	}
+
+	sort.Slice(profile.HotPCs, func(i, j int) bool {
+		a, b := profile.HotPCs[i], profile.HotPCs[j]
+		if a.Gas != b.Gas {
+			return a.Gas > b.Gas
+		}
+		if a.Contract != b.Contract {
+			return a.Contract.Cmp(b.Contract) < 0
+		}
+		return a.PC < b.PC
+	})
+	if len(profile.HotPCs) > maxHotPCs {
+		profile.HotPCs = profile.HotPCs[:maxHotPCs]
+	}
+	return profile
+}
+
+// sampledProfileReport converts the sampled profile for the JSON report
+func (t *GasOptimizationTracer) sampledProfileReport() map[string]interface{} {
+	profile := t.sampledProfile()
+	hot := make([]map[string]interface{}, 0, len(profile.HotPCs))
+	for _, h := range profile.HotPCs {
+		hot = append(hot, map[string]interface{}{
+			"contract":      h.Contract.Hex(),
+			"pc":            formatPC(h.PC),
+			"op":            h.Op,
+			"samples":       h.Samples,
+			"estimated_gas": h.Gas,
+		})
+	}
+	return map[string]interface{}{
+		"approximate": true,
+		"sample_rate": profile.Rate,
+		"samples":     profile.Samples,
+		"hot_pcs":     hot,
+	}
+}
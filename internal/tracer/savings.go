@@ -0,0 +1,73 @@
+package tracer
+
+// SavingsTotals sums the potential savings of optimizations. Heuristic savings are
+// too speculative to add up and are returned separately from the total.
+//
+// Findings of different types about the same storage slot, or at the same
+// location when they name no slot, overlap: redundant_sload and storage_in_loop
+// on one slot both count the same repeated reads. Such findings share credit,
+// so the total counts their savings once, as the largest of the overlapping
+// types' savings. Findings of the same type always add up. Slots are matched by
+// key alone, so overlap between contracts using the same slot errs towards a
+// smaller total.
+func SavingsTotals(optimizations []Optimization) (total, heuristic uint64) {
+	overlapping := make(map[string]map[string]uint64)
+	for _, opt := range optimizations {
+		if opt.Confidence == "heuristic" {
+			heuristic += opt.GasSavings
+			continue
+		}
+
+		key := overlapKey(opt)
+		if key == "" {
+			total += opt.GasSavings
+			continue
+		}
+		if overlapping[key] == nil {
+			overlapping[key] = make(map[string]uint64)
+		}
+		overlapping[key][opt.Type] += opt.GasSavings
+	}
+
+	for _, byType := range overlapping {
+		var shared uint64
+		for _, savings := range byType {
+			shared = max(shared, savings)
+		}
+		total += shared
+	}
+	return total, heuristic
+}
+
+// overlapKey returns the resource an optimization's savings come from: its
+// storage slot, else its code location, or "" when it names neither. Locations
+// such as "multiple" or "transaction" name no single place and never overlap.
+func overlapKey(opt Optimization) string {
+	if key, ok := opt.Details["storage_key"].(string); ok && key != "" {
+		return "slot:" + key
+	}
+	if opt.Location.IsPC() {
+		return "pc:" + opt.Location.String()
+	}
+	return ""
+}
+
+// setReportSavings sets the savings totals of the JSON report, the total capped
+// at the gas used. The caller must hold t.mu.
+func (t *GasOptimizationTracer) setReportSavings(report map[string]interface{}, optimizations []Optimization) {
+	savings, heuristic := SavingsTotals(optimizations)
+	savings, capped := CapSavings(savings, t.TotalGasUsed)
+	report["total_gas_savings"] = savings
+	report["heuristic_savings"] = heuristic
+	report["savings_capped"] = capped
+}
+
+// CapSavings caps a savings total at the gas used, since no change saves more
+// gas than was spent. It reports whether the total was capped; a zero gasUsed
+// leaves the total as is.
+func CapSavings(total, gasUsed uint64) (uint64, bool) {
+	if gasUsed > 0 && total > gasUsed {
+		return gasUsed, true
+	}
+	return total, false
+}
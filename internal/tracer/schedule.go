@@ -0,0 +1,105 @@
+package tracer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// GasSchedule maps opcode names to alternate gas costs per execution
+type GasSchedule map[string]uint64
+
+// LoadGasSchedule reads a gas schedule from a JSON object such as {"SLOAD": 50}
+func LoadGasSchedule(path string) (GasSchedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gas schedule: %w", err)
+	}
+
+	var schedule GasSchedule
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return nil, fmt.Errorf("failed to parse gas schedule %s: %w", path, err)
+	}
+
+	for op := range schedule {
+		if vm.StringToOp(op).String() != op {
+			return nil, fmt.Errorf("unknown opcode in gas schedule %s: %s", path, op)
+		}
+	}
+	return schedule, nil
+}
+
+// ScheduleComparison is the gas one opcode used under the current schedule and
+// would use under a baseline schedule
+type ScheduleComparison struct {
+	Opcode      string
+	Count       uint64
+	CurrentGas  uint64
+	BaselineGas uint64
+}
+
+// WhatIf projects the trace's gas under a baseline schedule
+type WhatIf struct {
+	Opcodes       []ScheduleComparison // Executed opcodes priced by the baseline, by opcode name
+	CurrentTotal  uint64
+	BaselineTotal uint64
+}
+
+// CompareSchedule projects the gas used under a baseline schedule as each
+// executed opcode's count times its baseline cost. Opcodes the schedule does not
+// price keep their current gas. Dynamic costs of repriced opcodes, such as cold
+// access surcharges and memory expansion, are replaced by the flat baseline cost.
+func (t *GasOptimizationTracer) CompareSchedule(schedule GasSchedule) WhatIf {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.compareSchedule(schedule)
+}
+
+func (t *GasOptimizationTracer) compareSchedule(schedule GasSchedule) WhatIf {
+	whatIf := WhatIf{CurrentTotal: t.TotalGasUsed, BaselineTotal: t.TotalGasUsed}
+	for op, cost := range schedule {
+		count := t.OpcodeCounts[op]
+		if count == 0 {
+			continue
+		}
+
+		c := ScheduleComparison{
+			Opcode:      op,
+			Count:       count,
+			CurrentGas:  t.GasPerOpcode[op],
+			BaselineGas: count * cost,
+		}
+		whatIf.Opcodes = append(whatIf.Opcodes, c)
+		whatIf.BaselineTotal = whatIf.BaselineTotal - c.CurrentGas + c.BaselineGas
+	}
+
+	sort.Slice(whatIf.Opcodes, func(i, j int) bool {
+		return whatIf.Opcodes[i].Opcode < whatIf.Opcodes[j].Opcode
+	})
+	return whatIf
+}
+
+// SetBaselineSchedule adds a what-if comparison against schedule to the report.
+// Passing nil removes it.
+func (t *GasOptimizationTracer) SetBaselineSchedule(schedule GasSchedule) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.baseline = schedule
+}
+
+// BaselineWhatIf returns the comparison against the schedule set with
+// SetBaselineSchedule, and false if none is set
+func (t *GasOptimizationTracer) BaselineWhatIf() (WhatIf, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.baseline == nil {
+		return WhatIf{}, false
+	}
+	return t.compareSchedule(t.baseline), true
+}
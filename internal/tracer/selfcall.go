@@ -0,0 +1,54 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// selfCallUsage holds the self-calls made at a call site
+type selfCallUsage struct {
+	op    string
+	calls int
+}
+
+// trackSelfCall records a CALL, CALLCODE or STATICCALL whose target is the
+// executing contract. Such calls re-enter the contract's own code and pay the
+// call overhead and ABI encoding an internal function call avoids.
+// DELEGATECALL to self runs the contract's code in its own context, as multicall
+// and batching do on purpose, so it is not counted.
+func (t *GasOptimizationTracer) trackSelfCall(pc uint64, op vm.OpCode, scope *vm.ScopeContext, to common.Address) {
+	if op == vm.DELEGATECALL || to != scope.Contract.Address() {
+		return
+	}
+
+	site := callSite{contract: contractAddress(scope), pc: pc}
+	usage, ok := t.selfCallSites[site]
+	if !ok {
+		usage = &selfCallUsage{op: op.String()}
+		t.selfCallSites[site] = usage
+		t.selfCallOrder = append(t.selfCallOrder, site)
+	}
+	usage.calls++
+}
+
+// analyzeSelfCalls reports each call site that called the executing contract
+// itself. The savings depend on how the call would be inlined, so none are
+// estimated.
+func (t *GasOptimizationTracer) analyzeSelfCalls() {
+	for _, site := range t.selfCallOrder {
+		usage := t.selfCallSites[site]
+		t.Optimizations = append(t.Optimizations, Optimization{
+			Type:        "self_call_overhead",
+			Severity:    "low",
+			Description: usage.op + " to the executing contract itself - an internal function call avoids the call overhead",
+			Location:    ContractPCLocation(site.contract, site.pc),
+			GasSavings:  0,
+			Confidence:  "heuristic",
+			Details: map[string]interface{}{
+				"call_type": usage.op,
+				"to":        site.contract.Hex(),
+				"calls":     usage.calls,
+			},
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package tracer
+
+// SignatureResolver maps 4-byte function selectors to human-readable signatures
+type SignatureResolver interface {
+	// ResolveSelector returns the signature for a selector and whether it was
+	// verified against a known ABI rather than guessed
+	ResolveSelector(selector [4]byte) (signature string, verified bool, ok bool)
+}
+
+// ResolveSignatures attaches function signatures to recorded calls and call tree
+// frames that carry a selector
+func (t *GasOptimizationTracer) ResolveSignatures(resolver SignatureResolver) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range t.CallOps {
+		call := &t.CallOps[i]
+		if !call.HasSelector {
+			continue
+		}
+		if signature, verified, ok := resolver.ResolveSelector(call.Selector); ok {
+			call.Signature = signature
+			call.SignatureVerified = verified
+		}
+	}
+
+	if t.CallTree != nil {
+		resolveNode(t.CallTree, resolver)
+	}
+}
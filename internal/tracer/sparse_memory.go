@@ -0,0 +1,71 @@
+package tracer
+
+import (
+	"math"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// sparseMemoryGap is how far past the memory high-water mark, in bytes, an access
+// must land to be reported as sparse
+const sparseMemoryGap = 1024
+
+// maxMemorySize is the largest memory size the EVM can price, as bounded by geth
+const maxMemorySize = 0x1FFFFFFFE0
+
+// memoryGas returns the total expansion cost of a memory of the given number of words
+func memoryGas(words uint64) uint64 {
+	return words*params.MemoryGas + words*words/params.QuadCoeffDiv
+}
+
+// memoryOffset returns the offset operand of a memory access, saturated at the maximum uint64
+func memoryOffset(scope *vm.ScopeContext) uint64 {
+	offset := scope.Stack.Back(0)
+	if offset == nil {
+		return 0
+	}
+	if !offset.IsUint64() {
+		return math.MaxUint64
+	}
+	return offset.Uint64()
+}
+
+// trackSparseMemory flags an MLOAD, MSTORE or MSTORE8 whose offset lies far beyond
+// the memory used so far. The expansion up to the offset is paid for in full, while
+// placing the value at the end of memory would only grow it by one word.
+func (t *GasOptimizationTracer) trackSparseMemory(pc uint64, op vm.OpCode, scope *vm.ScopeContext) {
+	offset := memoryOffset(scope)
+	if offset > maxMemorySize {
+		return
+	}
+
+	used := uint64(len(scope.Memory.Data()))
+	if offset < used+sparseMemoryGap {
+		return
+	}
+
+	size := uint64(wordSize)
+	if op == vm.MSTORE8 {
+		size = 1
+	}
+	usedWords := used / wordSize
+	expandedWords := (offset + size + wordSize - 1) / wordSize
+	expansionGas := memoryGas(expandedWords) - memoryGas(usedWords)
+	wasted := memoryGas(expandedWords) - memoryGas(usedWords+1)
+
+	t.Optimizations = append(t.Optimizations, Optimization{
+		Type:        "sparse_memory_access",
+		Severity:    "medium",
+		Description: op.String() + " far beyond the memory in use forces a large expansion for a small access - place the value at the free memory pointer",
+		Location:    ContractPCLocation(contractAddress(scope), pc),
+		GasSavings:  wasted,
+		Confidence:  "estimated",
+		Details: map[string]interface{}{
+			"offset":        offset,
+			"memory_size":   used,
+			"expanded_size": expandedWords * wordSize,
+			"expansion_gas": expansionGas,
+		},
+	})
+}
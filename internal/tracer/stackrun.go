@@ -0,0 +1,124 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// minStackRun is the number of consecutive DUP/SWAP instructions long enough to
+// suggest poor stack scheduling
+const minStackRun = 8
+
+// minStackRunRepeats is how often a long run must execute to be reported
+const minStackRunRepeats = 2
+
+// stackRunSite identifies a run of consecutive DUP/SWAP instructions in a contract's code
+type stackRunSite struct {
+	contract common.Address
+	startPC  uint64
+	endPC    uint64
+}
+
+// stackRun is the run of DUP/SWAP instructions currently executing
+type stackRun struct {
+	site   stackRunSite
+	depth  int
+	length int // Instructions in the run, 0 when no run is executing
+	gas    uint64
+}
+
+// stackRunUsage holds the executions of a long run
+type stackRunUsage struct {
+	executions int
+	length     int
+	gas        uint64
+}
+
+// isStackShuffle reports whether op is a DUP or SWAP instruction
+func isStackShuffle(op vm.OpCode) bool {
+	return op >= vm.DUP1 && op <= vm.SWAP16
+}
+
+// trackStackRun extends the current run of DUP/SWAP instructions with the step,
+// or ends it and starts a new one. DUP and SWAP are one byte long, so a run
+// continues while steps at the same depth execute consecutive PCs.
+func (t *GasOptimizationTracer) trackStackRun(pc uint64, op vm.OpCode, cost uint64, depth int, scope *vm.ScopeContext) {
+	run := &t.stackRun
+	if isStackShuffle(op) && run.length > 0 && run.depth == depth && pc == run.site.endPC+1 {
+		run.site.endPC = pc
+		run.length++
+		run.gas += cost
+		return
+	}
+
+	t.endStackRun()
+	if isStackShuffle(op) {
+		*run = stackRun{
+			site:   stackRunSite{contract: contractAddress(scope), startPC: pc, endPC: pc},
+			depth:  depth,
+			length: 1,
+			gas:    cost,
+		}
+	}
+}
+
+// endStackRun records the current run when it is long enough
+func (t *GasOptimizationTracer) endStackRun() {
+	run := t.stackRun
+	t.stackRun = stackRun{}
+	if run.length < minStackRun {
+		return
+	}
+
+	usage, ok := t.stackRunSites[run.site]
+	if !ok {
+		usage = &stackRunUsage{length: run.length}
+		t.stackRunSites[run.site] = usage
+		t.stackRunOrder = append(t.stackRunOrder, run.site)
+	}
+	usage.executions++
+	usage.gas += run.gas
+}
+
+// analyzeStackThrashing flags long runs of DUP/SWAP instructions executed
+// repeatedly, which point at poor stack scheduling by the compiler. How much
+// a better schedule would save cannot be told from the trace.
+func (t *GasOptimizationTracer) analyzeStackThrashing() {
+	t.endStackRun()
+
+	for _, site := range t.stackRunOrder {
+		usage := t.stackRunSites[site]
+		if usage.executions < minStackRunRepeats {
+			continue
+		}
+
+		inLoop := false
+		for _, loop := range t.Loops {
+			if loop.Contract == site.contract && site.startPC >= loop.StartPC && site.endPC <= loop.EndPC {
+				inLoop = true
+				break
+			}
+		}
+
+		description := "Long run of DUP/SWAP instructions executed repeatedly - poor stack scheduling; fewer live variables or an optimizer run (via-IR) may shorten it"
+		if inLoop {
+			description = "Long run of DUP/SWAP instructions inside a loop - poor stack scheduling; fewer live variables or an optimizer run (via-IR) may shorten it"
+		}
+
+		t.Optimizations = append(t.Optimizations, Optimization{
+			Type:        "stack_thrashing",
+			Severity:    "low",
+			Description: description,
+			Location:    ContractPCLocation(site.contract, site.startPC),
+			GasSavings:  0,
+			Confidence:  "heuristic",
+			Details: map[string]interface{}{
+				"pc_range":   formatPC(site.startPC) + "-" + formatPC(site.endPC),
+				"opcodes":    usage.length,
+				"executions": usage.executions,
+				"gas_spent":  usage.gas,
+				"in_loop":    inLoop,
+			},
+		})
+	}
+}
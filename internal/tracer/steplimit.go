@@ -0,0 +1,28 @@
+package tracer
+
+// SetMaxSteps aborts execution once n steps have been traced, marking the trace
+// as truncated; zero disables the limit. Call it before tracing.
+func (t *GasOptimizationTracer) SetMaxSteps(n uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.maxSteps = n
+}
+
+// Truncated reports whether execution was aborted by the step limit
+func (t *GasOptimizationTracer) Truncated() bool {
+	return t.truncated.Load()
+}
+
+// stepLimitReached reports whether the step limit has been reached, cancelling
+// the EVM the first time. The interpreter stops at the next jump, so steps
+// logged until then are ignored.
+func (t *GasOptimizationTracer) stepLimitReached() bool {
+	if t.maxSteps == 0 || t.steps.Load() < t.maxSteps {
+		return false
+	}
+	if !t.truncated.Swap(true) && t.env != nil {
+		t.env.Cancel()
+	}
+	return true
+}
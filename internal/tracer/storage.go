@@ -0,0 +1,69 @@
+package tracer
+
+import (
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// WriteOnlySlot is a storage slot written during the trace but never read
+type WriteOnlySlot struct {
+	Contract common.Address
+	Key      common.Hash
+	Writes   int
+	first    int    // Number of storage accesses before the first write
+	firstPC  uint64 // PC of the first write
+}
+
+// analyzeWriteOnlyStorage flags slots that are written but never read within the trace.
+// They may still be read by later transactions, so the finding is informational.
+func (t *GasOptimizationTracer) analyzeWriteOnlyStorage() {
+	slots := make(map[storageSlot]*WriteOnlySlot)
+	read := make(map[storageSlot]bool)
+
+	for site, usage := range t.storageSites {
+		key := storageSlot{site.contract, site.key}
+		if site.op == "SLOAD" {
+			read[key] = true
+			continue
+		}
+
+		slot, ok := slots[key]
+		if !ok {
+			slot = &WriteOnlySlot{Contract: site.contract, Key: site.key, first: usage.first, firstPC: site.pc}
+			slots[key] = slot
+		}
+		slot.Writes += usage.accesses
+		if usage.first < slot.first {
+			slot.first, slot.firstPC = usage.first, site.pc
+		}
+	}
+
+	t.WriteOnlySlots = t.WriteOnlySlots[:0]
+	for key, slot := range slots {
+		if !read[key] {
+			t.WriteOnlySlots = append(t.WriteOnlySlots, *slot)
+		}
+	}
+	if len(t.WriteOnlySlots) == 0 {
+		return
+	}
+
+	sort.Slice(t.WriteOnlySlots, func(i, j int) bool {
+		return t.WriteOnlySlots[i].first < t.WriteOnlySlots[j].first
+	})
+
+	first := t.WriteOnlySlots[0]
+	t.Optimizations = append(t.Optimizations, Optimization{
+		Type:        "write_only_storage",
+		Severity:    "low",
+		Description: "Storage written but never read in this transaction - consider omitting or deferring the write (it may still be read by future transactions)",
+		Location:    ContractPCLocation(first.Contract, first.firstPC),
+		GasSavings:  0,
+		Confidence:  "heuristic",
+		Details: map[string]interface{}{
+			"slot_count": len(t.WriteOnlySlots),
+			"first_slot": first.Key.Hex(),
+		},
+	})
+}
@@ -0,0 +1,92 @@
+package tracer
+
+// DefaultTimelinePoints is the default bound on the number of timeline points kept
+const DefaultTimelinePoints = 1000
+
+// minTimelinePoints is the smallest usable bound: the first and the last point
+const minTimelinePoints = 2
+
+// TimelinePoint is a sample of the cumulative gas consumed during execution
+type TimelinePoint struct {
+	Step  uint64 // Index of the step sampled, counting from 0
+	Gas   uint64 // Gas consumed before the step, including completed subcalls
+	Depth int    // Call depth of the step, 1 for the transaction's target
+}
+
+// SetTimeline records a timeline of cumulative gas against step index, keeping
+// at most maxPoints points. Steps are sampled at a stride that doubles, halving
+// the points kept, whenever the bound is reached. Zero disables the timeline.
+func (t *GasOptimizationTracer) SetTimeline(maxPoints int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if maxPoints > 0 && maxPoints < minTimelinePoints {
+		maxPoints = minTimelinePoints
+	}
+	t.timelineMax = maxPoints
+	t.timelineStride = 1
+}
+
+// GetTimeline returns a copy of the timeline points in step order
+func (t *GasOptimizationTracer) GetTimeline() []TimelinePoint {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]TimelinePoint(nil), t.Timeline...)
+}
+
+// recordTimeline samples the gas consumed before the given step
+func (t *GasOptimizationTracer) recordTimeline(step uint64, depth int) {
+	if t.timelineMax == 0 || step%t.timelineStride != 0 {
+		return
+	}
+
+	t.appendTimeline(TimelinePoint{Step: step, Gas: t.consumedGas(), Depth: depth})
+	if len(t.Timeline) >= t.timelineMax {
+		// Keep the points on the doubled stride, including the first
+		kept := t.Timeline[:0]
+		for i, point := range t.Timeline {
+			if i%2 == 0 {
+				kept = append(kept, point)
+			}
+		}
+		t.Timeline = kept
+		t.timelineStride *= 2
+	}
+}
+
+// appendTimeline adds a point, never letting the cumulative gas decrease
+func (t *GasOptimizationTracer) appendTimeline(point TimelinePoint) {
+	if n := len(t.Timeline); n > 0 && point.Gas < t.Timeline[n-1].Gas {
+		point.Gas = t.Timeline[n-1].Gas
+	}
+	t.Timeline = append(t.Timeline, point)
+}
+
+// consumedGas returns the gas consumed so far. The running total counts the
+// full allowance of each open call frame at its opening step, which the frame
+// has not necessarily used yet.
+func (t *GasOptimizationTracer) consumedGas() uint64 {
+	gas := t.TotalGasUsed
+	for _, frame := range t.frames {
+		charged := frame.chargedAllowance()
+		if gas < charged {
+			return 0
+		}
+		gas -= charged
+	}
+	return gas
+}
+
+// timelineReport converts the timeline for the JSON report
+func (t *GasOptimizationTracer) timelineReport() []map[string]interface{} {
+	points := make([]map[string]interface{}, 0, len(t.Timeline))
+	for _, point := range t.Timeline {
+		points = append(points, map[string]interface{}{
+			"step":  point.Step,
+			"gas":   point.Gas,
+			"depth": point.Depth,
+		})
+	}
+	return points
+}
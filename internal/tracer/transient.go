@@ -0,0 +1,87 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// storageSlot identifies a storage slot of a contract
+type storageSlot struct {
+	contract common.Address
+	key      common.Hash
+}
+
+// slotHistory follows the values written to a storage slot during the trace
+type slotHistory struct {
+	original common.Hash // Committed value before the transaction
+	last     common.Hash // Most recently written value
+	writes   int
+	gas      uint64 // Gas charged by the SSTOREs to the slot
+	pc       uint64 // PC of the first write
+}
+
+// trackSlotWrite records the value written by an SSTORE so that slots restored
+// to their original value by the end of the transaction can be recognized
+func (t *GasOptimizationTracer) trackSlotWrite(pc, cost uint64, scope *vm.ScopeContext) {
+	if t.env == nil {
+		return
+	}
+
+	key := scope.Stack.Back(0)
+	value := scope.Stack.Back(1)
+	if key == nil || value == nil {
+		return
+	}
+
+	slot := storageSlot{contract: contractAddress(scope), key: common.BytesToHash(key.Bytes())}
+	history, ok := t.slotWrites[slot]
+	if !ok {
+		history = &slotHistory{original: t.env.StateDB.GetCommittedState(slot.contract, slot.key), pc: pc}
+		t.slotWrites[slot] = history
+		t.slotWriteOrder = append(t.slotWriteOrder, slot)
+	}
+	history.last = common.BytesToHash(value.Bytes())
+	history.writes++
+	history.gas += cost
+}
+
+// analyzeTransientUse flags storage slots that are changed and then restored to
+// their original value within the transaction, such as reentrancy locks. Such
+// values never outlive the transaction and fit EIP-1153 transient storage.
+func (t *GasOptimizationTracer) analyzeTransientUse() {
+	for _, slot := range t.slotWriteOrder {
+		history := t.slotWrites[slot]
+		if history.writes < 2 || history.last != history.original {
+			continue
+		}
+
+		// Restoring the original value refunds most of the first write (EIP-3529)
+		model := t.gasModel
+		refund := model.SstoreResetGas - model.ColdSloadGas - model.SloadGas
+		if history.original == (common.Hash{}) {
+			refund = model.SstoreSetGas - model.SloadGas
+		}
+		transientGas := params.WarmStorageReadCostEIP2929 * uint64(history.writes)
+
+		var savings uint64
+		if history.gas > refund+transientGas {
+			savings = history.gas - refund - transientGas
+		}
+
+		t.Optimizations = append(t.Optimizations, Optimization{
+			Type:        "use_transient_storage",
+			Severity:    "low",
+			Description: "Storage slot restored to its original value within the transaction - use TSTORE/TLOAD (EIP-1153)",
+			Location:    ContractPCLocation(slot.contract, history.pc),
+			GasSavings:  savings,
+			Confidence:  "estimated",
+			Details: map[string]interface{}{
+				"contract":    slot.contract.Hex(),
+				"storage_key": slot.key.Hex(),
+				"writes":      history.writes,
+				"sstore_gas":  history.gas,
+			},
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package tracer
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	u256 "github.com/holiman/uint256"
+)
+
+// maxUint256 is the largest uint256, the bound checked before an increment
+var maxUint256 = new(u256.Int).SetAllOne()
+
+// overflowCheck tracks a comparison that may be a Solidity 0.8 overflow check
+// guarding a following ADD (gt(x, not(y)) or eq(x, max) before add)
+type overflowCheck struct {
+	pc       uint64
+	end      uint64 // PC of the JUMPI acting on the comparison
+	depth    int
+	contract common.Address
+	x, y     u256.Int
+	jumped   bool
+	steps    int
+	gas      uint64 // Gas spent on the check
+}
+
+// overflowGuard is an overflow check site and how often it ran
+type overflowGuard struct {
+	key        safeMathKey
+	executions int
+	gas        uint64
+}
+
+// trackOverflowCheck matches the overflow check Solidity 0.8 emits before checked
+// additions: a comparison of an operand against the largest value the other
+// operand can be added to, then a JUMPI, then the ADD itself
+func (t *GasOptimizationTracer) trackOverflowCheck(pc uint64, op vm.OpCode, cost uint64, depth int, scope *vm.ScopeContext) {
+	c := t.overflowCheck
+	if c != nil && depth == c.depth {
+		c.steps++
+		if c.steps > safeMathWindow {
+			t.overflowCheck, c = nil, nil
+		}
+	}
+
+	switch op {
+	case vm.EQ, vm.GT, vm.LT:
+		c = &overflowCheck{pc: pc, depth: depth, contract: contractAddress(scope), gas: cost}
+		c.x.Set(scope.Stack.Back(0))
+		c.y.Set(scope.Stack.Back(1))
+		t.overflowCheck = c
+
+	case vm.JUMPI:
+		if c != nil && depth == c.depth && !c.jumped {
+			c.jumped = true
+			c.end = pc
+			c.gas += cost
+		}
+
+	case vm.ADD:
+		if c != nil && depth == c.depth && c.jumped && c.guards(scope.Stack.Back(0), scope.Stack.Back(1)) {
+			t.recordOverflowGuard(safeMathKey{contract: c.contract, start: c.pc, end: c.end}, c.gas)
+		}
+		t.overflowCheck = nil
+
+	default:
+		if c != nil && depth == c.depth && !c.jumped {
+			c.gas += cost
+		}
+	}
+}
+
+// guards reports whether the comparison checks that a + b cannot overflow
+func (c *overflowCheck) guards(a, b *u256.Int) bool {
+	return c.bounds(a, b) || c.bounds(b, a)
+}
+
+// bounds reports whether the comparison tests value against the limit for adding addend
+func (c *overflowCheck) bounds(value, addend *u256.Int) bool {
+	var limit u256.Int
+	limit.Not(addend)
+
+	for _, pair := range [2][2]*u256.Int{{&c.x, &c.y}, {&c.y, &c.x}} {
+		operand, bound := pair[0], pair[1]
+		if !operand.Eq(value) {
+			continue
+		}
+		if bound.Eq(&limit) || (addend.IsUint64() && addend.Uint64() == 1 && bound.Eq(maxUint256)) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordOverflowGuard counts an execution of the overflow guard at key
+func (t *GasOptimizationTracer) recordOverflowGuard(key safeMathKey, gas uint64) {
+	if idx, ok := t.overflowGuardIndex[key]; ok {
+		t.overflowGuards[idx].executions++
+		t.overflowGuards[idx].gas += gas
+		return
+	}
+
+	t.overflowGuardIndex[key] = len(t.overflowGuards)
+	t.overflowGuards = append(t.overflowGuards, overflowGuard{key: key, executions: 1, gas: gas})
+}
+
+// innermostLoop returns the smallest detected loop of contract spanning start-end, or nil
+func (t *GasOptimizationTracer) innermostLoop(contract common.Address, start, end uint64) *LoopDetection {
+	var inner *LoopDetection
+	for i := range t.Loops {
+		loop := &t.Loops[i]
+		if loop.Contract != contract || loop.Iterations < 2 || start < loop.StartPC || end > loop.EndPC {
+			continue
+		}
+		if inner == nil || loop.EndPC-loop.StartPC < inner.EndPC-inner.StartPC {
+			inner = loop
+		}
+	}
+	return inner
+}
+
+// analyzeUnchecked suggests unchecked blocks for addition overflow guards that run
+// on every iteration of a detected loop, as loop counters bounded by the loop
+// condition cannot overflow. Whether the bound holds needs the source, so the
+// savings are heuristic.
+func (t *GasOptimizationTracer) analyzeUnchecked() {
+	for _, guard := range t.overflowGuards {
+		if guard.executions < 2 {
+			continue
+		}
+		loop := t.innermostLoop(guard.key.contract, guard.key.start, guard.key.end)
+		if loop == nil {
+			continue
+		}
+
+		t.Optimizations = append(t.Optimizations, Optimization{
+			Type:        "use_unchecked",
+			Severity:    "low",
+			Description: "Overflow check runs on every loop iteration - wrap arithmetic that cannot overflow, such as a bounded loop counter, in an unchecked block",
+			Location:    ContractPCLocation(guard.key.contract, guard.key.start),
+			GasSavings:  guard.gas,
+			Confidence:  "heuristic",
+			Details: map[string]interface{}{
+				"guard_range": formatPC(guard.key.start) + "-" + formatPC(guard.key.end),
+				"loop_range":  formatPC(loop.StartPC) + "-" + formatPC(loop.EndPC),
+				"contract":    guard.key.contract.Hex(),
+				"executions":  guard.executions,
+				"guard_gas":   guard.gas,
+			},
+		})
+	}
+}
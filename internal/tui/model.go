@@ -0,0 +1,325 @@
+// Package tui implements an interactive terminal viewer for trace results
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+)
+
+// Panel identifies one of the viewer's panels
+type Panel int
+
+const (
+	PanelOptimizations Panel = iota // Optimization list with details of the selection
+	PanelGas                        // Gas used per opcode
+	PanelCalls                      // Call tree
+	panelCount
+)
+
+// panelTitles are the tab labels of each panel
+var panelTitles = [panelCount]string{"Optimizations", "Gas by opcode", "Call tree"}
+
+// severityFilters are the severity filters cycled through with the filter key, "" showing all
+var severityFilters = []string{"", "high", "medium", "low"}
+
+// defaultHeight is the number of list rows shown before the terminal size is known
+const defaultHeight = 15
+
+// OpcodeGas is the gas used by one opcode
+type OpcodeGas struct {
+	Opcode string
+	Gas    uint64
+}
+
+// CallRow is one frame of the call tree as listed by the viewer
+type CallRow struct {
+	Call  *tracer.CallNode
+	Depth int // Nesting depth, 0 for the top-level frame
+}
+
+// Model holds the viewer's data and navigation state. It does not depend on a
+// terminal library so it can be driven and rendered in tests.
+type Model struct {
+	optimizations []tracer.Optimization
+	gasByOpcode   []OpcodeGas
+	calls         []CallRow
+	totalGas      uint64
+
+	panel  Panel
+	filter int             // Index into severityFilters
+	cursor [panelCount]int // Selected row of each panel
+	height int             // Number of list rows shown at once
+}
+
+// NewModel creates a viewer model from a finished trace
+func NewModel(tr *tracer.GasOptimizationTracer) Model {
+	gasPerOpcode := tr.GetGasPerOpcode()
+	gasByOpcode := make([]OpcodeGas, 0, len(gasPerOpcode))
+	for op, gas := range gasPerOpcode {
+		gasByOpcode = append(gasByOpcode, OpcodeGas{Opcode: op, Gas: gas})
+	}
+	sort.Slice(gasByOpcode, func(i, j int) bool {
+		if gasByOpcode[i].Gas != gasByOpcode[j].Gas {
+			return gasByOpcode[i].Gas > gasByOpcode[j].Gas
+		}
+		return gasByOpcode[i].Opcode < gasByOpcode[j].Opcode
+	})
+
+	return Model{
+		optimizations: tr.GetOptimizations(),
+		gasByOpcode:   gasByOpcode,
+		calls:         flattenCalls(tr.GetCallTree(), 0, nil),
+		totalGas:      tr.GetStats().TotalGasUsed,
+		height:        defaultHeight,
+	}
+}
+
+// flattenCalls lists node and its descendants in execution order
+func flattenCalls(node *tracer.CallNode, depth int, rows []CallRow) []CallRow {
+	if node == nil {
+		return rows
+	}
+	rows = append(rows, CallRow{Call: node, Depth: depth})
+	for _, child := range node.Children {
+		rows = flattenCalls(child, depth+1, rows)
+	}
+	return rows
+}
+
+// Panel returns the panel currently shown
+func (m Model) Panel() Panel {
+	return m.panel
+}
+
+// Filter returns the selected severity filter, "" when all severities are shown
+func (m Model) Filter() string {
+	return severityFilters[m.filter]
+}
+
+// Visible returns the optimizations matching the severity filter
+func (m Model) Visible() []tracer.Optimization {
+	severity := m.Filter()
+	if severity == "" {
+		return m.optimizations
+	}
+
+	var visible []tracer.Optimization
+	for _, opt := range m.optimizations {
+		if opt.Severity == severity {
+			visible = append(visible, opt)
+		}
+	}
+	return visible
+}
+
+// GasByOpcode returns the gas used per opcode, most expensive first
+func (m Model) GasByOpcode() []OpcodeGas {
+	return m.gasByOpcode
+}
+
+// Calls returns the call tree frames in execution order
+func (m Model) Calls() []CallRow {
+	return m.calls
+}
+
+// Selected returns the selected optimization, if any is visible
+func (m Model) Selected() (tracer.Optimization, bool) {
+	visible := m.Visible()
+	cursor := m.cursor[PanelOptimizations]
+	if cursor >= len(visible) {
+		return tracer.Optimization{}, false
+	}
+	return visible[cursor], true
+}
+
+// SetHeight sets the number of terminal rows available to the viewer
+func (m *Model) SetHeight(rows int) {
+	// Leave room for the header, the filter line and the details below the list
+	m.height = max(rows/2, 3)
+}
+
+// HandleKey applies a key press and reports whether the viewer should quit
+func (m *Model) HandleKey(key string) bool {
+	switch key {
+	case "q", "ctrl+c", "esc":
+		return true
+	case "tab", "right", "l":
+		m.panel = (m.panel + 1) % panelCount
+	case "shift+tab", "left", "h":
+		m.panel = (m.panel + panelCount - 1) % panelCount
+	case "up", "k":
+		if m.cursor[m.panel] > 0 {
+			m.cursor[m.panel]--
+		}
+	case "down", "j":
+		if m.cursor[m.panel] < m.rows()-1 {
+			m.cursor[m.panel]++
+		}
+	case "home", "g":
+		m.cursor[m.panel] = 0
+	case "end", "G":
+		m.cursor[m.panel] = max(m.rows()-1, 0)
+	case "f":
+		m.filter = (m.filter + 1) % len(severityFilters)
+		m.cursor[PanelOptimizations] = 0
+	}
+	return false
+}
+
+// rows returns the number of rows in the current panel
+func (m Model) rows() int {
+	switch m.panel {
+	case PanelGas:
+		return len(m.gasByOpcode)
+	case PanelCalls:
+		return len(m.calls)
+	default:
+		return len(m.Visible())
+	}
+}
+
+// Render draws the current panel
+func (m Model) Render() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "EVM TRACER - %d gas used, %d optimizations\n", m.totalGas, len(m.optimizations))
+	for p := Panel(0); p < panelCount; p++ {
+		if p == m.panel {
+			fmt.Fprintf(&b, "[%s]  ", panelTitles[p])
+		} else {
+			fmt.Fprintf(&b, " %s   ", panelTitles[p])
+		}
+	}
+	b.WriteString("\n\n")
+
+	switch m.panel {
+	case PanelGas:
+		m.renderGas(&b)
+	case PanelCalls:
+		m.renderCalls(&b)
+	default:
+		m.renderOptimizations(&b)
+	}
+
+	b.WriteString("\ntab: switch panel  ↑/↓: move  f: filter severity  q: quit\n")
+	return b.String()
+}
+
+// renderOptimizations draws the optimization list and the selection's details
+func (m Model) renderOptimizations(b *strings.Builder) {
+	visible := m.Visible()
+	filter := m.Filter()
+	if filter == "" {
+		filter = "all"
+	}
+	fmt.Fprintf(b, "Severity: %s (%d shown)\n", filter, len(visible))
+
+	if len(visible) == 0 {
+		b.WriteString("  No optimizations found\n")
+		return
+	}
+
+	cursor := m.cursor[PanelOptimizations]
+	start, end := m.window(cursor, len(visible))
+	for i := start; i < end; i++ {
+		opt := visible[i]
+		fmt.Fprintf(b, "%s %-6s  %-28s %-10s %d gas\n",
+			marker(i == cursor), strings.ToUpper(opt.Severity), opt.Type, opt.Location, opt.GasSavings)
+	}
+
+	opt := visible[cursor]
+	fmt.Fprintf(b, "\n%s\n", opt.Type)
+	fmt.Fprintf(b, "  Description: %s\n", opt.Description)
+	fmt.Fprintf(b, "  Location: %s\n", opt.Location)
+	fmt.Fprintf(b, "  Potential Savings: %d gas\n", opt.GasSavings)
+	if opt.Confidence != "" {
+		fmt.Fprintf(b, "  Confidence: %s\n", opt.Confidence)
+	}
+
+	keys := make([]string, 0, len(opt.Details))
+	for key := range opt.Details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := fmt.Sprintf("%v", opt.Details[key])
+		if strings.Contains(value, "\n") {
+			fmt.Fprintf(b, "  %s:\n      %s\n", key, strings.ReplaceAll(value, "\n", "\n      "))
+			continue
+		}
+		fmt.Fprintf(b, "  %s: %s\n", key, value)
+	}
+}
+
+// renderGas draws the gas used per opcode
+func (m Model) renderGas(b *strings.Builder) {
+	if len(m.gasByOpcode) == 0 {
+		b.WriteString("  No opcodes executed\n")
+		return
+	}
+
+	cursor := m.cursor[PanelGas]
+	start, end := m.window(cursor, len(m.gasByOpcode))
+	for i := start; i < end; i++ {
+		entry := m.gasByOpcode[i]
+		percentage := 0.0
+		if m.totalGas > 0 {
+			percentage = float64(entry.Gas) / float64(m.totalGas) * 100
+		}
+		fmt.Fprintf(b, "%s %-16s %10d gas  %5.1f%%\n", marker(i == cursor), entry.Opcode, entry.Gas, percentage)
+	}
+}
+
+// renderCalls draws the call tree, indenting each frame by its depth
+func (m Model) renderCalls(b *strings.Builder) {
+	if len(m.calls) == 0 {
+		b.WriteString("  No calls recorded\n")
+		return
+	}
+
+	cursor := m.cursor[PanelCalls]
+	start, end := m.window(cursor, len(m.calls))
+	for i := start; i < end; i++ {
+		row := m.calls[i]
+		status := ""
+		if row.Call.Reverted() {
+			status = "  (reverted)"
+		}
+		fmt.Fprintf(b, "%s %s%s %s  %d/%d gas%s\n", marker(i == cursor),
+			strings.Repeat("  ", row.Depth), row.Call.Type, row.Call.To.Hex(), row.Call.GasUsed, row.Call.Gas, status)
+	}
+
+	row := m.calls[cursor]
+	call := row.Call
+	fmt.Fprintf(b, "\n%s at depth %d\n", call.Type, row.Depth)
+	fmt.Fprintf(b, "  From: %s\n", call.From.Hex())
+	fmt.Fprintf(b, "  To: %s\n", call.To.Hex())
+	fmt.Fprintf(b, "  Gas: %d used of %d available\n", call.GasUsed, call.Gas)
+	if call.Value != nil && call.Value.Sign() > 0 {
+		fmt.Fprintf(b, "  Value: %s wei\n", call.Value)
+	}
+	fmt.Fprintf(b, "  Input: %d bytes\n", len(call.Input))
+	if call.Error != "" {
+		fmt.Fprintf(b, "  Error: %s\n", call.Error)
+	}
+}
+
+// window returns the range of rows to draw so the cursor stays visible
+func (m Model) window(cursor, rows int) (int, int) {
+	start := 0
+	if cursor >= m.height {
+		start = cursor - m.height + 1
+	}
+	return start, min(start+m.height, rows)
+}
+
+// marker returns the selection marker of a row
+func marker(selected bool) string {
+	if selected {
+		return ">"
+	}
+	return " "
+}
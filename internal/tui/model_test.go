@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/devlongs/evm-tracer/internal/tracer"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+)
+
+// tracedModel traces code that reads a slot repeatedly and calls a reverting
+// contract, then builds a viewer model from the result
+func tracedModel(t *testing.T) Model {
+	t.Helper()
+
+	callee := common.HexToAddress("0x00000000000000000000000000000000000000c1")
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	statedb.SetCode(callee, []byte{byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.REVERT)})
+
+	var code []byte
+	for i := 0; i < 3; i++ {
+		code = append(code, byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP))
+	}
+	code = append(code,
+		byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00,
+		byte(vm.PUSH1), 0x00, byte(vm.PUSH20))
+	code = append(code, callee.Bytes()...)
+	code = append(code, byte(vm.PUSH2), 0xff, 0xff, byte(vm.CALL), byte(vm.POP), byte(vm.STOP))
+
+	tr := tracer.NewGasOptimizationTracer()
+	runtime.Execute(code, nil, &runtime.Config{
+		State:     statedb,
+		GasLimit:  1000000,
+		EVMConfig: vm.Config{Tracer: tr},
+	})
+	return NewModel(tr)
+}
+
+func TestNewModelPopulated(t *testing.T) {
+	m := tracedModel(t)
+
+	if len(m.Visible()) == 0 {
+		t.Fatal("Expected optimizations to be listed")
+	}
+
+	gas := m.GasByOpcode()
+	if len(gas) == 0 {
+		t.Fatal("Expected gas per opcode to be listed")
+	}
+	for i := 1; i < len(gas); i++ {
+		if gas[i].Gas > gas[i-1].Gas {
+			t.Errorf("Expected opcodes ordered by gas, got %v", gas)
+		}
+	}
+
+	calls := m.Calls()
+	if len(calls) != 2 || calls[1].Depth != 1 || !calls[1].Call.Reverted() {
+		t.Fatalf("Expected a top-level frame and one reverted subcall, got %+v", calls)
+	}
+
+	selected, ok := m.Selected()
+	if !ok {
+		t.Fatal("Expected an optimization to be selected")
+	}
+
+	view := m.Render()
+	if !strings.Contains(view, selected.Type) || !strings.Contains(view, selected.Description) {
+		t.Errorf("Expected the view to show the selection's details, got:\n%s", view)
+	}
+}
+
+func TestModelNavigation(t *testing.T) {
+	m := tracedModel(t)
+
+	if len(m.Visible()) > 1 {
+		m.HandleKey("down")
+		if selected, _ := m.Selected(); selected.Type != m.Visible()[1].Type {
+			t.Errorf("Expected moving down to select %s, got %s", m.Visible()[1].Type, selected.Type)
+		}
+	}
+
+	m.HandleKey("f")
+	if m.Filter() != "high" {
+		t.Fatalf("Expected the first filter to be high, got '%s'", m.Filter())
+	}
+	for _, opt := range m.Visible() {
+		if opt.Severity != "high" {
+			t.Errorf("Expected only high severity optimizations, got %s", opt.Severity)
+		}
+	}
+
+	m.HandleKey("tab")
+	if m.Panel() != PanelGas || !strings.Contains(m.Render(), "SLOAD") {
+		t.Errorf("Expected the gas panel to show SLOAD, got:\n%s", m.Render())
+	}
+
+	m.HandleKey("tab")
+	if m.Panel() != PanelCalls || !strings.Contains(m.Render(), "(reverted)") {
+		t.Errorf("Expected the call tree to mark the reverted call, got:\n%s", m.Render())
+	}
+
+	if !m.HandleKey("q") {
+		t.Error("Expected q to quit")
+	}
+}
@@ -0,0 +1,42 @@
+//go:build tui
+
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/devlongs/evm-tracer/internal/tracer"
+)
+
+// program adapts Model to the bubbletea runtime
+type program struct {
+	model Model
+}
+
+// Init implements tea.Model
+func (p program) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (p program) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if p.model.HandleKey(msg.String()) {
+			return p, tea.Quit
+		}
+	case tea.WindowSizeMsg:
+		p.model.SetHeight(msg.Height)
+	}
+	return p, nil
+}
+
+// View implements tea.Model
+func (p program) View() string {
+	return p.model.Render()
+}
+
+// Run opens the interactive viewer for a finished trace and blocks until it is closed
+func Run(tr *tracer.GasOptimizationTracer) error {
+	_, err := tea.NewProgram(program{model: NewModel(tr)}, tea.WithAltScreen()).Run()
+	return err
+}